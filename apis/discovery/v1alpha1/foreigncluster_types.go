@@ -109,10 +109,21 @@ type ForeignClusterSpec struct {
 	// +kubebuilder:default=true
 	// +kubebuilder:validation:Optional
 	InsecureSkipTLSVerify *bool `json:"insecureSkipTLSVerify"`
+	// SHA-256 fingerprint of the CA certificate of the remote Authentication Service. If set, the remote Authentication
+	// Service is trusted only if it presents a certificate chain rooted in this CA, regardless of InsecureSkipTLSVerify.
+	// +kubebuilder:validation:Optional
+	CAFingerprint string `json:"caFingerprint,omitempty"`
 	// If discoveryType is LAN, this indicates the number of seconds after that
 	// this ForeignCluster will be removed if no updates have been received.
 	// +kubebuilder:validation:Minimum=0
 	TTL int `json:"ttl,omitempty"`
+	// Revoke the identity granted to the remote cluster, removing its permissions and denying any further request.
+	// +kubebuilder:validation:Optional
+	RevokeIncomingIdentity bool `json:"revokeIncomingIdentity,omitempty"`
+	// Name of the peering permission profile, which selects the ClusterRoles granted to the remote cluster
+	// in the different peering phases. If unset, the default ClusterRoles are granted.
+	// +kubebuilder:validation:Optional
+	PermissionProfile string `json:"permissionProfile,omitempty"`
 }
 
 // ClusterIdentity contains the information about a remote cluster (ID and Name).
@@ -140,6 +151,42 @@ type ForeignClusterStatus struct {
 	// PeeringConditions contains the conditions about the peering related to this
 	// ForeignCluster.
 	PeeringConditions []PeeringCondition `json:"peeringConditions,omitempty"`
+
+	// RevokedCertificates contains the certificates issued to the remote cluster which have been revoked.
+	// +kubebuilder:validation:Optional
+	RevokedCertificates []RevokedCertificate `json:"revokedCertificates,omitempty"`
+
+	// CAFingerprint is the SHA-256 fingerprint of the CA certificate of the remote Authentication Service, either pinned
+	// through the spec or trusted on first use. Subsequent connections are verified against it.
+	// +kubebuilder:validation:Optional
+	CAFingerprint string `json:"caFingerprint,omitempty"`
+
+	// NextCAFingerprint is the SHA-256 fingerprint of the CA certificate the remote Authentication Service announced it is
+	// going to switch to, which is trusted in addition to the current one and replaces it once served.
+	// +kubebuilder:validation:Optional
+	NextCAFingerprint string `json:"nextCaFingerprint,omitempty"`
+
+	// Permissions contains the details about the permissions currently granted to the remote cluster.
+	// +kubebuilder:validation:Optional
+	Permissions PeeringPermissionStatus `json:"permissions,omitempty"`
+}
+
+// PeeringPermissionStatus contains the details about the permissions granted to the remote cluster.
+type PeeringPermissionStatus struct {
+	// Profile is the name of the peering permission profile the permissions originate from (empty for the default one).
+	Profile string `json:"profile,omitempty"`
+	// ClusterRoles is the list of ClusterRoles currently bound to the remote cluster in the local tenant namespace.
+	ClusterRoles []string `json:"clusterRoles,omitempty"`
+	// Message is a human-readable message indicating why the selected profile could not be granted, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// RevokedCertificate contains details about a certificate issued to the remote cluster which has been revoked.
+type RevokedCertificate struct {
+	// SerialNumber is the serial number (hexadecimal) of the revoked certificate.
+	SerialNumber string `json:"serialNumber"`
+	// RevocationTime is the timestamp when the certificate has been revoked.
+	RevocationTime metav1.Time `json:"revocationTime,omitempty"`
 }
 
 // PeeringConditionType represents different conditions that a peering could assume.
@@ -129,6 +129,14 @@ func (in *ForeignClusterStatus) DeepCopyInto(out *ForeignClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RevokedCertificates != nil {
+		in, out := &in.RevokedCertificates, &out.RevokedCertificates
+		*out = make([]RevokedCertificate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Permissions.DeepCopyInto(&out.Permissions)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForeignClusterStatus.
@@ -141,6 +149,26 @@ func (in *ForeignClusterStatus) DeepCopy() *ForeignClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringPermissionStatus) DeepCopyInto(out *PeeringPermissionStatus) {
+	*out = *in
+	if in.ClusterRoles != nil {
+		in, out := &in.ClusterRoles, &out.ClusterRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringPermissionStatus.
+func (in *PeeringPermissionStatus) DeepCopy() *PeeringPermissionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringPermissionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PeeringCondition) DeepCopyInto(out *PeeringCondition) {
 	*out = *in
@@ -255,6 +283,22 @@ func (in *ResourceRequestStatus) DeepCopy() *ResourceRequestStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevokedCertificate) DeepCopyInto(out *RevokedCertificate) {
+	*out = *in
+	in.RevocationTime.DeepCopyInto(&out.RevocationTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevokedCertificate.
+func (in *RevokedCertificate) DeepCopy() *RevokedCertificate {
+	if in == nil {
+		return nil
+	}
+	out := new(RevokedCertificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantNamespaceType) DeepCopyInto(out *TenantNamespaceType) {
 	*out = *in
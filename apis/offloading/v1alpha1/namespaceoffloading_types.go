@@ -47,6 +47,9 @@ const (
 	// DefaultNameMappingStrategyType -> the remote namespace is assigned a default name which ensures uniqueness
 	// and avoids conflicts (localNamespaceName-localClusterID).
 	DefaultNameMappingStrategyType NamespaceMappingStrategyType = "DefaultName"
+	// TemplateNameMappingStrategyType -> the remote namespace is assigned the name obtained evaluating the
+	// NamespaceMappingTemplate (the creation may fail in case of conflicts).
+	TemplateNameMappingStrategyType NamespaceMappingStrategyType = "Template"
 )
 
 // PodOffloadingStrategyType represents different strategies to offload pods in this Namespace.
@@ -64,6 +67,44 @@ const (
 	LocalAndRemotePodOffloadingStrategyType PodOffloadingStrategyType = "LocalAndRemote"
 )
 
+// FailoverPolicyType represents different policies to react to the failure of remote clusters.
+type FailoverPolicyType string
+
+const (
+	// NoneFailoverPolicyType -> no action is performed in case a remote cluster fails, and the pods it hosts
+	// are not rescheduled elsewhere.
+	NoneFailoverPolicyType FailoverPolicyType = "None"
+	// RescheduleFailoverPolicyType -> the pods hosted by a remote cluster which is unavailable for longer than
+	// the grace period are evicted, to be rescheduled either locally or on other remote clusters.
+	RescheduleFailoverPolicyType FailoverPolicyType = "Reschedule"
+)
+
+// MigrationPolicyType represents different policies to handle the pods hosted by remote clusters no longer selected.
+type MigrationPolicyType string
+
+const (
+	// ImmediateMigrationPolicyType -> the remote namespace is removed as soon as the remote cluster is no longer
+	// selected, causing the termination of the pods it hosts.
+	ImmediateMigrationPolicyType MigrationPolicyType = "Immediate"
+	// DrainMigrationPolicyType -> the pods hosted by the remote cluster are gracefully evicted (respecting the
+	// PodDisruptionBudgets), and the remote namespace is removed once all of them have been terminated.
+	DrainMigrationPolicyType MigrationPolicyType = "Drain"
+	// RetainMigrationPolicyType -> the pods hosted by the remote cluster are left untouched, and the remote namespace
+	// is removed once all of them have terminated naturally.
+	RetainMigrationPolicyType MigrationPolicyType = "Retain"
+)
+
+// RemoteNamespaceRetentionPolicyType represents different policies to handle the remote namespaces upon removal.
+type RemoteNamespaceRetentionPolicyType string
+
+const (
+	// DeleteRemoteNamespaceRetentionPolicyType -> the remote namespace is deleted, along with all the resources it contains.
+	DeleteRemoteNamespaceRetentionPolicyType RemoteNamespaceRetentionPolicyType = "Delete"
+	// RetainRemoteNamespaceRetentionPolicyType -> the remote namespace is orphaned, i.e., it is no longer managed by Liqo,
+	// but it is preserved (along with the resources it contains, except for the offloaded pods) in the remote cluster.
+	RetainRemoteNamespaceRetentionPolicyType RemoteNamespaceRetentionPolicyType = "Retain"
+)
+
 // RemoteNamespaceConditionType represents different conditions that a remote namespace could assume.
 type RemoteNamespaceConditionType string
 
@@ -92,16 +133,52 @@ type RemoteNamespaceCondition struct {
 	Message string `json:"message,omitempty"`
 }
 
+// ClusterWeight defines the relative weight of a cluster, in terms of the share of replicas it should host.
+type ClusterWeight struct {
+	// ClusterID is the ID of the cluster (either the local or a remote one) the weight refers to.
+	// +kubebuilder:validation:MinLength=1
+	ClusterID string `json:"clusterID"`
+	// Weight is the relative weight of the cluster, compared to the ones of the other listed clusters.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+}
+
+// ClusterReplicas reports the number of pods currently hosted by a cluster.
+type ClusterReplicas struct {
+	// ClusterID is the ID of the cluster (either the local or a remote one).
+	ClusterID string `json:"clusterID"`
+	// Replicas is the number of running pods of the namespace hosted by the cluster.
+	Replicas int32 `json:"replicas"`
+}
+
+// ClusterResourceUsage reports the amount of resources currently requested by the pods hosted by a remote cluster.
+type ClusterResourceUsage struct {
+	// ClusterID is the ID of the remote cluster.
+	ClusterID string `json:"clusterID"`
+	// Used is the amount of resources requested by the pods of the namespace hosted by the remote cluster.
+	Used corev1.ResourceList `json:"used,omitempty"`
+}
+
 // NamespaceOffloadingSpec defines the desired state of NamespaceOffloading.
 type NamespaceOffloadingSpec struct {
-	//  NamespaceMappingStrategy allows users to map local and remote namespace names according to two
-	//  different strategies: "DefaultName", which ensures uniqueness and prevents conflicts, and "EnforceSameName",
-	//  which enforces the same name at the cost of possible conflicts.
-	// +kubebuilder:validation:Enum="EnforceSameName";"DefaultName"
+	//  NamespaceMappingStrategy allows users to map local and remote namespace names according to three
+	//  different strategies: "DefaultName", which ensures uniqueness and prevents conflicts, "EnforceSameName",
+	//  which enforces the same name at the cost of possible conflicts, and "Template", which assigns the name
+	//  obtained evaluating the NamespaceMappingTemplate.
+	// +kubebuilder:validation:Enum="EnforceSameName";"DefaultName";"Template"
 	// +kubebuilder:default="DefaultName"
 	// +kubebuilder:validation:Optional
 	NamespaceMappingStrategy NamespaceMappingStrategyType `json:"namespaceMappingStrategy"`
 
+	// NamespaceMappingTemplate is the Go template the remote namespace name is obtained from, in case the
+	// "Template" NamespaceMappingStrategy is selected. The template can refer to the name of the local namespace
+	// ({{ .Namespace }}), the name and ID of the local cluster ({{ .ClusterName }} and {{ .ClusterID }}), and the
+	// labels of the local namespace (e.g., {{ index .Labels "team" }}). A template without placeholders specifies
+	// an explicit name. The resulting name must be a valid DNS-1123 label.
+	// +kubebuilder:validation:Optional
+	NamespaceMappingTemplate string `json:"namespaceMappingTemplate,omitempty"`
+
 	// PodOffloadingStrategy allows users to configure how pods in this namespace are offloaded, according to three
 	// different strategies: "Local" (i.e. no pod offloading is performed), "Remote" (i.e. all pods are offloaded
 	// in remote clusters), "LocalAndRemote" (i.e. no constraints are enforced besides the ones
@@ -116,6 +193,60 @@ type NamespaceOffloadingSpec struct {
 	// (https://kubernetes.io/docs/concepts/scheduling-eviction/assign-pod-node/#node-affinity).
 	// A cluster selector with no NodeSelectorTerms matches all clusters.
 	ClusterSelector corev1.NodeSelector `json:"clusterSelector,omitempty"`
+
+	// ReplicaWeights allows users to declare how the pods of each workload (i.e., the pods sharing the same controller)
+	// should be distributed across the local and the remote clusters, in terms of relative weights (e.g., 70 for the
+	// local cluster and 30 for a remote one). Clusters not listed are not assigned any replica, hence they should
+	// also be selected through the ClusterSelector. In case no weight is specified, the distribution of the pods
+	// is left entirely to the scheduler. Weights cannot be specified in case of the "Local" PodOffloadingStrategy.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=clusterID
+	ReplicaWeights []ClusterWeight `json:"replicaWeights,omitempty"`
+
+	// FailoverPolicy allows users to configure how the pods of this namespace are handled in case a remote cluster
+	// hosting them becomes unavailable for longer than the grace period: "None" (i.e. pods are not rescheduled), or
+	// "Reschedule" (i.e. the virtual node is cordoned, and the pods managed by a controller are evicted, to be rescheduled
+	// either locally or on other remote clusters). Once the remote cluster is back, the original distribution is restored.
+	// +kubebuilder:validation:Enum="None";"Reschedule"
+	// +kubebuilder:default="None"
+	// +kubebuilder:validation:Optional
+	FailoverPolicy FailoverPolicyType `json:"failoverPolicy,omitempty"`
+
+	// RemoteClusterQuota allows users to limit the amount of resources (i.e., "cpu", "memory" and "pods") that can be
+	// requested by the pods of this namespace on each remote cluster. The quota is enforced both when assigning pods
+	// to the remote clusters, and through a ResourceQuota created in the corresponding remote namespaces.
+	// +kubebuilder:validation:Optional
+	RemoteClusterQuota corev1.ResourceList `json:"remoteClusterQuota,omitempty"`
+
+	// MigrationPolicy allows users to configure how the pods of this namespace are handled in case a remote cluster
+	// hosting them is no longer selected (e.g., following a ClusterSelector modification): "Immediate" (i.e. the remote
+	// namespace is removed straight away, terminating the pods it hosts), "Drain" (i.e. the pods are gracefully evicted,
+	// respecting the PodDisruptionBudgets, before removing the remote namespace), or "Retain" (i.e. the remote namespace
+	// is removed only once all the pods it hosts have terminated naturally).
+	// +kubebuilder:validation:Enum="Immediate";"Drain";"Retain"
+	// +kubebuilder:default="Immediate"
+	// +kubebuilder:validation:Optional
+	MigrationPolicy MigrationPolicyType `json:"migrationPolicy,omitempty"`
+
+	// RemoteNamespaceRetentionPolicy allows users to configure what happens to the remote namespaces when they are
+	// no longer required (e.g., when the namespace is unoffloaded): "Delete" (i.e. the remote namespaces are deleted,
+	// along with all the resources they contain), or "Retain" (i.e. the remote namespaces are orphaned and preserved,
+	// along with the resources they contain, except for the offloaded pods, which are terminated).
+	// +kubebuilder:validation:Enum="Delete";"Retain"
+	// +kubebuilder:default="Delete"
+	// +kubebuilder:validation:Optional
+	RemoteNamespaceRetentionPolicy RemoteNamespaceRetentionPolicyType `json:"remoteNamespaceRetentionPolicy,omitempty"`
+
+	// PropagatedLabels is the list of label keys which are propagated from the local namespace to the remote ones
+	// (e.g., to configure the Pod Security Admission level). A trailing "*" matches all keys with the given prefix.
+	// +kubebuilder:validation:Optional
+	PropagatedLabels []string `json:"propagatedLabels,omitempty"`
+
+	// PropagatedAnnotations is the list of annotation keys which are propagated from the local namespace to the remote
+	// ones. A trailing "*" matches all keys with the given prefix.
+	// +kubebuilder:validation:Optional
+	PropagatedAnnotations []string `json:"propagatedAnnotations,omitempty"`
 }
 
 // NamespaceOffloadingStatus defines the observed state of NamespaceOffloading.
@@ -137,6 +268,15 @@ type NamespaceOffloadingStatus struct {
 	// This field allows external tools (e.g., liqoctl) to detect whether a spec modification has already been processed
 	// or not (i.e., whether the status should be expected to be up-to-date or not), and thus act accordingly.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ReplicaDistribution reports, in case ReplicaWeights are specified, the actual number of running pods of
+	// this namespace hosted by each cluster, to be compared with the desired distribution.
+	ReplicaDistribution []ClusterReplicas `json:"replicaDistribution,omitempty"`
+	// RemoteQuotaUsage reports, in case a RemoteClusterQuota is specified, the amount of resources currently
+	// requested by the pods of this namespace on each remote cluster, to be compared with the quota.
+	RemoteQuotaUsage []ClusterResourceUsage `json:"remoteQuotaUsage,omitempty"`
+	// PendingMigrations reports, in case the MigrationPolicy is either "Drain" or "Retain", the remote clusters which
+	// are no longer selected, but whose remote namespace is retained since still hosting pods of this namespace.
+	PendingMigrations []ClusterReplicas `json:"pendingMigrations,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -20,9 +20,62 @@
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterReplicas) DeepCopyInto(out *ClusterReplicas) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterReplicas.
+func (in *ClusterReplicas) DeepCopy() *ClusterReplicas {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterReplicas)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterResourceUsage) DeepCopyInto(out *ClusterResourceUsage) {
+	*out = *in
+	if in.Used != nil {
+		in, out := &in.Used, &out.Used
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterResourceUsage.
+func (in *ClusterResourceUsage) DeepCopy() *ClusterResourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterResourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWeight) DeepCopyInto(out *ClusterWeight) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWeight.
+func (in *ClusterWeight) DeepCopy() *ClusterWeight {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWeight)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespaceOffloading) DeepCopyInto(out *NamespaceOffloading) {
 	*out = *in
@@ -86,6 +139,28 @@ func (in *NamespaceOffloadingList) DeepCopyObject() runtime.Object {
 func (in *NamespaceOffloadingSpec) DeepCopyInto(out *NamespaceOffloadingSpec) {
 	*out = *in
 	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+	if in.ReplicaWeights != nil {
+		in, out := &in.ReplicaWeights, &out.ReplicaWeights
+		*out = make([]ClusterWeight, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemoteClusterQuota != nil {
+		in, out := &in.RemoteClusterQuota, &out.RemoteClusterQuota
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.PropagatedLabels != nil {
+		in, out := &in.PropagatedLabels, &out.PropagatedLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PropagatedAnnotations != nil {
+		in, out := &in.PropagatedAnnotations, &out.PropagatedAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceOffloadingSpec.
@@ -118,6 +193,23 @@ func (in *NamespaceOffloadingStatus) DeepCopyInto(out *NamespaceOffloadingStatus
 			(*out)[key] = outVal
 		}
 	}
+	if in.ReplicaDistribution != nil {
+		in, out := &in.ReplicaDistribution, &out.ReplicaDistribution
+		*out = make([]ClusterReplicas, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemoteQuotaUsage != nil {
+		in, out := &in.RemoteQuotaUsage, &out.RemoteQuotaUsage
+		*out = make([]ClusterResourceUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingMigrations != nil {
+		in, out := &in.PendingMigrations, &out.PendingMigrations
+		*out = make([]ClusterReplicas, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceOffloadingStatus.
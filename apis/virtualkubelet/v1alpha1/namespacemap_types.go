@@ -15,6 +15,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -39,6 +40,16 @@ type RemoteNamespaceStatus struct {
 	Phase MappingPhase `json:"phase,omitempty"`
 }
 
+// RemoteNamespaceOptions contains the additional options concerning a remote namespace.
+type RemoteNamespaceOptions struct {
+	// Labels are the labels propagated from the local namespace to the remote one.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are the annotations propagated from the local namespace to the remote one.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Retain specifies whether the remote namespace shall be orphaned, rather than deleted, upon removal.
+	Retain bool `json:"retain,omitempty"`
+}
+
 // NamespaceMapSpec defines the desired state of NamespaceMap.
 type NamespaceMapSpec struct {
 
@@ -46,6 +57,16 @@ type NamespaceMapSpec struct {
 	// of the map represents the localNamespaceName[key]-remoteNamespaceName[value] association. When a new entry is
 	// created the NamespaceMap Controller tries to create the associated remote namespace.
 	DesiredMapping map[string]string `json:"desiredMapping,omitempty"`
+
+	// Quotas is filled by NamespaceController when a user requires to limit the resources consumed by the pods of an
+	// offloaded namespace, every entry of the map represents the localNamespaceName[key]-quota[value] association.
+	// The NamespaceMap Controller enforces the corresponding ResourceQuota in the associated remote namespace.
+	Quotas map[string]corev1.ResourceList `json:"quotas,omitempty"`
+
+	// Options is filled by NamespaceController when a user requires to propagate the metadata of an offloaded namespace,
+	// or to retain the remote namespaces upon removal, every entry of the map represents the localNamespaceName[key]-options[value]
+	// association. The NamespaceMap Controller enforces the corresponding options on the associated remote namespace.
+	Options map[string]RemoteNamespaceOptions `json:"options,omitempty"`
 }
 
 // NamespaceMapStatus defines the observed state of NamespaceMap.
@@ -0,0 +1,68 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReflectionRule restricts the reflection of the objects handled by a given reflector.
+// All the constraints specified by a rule shall be satisfied for an object to be reflected.
+type ReflectionRule struct {
+	// Reflector is the name of the reflector the rule applies to.
+	// +kubebuilder:validation:Enum="Service";"EndpointSlice";"Ingress";"NetworkPolicy";"ConfigMap";"Secret";"ServiceAccount"
+	Reflector string `json:"reflector"`
+	// Disabled completely disables the reflection of the objects handled by the given reflector.
+	Disabled bool `json:"disabled,omitempty"`
+	// Selector restricts the reflection to the objects matching the given label selector.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Types restricts the reflection to the secrets of the given types (meaningful only for the Secret reflector).
+	Types []corev1.SecretType `json:"types,omitempty"`
+	// ExcludedNames prevents the reflection of the objects whose name matches any of the given glob patterns (e.g., "*-private").
+	ExcludedNames []string `json:"excludedNames,omitempty"`
+}
+
+// ReflectionPolicySpec defines the desired state of ReflectionPolicy.
+type ReflectionPolicySpec struct {
+	// Rules is the list of rules restricting the reflection of the objects in the namespace of the policy.
+	Rules []ReflectionRule `json:"rules,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories=liqo
+// +genclient
+
+// ReflectionPolicy is the Schema for the reflectionpolicies API.
+// It restricts the reflection of the objects in the namespace it lives in towards all remote clusters.
+type ReflectionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReflectionPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReflectionPolicyList contains a list of ReflectionPolicy.
+type ReflectionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReflectionPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReflectionPolicy{}, &ReflectionPolicyList{})
+}
@@ -17,9 +17,11 @@ package main
 import (
 	"context"
 	"flag"
+	"net/http"
 	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -47,6 +49,37 @@ func main() {
 	enableAuth := flag.Bool("enable-authentication", true,
 		"Whether to authenticate remote clusters through tokens before granting an identity (warning: disable only for testing purposes)")
 
+	var tokenAuthConfig authservice.TokenAuthenticationConfig
+	flag.StringVar(&tokenAuthConfig.OIDC.IssuerURL, "oidc-issuer-url", "",
+		"The URL of the OIDC issuer whose ID tokens are accepted to authenticate remote clusters (OIDC authentication is disabled if empty)")
+	flag.StringVar(&tokenAuthConfig.OIDC.Audience, "oidc-audience", "", "The audience the OIDC ID tokens must be issued for")
+	flag.StringVar(&tokenAuthConfig.OIDC.ClusterIDsClaim, "oidc-cluster-ids-claim", "liqo_cluster_ids",
+		"The OIDC claim listing the ClusterIDs the holder of the ID token is allowed to peer as")
+	flag.StringVar(&tokenAuthConfig.Webhook.URL, "authentication-webhook-url", "",
+		"The URL of an external webhook authenticating the tokens presented by remote clusters (webhook authentication is disabled if empty)")
+	flag.DurationVar(&tokenAuthConfig.Webhook.Timeout, "authentication-webhook-timeout", 10*time.Second,
+		"The timeout of the requests towards the authentication webhook")
+	flag.BoolVar(&tokenAuthConfig.DisableClusterToken, "disable-cluster-token", false,
+		"Whether to reject the cluster-wide token, accepting only the named peering tokens and the external authenticators")
+
+	var auditConfig authservice.AuditConfig
+	var rateLimitConfig authservice.RateLimitConfig
+	flag.StringVar(&auditConfig.LogPath, "audit-log-path", "-",
+		"The path of the file the audit records are appended to (\"-\" for the standard output, disabled if empty)")
+	flag.Float64Var(&rateLimitConfig.QPS, "rate-limit-qps", 5, "The number of requests per second allowed from each source (disabled if zero)")
+	flag.IntVar(&rateLimitConfig.Burst, "rate-limit-burst", 20, "The maximum burst of requests allowed from each source")
+	flag.DurationVar(&rateLimitConfig.BackoffInitial, "authentication-failure-backoff", 1*time.Second,
+		"The period a cluster is blocked for (from the same source) after an authentication failure, "+
+			"doubling at every subsequent failure (disabled if zero)")
+	flag.DurationVar(&rateLimitConfig.BackoffMax, "authentication-failure-max-backoff", 5*time.Minute,
+		"The maximum period a cluster is blocked for (from the same source) after repeated authentication failures")
+	flag.IntVar(&rateLimitConfig.SourceFailureBurst, "authentication-failure-source-burst", 10,
+		"The maximum number of authentication failures allowed from each source (regardless of the claimed cluster) "+
+			"before blocking the source altogether, replenished at one per minute (disabled if zero)")
+	flag.BoolVar(&rateLimitConfig.TrustForwardedFor, "trust-forwarded-for", false,
+		"Whether to identify the source of the requests through the X-Forwarded-For header (to be enabled only behind a trusted reverse proxy)")
+	metricsAddress := flag.String("metrics-address", "", "The address the metrics endpoint binds to (disabled if empty)")
+
 	flag.StringVar(&awsConfig.AwsAccessKeyID, "aws-access-key-id", "", "AWS IAM AccessKeyID for the Liqo User")
 	flag.StringVar(&awsConfig.AwsSecretAccessKey, "aws-secret-access-key", "", "AWS IAM SecretAccessKey for the Liqo User")
 	flag.StringVar(&awsConfig.AwsRegion, "aws-region", "", "AWS region where the local cluster is running")
@@ -64,15 +97,36 @@ func main() {
 	config := restcfg.SetRateLimiter(ctrl.GetConfigOrDie())
 
 	clusterIdentity := clusterFlags.ReadOrDie()
+	if tokenAuthConfig.OIDC.IsEnabled() && tokenAuthConfig.OIDC.Audience == "" {
+		klog.Error("The OIDC audience must be specified when the OIDC authentication is enabled")
+		os.Exit(1)
+	}
+
 	authService, err := authservice.NewAuthServiceCtrl(
-		context.Background(), config, *namespace, awsConfig, *resync, apiserver.GetConfig(), *enableAuth, *useTLS, clusterIdentity)
+		context.Background(), config, *namespace, awsConfig, *resync, apiserver.GetConfig(), *enableAuth, *useTLS, clusterIdentity,
+		tokenAuthConfig, auditConfig, rateLimitConfig)
 	if err != nil {
 		klog.Error(err)
 		os.Exit(1)
 	}
 
+	if *metricsAddress != "" {
+		go serveMetrics(*metricsAddress)
+	}
+
 	if err = authService.Start(context.Background(), *address, *useTLS, *certPath, *keyPath); err != nil {
 		klog.Error(err)
 		os.Exit(1)
 	}
 }
+
+// serveMetrics exposes the Prometheus metrics on the given address.
+func serveMetrics(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{Addr: address, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		klog.Errorf("Failed to serve the metrics: %v", err)
+	}
+}
@@ -51,6 +51,7 @@ func main() {
 	workers := flag.Uint("workers", 1, "The number of workers managing the reflection of each remote cluster")
 
 	restcfg.InitFlags(nil)
+	identitymanager.InitStorageFlags(nil)
 	klog.InitFlags(nil)
 
 	flag.Parse()
@@ -73,6 +74,11 @@ func main() {
 	k8sClient := kubernetes.NewForConfigOrDie(cfg)
 
 	namespaceManager := tenantnamespace.NewCachedManager(ctx, k8sClient)
+	identityStorage, err := identitymanager.NewIdentityStorageFromFlags(ctx, k8sClient)
+	if err != nil {
+		klog.Error(err, "unable to configure the identity storage")
+		os.Exit(1)
+	}
 
 	dynClient := dynamic.NewForConfigOrDie(cfg)
 
@@ -89,7 +95,7 @@ func main() {
 		Reflectors:          make(map[string]*reflection.Reflector),
 
 		IdentityReader: identitymanager.NewCertificateIdentityReader(
-			k8sClient, clusterIdentity, namespaceManager),
+			k8sClient, clusterIdentity, namespaceManager, identityStorage),
 	}
 	if err = d.SetupWithManager(mgr); err != nil {
 		klog.Error(err, "unable to setup the crdreplicator-operator")
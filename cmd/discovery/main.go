@@ -66,6 +66,29 @@ func main() {
 	flag.DurationVar(&mdnsConfig.ResolveRefreshTime, "mdns-resolve-refresh-time", 10*time.Minute,
 		"Period after that mDNS resolve context is refreshed")
 
+	var dnsConfig discovery.DNSConfig
+	var dnsDomains args.StringList
+	flag.Var(&dnsDomains, "dns-discovery-domains",
+		"The comma-separated list of domains whose DNS SRV/TXT records are looked up to discover remote clusters across WANs")
+	flag.StringVar(&dnsConfig.Service, "dns-service-name", "_liqo_auth._tcp",
+		"The name of the service used for DNS discovery across WANs")
+	flag.StringVar(&dnsConfig.Server, "dns-server", "",
+		"The address (host:port) of the DNS server used for DNS discovery (defaults to the one configured in the system)")
+	flag.DurationVar(&dnsConfig.TTL, "dns-ttl", 30*time.Minute,
+		"The time-to-live before a cluster discovered through DNS is deleted if no longer advertised")
+	flag.DurationVar(&dnsConfig.ResolveRefreshTime, "dns-resolve-refresh-time", 5*time.Minute,
+		"Period after that the DNS records are resolved again")
+
+	var catalogConfig discovery.CatalogConfig
+	flag.StringVar(&catalogConfig.URL, "catalog-url", "",
+		"The HTTP(S) URL of the signed catalog of peerable clusters (its signature is retrieved from the same URL, with the .sig suffix)")
+	flag.StringVar(&catalogConfig.ConfigMap, "catalog-configmap", "",
+		"The name of the ConfigMap (in the Liqo namespace) containing the signed catalog of peerable clusters")
+	catalogPublicKey := flag.String("catalog-public-key", "",
+		"The path of the (PEM encoded) public key used to verify the signature of the catalog of peerable clusters")
+	flag.DurationVar(&catalogConfig.RefreshTime, "catalog-refresh-time", 5*time.Minute,
+		"Period after that the catalog of peerable clusters is fetched again")
+
 	dialTCPTimeout := flag.Duration("dial-tcp-timeout", 500*time.Millisecond,
 		"Time to wait for a TCP connection to a remote cluster before to consider it as not reachable")
 
@@ -74,6 +97,19 @@ func main() {
 	flag.Parse()
 
 	clusterIdentity := clusterFlags.ReadOrDie()
+	dnsConfig.Domains = dnsDomains.StringList
+
+	if catalogConfig.URL != "" && catalogConfig.ConfigMap != "" {
+		klog.Error("The catalog URL and ConfigMap are mutually exclusive")
+		os.Exit(1)
+	}
+	if catalogConfig.IsEnabled() {
+		var err error
+		if catalogConfig.PublicKey, err = discovery.LoadCatalogPublicKey(*catalogPublicKey); err != nil {
+			klog.Errorf("Unable to load the catalog public key: %v", err)
+			os.Exit(1)
+		}
+	}
 
 	klog.Info("Namespace: ", *namespace)
 	klog.Info("RequeueAfter: ", *requeueAfter)
@@ -107,7 +143,7 @@ func main() {
 
 	klog.Info("Starting the discovery logic")
 	discoveryCtl := discovery.NewDiscoveryCtrl(mgr.GetClient(), namespacedClient, *namespace,
-		clusterIdentity, mdnsConfig, *dialTCPTimeout)
+		clusterIdentity, mdnsConfig, dnsConfig, catalogConfig, *dialTCPTimeout)
 	if err := mgr.Add(discoveryCtl); err != nil {
 		klog.Errorf("Unable to add the discovery controller to the manager: %w", err)
 		os.Exit(1)
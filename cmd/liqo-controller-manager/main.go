@@ -51,9 +51,12 @@ import (
 	"github.com/liqotech/liqo/pkg/consts"
 	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
 	foreignclusteroperator "github.com/liqotech/liqo/pkg/liqo-controller-manager/foreign-cluster-operator"
+	localnodectrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/localnode-controller"
 	mapsctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/namespacemap-controller"
 	nsoffctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/namespaceoffloading-controller"
 	nodefailurectrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/nodefailure-controller"
+	offloadingoverridectrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloadingoverride-controller"
+	rebalancingctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/rebalancing-controller"
 	resourceRequestOperator "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller"
 	resourcemonitors "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller/resource-monitors"
 	resourceoffercontroller "github.com/liqotech/liqo/pkg/liqo-controller-manager/resourceoffer-controller"
@@ -61,6 +64,7 @@ import (
 	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
 	virtualNodectrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/virtualNode-controller"
 	fcwh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/foreigncluster"
+	identitywh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/identity"
 	nsoffwh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/namespaceoffloading"
 	podwh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/pod"
 	shadowpodswh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/shadowpod"
@@ -70,6 +74,7 @@ import (
 	"github.com/liqotech/liqo/pkg/utils/csr"
 	liqoerrors "github.com/liqotech/liqo/pkg/utils/errors"
 	"github.com/liqotech/liqo/pkg/utils/mapper"
+	"github.com/liqotech/liqo/pkg/utils/namespacemapping"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
 	"github.com/liqotech/liqo/pkg/vkMachinery/forge"
 )
@@ -92,6 +97,7 @@ func main() {
 	var clusterLabels argsutils.StringMap
 	var kubeletExtraAnnotations, kubeletExtraLabels argsutils.StringMap
 	var kubeletExtraArgs argsutils.StringList
+	var remoteNamespaceAllowedLabels, remoteNamespaceAllowedAnnotations argsutils.StringList
 	var nodeExtraAnnotations, nodeExtraLabels argsutils.StringMap
 	var kubeletCPURequests, kubeletCPULimits argsutils.Quantity
 	var kubeletRAMRequests, kubeletRAMLimits argsutils.Quantity
@@ -119,6 +125,8 @@ func main() {
 
 	// Discovery parameters
 	autoJoin := flag.Bool("auto-join-discovered-clusters", true, "Whether to automatically peer with discovered clusters")
+	identityRenewalFraction := flag.Float64("identity-renewal-fraction", foreignclusteroperator.DefaultIdentityRenewalFraction,
+		"The fraction of the certificate lifetime after which the identity to access remote clusters is renewed (in the (0, 1) range)")
 
 	// Resource sharing parameters
 	resourcePluginAddress := flag.String(consts.ResourcePluginAddressParameter, "",
@@ -153,16 +161,61 @@ func main() {
 	realStorageClassName := flag.String("real-storage-class-name", "", "Name of the real storage class to use for the actual volumes")
 	storageNamespace := flag.String("storage-namespace", "liqo-storage", "Namespace where the liqo storage-related resources are stored")
 
+	// Remote namespaces parameters
+	flag.Var(&remoteNamespaceAllowedLabels, "remote-namespace-allowed-labels",
+		"The keys of the labels remote clusters are allowed to propagate to the namespaces created on their behalf (trailing * as wildcard)")
+	flag.Var(&remoteNamespaceAllowedAnnotations, "remote-namespace-allowed-annotations",
+		"The keys of the annotations remote clusters are allowed to propagate to the namespaces created on their behalf (trailing * as wildcard)")
+	remoteNamespacePodSecurityLevel := argsutils.NewEnum(namespacemapping.PodSecurityLevels, namespacemapping.PodSecurityLevels[0])
+	flag.Var(remoteNamespacePodSecurityLevel, "remote-namespace-minimum-pod-security-level",
+		"The Pod Security Admission level enforced by default, below which remote clusters cannot lower the one of their namespaces")
+	remoteNamespaceGrantNodeStats := flag.Bool("remote-namespace-grant-node-stats", false,
+		"Grant remote clusters access to the summary API of the local nodes, to retrieve the stats of their offloaded pods")
+	remoteNamespaceAllowRetention := flag.Bool("remote-namespace-allow-retention", false,
+		"Allow remote clusters to request the namespaces created on their behalf to be orphaned, rather than deleted, upon removal")
+	remoteNamespaceOrphanedTTL := flag.Duration("remote-namespace-orphaned-ttl", 0,
+		"The period after which the orphaned namespaces are deleted, unless adopted again (0 to retain them until manually deleted)")
+
 	// Node failure controller parameter
 	enableNodeFailureController := flag.Bool("enable-node-failure-controller", false, "Enable the node failure controller")
+	failoverGracePeriod := flag.Duration("failover-grace-period", 5*time.Minute,
+		"The period a remote cluster shall be unavailable for, before the pods it hosts are rescheduled according to the failover policy")
+
+	// Rebalancing controller parameters
+	enableRebalancing := flag.Bool("enable-rebalancing", false, "Enable the periodic rebalancing of the pods offloaded to remote clusters")
+	rebalancingInterval := flag.Duration("rebalancing-interval", 5*time.Minute,
+		"The period between two subsequent evaluations of the rebalancing policies")
+	rebalancingMaxMoves := flag.Int("rebalancing-max-moves-per-interval", 5, "The maximum number of pods evicted during each rebalancing evaluation")
+	rebalancingOverloadThreshold := flag.Int("rebalancing-overload-threshold", 100,
+		"The percentage of the resources offered by a remote cluster above which the pods it hosts are moved elsewhere (0 to disable)")
+	rebalancingLatencyThreshold := flag.Duration("rebalancing-latency-threshold", 0,
+		"The latency towards a remote cluster above which the pods it hosts are moved elsewhere (0 to disable)")
+	rebalancingReclaimLocalCapacity := flag.Bool("rebalancing-reclaim-local-capacity", false,
+		"Whether offloaded pods allowed to run locally are moved back once the local cluster has enough free capacity")
+
+	// Identity storage parameters
+	identityStorageMigrateFrom := argsutils.NewEnum([]string{"", identitymanager.StorageBackendSecret, identitymanager.StorageBackendVault}, "")
+	flag.Var(identityStorageMigrateFrom, "identity-storage-migrate-from",
+		"The backend the identities are migrated from to the configured one at startup (secret, vault), empty to disable the migration")
 
 	liqoerrors.InitFlags(nil)
 	restcfg.InitFlags(nil)
+	identitymanager.InitStorageFlags(nil)
 	klog.InitFlags(nil)
 	flag.Parse()
 
 	clusterIdentity := clusterIdentityFlags.ReadOrDie()
 
+	if *identityRenewalFraction <= 0 || *identityRenewalFraction >= 1 {
+		klog.Fatalf("Invalid identity renewal fraction %v: it must be in the (0, 1) range", *identityRenewalFraction)
+	}
+
+	for _, keys := range [][]string{remoteNamespaceAllowedLabels.StringList, remoteNamespaceAllowedAnnotations.StringList} {
+		if err := namespacemapping.ValidatePropagatedKeys(keys); err != nil {
+			klog.Fatalf("Invalid remote namespace allowed metadata: %v", err)
+		}
+	}
+
 	ctx := ctrl.SetupSignalHandler()
 
 	config := restcfg.SetRateLimiter(ctrl.GetConfigOrDie())
@@ -212,13 +265,29 @@ func main() {
 	mgr.GetWebhookServer().Register("/validate/foreign-cluster", fcwh.NewValidator())
 	mgr.GetWebhookServer().Register("/mutate/foreign-cluster", fcwh.NewMutator())
 	mgr.GetWebhookServer().Register("/validate/shadowpods", &webhook.Admission{Handler: spv})
-	mgr.GetWebhookServer().Register("/validate/namespace-offloading", nsoffwh.New())
-	mgr.GetWebhookServer().Register("/mutate/pod", podwh.New(mgr.GetClient()))
+	mgr.GetWebhookServer().Register("/validate/namespace-offloading", nsoffwh.New(mgr.GetClient(), clusterIdentity))
+	mgr.GetWebhookServer().Register("/mutate/pod", podwh.New(mgr.GetClient(), mgr.GetAPIReader(), clusterIdentity))
+	mgr.GetWebhookServer().Register("/validate/revoked-identities", identitywh.New(mgr.GetClient()))
 
 	clientset := kubernetes.NewForConfigOrDie(config)
 
 	namespaceManager := tenantnamespace.NewCachedManager(ctx, clientset)
-	idManager := identitymanager.NewCertificateIdentityManager(clientset, clusterIdentity, namespaceManager)
+	identityStorage, err := identitymanager.NewIdentityStorageFromFlags(ctx, clientset)
+	if err != nil {
+		klog.Fatalf("Unable to configure the identity storage: %v", err)
+	}
+	if identityStorageMigrateFrom.Value != "" {
+		source, sourceErr := identitymanager.NewIdentityStorageForBackend(ctx, clientset, identityStorageMigrateFrom.Value)
+		if sourceErr != nil {
+			klog.Fatalf("Unable to configure the identity storage to migrate from: %v", sourceErr)
+		}
+		migrated, migrationErr := identitymanager.MigrateIdentities(ctx, source, identityStorage)
+		if migrationErr != nil {
+			klog.Fatalf("Unable to migrate the identities from the %v backend: %v", identityStorageMigrateFrom.Value, migrationErr)
+		}
+		klog.Infof("Migrated %d identities from the %v backend", migrated, identityStorageMigrateFrom.Value)
+	}
+	idManager := identitymanager.NewCertificateIdentityManager(clientset, clusterIdentity, namespaceManager, identityStorage)
 
 	// populate the lists of ClusterRoles to bind in the different peering states
 	permissions, err := peeringroles.GetPeeringPermission(ctx, clientset)
@@ -242,9 +311,10 @@ func main() {
 		HomeCluster:  clusterIdentity,
 		AutoJoin:     *autoJoin,
 
-		NamespaceManager:  namespaceManager,
-		IdentityManager:   idManager,
-		PeeringPermission: *permissions,
+		NamespaceManager:        namespaceManager,
+		IdentityManager:         idManager,
+		IdentityRenewalFraction: *identityRenewalFraction,
+		PeeringPermission:       *permissions,
 
 		SecureTransport:   secureTransport,
 		InsecureTransport: insecureTransport,
@@ -289,7 +359,7 @@ func main() {
 		ContainerImage:       *kubeletImage,
 		ExtraAnnotations:     kubeletExtraAnnotations.StringMap,
 		ExtraLabels:          kubeletExtraLabels.StringMap,
-		ExtraArgs:            kubeletExtraArgs.StringList,
+		ExtraArgs:            append(kubeletExtraArgs.StringList, identitymanager.StorageArgs()...),
 		NodeExtraAnnotations: nodeExtraAnnotations,
 		NodeExtraLabels:      nodeExtraLabels,
 		RequestsCPU:          kubeletCPURequests.Quantity,
@@ -314,23 +384,49 @@ func main() {
 	}
 
 	namespaceMapReconciler := &mapsctrl.NamespaceMapReconciler{
-		Client: mgr.GetClient(),
+		Client:                  mgr.GetClient(),
+		AllowedLabels:           remoteNamespaceAllowedLabels.StringList,
+		AllowedAnnotations:      remoteNamespaceAllowedAnnotations.StringList,
+		MinimumPodSecurityLevel: remoteNamespacePodSecurityLevel.Value,
+		GrantNodeStats:          *remoteNamespaceGrantNodeStats,
+		AllowRetention:          *remoteNamespaceAllowRetention,
 	}
 
 	if err = namespaceMapReconciler.SetupWithManager(mgr); err != nil {
 		klog.Fatal(err)
 	}
 
+	if *remoteNamespaceOrphanedTTL > 0 {
+		orphanedNamespaceReconciler := &mapsctrl.OrphanedNamespaceReconciler{
+			Client: mgr.GetClient(),
+			TTL:    *remoteNamespaceOrphanedTTL,
+		}
+
+		if err = orphanedNamespaceReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatal(err)
+		}
+	}
+
 	namespaceOffloadingReconciler := &nsoffctrl.NamespaceOffloadingReconciler{
 		Client:       mgr.GetClient(),
 		Recorder:     mgr.GetEventRecorderFor("namespaceoffloading-controller"),
 		LocalCluster: clusterIdentity,
+		APIReader:    mgr.GetAPIReader(),
 	}
 
 	if err = namespaceOffloadingReconciler.SetupWithManager(mgr); err != nil {
 		klog.Fatal(err)
 	}
 
+	offloadingOverrideReconciler := &offloadingoverridectrl.OffloadingOverrideReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("offloadingoverride-controller"),
+	}
+
+	if err = offloadingOverrideReconciler.SetupWithManager(mgr); err != nil {
+		klog.Fatal(err)
+	}
+
 	shadowPodReconciler := &shadowpodctrl.Reconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -378,6 +474,43 @@ func main() {
 		}
 	}
 
+	localNodeReconciler := &localnodectrl.LocalNodeReconciler{
+		Client:         mgr.GetClient(),
+		LocalClusterID: clusterIdentity.ClusterID,
+	}
+	if err = localNodeReconciler.SetupWithManager(mgr); err != nil {
+		klog.Errorf("Unable to start the localNodeReconciler: %v", err)
+		os.Exit(1)
+	}
+
+	failoverReconciler := &nodefailurectrl.FailoverReconciler{
+		Client:      mgr.GetClient(),
+		APIReader:   mgr.GetAPIReader(),
+		Recorder:    mgr.GetEventRecorderFor("failover-controller"),
+		GracePeriod: *failoverGracePeriod,
+	}
+	if err = failoverReconciler.SetupWithManager(mgr); err != nil {
+		klog.Errorf("Unable to start the failoverReconciler: %v", err)
+		os.Exit(1)
+	}
+
+	if *enableRebalancing {
+		rebalancer := &rebalancingctrl.Rebalancer{
+			Client:               mgr.GetClient(),
+			APIReader:            mgr.GetAPIReader(),
+			Recorder:             mgr.GetEventRecorderFor("rebalancing-controller"),
+			Interval:             *rebalancingInterval,
+			MaxMovesPerInterval:  *rebalancingMaxMoves,
+			OverloadThreshold:    *rebalancingOverloadThreshold,
+			LatencyThreshold:     *rebalancingLatencyThreshold,
+			ReclaimLocalCapacity: *rebalancingReclaimLocalCapacity,
+		}
+		if err = mgr.Add(rebalancer); err != nil {
+			klog.Errorf("Unable to start the rebalancer: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	if *enableNodeFailureController {
 		nodeFailureReconciler := &nodefailurectrl.NodeFailureReconciler{
 			Client: mgr.GetClient(),
@@ -34,10 +34,20 @@ outgoing peering towards the local cluster. Once established, the remote cluster
 will get access to a slice of the current cluster, and have the possibility to
 offload workloads through the virtual node abstraction.
 
+By default, the generated command embeds the cluster-wide authentication token.
+Alternatively, a dedicated peering token can be generated, possibly bound to a
+given remote cluster, and with a limited validity (in terms of duration and number
+of uses). Each peering token is stored in a separate secret, which can be deleted
+to revoke it.
+
 Examples:
   $ {{ .Executable }} generate peer-command
 or
   $ {{ .Executable }} generate peer-command --namespace liqo-system --only-command
+or
+  $ {{ .Executable }} generate peer-command --ttl 1h --single-use
+or
+  $ {{ .Executable }} generate peer-command --token-name foo --remote-cluster-id <cluster-id>
 `
 
 func newGenerateCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
@@ -66,6 +76,12 @@ func newGeneratePeerCommand(ctx context.Context, f *factory.Factory) *cobra.Comm
 	}
 
 	cmd.Flags().BoolVar(&options.OnlyCommand, "only-command", false, "Print only the resulting peer command, for scripts usage (default false)")
+	cmd.Flags().StringVar(&options.TokenName, "token-name", "",
+		"The name of the dedicated peering token to generate (a random one is generated, if not specified)")
+	cmd.Flags().StringVar(&options.RemoteClusterID, "remote-cluster-id", "",
+		"The cluster ID of the only remote cluster allowed to use the generated peering token (default any)")
+	cmd.Flags().DurationVar(&options.TTL, "ttl", 0, "The validity duration of the generated peering token (default unlimited)")
+	cmd.Flags().BoolVar(&options.SingleUse, "single-use", false, "Whether the generated peering token can be used only once (default false)")
 
 	f.AddLiqoNamespaceFlag(cmd.Flags())
 	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc(factory.FlagNamespace, completion.Namespaces(ctx, f, completion.NoLimit)))
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -42,12 +43,25 @@ Namespace offloading can be tuned in terms of:
 * Pod offloading: whether pods should be scheduled on physical nodes only,
   virtual nodes only, or both. Forcing all pods to be scheduled locally enables
   the consumption of services from remote clusters.
-* Naming: whether remote namespaces have the same name or a suffix is added to
-  prevent conflicts.
+* Naming: whether remote namespaces have the same name, a suffix is added to
+  prevent conflicts, or the name is obtained from a template.
+* Failover: whether pods hosted by an unavailable remote cluster should be
+  rescheduled elsewhere, and moved back once the cluster is available again.
+* Migration: whether pods hosted by a remote cluster no longer selected should
+  be terminated immediately, drained gracefully, or retained until completion.
+* Quota: the maximum amount of resources the pods of the namespace can request
+  on each remote cluster.
+* Retention: whether remote namespaces should be deleted, or orphaned and
+  preserved, once no longer required (e.g., when unoffloading the namespace).
+* Metadata: the labels and annotations (e.g., the Pod Security Admission level)
+  propagated from the local namespace to the remote ones.
 
 Besides the direct offloading of a namespace, this command also provides the
 possibility to generate and output the underlying NamespaceOffloading
-resource, that can later be applied through automation tools.
+resource, that can later be applied through automation tools. Additionally, the
+offloading can be simulated without applying any change, to preview the selected
+clusters, the resulting remote namespace names and possible conflicts, as well
+as whether the current workload fits into the resources offered by each cluster.
 
 Examples:
   $ {{ .Executable }} offload namespace foo
@@ -59,8 +73,21 @@ or (cluster labels in logical AND)
 or (cluster labels in logical OR)
   $ {{ .Executable }} offload namespace foo --namespace-mapping-strategy EnforceSameName \
       --selector 'region in (europe,us-west)' --selector '!staging'
+or (remote namespace name obtained from a template)
+  $ {{ .Executable }} offload namespace foo --namespace-mapping-strategy Template \
+      --namespace-mapping-template 'tenant-{{"{{"}} .ClusterName {{"}}"}}-{{"{{"}} .Namespace {{"}}"}}'
+or (70% of the replicas of each workload in the local cluster, and 30% in a remote one)
+  $ {{ .Executable }} offload namespace foo \
+      --replica-weight <local-cluster-id>=70 --replica-weight <remote-cluster-id>=30
+or (at most 2 CPUs, 4GiB of memory and 10 pods on each remote cluster)
+  $ {{ .Executable }} offload namespace foo --remote-cluster-quota cpu=2,memory=4Gi,pods=10
+or (retain the remote namespaces upon unoffloading, and propagate the Pod Security Admission labels)
+  $ {{ .Executable }} offload namespace foo --remote-namespace-retention-policy Retain \
+      --propagate-label 'pod-security.kubernetes.io/*'
 or (output the NamespaceOffloading resource as a yaml manifest, without applying it)
   $ {{ .Executable }} offload namespace foo --output yaml
+or (simulate the offloading, without applying it)
+  $ {{ .Executable }} offload namespace foo --namespace-mapping-strategy EnforceSameName --selector 'region=europe' --dry-run
 `
 
 func newOffloadCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
@@ -76,7 +103,8 @@ func newOffloadCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
 }
 
 func newOffloadNamespaceCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
-	var selectors []string
+	var selectors, weights []string
+	var quota map[string]string
 
 	podOffloadingStrategy := args.NewEnum([]string{
 		string(offloadingv1alpha1.LocalAndRemotePodOffloadingStrategyType),
@@ -86,9 +114,26 @@ func newOffloadNamespaceCommand(ctx context.Context, f *factory.Factory) *cobra.
 
 	namespaceMappingStrategy := args.NewEnum([]string{
 		string(offloadingv1alpha1.EnforceSameNameMappingStrategyType),
-		string(offloadingv1alpha1.DefaultNameMappingStrategyType)},
+		string(offloadingv1alpha1.DefaultNameMappingStrategyType),
+		string(offloadingv1alpha1.TemplateNameMappingStrategyType)},
 		string(offloadingv1alpha1.DefaultNameMappingStrategyType))
 
+	failoverPolicy := args.NewEnum([]string{
+		string(offloadingv1alpha1.NoneFailoverPolicyType),
+		string(offloadingv1alpha1.RescheduleFailoverPolicyType)},
+		string(offloadingv1alpha1.NoneFailoverPolicyType))
+
+	migrationPolicy := args.NewEnum([]string{
+		string(offloadingv1alpha1.ImmediateMigrationPolicyType),
+		string(offloadingv1alpha1.DrainMigrationPolicyType),
+		string(offloadingv1alpha1.RetainMigrationPolicyType)},
+		string(offloadingv1alpha1.ImmediateMigrationPolicyType))
+
+	retentionPolicy := args.NewEnum([]string{
+		string(offloadingv1alpha1.DeleteRemoteNamespaceRetentionPolicyType),
+		string(offloadingv1alpha1.RetainRemoteNamespaceRetentionPolicyType)},
+		string(offloadingv1alpha1.DeleteRemoteNamespaceRetentionPolicyType))
+
 	outputFormat := args.NewEnum([]string{"json", "yaml"}, "")
 
 	options := offload.Options{Factory: f}
@@ -104,8 +149,19 @@ func newOffloadNamespaceCommand(ctx context.Context, f *factory.Factory) *cobra.
 		PreRun: func(cmd *cobra.Command, args []string) {
 			options.PodOffloadingStrategy = offloadingv1alpha1.PodOffloadingStrategyType(podOffloadingStrategy.Value)
 			options.NamespaceMappingStrategy = offloadingv1alpha1.NamespaceMappingStrategyType(namespaceMappingStrategy.Value)
+			options.FailoverPolicy = offloadingv1alpha1.FailoverPolicyType(failoverPolicy.Value)
+			options.MigrationPolicy = offloadingv1alpha1.MigrationPolicyType(migrationPolicy.Value)
+			options.RetentionPolicy = offloadingv1alpha1.RemoteNamespaceRetentionPolicyType(retentionPolicy.Value)
+			if (options.NamespaceMappingStrategy == offloadingv1alpha1.TemplateNameMappingStrategyType) != (options.NamespaceMappingTemplate != "") {
+				options.Printer.CheckErr(fmt.Errorf("--namespace-mapping-template shall be specified if and only if the Template strategy is selected"))
+			}
 			options.OutputFormat = outputFormat.Value
+			if options.DryRun && options.OutputFormat != "" {
+				options.Printer.CheckErr(fmt.Errorf("--dry-run and --output cannot be specified together"))
+			}
 			options.Printer.CheckErr(options.ParseClusterSelectors(selectors))
+			options.Printer.CheckErr(options.ParseReplicaWeights(weights))
+			options.Printer.CheckErr(options.ParseRemoteClusterQuota(quota))
 		},
 
 		Run: func(cmd *cobra.Command, args []string) {
@@ -117,17 +173,44 @@ func newOffloadNamespaceCommand(ctx context.Context, f *factory.Factory) *cobra.
 	cmd.Flags().Var(podOffloadingStrategy, "pod-offloading-strategy",
 		"The constraints regarding pods scheduling in this namespace, among Local, Remote and LocalAndRemote")
 	cmd.Flags().Var(namespaceMappingStrategy, "namespace-mapping-strategy",
-		"The naming strategy adopted for the creation of remote namespaces, among DefaultName and EnforceSameName")
+		"The naming strategy adopted for the creation of remote namespaces, among DefaultName, EnforceSameName and Template")
+	cmd.Flags().StringVar(&options.NamespaceMappingTemplate, "namespace-mapping-template", "",
+		"The template the name of remote namespaces is obtained from, with the Template naming strategy")
+	cmd.Flags().Var(failoverPolicy, "failover-policy",
+		"The policy adopted in case a remote cluster hosting pods of this namespace becomes unavailable, among None and Reschedule")
+	cmd.Flags().Var(migrationPolicy, "migration-policy",
+		"The policy adopted in case a remote cluster hosting pods of this namespace is no longer selected, among Immediate, Drain and Retain")
+	cmd.Flags().Var(retentionPolicy, "remote-namespace-retention-policy",
+		"The policy adopted for remote namespaces no longer required (e.g., upon unoffloading), among Delete and Retain")
 	cmd.Flags().DurationVar(&options.Timeout, "timeout", 20*time.Second, "The timeout for the offloading process")
 
 	cmd.Flags().StringArrayVarP(&selectors, "selector", "l", []string{},
 		"The selector to filter the target clusters. Can be specified multiple times, defining alternative requirements (i.e., in logical OR)")
+	cmd.Flags().StringArrayVar(&weights, "replica-weight", []string{},
+		"The relative weight of a cluster in the distribution of the replicas of each workload, in the form <cluster-id>=<weight>. "+
+			"Can be specified multiple times, once for each target cluster")
+	cmd.Flags().StringToStringVar(&quota, "remote-cluster-quota", map[string]string{},
+		"The maximum amount of resources the pods of this namespace can request on each remote cluster, "+
+			"in the form <resource>=<quantity> (e.g., cpu=2,memory=4Gi,pods=10). Supported resources: cpu, memory and pods")
+	cmd.Flags().StringArrayVar(&options.PropagatedLabels, "propagate-label", []string{},
+		"The key of a label propagated from the local namespace to the remote ones. A trailing '*' matches all keys with the given prefix. "+
+			"Can be specified multiple times")
+	cmd.Flags().StringArrayVar(&options.PropagatedAnnotations, "propagate-annotation", []string{},
+		"The key of an annotation propagated from the local namespace to the remote ones. A trailing '*' matches all keys with the given prefix. "+
+			"Can be specified multiple times")
 
 	cmd.Flags().VarP(outputFormat, "output", "o",
 		"Output the resulting NamespaceOffloading resource, instead of applying it. Supported formats: json, yaml")
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", false,
+		"Simulate the offloading against the current remote clusters, without applying it")
+
+	f.AddLiqoNamespaceFlag(cmd.Flags())
 
 	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("pod-offloading-strategy", completion.Enumeration(podOffloadingStrategy.Allowed)))
 	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("namespace-mapping-strategy", completion.Enumeration(namespaceMappingStrategy.Allowed)))
+	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("failover-policy", completion.Enumeration(failoverPolicy.Allowed)))
+	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("migration-policy", completion.Enumeration(migrationPolicy.Allowed)))
+	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("remote-namespace-retention-policy", completion.Enumeration(retentionPolicy.Allowed)))
 	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("output", completion.Enumeration(outputFormat.Allowed)))
 
 	return cmd
@@ -152,6 +152,8 @@ func newPeerOutOfBandCommand(ctx context.Context, peerOptions *peer.Options) *co
 		"The authentication token of the target remote cluster")
 	cmd.Flags().StringVar(&options.ClusterID, peeroob.ClusterIDFlagName, "",
 		"The Cluster ID identifying the target remote cluster")
+	cmd.Flags().StringVar(&options.CAFingerprint, peeroob.CAFingerprintFlagName, "",
+		"The SHA-256 fingerprint of the CA of the authentication service of the target remote cluster, to be pinned")
 
 	f := peerOptions.Factory
 	f.AddLiqoNamespaceFlag(cmd.Flags())
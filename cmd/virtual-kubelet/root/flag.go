@@ -20,6 +20,7 @@ import (
 	"github.com/spf13/pflag"
 	"k8s.io/klog/v2"
 
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
 )
 
@@ -45,7 +46,11 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flags.UintVar(&o.ServiceWorkers, "service-reflection-workers", o.ServiceWorkers, "The number of service reflection workers")
 	flags.UintVar(&o.EndpointSliceWorkers, "endpointslice-reflection-workers", o.EndpointSliceWorkers,
 		"The number of endpointslice reflection workers")
+	flags.UintVar(&o.ImportedServiceWorkers, "imported-service-reflection-workers", o.ImportedServiceWorkers,
+		"The number of workers importing the services exported by the remote cluster, 0 to disable")
 	flags.UintVar(&o.IngressWorkers, "ingress-reflection-workers", o.IngressWorkers, "The number of ingress reflection workers")
+	flags.UintVar(&o.NetworkPolicyWorkers, "networkpolicy-reflection-workers", o.NetworkPolicyWorkers,
+		"The number of networkpolicy reflection workers")
 	flags.UintVar(&o.ConfigMapWorkers, "configmap-reflection-workers", o.ConfigMapWorkers, "The number of configmap reflection workers")
 	flags.UintVar(&o.SecretWorkers, "secret-reflection-workers", o.SecretWorkers, "The number of secret reflection workers")
 	flags.UintVar(&o.ServiceAccountWorkers, "service-account-reflection-workers", o.ServiceAccountWorkers,
@@ -67,6 +72,8 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flags.BoolVar(&o.EnableStorage, "enable-storage", false, "Enable the Liqo storage reflection")
 	flags.StringVar(&o.VirtualStorageClassName, "virtual-storage-class-name", "liqo", "Name of the virtual storage class")
 	flags.StringVar(&o.RemoteRealStorageClassName, "remote-real-storage-class-name", "", "Name of the real storage class to use for the actual volumes")
+	flags.Var(&o.PriorityClassMapping, "priority-class-mapping",
+		"The mapping between local and remote priority classes of offloaded pods, in the form local1=remote1,local2=remote2")
 
 	flagset := flag.NewFlagSet("klog", flag.PanicOnError)
 	klog.InitFlags(flagset)
@@ -78,4 +85,8 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flagset = flag.NewFlagSet("restcfg", flag.PanicOnError)
 	restcfg.InitFlags(flagset)
 	flags.AddGoFlagSet(flagset)
+
+	flagset = flag.NewFlagSet("identity-storage", flag.PanicOnError)
+	identitymanager.InitStorageFlags(flagset)
+	flags.AddGoFlagSet(flagset)
 }
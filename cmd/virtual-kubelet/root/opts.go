@@ -45,7 +45,9 @@ const (
 	DefaultPodWorkers                  = 10
 	DefaultServiceWorkers              = 3
 	DefaultEndpointSliceWorkers        = 10
+	DefaultImportedServiceWorkers      = 3
 	DefaultIngressWorkers              = 3
+	DefaultNetworkPolicyWorkers        = 3
 	DefaultConfigMapWorkers            = 3
 	DefaultSecretWorkers               = 3
 	DefaultServiceAccountWorkers       = 3
@@ -78,7 +80,9 @@ type Opts struct {
 	PodWorkers                   uint
 	ServiceWorkers               uint
 	EndpointSliceWorkers         uint
+	ImportedServiceWorkers       uint
 	IngressWorkers               uint
+	NetworkPolicyWorkers         uint
 	ConfigMapWorkers             uint
 	SecretWorkers                uint
 	ServiceAccountWorkers        uint
@@ -95,6 +99,7 @@ type Opts struct {
 	EnableStorage              bool
 	VirtualStorageClassName    string
 	RemoteRealStorageClassName string
+	PriorityClassMapping       argsutils.StringMap
 }
 
 // NewOpts returns an Opts struct with the default values set.
@@ -114,7 +119,9 @@ func NewOpts() *Opts {
 		PodWorkers:                   DefaultPodWorkers,
 		ServiceWorkers:               DefaultServiceWorkers,
 		EndpointSliceWorkers:         DefaultEndpointSliceWorkers,
+		ImportedServiceWorkers:       DefaultImportedServiceWorkers,
 		IngressWorkers:               DefaultIngressWorkers,
+		NetworkPolicyWorkers:         DefaultNetworkPolicyWorkers,
 		ConfigMapWorkers:             DefaultConfigMapWorkers,
 		SecretWorkers:                DefaultSecretWorkers,
 		ServiceAccountWorkers:        DefaultServiceAccountWorkers,
@@ -73,7 +73,11 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 
 	// Retrieve the remote restcfg
 	tenantNamespaceManager := tenantnamespace.NewManager(localClient) // Do not use the cached version, as leveraged only once.
-	identityManager := identitymanager.NewCertificateIdentityReader(localClient, c.HomeCluster, tenantNamespaceManager)
+	identityStorage, err := identitymanager.NewIdentityStorageFromFlags(ctx, localClient)
+	if err != nil {
+		return err
+	}
+	identityManager := identitymanager.NewCertificateIdentityReader(localClient, c.HomeCluster, tenantNamespaceManager, identityStorage)
 
 	remoteConfig, err := identityManager.GetConfig(c.ForeignCluster, c.TenantNamespace)
 	if err != nil {
@@ -99,7 +103,9 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 		PodWorkers:                  c.PodWorkers,
 		ServiceWorkers:              c.ServiceWorkers,
 		EndpointSliceWorkers:        c.EndpointSliceWorkers,
+		ImportedServiceWorkers:      c.ImportedServiceWorkers,
 		IngressWorkers:              c.IngressWorkers,
+		NetworkPolicyWorkers:        c.NetworkPolicyWorkers,
 		ConfigMapWorkers:            c.ConfigMapWorkers,
 		SecretWorkers:               c.SecretWorkers,
 		ServiceAccountWorkers:       c.ServiceAccountWorkers,
@@ -109,6 +115,7 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 		EnableStorage:              c.EnableStorage,
 		VirtualStorageClassName:    c.VirtualStorageClassName,
 		RemoteRealStorageClassName: c.RemoteRealStorageClassName,
+		PriorityClassMapping:       c.PriorityClassMapping.StringMap,
 	}
 
 	eb := record.NewBroadcaster()
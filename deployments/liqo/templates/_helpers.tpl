@@ -172,6 +172,24 @@ Concatenates a values list into a string in the form "--commandName=val1,val2"
 - {{ trimSuffix "," $res }}
 {{- end -}}
 
+{{/*
+Get the arguments to configure the backend storing the identities used to authenticate with remote clusters
+*/}}
+{{- define "liqo.identityStorageArgs" -}}
+{{- if eq .Values.identityStorage.backend "vault" }}
+- --identity-storage-backend=vault
+{{- else if ne .Values.identityStorage.backend "secret" }}
+{{ fail (printf "Unsupported identity storage backend \"%s\"" .Values.identityStorage.backend) }}
+{{- end }}
+{{- if or (eq .Values.identityStorage.backend "vault") (eq .Values.identityStorage.migrateFrom "vault") }}
+- --identity-storage-vault-address={{ required "identityStorage.vault.address is required with the vault backend" .Values.identityStorage.vault.address }}
+- --identity-storage-vault-mount-path={{ .Values.identityStorage.vault.mountPath }}
+- --identity-storage-vault-path-prefix={{ .Values.identityStorage.vault.pathPrefix }}
+- --identity-storage-vault-token-secret={{ required "identityStorage.vault.tokenSecret is required with the vault backend" .Values.identityStorage.vault.tokenSecret }}
+- --identity-storage-vault-token-renew-period={{ .Values.identityStorage.vault.tokenRenewPeriod }}
+{{- end }}
+{{- end -}}
+
 {{/*
 Get the liqo clusterID ConfigMap name
 */}}
@@ -0,0 +1,219 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	autherrors "github.com/liqotech/liqo/pkg/auth/errors"
+)
+
+// auditOutcome is the outcome of a request towards the authentication service.
+type auditOutcome string
+
+const (
+	// auditOutcomeSuccess -> the request has been served successfully.
+	auditOutcomeSuccess auditOutcome = "Success"
+	// auditOutcomeAuthenticationFailed -> the credentials provided by the remote cluster are not valid.
+	auditOutcomeAuthenticationFailed auditOutcome = "AuthenticationFailed"
+	// auditOutcomeDenied -> the request has been denied (e.g., the identity of the remote cluster has been revoked).
+	auditOutcomeDenied auditOutcome = "Denied"
+	// auditOutcomeInvalid -> the request is malformed.
+	auditOutcomeInvalid auditOutcome = "Invalid"
+	// auditOutcomeRateLimited -> the request has been rejected due to rate limiting.
+	auditOutcomeRateLimited auditOutcome = "RateLimited"
+	// auditOutcomeError -> an error occurred while serving the request.
+	auditOutcomeError auditOutcome = "Error"
+)
+
+// AuditConfig contains the configuration of the audit log of the authentication service.
+type AuditConfig struct {
+	// LogPath is the path of the file the audit records are appended to ("-" for the standard output, disabled if empty).
+	LogPath string
+}
+
+// requestsCounter counts the requests served by the authentication service, by endpoint and outcome.
+var requestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "liqo_auth_requests_total",
+	Help: "The total number of requests towards the authentication service, by endpoint and outcome.",
+}, []string{"endpoint", "outcome"})
+
+func init() {
+	prometheus.MustRegister(requestsCounter)
+}
+
+// auditRecord is a structured record describing a request towards the authentication service.
+type auditRecord struct {
+	Timestamp     time.Time                          `json:"timestamp"`
+	Endpoint      string                             `json:"endpoint"`
+	RemoteAddress string                             `json:"remoteAddress"`
+	Source        string                             `json:"source"`
+	Cluster       *discoveryv1alpha1.ClusterIdentity `json:"cluster,omitempty"`
+	Outcome       auditOutcome                       `json:"outcome"`
+	Reason        string                             `json:"reason,omitempty"`
+}
+
+// auditLogger writes the audit records to the configured sink.
+type auditLogger struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// newAuditLogger returns a new audit logger writing to the sink configured by the given configuration (nil if disabled).
+func newAuditLogger(config AuditConfig) (*auditLogger, error) {
+	var sink io.Writer
+	switch config.LogPath {
+	case "":
+		return nil, nil
+	case "-":
+		sink = os.Stdout
+	default:
+		file, err := os.OpenFile(config.LogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the audit log: %w", err)
+		}
+		sink = file
+	}
+	return &auditLogger{encoder: json.NewEncoder(sink)}, nil
+}
+
+// log writes the given record to the audit log.
+func (logger *auditLogger) log(record *auditRecord) {
+	if logger == nil {
+		return
+	}
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	if err := logger.encoder.Encode(record); err != nil {
+		klog.Errorf("Failed to write the audit record: %v", err)
+	}
+}
+
+type auditRecordKey struct{}
+
+// claimCluster records the identity claimed by the remote cluster in the audit record associated with the given context,
+// and returns an error if that cluster is currently blocked from the same source, due to repeated authentication failures.
+func (authService *Controller) claimCluster(ctx context.Context, cluster discoveryv1alpha1.ClusterIdentity) error {
+	record, ok := ctx.Value(auditRecordKey{}).(*auditRecord)
+	if !ok {
+		return nil
+	}
+
+	record.Cluster = &cluster
+	if authService.rateLimiter.backedOff(cluster.ClusterID, record.Source) {
+		return &autherrors.TooManyRequestsError{Reason: "too many authentication failures"}
+	}
+	return nil
+}
+
+// auditResponseWriter wraps an http.ResponseWriter, to track the outcome of the request.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	reason string
+}
+
+// WriteHeader records the status code, and then forwards it to the wrapped ResponseWriter.
+func (w *auditResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// audited wraps the given handler, enforcing the per-source rate limiting and the authentication failure backoff,
+// and recording the outcome of each request.
+func (authService *Controller) audited(endpoint string, handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		source := authService.requestSource(r)
+		record := &auditRecord{Timestamp: time.Now(), Endpoint: endpoint, RemoteAddress: r.RemoteAddr, Source: source}
+		defer func() {
+			requestsCounter.WithLabelValues(endpoint, string(record.Outcome)).Inc()
+			authService.auditLogger.log(record)
+		}()
+
+		if allowed, reason := authService.rateLimiter.allow(source); !allowed {
+			klog.Warningf("Rejecting request from %s to %s: %s", r.RemoteAddr, endpoint, reason)
+			record.Outcome, record.Reason = auditOutcomeRateLimited, reason
+			http.Error(w, reason, http.StatusTooManyRequests)
+			return
+		}
+
+		aw := &auditResponseWriter{ResponseWriter: w}
+		handle(aw, r.WithContext(context.WithValue(r.Context(), auditRecordKey{}, record)), ps)
+
+		record.Outcome, record.Reason = outcomeFromStatus(aw.status), aw.reason
+		if record.Cluster == nil {
+			return
+		}
+
+		switch record.Outcome {
+		case auditOutcomeAuthenticationFailed:
+			authService.rateLimiter.authenticationFailed(record.Cluster.ClusterID, source)
+		case auditOutcomeSuccess:
+			authService.rateLimiter.authenticationSucceeded(record.Cluster.ClusterID, source)
+		}
+	}
+}
+
+// requestSource returns the source of the given request, that is the last address of the X-Forwarded-For header
+// if configured to be trusted and present, and the remote address of the connection otherwise.
+func (authService *Controller) requestSource(r *http.Request) string {
+	if authService.rateLimiter.config.TrustForwardedFor {
+		forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		if source := strings.TrimSpace(forwarded[len(forwarded)-1]); source != "" {
+			return source
+		}
+	}
+
+	source, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return source
+}
+
+// outcomeFromStatus returns the outcome corresponding to the given status code.
+func outcomeFromStatus(status int) auditOutcome {
+	switch {
+	case status == 0 || status < http.StatusBadRequest:
+		return auditOutcomeSuccess
+	case status == http.StatusUnauthorized:
+		return auditOutcomeAuthenticationFailed
+	case status == http.StatusForbidden:
+		return auditOutcomeDenied
+	case status == http.StatusBadRequest:
+		return auditOutcomeInvalid
+	case status == http.StatusTooManyRequests:
+		return auditOutcomeRateLimited
+	default:
+		return auditOutcomeError
+	}
+}
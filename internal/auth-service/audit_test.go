@@ -0,0 +1,198 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	autherrors "github.com/liqotech/liqo/pkg/auth/errors"
+)
+
+var _ = Describe("Audit and rate limiting", func() {
+	const endpoint = "/test"
+
+	var (
+		controller Controller
+		config     RateLimitConfig
+		sink       bytes.Buffer
+		handlerErr error
+		handler    httprouter.Handle
+	)
+
+	requestWithHeaders := func(source, clusterID string, headers map[string]string) int {
+		req := httptest.NewRequest(http.MethodPost, endpoint, http.NoBody)
+		req.RemoteAddr = source + ":12345"
+		req.Header.Set("X-Cluster-ID", clusterID)
+		for key, value := range headers {
+			req.Header.Add(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, req, nil)
+		return recorder.Code
+	}
+
+	request := func(source string) int {
+		return requestWithHeaders(source, "remote-id", nil)
+	}
+
+	records := func() []auditRecord {
+		var result []auditRecord
+		decoder := json.NewDecoder(bytes.NewReader(sink.Bytes()))
+		for decoder.More() {
+			var record auditRecord
+			Expect(decoder.Decode(&record)).To(Succeed())
+			result = append(result, record)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		sink.Reset()
+		handlerErr = nil
+		config = RateLimitConfig{QPS: 1, Burst: 3, BackoffInitial: time.Minute, BackoffMax: time.Hour}
+	})
+
+	JustBeforeEach(func() {
+		controller = Controller{
+			auditLogger: &auditLogger{encoder: json.NewEncoder(&sink)},
+			rateLimiter: newSourceRateLimiter(config),
+		}
+		handler = controller.audited(endpoint, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			cluster := discoveryv1alpha1.ClusterIdentity{ClusterID: r.Header.Get("X-Cluster-ID"), ClusterName: "remote-name"}
+			if err := controller.claimCluster(r.Context(), cluster); err != nil {
+				controller.handleError(w, err)
+				return
+			}
+			if handlerErr != nil {
+				controller.handleError(w, handlerErr)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+
+	It("should record the outcome of successful requests", func() {
+		before := testutil.ToFloat64(requestsCounter.WithLabelValues(endpoint, string(auditOutcomeSuccess)))
+		Expect(request("1.1.1.1")).To(Equal(http.StatusOK))
+
+		Expect(records()).To(ConsistOf(And(
+			HaveField("Endpoint", endpoint),
+			HaveField("RemoteAddress", "1.1.1.1:12345"),
+			HaveField("Source", "1.1.1.1"),
+			HaveField("Cluster.ClusterID", "remote-id"),
+			HaveField("Outcome", auditOutcomeSuccess),
+		)))
+		Expect(testutil.ToFloat64(requestsCounter.WithLabelValues(endpoint, string(auditOutcomeSuccess)))).To(Equal(before + 1))
+	})
+
+	It("should record the reason of failed requests, and back off the cluster from the same source", func() {
+		handlerErr = &autherrors.AuthenticationFailedError{Reason: "invalid token"}
+		Expect(request("2.2.2.2")).To(Equal(http.StatusUnauthorized))
+		Expect(request("2.2.2.2")).To(Equal(http.StatusTooManyRequests))
+		// Different sources shall not be affected.
+		Expect(request("3.3.3.3")).To(Equal(http.StatusUnauthorized))
+
+		Expect(records()).To(HaveLen(3))
+		Expect(records()[0]).To(And(
+			HaveField("Outcome", auditOutcomeAuthenticationFailed),
+			HaveField("Reason", ContainSubstring("invalid token")),
+		))
+		Expect(records()[1]).To(HaveField("Outcome", auditOutcomeRateLimited))
+	})
+
+	It("should not back off the other clusters sharing the same source", func() {
+		handlerErr = &autherrors.AuthenticationFailedError{Reason: "invalid token"}
+		Expect(requestWithHeaders("6.6.6.6", "attacker-id", nil)).To(Equal(http.StatusUnauthorized))
+		Expect(requestWithHeaders("6.6.6.6", "attacker-id", nil)).To(Equal(http.StatusTooManyRequests))
+
+		handlerErr = nil
+		Expect(requestWithHeaders("6.6.6.6", "remote-id", nil)).To(Equal(http.StatusOK))
+	})
+
+	When("the authentication failures from the same source exceed the allowed burst", func() {
+		BeforeEach(func() { config.QPS, config.SourceFailureBurst = 0, 2 })
+
+		It("should back off the source regardless of the claimed cluster", func() {
+			handlerErr = &autherrors.AuthenticationFailedError{Reason: "invalid token"}
+			Expect(requestWithHeaders("12.12.12.12", "attacker-1", nil)).To(Equal(http.StatusUnauthorized))
+			Expect(requestWithHeaders("12.12.12.12", "attacker-2", nil)).To(Equal(http.StatusUnauthorized))
+			Expect(requestWithHeaders("12.12.12.12", "attacker-3", nil)).To(Equal(http.StatusUnauthorized))
+			Expect(requestWithHeaders("12.12.12.12", "attacker-4", nil)).To(Equal(http.StatusTooManyRequests))
+
+			// Different sources shall not be affected.
+			handlerErr = nil
+			Expect(requestWithHeaders("13.13.13.13", "remote-id", nil)).To(Equal(http.StatusOK))
+		})
+	})
+
+	It("should ignore the X-Forwarded-For header if not trusted", func() {
+		headers := map[string]string{"X-Forwarded-For": "7.7.7.7"}
+		Expect(requestWithHeaders("8.8.8.8", "remote-id", headers)).To(Equal(http.StatusOK))
+		Expect(records()).To(ConsistOf(HaveField("Source", "8.8.8.8")))
+	})
+
+	When("the X-Forwarded-For header is trusted", func() {
+		BeforeEach(func() { config.TrustForwardedFor = true })
+
+		It("should identify the source through the last forwarded address", func() {
+			headers := map[string]string{"X-Forwarded-For": "1.2.3.4, 7.7.7.7"}
+			Expect(requestWithHeaders("8.8.8.8", "remote-id", headers)).To(Equal(http.StatusOK))
+			Expect(requestWithHeaders("8.8.8.8", "remote-id", nil)).To(Equal(http.StatusOK))
+			Expect(records()).To(HaveLen(2))
+			Expect(records()[0]).To(HaveField("Source", "7.7.7.7"))
+			Expect(records()[1]).To(HaveField("Source", "8.8.8.8"))
+		})
+
+		It("should rate limit the requests based on the forwarded address", func() {
+			headers := map[string]string{"X-Forwarded-For": "9.9.9.9"}
+			for i := 0; i < 3; i++ {
+				Expect(requestWithHeaders("8.8.8.8", "remote-id", headers)).To(Equal(http.StatusOK))
+			}
+			Expect(requestWithHeaders("8.8.8.8", "remote-id", headers)).To(Equal(http.StatusTooManyRequests))
+			Expect(requestWithHeaders("8.8.8.8", "remote-id", map[string]string{"X-Forwarded-For": "10.10.10.10"})).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("the rate limiting is disabled", func() {
+		BeforeEach(func() { config.QPS = 0 })
+
+		It("should still garbage collect the backoff state", func() {
+			handlerErr = &autherrors.AuthenticationFailedError{Reason: "invalid token"}
+			Expect(request("11.11.11.11")).To(Equal(http.StatusUnauthorized))
+
+			controller.rateLimiter.lastGC = time.Now().Add(-2 * sourceGCInterval)
+			Expect(request("11.11.11.11")).To(Equal(http.StatusTooManyRequests))
+			Expect(controller.rateLimiter.lastGC).To(BeTemporally("~", time.Now(), time.Minute))
+		})
+	})
+
+	It("should rate limit the requests from the same source", func() {
+		for i := 0; i < 3; i++ {
+			Expect(request("4.4.4.4")).To(Equal(http.StatusOK))
+		}
+		Expect(request("4.4.4.4")).To(Equal(http.StatusTooManyRequests))
+		Expect(request("5.5.5.5")).To(Equal(http.StatusOK))
+	})
+})
@@ -16,7 +16,9 @@ package authservice
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -51,6 +53,7 @@ import (
 // role
 // +kubebuilder:rbac:groups=core,namespace="do-not-care",resources=secrets,verbs=create;update;patch;get;list;watch;delete
 // +kubebuilder:rbac:groups=core,namespace="do-not-care",resources=configmaps,verbs=create;update;patch;get;list;watch;delete
+// +kubebuilder:rbac:groups=core,namespace="do-not-care",resources=services,verbs=get
 
 // Controller is the controller for the Authentication Service.
 type Controller struct {
@@ -68,13 +71,19 @@ type Controller struct {
 	apiServerConfig apiserver.Config
 
 	peeringPermission peeringroles.PeeringPermission
+
+	auditLogger *auditLogger
+	rateLimiter *sourceRateLimiter
+
+	servingCertificate atomic.Pointer[servingCertificate]
 }
 
 // NewAuthServiceCtrl creates a new Auth Controller.
 func NewAuthServiceCtrl(ctx context.Context, config *rest.Config, namespace string,
 	awsConfig identitymanager.AwsConfig, resyncTime time.Duration,
 	apiServerConfig apiserver.Config, authEnabled, useTLS bool,
-	localCluster discoveryv1alpha1.ClusterIdentity) (*Controller, error) {
+	localCluster discoveryv1alpha1.ClusterIdentity, tokenAuthConfig TokenAuthenticationConfig,
+	auditConfig AuditConfig, rateLimitConfig RateLimitConfig) (*Controller, error) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
@@ -104,6 +113,11 @@ func NewAuthServiceCtrl(ctx context.Context, config *rest.Config, namespace stri
 			clientset, localCluster, &awsConfig, namespaceManager)
 	}
 
+	auditLogger, err := newAuditLogger(auditConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Controller{
 		namespace:        namespace,
 		clientset:        clientset,
@@ -115,7 +129,13 @@ func NewAuthServiceCtrl(ctx context.Context, config *rest.Config, namespace stri
 		apiServerConfig: apiServerConfig,
 
 		authenticationEnabled: authEnabled,
-		credentialsValidator:  &tokenValidator{},
+		credentialsValidator: &tokenValidator{
+			authenticators:       tokenAuthConfig.authenticators(),
+			clusterTokenDisabled: tokenAuthConfig.DisableClusterToken,
+		},
+
+		auditLogger: auditLogger,
+		rateLimiter: newSourceRateLimiter(rateLimitConfig),
 	}, nil
 }
 
@@ -135,11 +155,22 @@ func (authService *Controller) Start(ctx context.Context, address string, useTLS
 
 	router := httprouter.New()
 
-	router.POST(auth.CertIdentityURI, authService.identity)
-	router.GET(auth.IdsURI, authService.ids)
+	router.POST(auth.CertIdentityURI, authService.audited(auth.CertIdentityURI, authService.identity))
+	router.GET(auth.IdsURI, authService.audited(auth.IdsURI, authService.ids))
 
 	if useTLS {
-		err = http.ListenAndServeTLS(address, certPath, keyPath, router)
+		if err = authService.ensureServingCertificate(ctx, certPath, keyPath); err != nil {
+			klog.Error(err)
+			return err
+		}
+
+		server := &http.Server{
+			Addr:              address,
+			Handler:           router,
+			ReadHeaderTimeout: 10 * time.Second,
+			TLSConfig:         &tls.Config{GetCertificate: authService.getServingCertificate, MinVersion: tls.VersionTLS12},
+		}
+		err = server.ListenAndServeTLS("", "")
 	} else {
 		err = http.ListenAndServe(address, router)
 	}
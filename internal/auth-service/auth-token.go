@@ -29,6 +29,8 @@ import (
 type tokenManager interface {
 	getToken() (string, error)
 	createToken() error
+	getPeeringTokens() ([]*auth.PeeringToken, error)
+	consumePeeringToken(ctx context.Context, token *auth.PeeringToken) error
 }
 
 func (authService *Controller) getToken() (string, error) {
@@ -85,3 +87,36 @@ func (authService *Controller) createToken() error {
 	}
 	return nil
 }
+
+// getPeeringTokens returns the named peering tokens issued by the local cluster.
+func (authService *Controller) getPeeringTokens() ([]*auth.PeeringToken, error) {
+	var tokens []*auth.PeeringToken
+	for _, obj := range authService.secretInformer.GetStore().List() {
+		secret, ok := obj.(*v1.Secret)
+		if !ok {
+			continue
+		}
+		if _, found := secret.GetLabels()[auth.PeeringTokenLabelKey]; !found {
+			continue
+		}
+
+		token, err := auth.PeeringTokenFromSecret(secret.DeepCopy())
+		if err != nil {
+			klog.Warningf("Ignoring malformed peering token: %v", err)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// consumePeeringToken records the usage of a named peering token.
+// The update fails in case the token has been concurrently used, hence preventing single-use tokens from being reused.
+func (authService *Controller) consumePeeringToken(ctx context.Context, token *auth.PeeringToken) error {
+	_, err := authService.clientset.CoreV1().Secrets(authService.namespace).Update(ctx, token.Consumed(), metav1.UpdateOptions{})
+	if err != nil {
+		klog.Errorf("Failed to record the usage of peering token %q: %v", token.Name, err)
+		return err
+	}
+	return nil
+}
@@ -92,6 +92,7 @@ var _ = BeforeSuite(func() {
 		identityProvider:     identityProvider,
 		credentialsValidator: &tokenValidator{},
 		apiServerConfig:      config,
+		rateLimiter:          newSourceRateLimiter(RateLimitConfig{}),
 	}
 
 	clusterRole := &rbacv1.ClusterRole{
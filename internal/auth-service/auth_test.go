@@ -15,16 +15,19 @@
 package authservice
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	"github.com/liqotech/liqo/pkg/auth"
@@ -33,7 +36,8 @@ import (
 )
 
 type tokenManagerMock struct {
-	token string
+	token         string
+	peeringTokens []*auth.PeeringToken
 }
 
 func (man *tokenManagerMock) getToken() (string, error) {
@@ -45,6 +49,21 @@ func (man *tokenManagerMock) createToken() error {
 	return nil
 }
 
+func (man *tokenManagerMock) getPeeringTokens() ([]*auth.PeeringToken, error) {
+	return man.peeringTokens, nil
+}
+
+func (man *tokenManagerMock) consumePeeringToken(_ context.Context, token *auth.PeeringToken) error {
+	token.Uses++
+	return nil
+}
+
+func newPeeringToken(name, value string, opts *auth.PeeringTokenOptions, now time.Time) *auth.PeeringToken {
+	token, err := auth.PeeringTokenFromSecret(auth.ForgePeeringTokenSecret(name, "default", value, opts, now))
+	Expect(err).ToNot(HaveOccurred())
+	return token
+}
+
 var _ = Describe("Auth", func() {
 
 	Context("Token", func() {
@@ -73,7 +92,7 @@ var _ = Describe("Auth", func() {
 
 		DescribeTable("Credential Validator table",
 			func(c credentialValidatorTestcase) {
-				err := authService.credentialsValidator.checkCredentials(&c.credentials, &tMan, c.authEnabled)
+				_, err := authService.credentialsValidator.checkCredentials(ctx, &c.credentials, &tMan, c.authEnabled)
 				Expect(err).To(c.expectedOutput)
 			},
 
@@ -116,6 +135,110 @@ var _ = Describe("Auth", func() {
 
 	})
 
+	Context("Peering tokens", func() {
+		var (
+			manager tokenManagerMock
+			valid   bool
+			matched *auth.PeeringToken
+			err     error
+		)
+
+		BeforeEach(func() {
+			manager = tokenManagerMock{}
+			Expect(manager.createToken()).To(Succeed())
+			manager.peeringTokens = []*auth.PeeringToken{
+				newPeeringToken("any", "any-token", &auth.PeeringTokenOptions{}, time.Now()),
+				newPeeringToken("bound", "bound-token", &auth.PeeringTokenOptions{ClusterID: "bound-cluster"}, time.Now()),
+				newPeeringToken("expired", "expired-token", &auth.PeeringTokenOptions{TTL: time.Minute}, time.Now().Add(-time.Hour)),
+				newPeeringToken("single-use", "single-use-token", &auth.PeeringTokenOptions{MaxUses: 1}, time.Now()),
+			}
+		})
+
+		DescribeTable("Peering token validation table",
+			func(token, clusterID string, expected bool) {
+				valid, _, err = authService.credentialsValidator.validToken(ctx, &manager, token, clusterID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(valid).To(Equal(expected))
+			},
+			Entry("cluster-wide token", "token", "cluster", true),
+			Entry("unknown token", "unknown-token", "cluster", false),
+			Entry("unbound token", "any-token", "cluster", true),
+			Entry("bound token, matching cluster", "bound-token", "bound-cluster", true),
+			Entry("bound token, different cluster", "bound-token", "cluster", false),
+			Entry("expired token", "expired-token", "cluster", false),
+			Entry("single-use token", "single-use-token", "cluster", true),
+		)
+
+		It("should reject the cluster-wide token if disabled", func() {
+			validator := tokenValidator{clusterTokenDisabled: true}
+			valid, matched, err = validator.validToken(ctx, &manager, "token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeFalse())
+			Expect(matched).To(BeNil())
+
+			valid, matched, err = validator.validToken(ctx, &manager, "any-token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeTrue())
+			Expect(matched).ToNot(BeNil())
+		})
+
+		It("should not consume the peering token during validation", func() {
+			valid, matched, err = authService.credentialsValidator.validToken(ctx, &manager, "single-use-token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeTrue())
+			Expect(matched).ToNot(BeNil())
+			Expect(matched.Name).To(Equal("single-use"))
+			Expect(matched.Uses).To(BeZero())
+
+			valid, _, err = authService.credentialsValidator.validToken(ctx, &manager, "single-use-token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeTrue())
+		})
+
+		It("should not return any peering token when matching the cluster-wide token", func() {
+			valid, matched, err = authService.credentialsValidator.validToken(ctx, &manager, "token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeTrue())
+			Expect(matched).To(BeNil())
+		})
+
+		It("should reject a single-use token once already used", func() {
+			valid, matched, err = authService.credentialsValidator.validToken(ctx, &manager, "single-use-token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeTrue())
+			Expect(manager.consumePeeringToken(ctx, matched)).To(Succeed())
+
+			valid, _, err = authService.credentialsValidator.validToken(ctx, &manager, "single-use-token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeFalse())
+		})
+
+		It("should retrieve and consume the peering tokens stored in secrets", func() {
+			secret := auth.ForgePeeringTokenSecret("stored", "default", "stored-token", &auth.PeeringTokenOptions{MaxUses: 2}, time.Now())
+			_, err = authService.clientset.CoreV1().Secrets("default").Create(ctx, secret, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			var stored *auth.PeeringToken
+			Eventually(func() *auth.PeeringToken {
+				tokens, _ := authService.getPeeringTokens()
+				for _, token := range tokens {
+					if token.Name == "stored" {
+						stored = token
+					}
+				}
+				return stored
+			}).ShouldNot(BeNil())
+
+			Expect(authService.consumePeeringToken(ctx, stored)).To(Succeed())
+			// A concurrent usage of the same token version should be rejected.
+			Expect(authService.consumePeeringToken(ctx, stored)).ToNot(Succeed())
+
+			updated, err := authService.clientset.CoreV1().Secrets("default").Get(ctx, secret.GetName(), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.GetAnnotations()).To(HaveKeyWithValue(auth.PeeringTokenUsesAnnotationKey, "1"))
+		})
+	})
+
 	Context("Certificate Identity Creation", func() {
 
 		var (
@@ -31,6 +31,8 @@ func (authService *Controller) handleError(w http.ResponseWriter, err error) {
 		authService.sendError(w, err.Error(), http.StatusBadRequest)
 	case *autherrors.AuthenticationFailedError:
 		authService.sendError(w, err.Error(), http.StatusUnauthorized)
+	case *autherrors.TooManyRequestsError:
+		authService.sendError(w, err.Error(), http.StatusTooManyRequests)
 	default:
 		authService.sendError(w, err.Error(), http.StatusInternalServerError)
 	}
@@ -38,5 +40,8 @@ func (authService *Controller) handleError(w http.ResponseWriter, err error) {
 
 func (authService *Controller) sendError(w http.ResponseWriter, resp string, code int) {
 	klog.V(3).Infof("%v - sending error response: %v", code, resp)
+	if aw, ok := w.(*auditResponseWriter); ok {
+		aw.reason = resp
+	}
 	http.Error(w, resp, code)
 }
@@ -17,8 +17,10 @@ package authservice
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -28,7 +30,9 @@ import (
 
 	"github.com/liqotech/liqo/pkg/auth"
 	autherrors "github.com/liqotech/liqo/pkg/auth/errors"
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
 	"github.com/liqotech/liqo/pkg/utils/authenticationtoken"
+	csrutil "github.com/liqotech/liqo/pkg/utils/csr"
 	traceutils "github.com/liqotech/liqo/pkg/utils/trace"
 )
 
@@ -56,6 +60,12 @@ func (authService *Controller) identity(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	if err = authService.claimCluster(ctx, identityRequest.ClusterIdentity); err != nil {
+		klog.Warningf("Rejecting the identity request of cluster %v: %v", identityRequest.ClusterIdentity, err)
+		authService.handleError(w, err)
+		return
+	}
+
 	response, err := authService.handleIdentity(ctx, identityRequest)
 	if err != nil {
 		klog.Error(err)
@@ -84,15 +94,18 @@ func (authService *Controller) handleIdentity(
 	tracer := trace.FromContext(ctx).Nest("Identity handling")
 	defer tracer.LogIfLong(traceutils.LongThreshold())
 	var err error
+	var peeringToken *auth.PeeringToken
 
-	// check that the provided credentials are valid
-	klog.V(4).Info("Checking credentials")
-	if err = authService.credentialsValidator.checkCredentials(
-		&identityRequest, authService.getTokenManager(), authService.authenticationEnabled); err != nil {
-		klog.Error(err)
-		return nil, err
+	// check that the provided credentials are valid (renewals are authenticated through the current certificate)
+	if !identityRequest.IsRenewal() {
+		klog.V(4).Info("Checking credentials")
+		if peeringToken, err = authService.credentialsValidator.checkCredentials(ctx,
+			&identityRequest, authService.getTokenManager(), authService.authenticationEnabled); err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		tracer.Step("Credentials checked")
 	}
-	tracer.Step("Credentials checked")
 
 	remoteClusterIdentity := identityRequest.ClusterIdentity
 	klog.V(4).Infof("Creating Tenant Namespace for cluster %s", remoteClusterIdentity)
@@ -103,22 +116,61 @@ func (authService *Controller) handleIdentity(
 	}
 	tracer.Step("Tenant namespace created")
 
-	// check that there is no available certificate for that clusterID
-	if _, err = authService.identityProvider.GetRemoteCertificate(
-		remoteClusterIdentity, namespace.Name, identityRequest.CertificateSigningRequest); err == nil {
-		klog.Info("multiple identity validations with unique clusterID")
+	// check that the identity of the remote cluster has not been revoked
+	revocation, err := authService.identityProvider.GetRevocation(remoteClusterIdentity, namespace.Name)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	if revocation.Revoked {
+		klog.Infof("Rejecting identity request from cluster %s, as revoked", remoteClusterIdentity)
 		err = &kerrors.StatusError{ErrStatus: metav1.Status{
-			Status: metav1.StatusFailure,
-			Code:   http.StatusForbidden,
-			Reason: metav1.StatusReasonForbidden,
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: "the identity of the cluster has been revoked",
 		}}
-		klog.Error(err)
 		return nil, err
-	} else if !kerrors.IsNotFound(err) {
-		klog.Error(err)
+	}
+	if revocation.HasValidRevokedCertificates(time.Now()) {
+		// The revoked certificates are associated with the same user as any new one, hence they would regain its permissions.
+		klog.Infof("Rejecting identity request from cluster %s, as the revoked certificates are valid until %v",
+			remoteClusterIdentity, revocation.RevokedUntil)
+		err = &kerrors.StatusError{ErrStatus: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: fmt.Sprintf("the revoked certificates of the cluster are still valid until %v", revocation.RevokedUntil.UTC().Format(time.RFC3339)),
+		}}
 		return nil, err
 	}
-	tracer.Step("Cluster ID uniqueness ensured")
+	tracer.Step("Identity revocation checked")
+
+	if identityRequest.IsRenewal() {
+		// check that the renewal request comes from the owner of the certificate currently issued for that clusterID
+		if err = authService.checkRenewal(&identityRequest, namespace.Name, revocation); err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		tracer.Step("Identity renewal checked")
+	} else {
+		// check that there is no available (and still valid) certificate for that clusterID
+		if err = authService.checkUniqueness(&identityRequest, namespace.Name, revocation); err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		tracer.Step("Cluster ID uniqueness ensured")
+	}
+
+	if peeringToken != nil {
+		// record the peering token usage before issuing anything, so that the same token cannot grant multiple identities.
+		// The update is conditional on the token version, hence concurrent requests cannot exceed the allowed uses.
+		if err = authService.getTokenManager().consumePeeringToken(ctx, peeringToken); err != nil {
+			klog.Errorf("Failed to record the usage of peering token %q: %v", peeringToken.Name, err)
+			return nil, err
+		}
+		tracer.Step("Peering token consumed")
+	}
 
 	// issue certificate request
 	identityResponse, err := authService.identityProvider.ApproveSigningRequest(
@@ -159,3 +211,67 @@ func (authService *Controller) handleIdentity(
 	klog.Infof("Identity Request successfully validated for cluster %s", remoteClusterIdentity)
 	return response, nil
 }
+
+// checkUniqueness checks that no valid certificate has already been issued for the cluster requesting a new identity.
+// A new identity can instead be issued in case the previous certificate already expired or has been revoked,
+// as it can no longer be renewed.
+func (authService *Controller) checkUniqueness(identityRequest *auth.CertificateIdentityRequest,
+	namespace string, revocation *identitymanager.Revocation) error {
+	_, err := authService.identityProvider.GetRemoteCertificate(
+		identityRequest.ClusterIdentity, namespace, identityRequest.CertificateSigningRequest)
+	switch {
+	case kerrors.IsNotFound(err):
+		return nil
+	case kerrors.IsBadRequest(err):
+		// A certificate has been issued for a different signing request. Allow the new one only if expired.
+		issued, ierr := authService.identityProvider.GetIssuedCertificate(identityRequest.ClusterIdentity, namespace)
+		if ierr != nil {
+			return ierr
+		}
+		if certificate, perr := csrutil.ParseCertificate(issued); perr == nil {
+			if time.Now().After(certificate.NotAfter) {
+				klog.Infof("The certificate previously issued to cluster %s expired, issuing a new one", identityRequest.ClusterIdentity)
+				return nil
+			}
+			if revocation.IsCertificateRevoked(identitymanager.SerialNumber(certificate)) {
+				klog.Infof("The certificate previously issued to cluster %s was revoked, issuing a new one", identityRequest.ClusterIdentity)
+				return nil
+			}
+		}
+	case err != nil:
+		return err
+	}
+
+	klog.Info("multiple identity validations with unique clusterID")
+	return &kerrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   http.StatusForbidden,
+		Reason: metav1.StatusReasonForbidden,
+	}}
+}
+
+// checkRenewal checks that the identity renewal request is legitimate, i.e., it proves the ownership of the
+// still valid certificate previously issued to the requesting cluster.
+func (authService *Controller) checkRenewal(identityRequest *auth.CertificateIdentityRequest,
+	namespace string, revocation *identitymanager.Revocation) error {
+	issued, err := authService.identityProvider.GetIssuedCertificate(identityRequest.ClusterIdentity, namespace)
+	if kerrors.IsNotFound(err) {
+		return &autherrors.AuthenticationFailedError{Reason: "no certificate to be renewed has been issued"}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = identityRequest.CheckRenewal(issued, time.Now()); err != nil {
+		return &autherrors.AuthenticationFailedError{Reason: fmt.Sprintf("identity renewal denied: %v", err)}
+	}
+
+	certificate, err := csrutil.ParseCertificate(issued)
+	if err != nil {
+		return err
+	}
+	if revocation.IsCertificateRevoked(identitymanager.SerialNumber(certificate)) {
+		return &autherrors.AuthenticationFailedError{Reason: "identity renewal denied: the presented certificate has been revoked"}
+	}
+	return nil
+}
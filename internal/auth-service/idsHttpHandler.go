@@ -51,8 +51,13 @@ func (authService *Controller) ids(w http.ResponseWriter, r *http.Request, ps ht
 }
 
 func (authService *Controller) getIdsResponse() *auth.ClusterInfo {
-	return &auth.ClusterInfo{
+	info := &auth.ClusterInfo{
 		ClusterID:   authService.localCluster.ClusterID,
 		ClusterName: authService.localCluster.ClusterName,
 	}
+	// Announce the CA of the next serving certificate, so that remote clusters can trust it before the rotation.
+	if serving := authService.servingCertificate.Load(); serving != nil {
+		info.NextCAFingerprint = serving.nextFingerprint
+	}
+	return info
 }
@@ -0,0 +1,292 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authservice
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// oidcDiscoveryPath is the path, relative to the issuer URL, exposing the OIDC provider metadata.
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+	// oidcKeysMinRefreshInterval is the minimum interval between two retrievals of the signing keys,
+	// to prevent tokens with unknown key IDs from flooding the identity provider.
+	oidcKeysMinRefreshInterval = 1 * time.Minute
+	// oidcRequestTimeout is the timeout of the requests towards the identity provider.
+	oidcRequestTimeout = 10 * time.Second
+)
+
+// OIDCConfig contains the configuration to authenticate remote clusters through OIDC ID tokens.
+type OIDCConfig struct {
+	// IssuerURL is the URL of the OIDC issuer, used to discover the signing keys and to validate the iss claim.
+	IssuerURL string
+	// Audience is the expected value of the aud claim.
+	Audience string
+	// ClusterIDsClaim is the name of the claim listing the ClusterIDs the holder of the token is allowed to peer as.
+	ClusterIDsClaim string
+}
+
+// IsEnabled returns whether the OIDC authentication is enabled.
+func (config *OIDCConfig) IsEnabled() bool {
+	return config.IssuerURL != ""
+}
+
+// oidcAuthenticator authenticates remote clusters through OIDC ID tokens issued by a trusted identity provider.
+type oidcAuthenticator struct {
+	config OIDCConfig
+	client *http.Client
+
+	mutex       sync.Mutex
+	keys        map[string]interface{}
+	lastRefresh time.Time
+}
+
+// jsonWebKey is a (subset of a) JSON Web Key, as defined by RFC 7517.
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	// RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC keys.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// newOIDCAuthenticator returns a new authenticator validating OIDC ID tokens.
+func newOIDCAuthenticator(config OIDCConfig) *oidcAuthenticator {
+	config.IssuerURL = strings.TrimSuffix(config.IssuerURL, "/")
+	return &oidcAuthenticator{
+		config: config,
+		client: &http.Client{Timeout: oidcRequestTimeout},
+	}
+}
+
+// authenticate returns whether the given token is a valid ID token, issued for the configured audience
+// and allowing the given remote cluster to peer.
+func (authenticator *oidcAuthenticator) authenticate(ctx context.Context, token, clusterID string) (bool, error) {
+	var keyErr error
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := authenticator.getKey(ctx, kid)
+		keyErr = err
+		return key, err
+	}, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}))
+
+	switch {
+	case keyErr != nil && !errors.Is(keyErr, errUnknownSigningKey):
+		return false, keyErr
+	case err != nil:
+		klog.Warningf("[%s] rejecting OIDC token: %v", clusterID, err)
+		return false, nil
+	}
+
+	now := time.Now().Unix()
+	switch {
+	case !claims.VerifyIssuer(authenticator.config.IssuerURL, true):
+		klog.Warningf("[%s] rejecting OIDC token: unexpected issuer %v", clusterID, claims["iss"])
+		return false, nil
+	case !claims.VerifyAudience(authenticator.config.Audience, true):
+		klog.Warningf("[%s] rejecting OIDC token: unexpected audience %v", clusterID, claims["aud"])
+		return false, nil
+	case !claims.VerifyExpiresAt(now, true):
+		klog.Warningf("[%s] rejecting OIDC token: missing or expired expiration time", clusterID)
+		return false, nil
+	}
+
+	if !containsClusterID(claims[authenticator.config.ClusterIDsClaim], clusterID) {
+		klog.Warningf("[%s] rejecting OIDC token: cluster not allowed by claim %q (subject: %v)",
+			clusterID, authenticator.config.ClusterIDsClaim, claims["sub"])
+		return false, nil
+	}
+
+	klog.Infof("[%s] accepting credentials through OIDC token (subject: %v)", clusterID, claims["sub"])
+	return true, nil
+}
+
+// containsClusterID returns whether the given claim (either a string or a list of strings) includes the given ClusterID.
+func containsClusterID(claim interface{}, clusterID string) bool {
+	switch value := claim.(type) {
+	case string:
+		return value == clusterID
+	case []interface{}:
+		for i := range value {
+			if str, ok := value[i].(string); ok && str == clusterID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var errUnknownSigningKey = errors.New("unknown signing key")
+
+// getKey returns the signing key with the given ID, retrieving again the keys from the identity provider if not found.
+// In case no ID is specified, the only key available is returned, if any.
+func (authenticator *oidcAuthenticator) getKey(ctx context.Context, kid string) (interface{}, error) {
+	authenticator.mutex.Lock()
+	defer authenticator.mutex.Unlock()
+
+	if key, found := authenticator.lookupKey(kid); found {
+		return key, nil
+	}
+
+	if time.Since(authenticator.lastRefresh) < oidcKeysMinRefreshInterval {
+		return nil, fmt.Errorf("%w %q", errUnknownSigningKey, kid)
+	}
+
+	keys, err := authenticator.fetchKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the OIDC signing keys: %w", err)
+	}
+	authenticator.keys = keys
+	authenticator.lastRefresh = time.Now()
+
+	if key, found := authenticator.lookupKey(kid); found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w %q", errUnknownSigningKey, kid)
+}
+
+// lookupKey returns the cached signing key with the given ID (or the only one, if the ID is empty).
+func (authenticator *oidcAuthenticator) lookupKey(kid string) (key interface{}, found bool) {
+	if kid == "" && len(authenticator.keys) == 1 {
+		for _, key = range authenticator.keys {
+			return key, true
+		}
+	}
+	key, found = authenticator.keys[kid]
+	return key, found
+}
+
+// fetchKeys discovers the JWKS URI of the identity provider, and then retrieves the signing keys.
+func (authenticator *oidcAuthenticator) fetchKeys(ctx context.Context) (map[string]interface{}, error) {
+	var metadata struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := authenticator.getJSON(ctx, authenticator.config.IssuerURL+oidcDiscoveryPath, &metadata); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != authenticator.config.IssuerURL {
+		return nil, fmt.Errorf("issuer mismatch: expected %q, found %q", authenticator.config.IssuerURL, metadata.Issuer)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := authenticator.getJSON(ctx, metadata.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for i := range jwks.Keys {
+		if jwks.Keys[i].Use != "" && jwks.Keys[i].Use != "sig" {
+			continue
+		}
+		key, err := jwks.Keys[i].publicKey()
+		if err != nil {
+			klog.Warningf("Ignoring OIDC signing key %q: %v", jwks.Keys[i].Kid, err)
+			continue
+		}
+		keys[jwks.Keys[i].Kid] = key
+	}
+	return keys, nil
+}
+
+// getJSON performs an HTTP GET request towards the given URL, and decodes the JSON response into the given object.
+func (authenticator *oidcAuthenticator) getJSON(ctx context.Context, url string, obj interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	resp, err := authenticator.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %q", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(obj)
+}
+
+// publicKey returns the public key corresponding to the JSON Web Key.
+func (jwk *jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer.
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
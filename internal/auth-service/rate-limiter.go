@@ -0,0 +1,178 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authservice
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// sourceGCInterval is the interval after which the state associated with idle sources is garbage collected.
+	sourceGCInterval = 10 * time.Minute
+	// sourceFailureRefill is the interval after which an additional authentication failure is allowed from each source.
+	sourceFailureRefill = time.Minute
+)
+
+// RateLimitConfig contains the configuration of the per-source rate limiting of the requests towards the authentication service.
+type RateLimitConfig struct {
+	// QPS is the number of requests per second allowed from each source (rate limiting is disabled if zero).
+	QPS float64
+	// Burst is the maximum burst of requests allowed from each source.
+	Burst int
+	// BackoffInitial is the period each cluster is blocked for (from the same source) after the first authentication failure,
+	// which doubles at every subsequent failure.
+	BackoffInitial time.Duration
+	// BackoffMax is the maximum period each cluster is blocked for (from the same source) after repeated authentication failures.
+	BackoffMax time.Duration
+	// SourceFailureBurst is the maximum number of authentication failures allowed from each source, regardless of the claimed
+	// cluster, before blocking the source altogether (disabled if zero). One additional failure is allowed every minute.
+	SourceFailureBurst int
+	// TrustForwardedFor configures whether the source of the requests is identified through the last address of the
+	// X-Forwarded-For header, rather than the remote address of the connection. It shall be enabled only when the
+	// authentication service is exposed through a trusted reverse proxy (e.g., the ingress), which sets the header.
+	TrustForwardedFor bool
+}
+
+// sourceRateLimiter limits the requests performed by each source, and slows down the clusters
+// repeatedly failing to authenticate (e.g., brute force attempts). The authentication failures are tracked
+// per claimed cluster and source, to prevent a single misbehaving client from locking out all the other
+// clusters sharing the same source address (e.g., when exposed through a proxy or a SNATted load balancer).
+// Additionally, the overall failures from each source are limited, so that the backoff cannot be evaded by
+// claiming a different cluster at each attempt.
+type sourceRateLimiter struct {
+	config  RateLimitConfig
+	backoff *flowcontrol.Backoff
+
+	mutex   sync.Mutex
+	sources map[string]*sourceState
+	lastGC  time.Time
+}
+
+type sourceState struct {
+	limiter  flowcontrol.RateLimiter
+	failures flowcontrol.RateLimiter
+	lastSeen time.Time
+}
+
+// newSourceRateLimiter returns a new per-source rate limiter.
+func newSourceRateLimiter(config RateLimitConfig) *sourceRateLimiter {
+	return &sourceRateLimiter{
+		config:  config,
+		backoff: flowcontrol.NewBackOff(config.BackoffInitial, config.BackoffMax),
+		sources: make(map[string]*sourceState),
+		lastGC:  time.Now(),
+	}
+}
+
+// allow returns whether a request from the given source shall be accepted, along with the reason in case it is not.
+func (limiter *sourceRateLimiter) allow(source string) (allowed bool, reason string) {
+	now := time.Now()
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.gc(now)
+	if limiter.config.QPS <= 0 {
+		return true, ""
+	}
+
+	state := limiter.sourceState(source, now)
+	if state.limiter == nil {
+		state.limiter = flowcontrol.NewTokenBucketRateLimiter(float32(limiter.config.QPS), limiter.config.Burst)
+	}
+
+	if !state.limiter.TryAccept() {
+		return false, "too many requests"
+	}
+	return true, ""
+}
+
+// sourceState returns the state associated with the given source, creating it if necessary. The mutex shall be held by the caller.
+func (limiter *sourceRateLimiter) sourceState(source string, now time.Time) *sourceState {
+	state, found := limiter.sources[source]
+	if !found {
+		state = &sourceState{}
+		limiter.sources[source] = state
+	}
+	state.lastSeen = now
+	return state
+}
+
+// backedOff returns whether the given cluster is currently blocked from the given source, due to repeated authentication
+// failures, either of the given cluster or of the source as a whole.
+func (limiter *sourceRateLimiter) backedOff(clusterID, source string) bool {
+	now := time.Now()
+	return limiter.config.BackoffInitial > 0 && (limiter.backoff.IsInBackOffSinceUpdate(backoffKey(clusterID, source), now) ||
+		limiter.backoff.IsInBackOffSinceUpdate(sourceBackoffKey(source), now))
+}
+
+// authenticationFailed records an authentication failure of the given cluster from the given source, extending its backoff period.
+// Once the failures from the given source exceed the allowed burst, the source as a whole is backed off as well.
+func (limiter *sourceRateLimiter) authenticationFailed(clusterID, source string) {
+	if limiter.config.BackoffInitial <= 0 {
+		return
+	}
+
+	now := time.Now()
+	limiter.backoff.Next(backoffKey(clusterID, source), now)
+	if limiter.config.SourceFailureBurst <= 0 {
+		return
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	state := limiter.sourceState(source, now)
+	if state.failures == nil {
+		state.failures = flowcontrol.NewTokenBucketRateLimiter(float32(time.Second)/float32(sourceFailureRefill), limiter.config.SourceFailureBurst)
+	}
+	if !state.failures.TryAccept() {
+		limiter.backoff.Next(sourceBackoffKey(source), now)
+	}
+}
+
+// authenticationSucceeded records a successful authentication of the given cluster from the given source, resetting its backoff period.
+// The backoff of the source as a whole is not reset, as it would be otherwise trivially evaded by a legitimate cluster sharing the source.
+func (limiter *sourceRateLimiter) authenticationSucceeded(clusterID, source string) {
+	limiter.backoff.Reset(backoffKey(clusterID, source))
+}
+
+// backoffKey returns the key the authentication failures of the given cluster from the given source are tracked with.
+func backoffKey(clusterID, source string) string {
+	return clusterID + "/" + source
+}
+
+// sourceBackoffKey returns the key the backoff of the given source as a whole is tracked with.
+func sourceBackoffKey(source string) string {
+	return "/" + source
+}
+
+// gc removes the state associated with the sources which have been idle for a while. The mutex shall be held by the caller.
+func (limiter *sourceRateLimiter) gc(now time.Time) {
+	if now.Sub(limiter.lastGC) < sourceGCInterval {
+		return
+	}
+
+	for source, state := range limiter.sources {
+		if now.Sub(state.lastSeen) > sourceGCInterval {
+			delete(limiter.sources, source)
+		}
+	}
+	limiter.backoff.GC()
+	limiter.lastGC = now
+}
@@ -0,0 +1,363 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authservice
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/liqotech/liqo/pkg/auth"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	csrutil "github.com/liqotech/liqo/pkg/utils/csr"
+)
+
+const (
+	// servingCertificateValidity is the validity of the serving certificates generated upon rotation.
+	servingCertificateValidity = 365 * 24 * time.Hour
+	// servingCertificateAnnounceBefore is how long before the expiration of the current serving certificate the next one
+	// is generated, and the fingerprint of its CA announced to the remote clusters (which start trusting it as well).
+	servingCertificateAnnounceBefore = 90 * 24 * time.Hour
+	// servingCertificateAnnouncement is the minimum time the next serving certificate is announced before being served,
+	// unless the current one expires earlier.
+	servingCertificateAnnouncement = 60 * 24 * time.Hour
+	// servingCertificateCheckInterval is the interval between consecutive checks of the serving certificate.
+	servingCertificateCheckInterval = time.Hour
+
+	// nextCertificateKey and nextPrivateKeyKey are the keys of the serving certificate secret storing the next certificate.
+	nextCertificateKey = "next.crt"
+	nextPrivateKeyKey  = "next.key"
+)
+
+// servingCertificate contains the certificate currently served by the authentication service, issued by the CA stored in
+// the serving certificate secret for the given hosts, along with the fingerprint of the CA of the next one, if already announced.
+type servingCertificate struct {
+	current         *tls.Certificate
+	hosts           []string
+	fingerprint     string
+	nextFingerprint string
+}
+
+// ensureServingCertificate loads the certificate to be served by the authentication service, and starts its periodic
+// rotation. The certificate is persisted in a secret, so that it (and its fingerprint, which may be pinned by remote
+// clusters) is preserved across restarts. The one read from the given files is used only in case the secret does not
+// exist yet. Before expiring, the certificate is replaced by a new one, whose CA is announced in advance to the remote
+// clusters through the ids endpoint, so that the pinned fingerprints can be transparently updated.
+func (authService *Controller) ensureServingCertificate(ctx context.Context, certPath, keyPath string) error {
+	secrets := authService.clientset.CoreV1().Secrets(authService.namespace)
+	secret, err := secrets.Get(ctx, auth.ServingCertificateSecretName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		var cert, key []byte
+		if cert, err = os.ReadFile(certPath); err != nil {
+			return fmt.Errorf("failed to read the certificate: %w", err)
+		}
+		if key, err = os.ReadFile(keyPath); err != nil {
+			return fmt.Errorf("failed to read the private key: %w", err)
+		}
+
+		secret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: auth.ServingCertificateSecretName},
+			Type:       v1.SecretTypeTLS,
+			Data:       map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: key},
+		}
+		secret, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		if kerrors.IsAlreadyExists(err) {
+			// The secret has been concurrently created by another replica.
+			secret, err = secrets.Get(ctx, auth.ServingCertificateSecretName, metav1.GetOptions{})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to ensure the serving certificate secret: %w", err)
+	}
+
+	if err = authService.reconcileServingCertificate(ctx, secret); err != nil {
+		return err
+	}
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		current, gerr := secrets.Get(ctx, auth.ServingCertificateSecretName, metav1.GetOptions{})
+		if gerr == nil {
+			gerr = authService.reconcileServingCertificate(ctx, current)
+		}
+		if gerr != nil {
+			klog.Errorf("Failed to reconcile the serving certificate: %v", gerr)
+		}
+	}, servingCertificateCheckInterval)
+	return nil
+}
+
+// reconcileServingCertificate rotates the certificate stored in the given secret, if necessary, and loads it to be served.
+// Concurrent rotations performed by different replicas are prevented by the optimistic concurrency of the secret update.
+func (authService *Controller) reconcileServingCertificate(ctx context.Context, secret *v1.Secret) error {
+	rotated, err := rotateServingCertificate(secret, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to rotate the serving certificate: %w", err)
+	}
+	if rotated {
+		secret, err = authService.clientset.CoreV1().Secrets(authService.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update the serving certificate secret: %w", err)
+		}
+	}
+
+	ca, err := tls.X509KeyPair(secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey])
+	if err != nil {
+		return fmt.Errorf("failed to load the serving certificate: %w", err)
+	}
+
+	serving := &servingCertificate{}
+	if serving.fingerprint, err = auth.CAFingerprintFromPEM(secret.Data[v1.TLSCertKey]); err != nil {
+		return fmt.Errorf("failed to compute the CA fingerprint of the serving certificate: %w", err)
+	}
+	if next, found := secret.Data[nextCertificateKey]; found {
+		if serving.nextFingerprint, err = auth.CAFingerprintFromPEM(next); err != nil {
+			return fmt.Errorf("failed to compute the CA fingerprint of the next serving certificate: %w", err)
+		}
+	}
+	if serving.hosts, err = authService.servingHosts(ctx); err != nil {
+		return fmt.Errorf("failed to retrieve the hosts the authentication service is reachable at: %w", err)
+	}
+
+	// The certificate for the current hosts is issued again only in case either the CA or the hosts changed, since the
+	// remote clusters pin the CA (whose fingerprint is preserved), while verifying the served certificate against it.
+	previous := authService.servingCertificate.Load()
+	if previous != nil && previous.fingerprint == serving.fingerprint && reflect.DeepEqual(previous.hosts, serving.hosts) {
+		serving.current = previous.current
+	} else if serving.current, err = issueServingCertificate(&ca, serving.hosts, time.Now()); err != nil {
+		return fmt.Errorf("failed to issue the serving certificate: %w", err)
+	}
+
+	authService.servingCertificate.Store(serving)
+	if previous == nil || previous.fingerprint != serving.fingerprint {
+		klog.Infof("Serving the certificate rooted in the CA with fingerprint %v", serving.fingerprint)
+	}
+	if previous == nil || !reflect.DeepEqual(previous.hosts, serving.hosts) {
+		klog.Infof("Serving the certificate issued for %v", strings.Join(serving.hosts, ", "))
+	}
+	if serving.nextFingerprint != "" && (previous == nil || previous.nextFingerprint != serving.nextFingerprint) {
+		klog.Infof("Announcing the rotation to the certificate rooted in the CA with fingerprint %v", serving.nextFingerprint)
+	}
+	return nil
+}
+
+// getServingCertificate returns the certificate currently served by the authentication service.
+func (authService *Controller) getServingCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	serving := authService.servingCertificate.Load()
+	if serving == nil {
+		return nil, fmt.Errorf("serving certificate not yet loaded")
+	}
+	return serving.current, nil
+}
+
+// rotateServingCertificate generates the next serving certificate once the current one approaches its expiration, and
+// replaces the current one once the next has been announced for long enough (or the current one expired). It returns
+// whether the given secret has been modified.
+func rotateServingCertificate(secret *v1.Secret, now time.Time) (bool, error) {
+	current, err := csrutil.ParseCertificate(secret.Data[v1.TLSCertKey])
+	if err != nil {
+		return false, err
+	}
+
+	if nextData, found := secret.Data[nextCertificateKey]; found {
+		next, perr := csrutil.ParseCertificate(nextData)
+		if perr != nil {
+			return false, perr
+		}
+
+		if now.Before(current.NotAfter) && now.Before(next.NotBefore.Add(servingCertificateAnnouncement)) {
+			return false, nil
+		}
+
+		klog.Infof("Switching to the next serving certificate, valid until %v", next.NotAfter.Format(time.RFC3339))
+		secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey] = nextData, secret.Data[nextPrivateKeyKey]
+		delete(secret.Data, nextCertificateKey)
+		delete(secret.Data, nextPrivateKeyKey)
+		return true, nil
+	}
+
+	if now.Before(current.NotAfter.Add(-servingCertificateAnnounceBefore)) {
+		return false, nil
+	}
+
+	klog.Infof("The serving certificate expires at %v, generating the next one", current.NotAfter.Format(time.RFC3339))
+	certificate, key, err := generateServingCertificate(now)
+	if err != nil {
+		return false, err
+	}
+	secret.Data[nextCertificateKey], secret.Data[nextPrivateKeyKey] = certificate, key
+	return true, nil
+}
+
+// generateServingCertificate generates a new self-signed serving certificate, returning it along with its private key (PEM encoded).
+func generateServingCertificate(now time.Time) (certificate, key []byte, err error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate the private key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate the serial number: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Country: []string{"IT"}, Province: []string{"Turin"}, Organization: []string{"Liqo"}},
+		NotBefore:             now,
+		NotAfter:              now.Add(servingCertificateValidity),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate the certificate: %w", err)
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode the private key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), nil
+}
+
+// issueServingCertificate issues the certificate served by the authentication service for the given hosts, signed by the
+// given CA (i.e., the certificate whose fingerprint is pinned by the remote clusters). The certificate expires with the CA.
+func issueServingCertificate(ca *tls.Certificate, hosts []string, now time.Time) (*tls.Certificate, error) {
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the CA certificate: %w", err)
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the private key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the serial number: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Country: []string{"IT"}, Province: []string{"Turin"}, Organization: []string{"Liqo"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     caCert.NotAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &privateKey.PublicKey, ca.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the certificate: %w", err)
+	}
+
+	return &tls.Certificate{Certificate: [][]byte{der, ca.Certificate[0]}, PrivateKey: privateKey}, nil
+}
+
+// servingHosts returns the (sorted) hosts the authentication service is reachable at, that is the DNS names of the
+// corresponding service, the override address (if any), and either the load balancer or the node addresses.
+func (authService *Controller) servingHosts(ctx context.Context) ([]string, error) {
+	name, namespace := liqoconst.AuthServiceName, authService.namespace
+	hosts := map[string]struct{}{
+		name: {}, name + "." + namespace: {}, name + "." + namespace + ".svc": {}, name + "." + namespace + ".svc.cluster.local": {},
+	}
+
+	svc, err := authService.clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if override, found := svc.Annotations[liqoconst.OverrideAddressAnnotation]; found {
+		hosts[addressHost(override)] = struct{}{}
+	}
+	for _, ip := range svc.Spec.ExternalIPs {
+		hosts[ip] = struct{}{}
+	}
+
+	switch svc.Spec.Type {
+	case v1.ServiceTypeLoadBalancer:
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			hosts[ingress.IP], hosts[ingress.Hostname] = struct{}{}, struct{}{}
+		}
+	case v1.ServiceTypeNodePort:
+		// Virtual nodes are excluded, since their addresses are not reachable from the remote clusters.
+		req, rerr := labels.NewRequirement(liqoconst.TypeLabel, selection.NotIn, []string{liqoconst.TypeNode})
+		utilruntime.Must(rerr)
+		nodes, lerr := authService.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: labels.NewSelector().Add(*req).String()})
+		if lerr != nil {
+			return nil, lerr
+		}
+		for i := range nodes.Items {
+			for _, address := range nodes.Items[i].Status.Addresses {
+				if address.Type == v1.NodeInternalIP || address.Type == v1.NodeExternalIP {
+					hosts[address.Address] = struct{}{}
+				}
+			}
+		}
+	}
+
+	delete(hosts, "")
+	sorted := make([]string, 0, len(hosts))
+	for host := range hosts {
+		sorted = append(sorted, host)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// addressHost returns the host of the given address, removing the scheme, the port and the path (if any).
+func addressHost(address string) string {
+	address = strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+	address, _, _ = strings.Cut(address, "/")
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
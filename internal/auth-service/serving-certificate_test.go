@@ -0,0 +1,164 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authservice
+
+import (
+	"crypto/tls"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/liqotech/liqo/pkg/auth"
+)
+
+var _ = Describe("Serving certificate rotation", func() {
+	var (
+		secret  v1.Secret
+		now     time.Time
+		rotated bool
+		err     error
+	)
+
+	forgeSecret := func(issued time.Time) v1.Secret {
+		certificate, key, gerr := generateServingCertificate(issued)
+		Expect(gerr).ToNot(HaveOccurred())
+		return v1.Secret{Data: map[string][]byte{v1.TLSCertKey: certificate, v1.TLSPrivateKeyKey: key}}
+	}
+
+	fingerprint := func(data []byte) string {
+		fp, ferr := auth.CAFingerprintFromPEM(data)
+		Expect(ferr).ToNot(HaveOccurred())
+		return fp
+	}
+
+	BeforeEach(func() { now = time.Now() })
+	JustBeforeEach(func() { rotated, err = rotateServingCertificate(&secret, now) })
+
+	When("the current certificate is far from its expiration", func() {
+		BeforeEach(func() { secret = forgeSecret(now.Add(-24 * time.Hour)) })
+
+		It("should not modify the secret", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rotated).To(BeFalse())
+			Expect(secret.Data).ToNot(HaveKey(nextCertificateKey))
+		})
+	})
+
+	When("the current certificate approaches its expiration", func() {
+		var current []byte
+
+		BeforeEach(func() {
+			secret = forgeSecret(now.Add(-servingCertificateValidity + servingCertificateAnnounceBefore/2))
+			current = secret.Data[v1.TLSCertKey]
+		})
+
+		It("should generate and announce the next certificate, without serving it yet", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rotated).To(BeTrue())
+			Expect(secret.Data).To(HaveKey(nextCertificateKey))
+			Expect(secret.Data).To(HaveKey(nextPrivateKeyKey))
+			Expect(secret.Data[v1.TLSCertKey]).To(Equal(current))
+			Expect(fingerprint(secret.Data[nextCertificateKey])).ToNot(Equal(fingerprint(current)))
+		})
+
+		When("the next certificate has been announced for long enough", func() {
+			var next []byte
+
+			BeforeEach(func() {
+				announced := forgeSecret(now.Add(-servingCertificateAnnouncement - time.Hour))
+				next = announced.Data[v1.TLSCertKey]
+				secret.Data[nextCertificateKey] = next
+				secret.Data[nextPrivateKeyKey] = announced.Data[v1.TLSPrivateKeyKey]
+			})
+
+			It("should serve the next certificate", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rotated).To(BeTrue())
+				Expect(secret.Data[v1.TLSCertKey]).To(Equal(next))
+				Expect(secret.Data).ToNot(HaveKey(nextCertificateKey))
+				Expect(secret.Data).ToNot(HaveKey(nextPrivateKeyKey))
+			})
+		})
+
+		When("the next certificate has been announced recently", func() {
+			BeforeEach(func() {
+				announced := forgeSecret(now.Add(-time.Hour))
+				secret.Data[nextCertificateKey] = announced.Data[v1.TLSCertKey]
+				secret.Data[nextPrivateKeyKey] = announced.Data[v1.TLSPrivateKeyKey]
+			})
+
+			It("should keep serving the current certificate", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rotated).To(BeFalse())
+				Expect(secret.Data[v1.TLSCertKey]).To(Equal(current))
+			})
+
+			When("the current certificate already expired", func() {
+				BeforeEach(func() { now = now.Add(servingCertificateAnnounceBefore/2 + time.Hour) })
+
+				It("should serve the next certificate", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(rotated).To(BeTrue())
+					Expect(secret.Data[v1.TLSCertKey]).ToNot(Equal(current))
+				})
+			})
+		})
+	})
+})
+
+var _ = Describe("Serving certificate issuance", func() {
+	var (
+		chain       [][]byte
+		fingerprint string
+	)
+
+	BeforeEach(func() {
+		caCert, caKey, err := generateServingCertificate(time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		ca, err := tls.X509KeyPair(caCert, caKey)
+		Expect(err).ToNot(HaveOccurred())
+		fingerprint, err = auth.CAFingerprintFromPEM(caCert)
+		Expect(err).ToNot(HaveOccurred())
+
+		certificate, err := issueServingCertificate(&ca, []string{"auth.example.com", "10.0.0.1"}, time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		chain = certificate.Certificate
+	})
+
+	DescribeTable("the certificate chain should be verified against the pinned CA",
+		func(serverName string, pinned func() string, shouldSucceed bool) {
+			observed, err := auth.VerifyPinnedChain(chain, serverName, pinned())
+			Expect(observed).To(Equal(fingerprint))
+			if shouldSucceed {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("a matching DNS name", "auth.example.com", func() string { return fingerprint }, true),
+		Entry("a matching IP address", "10.0.0.1", func() string { return fingerprint }, true),
+		Entry("a non matching host", "other.example.com", func() string { return fingerprint }, false),
+		Entry("a non matching fingerprint", "auth.example.com", func() string { return "00:11" }, false),
+	)
+
+	DescribeTable("addressHost should return the host of the given address",
+		func(address, expected string) { Expect(addressHost(address)).To(Equal(expected)) },
+		Entry("a plain host", "auth.example.com", "auth.example.com"),
+		Entry("an URL with port", "https://auth.example.com:8443/", "auth.example.com"),
+		Entry("an IP address with port", "10.0.0.1:443", "10.0.0.1"),
+	)
+})
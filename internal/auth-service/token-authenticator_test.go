@@ -0,0 +1,184 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authservice
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/liqotech/liqo/pkg/auth"
+)
+
+var _ = Describe("External token authenticators", func() {
+
+	Context("OIDC", func() {
+		const (
+			audience = "liqo"
+			kid      = "key-id"
+		)
+
+		var (
+			server        *httptest.Server
+			key           *rsa.PrivateKey
+			authenticator *oidcAuthenticator
+			claims        jwt.MapClaims
+			valid         bool
+			err           error
+		)
+
+		sign := func(claims jwt.MapClaims, key *rsa.PrivateKey) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			token.Header["kid"] = kid
+			signed, serr := token.SignedString(key)
+			Expect(serr).ToNot(HaveOccurred())
+			return signed
+		}
+
+		BeforeEach(func() {
+			key, err = rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).ToNot(HaveOccurred())
+
+			mux := http.NewServeMux()
+			server = httptest.NewServer(mux)
+			mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+				Expect(json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/keys"})).To(Succeed())
+			})
+			mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+				Expect(json.NewEncoder(w).Encode(map[string]interface{}{"keys": []jsonWebKey{{
+					Kid: kid, Kty: "RSA", Use: "sig",
+					N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				}}})).To(Succeed())
+			})
+
+			authenticator = newOIDCAuthenticator(OIDCConfig{IssuerURL: server.URL, Audience: audience, ClusterIDsClaim: "liqo_cluster_ids"})
+			claims = jwt.MapClaims{
+				"iss":              server.URL,
+				"aud":              audience,
+				"sub":              "partner",
+				"exp":              time.Now().Add(time.Hour).Unix(),
+				"liqo_cluster_ids": []string{"cluster-1", "cluster-2"},
+			}
+		})
+
+		AfterEach(func() { server.Close() })
+
+		It("should accept a valid token for an allowed cluster", func() {
+			valid, err = authenticator.authenticate(ctx, sign(claims, key), "cluster-2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeTrue())
+		})
+
+		It("should reject a valid token for a cluster not allowed", func() {
+			valid, err = authenticator.authenticate(ctx, sign(claims, key), "cluster-3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeFalse())
+		})
+
+		It("should reject a token issued for a different audience", func() {
+			claims["aud"] = "other"
+			valid, err = authenticator.authenticate(ctx, sign(claims, key), "cluster-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeFalse())
+		})
+
+		It("should reject an expired token", func() {
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			valid, err = authenticator.authenticate(ctx, sign(claims, key), "cluster-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeFalse())
+		})
+
+		It("should reject a token signed by a different key", func() {
+			other, gerr := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(gerr).ToNot(HaveOccurred())
+			valid, err = authenticator.authenticate(ctx, sign(claims, other), "cluster-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeFalse())
+		})
+
+		It("should fail if the identity provider is not reachable", func() {
+			server.Close()
+			valid, err = authenticator.authenticate(ctx, sign(claims, key), "cluster-1")
+			Expect(err).To(HaveOccurred())
+			Expect(valid).To(BeFalse())
+		})
+	})
+
+	Context("Webhook", func() {
+		var (
+			server        *httptest.Server
+			authenticator *webhookAuthenticator
+			valid         bool
+			err           error
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var request auth.WebhookAuthenticationRequest
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				if request.Token == "error" {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				response := auth.WebhookAuthenticationResponse{Allowed: request.Token == "valid-token" && request.ClusterID == "cluster"}
+				Expect(json.NewEncoder(w).Encode(&response)).To(Succeed())
+			}))
+			authenticator = newWebhookAuthenticator(WebhookConfig{URL: server.URL, Timeout: 10 * time.Second})
+		})
+
+		AfterEach(func() { server.Close() })
+
+		DescribeTable("Webhook authentication table",
+			func(token, clusterID string, expectedValid, expectedErr bool) {
+				valid, err = authenticator.authenticate(ctx, token, clusterID)
+				Expect(valid).To(Equal(expectedValid))
+				if expectedErr {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+				}
+			},
+			Entry("allowed token", "valid-token", "cluster", true, false),
+			Entry("token not allowed for the cluster", "valid-token", "other-cluster", false, false),
+			Entry("invalid token", "invalid-token", "cluster", false, false),
+			Entry("webhook failure", "error", "cluster", false, true),
+		)
+
+		It("should be checked by the token validator if the token is not a local one", func() {
+			validator := tokenValidator{authenticators: []tokenAuthenticator{authenticator}}
+			manager := tokenManagerMock{}
+			Expect(manager.createToken()).To(Succeed())
+
+			valid, _, err = validator.validToken(ctx, &manager, "valid-token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeTrue())
+
+			valid, _, err = validator.validToken(ctx, &manager, "invalid-token", "cluster")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(valid).To(BeFalse())
+		})
+	})
+})
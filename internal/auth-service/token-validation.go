@@ -15,8 +15,11 @@
 package authservice
 
 import (
-	"fmt"
+	"context"
+	"crypto/subtle"
+	"time"
 
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 
 	"github.com/liqotech/liqo/pkg/auth"
@@ -24,46 +27,133 @@ import (
 )
 
 type credentialsValidator interface {
-	checkCredentials(roleRequest auth.IdentityRequest, tokenManager tokenManager, authenticationEnabled bool) error
-	validToken(tokenManager tokenManager, token string) (bool, error)
+	checkCredentials(ctx context.Context, roleRequest auth.IdentityRequest, tokenManager tokenManager,
+		authenticationEnabled bool) (*auth.PeeringToken, error)
+	validToken(ctx context.Context, tokenManager tokenManager, token, clusterID string) (bool, *auth.PeeringToken, error)
 }
 
-type tokenValidator struct{}
+// tokenAuthenticator authenticates the tokens presented by remote clusters against an external identity provider.
+type tokenAuthenticator interface {
+	// authenticate returns whether the given token allows the given remote cluster to obtain an identity.
+	authenticate(ctx context.Context, token, clusterID string) (bool, error)
+}
+
+// TokenAuthenticationConfig contains the configuration of the external authenticators of the tokens presented by remote clusters,
+// which are checked in addition to the tokens issued by the local cluster.
+type TokenAuthenticationConfig struct {
+	OIDC    OIDCConfig
+	Webhook WebhookConfig
+	// DisableClusterToken disables the cluster-wide token, so that remote clusters can authenticate only
+	// through the named peering tokens and the external authenticators.
+	DisableClusterToken bool
+}
+
+// authenticators returns the external authenticators enabled by the configuration.
+func (config *TokenAuthenticationConfig) authenticators() []tokenAuthenticator {
+	var authenticators []tokenAuthenticator
+	if config.OIDC.IsEnabled() {
+		authenticators = append(authenticators, newOIDCAuthenticator(config.OIDC))
+	}
+	if config.Webhook.IsEnabled() {
+		authenticators = append(authenticators, newWebhookAuthenticator(config.Webhook))
+	}
+	return authenticators
+}
+
+type tokenValidator struct {
+	// authenticators are the external authenticators checked in case the token does not match any local one.
+	authenticators []tokenAuthenticator
+	// clusterTokenDisabled prevents remote clusters from authenticating through the cluster-wide token.
+	clusterTokenDisabled bool
+}
 
 // checkCredentials checks if the provided token is valid for the local cluster given an IdentityRequest.
-func (tokenValidator *tokenValidator) checkCredentials(
-	roleRequest auth.IdentityRequest, tokenManager tokenManager, authenticationEnabled bool) error {
+// In case the credentials match a named peering token, it is returned, so that its usage can be recorded once the identity is issued.
+func (tokenValidator *tokenValidator) checkCredentials(ctx context.Context,
+	roleRequest auth.IdentityRequest, tokenManager tokenManager, authenticationEnabled bool) (*auth.PeeringToken, error) {
 	// token check fails if the token is different from the correct one
 	// and the authentication is disabled
 
 	if !authenticationEnabled {
 		klog.V(3).Infof("[%s] accepting credentials since authentication is disabled",
 			roleRequest.GetClusterIdentity())
-		return nil
+		return nil, nil
 	}
 
-	valid, err := tokenValidator.validToken(tokenManager, roleRequest.GetToken())
+	valid, peeringToken, err := tokenValidator.validToken(ctx, tokenManager, roleRequest.GetToken(), roleRequest.GetClusterIdentity().ClusterID)
 	if err != nil {
 		klog.Error(err)
-		return err
+		return nil, err
 	}
 	if !valid {
-		err = &autherrors.AuthenticationFailedError{
-			Reason: fmt.Sprintf("invalid token %q", roleRequest.GetToken()),
-		}
+		// The token is never included in the reason, which is logged and recorded in the audit log.
+		err = &autherrors.AuthenticationFailedError{Reason: "invalid token"}
 		klog.Error(err)
-		return err
+		return nil, err
 	}
-	return nil
+	return peeringToken, nil
 }
 
-// validToken checks if the token provided is valid.
-func (tokenValidator *tokenValidator) validToken(tokenManager tokenManager, token string) (bool, error) {
-	correctToken, err := tokenManager.getToken()
+// validToken checks if the token provided is valid, either matching the cluster-wide token (unless disabled)
+// or a named peering token which can be used by the given remote cluster. The matching peering token, if any, is returned
+// without recording its usage, which is up to the caller through tokenManager.consumePeeringToken.
+func (tokenValidator *tokenValidator) validToken(ctx context.Context, tokenManager tokenManager,
+	token, clusterID string) (bool, *auth.PeeringToken, error) {
+	if !tokenValidator.clusterTokenDisabled {
+		correctToken, err := tokenManager.getToken()
+		if err != nil {
+			klog.Error(err)
+			return false, nil, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(correctToken)) == 1 {
+			return true, nil, nil
+		}
+	}
+
+	peeringTokens, err := tokenManager.getPeeringTokens()
 	if err != nil {
 		klog.Error(err)
-		return false, err
+		return false, nil, err
+	}
+
+	for _, peeringToken := range peeringTokens {
+		if subtle.ConstantTimeCompare([]byte(peeringToken.Token), []byte(token)) != 1 {
+			continue
+		}
+
+		if err = peeringToken.CheckValidity(clusterID, time.Now()); err != nil {
+			klog.Warningf("[%s] rejecting credentials: %v", clusterID, err)
+			return false, nil, nil
+		}
+
+		klog.Infof("[%s] accepting credentials through peering token %q", clusterID, peeringToken.Name)
+		return true, peeringToken, nil
+	}
+
+	valid, err := tokenValidator.validExternalToken(ctx, token, clusterID)
+	return valid, nil, err
+}
+
+// validExternalToken checks if the token provided is accepted by any of the external authenticators.
+// An error is returned only if no authenticator accepted the token, and at least one of them failed.
+func (tokenValidator *tokenValidator) validExternalToken(ctx context.Context, token, clusterID string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	var errs []error
+	for _, authenticator := range tokenValidator.authenticators {
+		valid, err := authenticator.authenticate(ctx, token, clusterID)
+		if err != nil {
+			klog.Errorf("[%s] failed to authenticate the token: %v", clusterID, err)
+			errs = append(errs, err)
+			continue
+		}
+		if valid {
+			return true, nil
+		}
 	}
 
-	return token == correctToken, nil
+	return false, utilerrors.NewAggregate(errs)
 }
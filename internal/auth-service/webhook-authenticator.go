@@ -0,0 +1,92 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/liqotech/liqo/pkg/auth"
+)
+
+// WebhookConfig contains the configuration to authenticate remote clusters through an external HTTP webhook.
+type WebhookConfig struct {
+	// URL is the URL of the webhook, which receives a WebhookAuthenticationRequest and returns a WebhookAuthenticationResponse.
+	URL string
+	// Timeout is the timeout of the requests towards the webhook.
+	Timeout time.Duration
+}
+
+// IsEnabled returns whether the webhook authentication is enabled.
+func (config *WebhookConfig) IsEnabled() bool {
+	return config.URL != ""
+}
+
+// webhookAuthenticator authenticates remote clusters delegating the decision to an external HTTP webhook.
+type webhookAuthenticator struct {
+	url    string
+	client *http.Client
+}
+
+// newWebhookAuthenticator returns a new authenticator delegating the token validation to an external HTTP webhook.
+func newWebhookAuthenticator(config WebhookConfig) *webhookAuthenticator {
+	return &webhookAuthenticator{
+		url:    config.URL,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// authenticate returns whether the webhook allows the given remote cluster to peer through the given token.
+func (authenticator *webhookAuthenticator) authenticate(ctx context.Context, token, clusterID string) (bool, error) {
+	body, err := json.Marshal(&auth.WebhookAuthenticationRequest{Token: token, ClusterID: clusterID})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authenticator.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticator.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to contact the authentication webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code %d from the authentication webhook", resp.StatusCode)
+	}
+
+	var response auth.WebhookAuthenticationResponse
+	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return false, fmt.Errorf("failed to decode the authentication webhook response: %w", err)
+	}
+
+	if !response.Allowed {
+		klog.Warningf("[%s] rejecting credentials through authentication webhook: %s", clusterID, response.Reason)
+		return false, nil
+	}
+
+	klog.Infof("[%s] accepting credentials through authentication webhook", clusterID)
+	return true, nil
+}
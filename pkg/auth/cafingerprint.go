@@ -0,0 +1,167 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ServingCertificateSecretName is the name of the secret storing the certificate served by the authentication service.
+	ServingCertificateSecretName = "auth-serving-certificate"
+)
+
+// CAFingerprintMismatchError is returned when the certificate chain presented by a remote authentication service
+// is not rooted in the pinned CA.
+type CAFingerprintMismatchError struct {
+	Expected string
+	Observed string
+}
+
+func (err *CAFingerprintMismatchError) Error() string {
+	return fmt.Sprintf("the certificate chain presented by the remote authentication service is rooted in the CA with fingerprint %v, "+
+		"while %v was expected", err.Observed, err.Expected)
+}
+
+// CAFingerprint returns the SHA-256 fingerprint (hex encoded) of the given certificate.
+func CAFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeCAFingerprint normalizes the given fingerprint, removing the separators and converting it to lower case.
+func NormalizeCAFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
+
+// CAFingerprintFromPEM returns the fingerprint of the CA of the given PEM encoded certificate chain,
+// that is the last certificate of the chain (i.e., the certificate itself, if self-signed).
+func CAFingerprintFromPEM(data []byte) (string, error) {
+	var ca *x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		ca = cert
+	}
+
+	if ca == nil {
+		return "", fmt.Errorf("no certificate found")
+	}
+	return CAFingerprint(ca), nil
+}
+
+// GetCAFingerprint retrieves the fingerprint of the CA of the certificate served by the local authentication service.
+func GetCAFingerprint(ctx context.Context, c client.Client, namespace string) (string, error) {
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: ServingCertificateSecretName, Namespace: namespace}, &secret); err != nil {
+		return "", err
+	}
+
+	return CAFingerprintFromPEM(secret.Data[corev1.TLSCertKey])
+}
+
+// PinnedTLSConfig returns the TLS configuration to contact a remote authentication service. In case any fingerprint is set,
+// the certificate chain is accepted only if rooted in one of the corresponding CAs, valid at the current time and issued
+// for the given server name. Otherwise, any certificate is accepted (i.e., trust on first use). In both cases, the fingerprint
+// of the CA the chain is rooted in is stored in observed (if not nil).
+func PinnedTLSConfig(observed *string, serverName string, fingerprints ...string) *tls.Config {
+	return &tls.Config{
+		// The standard verification is replaced by the one based on the pinned CA, performed by VerifyPeerCertificate.
+		InsecureSkipVerify: true, //nolint:gosec // The certificate chain is verified against the pinned CA.
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			fp, err := VerifyPinnedChain(rawCerts, serverName, fingerprints...)
+			if observed != nil {
+				*observed = fp
+			}
+			return err
+		},
+	}
+}
+
+// VerifyPinnedChain verifies that the given (DER encoded) certificate chain is rooted in the CA with one of the given
+// fingerprints, and returns the fingerprint of the matching CA (or of the last certificate of the chain, if none matches).
+// The leaf certificate is verified using the pinned CA as the only root, checking that it is currently valid and issued
+// for the given server name. No verification is performed if no fingerprint is given.
+func VerifyPinnedChain(rawCerts [][]byte, serverName string, fingerprints ...string) (string, error) {
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCerts[i])
+		if err != nil {
+			return "", fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs[i] = cert
+	}
+
+	if len(certs) == 0 {
+		return "", fmt.Errorf("no certificate presented by the remote authentication service")
+	}
+
+	observed := CAFingerprint(certs[len(certs)-1])
+	if len(fingerprints) == 0 {
+		return observed, nil
+	}
+
+	pinned := make(map[string]struct{}, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		pinned[NormalizeCAFingerprint(fingerprint)] = struct{}{}
+	}
+
+	for i := range certs {
+		fingerprint := CAFingerprint(certs[i])
+		if _, found := pinned[fingerprint]; !found {
+			continue
+		}
+
+		// The pinned CA is the only trusted root, while the certificates in between are used as intermediates.
+		roots := x509.NewCertPool()
+		roots.AddCert(certs[i])
+		intermediates := x509.NewCertPool()
+		for j := 1; j < i; j++ {
+			intermediates.AddCert(certs[j])
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			DNSName:       serverName,
+			CurrentTime:   time.Now(),
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		if _, err := certs[0].Verify(opts); err != nil {
+			return fingerprint, fmt.Errorf("invalid certificate chain: %w", err)
+		}
+		return fingerprint, nil
+	}
+
+	return observed, &CAFingerprintMismatchError{Expected: strings.Join(fingerprints, " or "), Observed: observed}
+}
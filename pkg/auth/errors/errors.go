@@ -31,3 +31,12 @@ type AuthenticationFailedError struct {
 func (err *AuthenticationFailedError) Error() string {
 	return err.Reason
 }
+
+// TooManyRequestsError is returned when the request is rejected due to rate limiting (e.g., after repeated authentication failures).
+type TooManyRequestsError struct {
+	Reason string
+}
+
+func (err *TooManyRequestsError) Error() string {
+	return err.Reason
+}
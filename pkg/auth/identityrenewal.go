@@ -0,0 +1,136 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	csrutil "github.com/liqotech/liqo/pkg/utils/csr"
+)
+
+// NewCertificateIdentityRenewalRequest creates and returns a new CertificateIdentityRequest to renew an existing identity.
+// The request carries the current certificate, along with the signature of the new signing request generated with the
+// current private key, to prove the possession of a still valid identity without requiring an authentication token.
+func NewCertificateIdentityRenewalRequest(cluster discoveryv1alpha1.ClusterIdentity, originClusterToken string,
+	certificateSigningRequest, certificate, privateKey []byte) (*CertificateIdentityRequest, error) {
+	signature, err := signRenewal(privateKey, certificateSigningRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	request := NewCertificateIdentityRequest(cluster, originClusterToken, "", certificateSigningRequest)
+	request.RenewalCertificate = base64.StdEncoding.EncodeToString(certificate)
+	request.RenewalSignature = base64.StdEncoding.EncodeToString(signature)
+	return request, nil
+}
+
+// IsRenewal returns whether the CertificateIdentityRequest refers to the renewal of an existing identity.
+func (certIdentityRequest *CertificateIdentityRequest) IsRenewal() bool {
+	return certIdentityRequest.RenewalCertificate != ""
+}
+
+// CheckRenewal verifies that the renewal request is legitimate, given the certificate previously issued to the
+// requesting cluster. In particular, the certificate carried by the request shall match the issued one, be still
+// valid and refer to the requesting cluster, and the signature shall be generated with the corresponding private key.
+func (certIdentityRequest *CertificateIdentityRequest) CheckRenewal(issued []byte, now time.Time) error {
+	presented, err := base64.StdEncoding.DecodeString(certIdentityRequest.RenewalCertificate)
+	if err != nil {
+		return fmt.Errorf("failed to decode certificate: %w", err)
+	}
+	if !bytes.Equal(presented, issued) {
+		return errors.New("the presented certificate does not match the issued one")
+	}
+
+	certificate, err := csrutil.ParseCertificate(presented)
+	if err != nil {
+		return err
+	}
+	if now.After(certificate.NotAfter) {
+		return fmt.Errorf("the presented certificate expired at %v", certificate.NotAfter.Format(time.RFC3339))
+	}
+	if certificate.Subject.CommonName != certIdentityRequest.ClusterIdentity.ClusterID {
+		return fmt.Errorf("the presented certificate does not refer to cluster %v", certIdentityRequest.ClusterIdentity.ClusterID)
+	}
+
+	signingRequest, err := base64.StdEncoding.DecodeString(certIdentityRequest.CertificateSigningRequest)
+	if err != nil {
+		return fmt.Errorf("failed to decode certificate signing request: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(certIdentityRequest.RenewalSignature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	return verifyRenewal(certificate, signingRequest, signature)
+}
+
+// signRenewal signs the given message with the PEM encoded (PKCS #8) private key.
+func signRenewal(privateKey, message []byte) ([]byte, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM private key")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch signer := key.(type) {
+	case ed25519.PrivateKey:
+		return signer.Sign(rand.Reader, message, crypto.Hash(0))
+	case crypto.Signer:
+		digest := sha256.Sum256(message)
+		return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// verifyRenewal verifies that the signature of the given message has been generated with the private key
+// corresponding to the given certificate.
+func verifyRenewal(certificate *x509.Certificate, message, signature []byte) error {
+	digest := sha256.Sum256(message)
+
+	var valid bool
+	switch key := certificate.PublicKey.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, message, signature)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	if !valid {
+		return errors.New("invalid renewal signature")
+	}
+	return nil
+}
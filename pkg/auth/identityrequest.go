@@ -41,6 +41,10 @@ type CertificateIdentityRequest struct {
 	OriginClusterToken        string `json:"originClusterToken,omitempty"`
 	DestinationClusterToken   string `json:"destinationClusterToken"`
 	CertificateSigningRequest string `json:"certificateSigningRequest"`
+	// RenewalCertificate and RenewalSignature are set when renewing an identity, and prove the possession of the
+	// certificate previously issued by the remote cluster (in replacement of the DestinationClusterToken).
+	RenewalCertificate string `json:"renewalCertificate,omitempty"`
+	RenewalSignature   string `json:"renewalSignature,omitempty"`
 }
 
 // NewCertificateIdentityRequest creates and returns a new CertificateIdentityRequest.
@@ -18,4 +18,7 @@ package auth
 type ClusterInfo struct {
 	ClusterID   string `json:"clusterId"`
 	ClusterName string `json:"clusterName,omitempty"`
+	// NextCAFingerprint is the fingerprint of the CA of the certificate the authentication service is going to serve
+	// after the rotation of the current one, which is announced in advance so that remote clusters can trust it.
+	NextCAFingerprint string `json:"nextCaFingerprint,omitempty"`
 }
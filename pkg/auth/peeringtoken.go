@@ -0,0 +1,197 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PeeringTokenSecretNamePrefix is the prefix of the name of the secrets containing the named peering tokens.
+	PeeringTokenSecretNamePrefix = "peering-token-"
+
+	// PeeringTokenLabelKey is the label key identifying the secrets containing the named peering tokens.
+	PeeringTokenLabelKey = "auth.liqo.io/peering-token"
+	// PeeringTokenClusterIDAnnotationKey is the annotation key specifying the ClusterID a peering token is bound to.
+	PeeringTokenClusterIDAnnotationKey = "auth.liqo.io/peering-token-cluster-id"
+	// PeeringTokenExpirationAnnotationKey is the annotation key specifying the expiration time (RFC3339) of a peering token.
+	PeeringTokenExpirationAnnotationKey = "auth.liqo.io/peering-token-expiration"
+	// PeeringTokenMaxUsesAnnotationKey is the annotation key specifying the maximum number of times a peering token can be used.
+	PeeringTokenMaxUsesAnnotationKey = "auth.liqo.io/peering-token-max-uses"
+	// PeeringTokenUsesAnnotationKey is the annotation key specifying the number of times a peering token has already been used.
+	PeeringTokenUsesAnnotationKey = "auth.liqo.io/peering-token-uses"
+)
+
+// PeeringToken represents a named peering token, possibly bound to a given remote cluster, and with a limited validity.
+type PeeringToken struct {
+	// Name is the name of the token.
+	Name string
+	// Token is the actual token value.
+	Token string
+	// ClusterID is the ClusterID of the only remote cluster allowed to use the token (empty if any).
+	ClusterID string
+	// Expiration is the time after which the token is no longer valid (zero if it never expires).
+	Expiration time.Time
+	// MaxUses is the maximum number of times the token can be used (zero if unlimited).
+	MaxUses int
+	// Uses is the number of times the token has already been used.
+	Uses int
+
+	secret *v1.Secret
+}
+
+// PeeringTokenOptions contains the parameters to generate a new peering token.
+type PeeringTokenOptions struct {
+	// Name is the name of the token (a random one is generated, if empty).
+	Name string
+	// ClusterID is the ClusterID of the only remote cluster allowed to use the token (empty if any).
+	ClusterID string
+	// TTL is the validity duration of the token (zero if it never expires).
+	TTL time.Duration
+	// MaxUses is the maximum number of times the token can be used (zero if unlimited).
+	MaxUses int
+}
+
+// PeeringTokenSecretName returns the name of the secret containing the peering token with the given name.
+func PeeringTokenSecretName(name string) string {
+	return PeeringTokenSecretNamePrefix + name
+}
+
+// CreatePeeringToken generates a new named peering token, and stores it in a secret in the given namespace.
+func CreatePeeringToken(ctx context.Context, c client.Client, namespace string, opts *PeeringTokenOptions) (*PeeringToken, error) {
+	value, err := GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = utilrand.String(8)
+	}
+
+	secret := ForgePeeringTokenSecret(name, namespace, value, opts, time.Now())
+	if err := c.Create(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	return PeeringTokenFromSecret(secret)
+}
+
+// ForgePeeringTokenSecret forges the secret storing a named peering token.
+func ForgePeeringTokenSecret(name, namespace, token string, opts *PeeringTokenOptions, now time.Time) *v1.Secret {
+	annotations := map[string]string{
+		PeeringTokenUsesAnnotationKey: "0",
+	}
+	if opts.ClusterID != "" {
+		annotations[PeeringTokenClusterIDAnnotationKey] = opts.ClusterID
+	}
+	if opts.TTL > 0 {
+		annotations[PeeringTokenExpirationAnnotationKey] = now.Add(opts.TTL).UTC().Format(time.RFC3339)
+	}
+	if opts.MaxUses > 0 {
+		annotations[PeeringTokenMaxUsesAnnotationKey] = strconv.Itoa(opts.MaxUses)
+	}
+
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        PeeringTokenSecretName(name),
+			Namespace:   namespace,
+			Labels:      map[string]string{PeeringTokenLabelKey: name},
+			Annotations: annotations,
+		},
+		Data: map[string][]byte{"token": []byte(token)},
+	}
+}
+
+// PeeringTokenFromSecret retrieves the named peering token stored in the given secret.
+func PeeringTokenFromSecret(secret *v1.Secret) (*PeeringToken, error) {
+	name, found := secret.GetLabels()[PeeringTokenLabelKey]
+	if !found {
+		return nil, fmt.Errorf("invalid secret %v/%v: not a peering token", secret.GetNamespace(), secret.GetName())
+	}
+
+	value, err := GetTokenFromSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &PeeringToken{
+		Name:      name,
+		Token:     value,
+		ClusterID: secret.GetAnnotations()[PeeringTokenClusterIDAnnotationKey],
+		secret:    secret,
+	}
+
+	if expiration, found := secret.GetAnnotations()[PeeringTokenExpirationAnnotationKey]; found {
+		if token.Expiration, err = time.Parse(time.RFC3339, expiration); err != nil {
+			return nil, fmt.Errorf("invalid peering token %q: malformed expiration: %w", name, err)
+		}
+	}
+
+	if token.MaxUses, err = parseCounter(secret, PeeringTokenMaxUsesAnnotationKey); err != nil {
+		return nil, fmt.Errorf("invalid peering token %q: %w", name, err)
+	}
+	if token.Uses, err = parseCounter(secret, PeeringTokenUsesAnnotationKey); err != nil {
+		return nil, fmt.Errorf("invalid peering token %q: %w", name, err)
+	}
+
+	return token, nil
+}
+
+// CheckValidity checks whether the peering token can be used by the given remote cluster at the given time.
+func (pt *PeeringToken) CheckValidity(clusterID string, now time.Time) error {
+	if pt.ClusterID != "" && pt.ClusterID != clusterID {
+		return fmt.Errorf("peering token %q is bound to a different cluster", pt.Name)
+	}
+	if !pt.Expiration.IsZero() && now.After(pt.Expiration) {
+		return fmt.Errorf("peering token %q expired at %v", pt.Name, pt.Expiration.Format(time.RFC3339))
+	}
+	if pt.MaxUses > 0 && pt.Uses >= pt.MaxUses {
+		return fmt.Errorf("peering token %q has already been used %d times", pt.Name, pt.Uses)
+	}
+	return nil
+}
+
+// Consumed returns a copy of the secret storing the peering token, with the number of uses incremented by one.
+// The copy preserves the resource version, so that concurrent uses of the same token are detected as conflicts.
+func (pt *PeeringToken) Consumed() *v1.Secret {
+	secret := pt.secret.DeepCopy()
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[PeeringTokenUsesAnnotationKey] = strconv.Itoa(pt.Uses + 1)
+	return secret
+}
+
+func parseCounter(secret *v1.Secret, key string) (int, error) {
+	value, found := secret.GetAnnotations()[key]
+	if !found {
+		return 0, nil
+	}
+
+	counter, err := strconv.Atoi(value)
+	if err != nil || counter < 0 {
+		return 0, fmt.Errorf("malformed %q annotation %q", key, value)
+	}
+	return counter, nil
+}
@@ -0,0 +1,28 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+// WebhookAuthenticationRequest is the payload sent by the Authentication Service to an external
+// authentication webhook, to check whether the token presented by a remote cluster is valid.
+type WebhookAuthenticationRequest struct {
+	Token     string `json:"token"`
+	ClusterID string `json:"clusterID"`
+}
+
+// WebhookAuthenticationResponse is the payload returned by an external authentication webhook.
+type WebhookAuthenticationResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
@@ -28,6 +28,7 @@ import (
 type VirtualkubeletV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	NamespaceMapsGetter
+	ReflectionPoliciesGetter
 	ShadowPodsGetter
 }
 
@@ -40,6 +41,10 @@ func (c *VirtualkubeletV1alpha1Client) NamespaceMaps(namespace string) Namespace
 	return newNamespaceMaps(c, namespace)
 }
 
+func (c *VirtualkubeletV1alpha1Client) ReflectionPolicies(namespace string) ReflectionPolicyInterface {
+	return newReflectionPolicies(c, namespace)
+}
+
 func (c *VirtualkubeletV1alpha1Client) ShadowPods(namespace string) ShadowPodInterface {
 	return newShadowPods(c, namespace)
 }
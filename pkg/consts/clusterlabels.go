@@ -19,4 +19,6 @@ const (
 	ProviderClusterLabel = "liqo.io/provider"
 	// TopologyRegionClusterLabel is the cluster label used to indicate the cluster region.
 	TopologyRegionClusterLabel = "topology.kubernetes.io/region"
+	// TopologyZoneClusterLabel is the cluster label used to indicate the cluster zone.
+	TopologyZoneClusterLabel = "topology.kubernetes.io/zone"
 )
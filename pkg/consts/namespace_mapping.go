@@ -37,6 +37,49 @@ const (
 	RemoteNamespaceManagedByAnnotationKey = "liqo.io/managed-by-namespace-map"
 	// RemoteNamespaceOriginalNameAnnotationKey is the annotation that identifies the original name of a remote namespace.
 	RemoteNamespaceOriginalNameAnnotationKey = "liqo.io/original-name"
+	// RemoteNamespaceOrphanedByAnnotationKey is the annotation that identifies the NamespaceMap which previously managed a
+	// given remote namespace, retained upon removal according to the RemoteNamespaceRetentionPolicy.
+	RemoteNamespaceOrphanedByAnnotationKey = "liqo.io/orphaned-by-namespace-map"
+	// RemoteNamespaceRetainAnnotationKey is the annotation marking the remote namespaces to be orphaned, rather than deleted, upon removal.
+	RemoteNamespaceRetainAnnotationKey = "liqo.io/retain-on-removal"
+	// RemoteNamespaceOrphanedLabelKey is the label marking the remote namespaces orphaned upon removal, to make them discoverable.
+	RemoteNamespaceOrphanedLabelKey = "liqo.io/orphaned"
+	// RemoteNamespaceOrphanedLabelValue is the value of the RemoteNamespaceOrphanedLabelKey label.
+	RemoteNamespaceOrphanedLabelValue = "true"
+	// RemoteNamespaceOrphanedAtAnnotationKey is the annotation storing the time a remote namespace has been orphaned (in RFC3339 format).
+	RemoteNamespaceOrphanedAtAnnotationKey = "liqo.io/orphaned-at"
+	// RemoteNamespacePropagatedLabelsAnnotationKey is the annotation tracking the keys of the labels propagated to a remote namespace.
+	RemoteNamespacePropagatedLabelsAnnotationKey = "liqo.io/propagated-labels"
+	// RemoteNamespacePropagatedAnnotationsAnnotationKey is the annotation tracking the keys of the annotations propagated to a remote namespace.
+	RemoteNamespacePropagatedAnnotationsAnnotationKey = "liqo.io/propagated-annotations"
 	// RemoteNamespaceClusterRoleName is the name of the cluster role used to grant permissions to the virtual kubelet in remote namespaces.
 	RemoteNamespaceClusterRoleName = "liqo-virtual-kubelet-remote"
+	// RemoteStatsClusterRoleName is the name of the cluster role used to grant the virtual kubelet access to the summary API of the
+	// remote nodes. Differently from the above one, it is bound cluster-wide, since nodes are not namespaced.
+	RemoteStatsClusterRoleName = "liqo-virtual-kubelet-remote-stats"
+
+	// PodOffloadingStrategyAnnotationKey is the pod annotation used to override, for a given workload, the pod offloading strategy
+	// configured for the entire namespace. The override can only narrow the strategy of the namespace (e.g., force a pod to run
+	// locally, or remotely if the namespace allows both).
+	PodOffloadingStrategyAnnotationKey = "liqo.io/pod-offloading-strategy"
+	// PodClusterSelectorAnnotationKey is the pod annotation used to specify an additional cluster selector (in the form of a
+	// label selector, e.g., "key1=value1,key2 in (value2,value3)"), which is combined in AND with the namespace one.
+	PodClusterSelectorAnnotationKey = "liqo.io/cluster-selector"
+	// OffloadingOverrideAnnotationKey is the annotation added to pods subject to workload-level offloading overrides,
+	// describing the constraints actually enforced.
+	OffloadingOverrideAnnotationKey = "liqo.io/offloading-override"
+	// OffloadingOverrideLabelKey is the label added to pods subject to workload-level offloading overrides,
+	// tracking whether the corresponding event has already been reported.
+	OffloadingOverrideLabelKey = "liqo.io/offloading-override"
+	// OffloadingOverridePendingValue is the value of the OffloadingOverrideLabelKey label when the event has not yet been reported.
+	OffloadingOverridePendingValue = "pending"
+	// OffloadingOverrideReportedValue is the value of the OffloadingOverrideLabelKey label once the event has been reported.
+	OffloadingOverrideReportedValue = "reported"
+	// ReplicaTargetClusterLabelKey is the label added to pods subject to a weighted replica distribution,
+	// identifying the cluster they have been assigned to.
+	ReplicaTargetClusterLabelKey = "liqo.io/replica-target-cluster"
+	// ClusterIdentityLabelKey is the label carried by both the local and the virtual nodes, identifying the cluster
+	// hosting the pods scheduled onto them (i.e., the local one, or the remote one in case of virtual nodes).
+	// It is used as the topology key to spread the replicas across clusters.
+	ClusterIdentityLabelKey = "liqo.io/cluster-id"
 )
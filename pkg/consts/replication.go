@@ -57,6 +57,10 @@ const (
 	// SkipReflectionAnnotationKey is the annotation key used to indicate that a given object should not be reflected into a remote cluster.
 	SkipReflectionAnnotationKey = "liqo.io/skip-reflection"
 
+	// ExportServiceAnnotationKey is the annotation key used to indicate that a given service, living in a namespace
+	// created by a remote cluster, should be imported into the remote cluster itself (i.e., reversely reflected).
+	ExportServiceAnnotationKey = "liqo.io/export-service"
+
 	// PodAntiAffinityPresetKey is the annotation key used to express an anti-affinity preset to apply to offloaded pods.
 	PodAntiAffinityPresetKey = "liqo.io/anti-affinity-preset"
 
@@ -71,4 +75,23 @@ const (
 
 	// PodAntiAffinityLabelsKey is the annotation key used to specify a subset of the pod label keys for the anti-affinity constraints.
 	PodAntiAffinityLabelsKey = "liqo.io/anti-affinity-labels"
+
+	// RemoteNodeSelectorAnnotationKey is the annotation key used to specify the node selector (in the form "key1=value1,key2=value2")
+	// to apply to offloaded pods, to constrain their placement inside the remote cluster.
+	RemoteNodeSelectorAnnotationKey = "liqo.io/remote-node-selector"
+
+	// RemoteNodeAffinityAnnotationKey is the annotation key used to specify the node affinity (JSON encoded)
+	// to apply to offloaded pods, to constrain their placement inside the remote cluster.
+	RemoteNodeAffinityAnnotationKey = "liqo.io/remote-node-affinity"
+
+	// RemoteRuntimeClassAnnotationKey is the annotation key used to specify the runtime class to apply to offloaded pods.
+	RemoteRuntimeClassAnnotationKey = "liqo.io/remote-runtime-class"
+
+	// PriorityClassMappingAnnotationKey is the ForeignCluster annotation key used to specify the mapping
+	// (in the form "local1=remote1,local2=remote2") between the local priority classes and the remote ones.
+	PriorityClassMappingAnnotationKey = "liqo.io/priority-class-mapping"
+
+	// RemotePriorityClassLabelKey is the label key used by provider clusters to mark (with value "true")
+	// the priority classes which the pods offloaded by remote clusters are allowed to refer to.
+	RemotePriorityClassLabelKey = "liqo.io/allow-offloaded-pods"
 )
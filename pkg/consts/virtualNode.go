@@ -21,3 +21,34 @@ const NodeFinalizer = "liqo.io/node"
 // VirtualKubeletFinalizer is the finalizer added on a ResourceOffer when the related VirtualKubelet is up.
 // (managed by the ResourceOffer Operator).
 const VirtualKubeletFinalizer = "liqo.io/virtualkubelet"
+
+const (
+	// FailoverLabelKey is the label added to the virtual nodes whose remote cluster is unavailable for longer than the
+	// failover grace period, hence subject to the rescheduling of the pods they host.
+	FailoverLabelKey = "liqo.io/failover"
+	// FailoverCordonedValue is the value of the FailoverLabelKey label in case the virtual node has been cordoned
+	// as part of the failover process, and shall be uncordoned once the remote cluster is back.
+	FailoverCordonedValue = "cordoned"
+	// FailoverAlreadyCordonedValue is the value of the FailoverLabelKey label in case the virtual node was
+	// already cordoned when the failover process started, and shall not be uncordoned once the remote cluster is back.
+	FailoverAlreadyCordonedValue = "already-cordoned"
+	// FailoverEvictionsAnnotationKey is the annotation added to the virtual nodes subject to failover, tracking
+	// the number of pods evicted for each controller (JSON encoded), to restore the original distribution afterwards.
+	FailoverEvictionsAnnotationKey = "liqo.io/failover-evictions"
+	// FailoverRestoringAnnotationKey is the annotation added to the virtual nodes whose remote cluster is available again,
+	// tracking the controllers whose pods are being rescheduled to restore the original distribution (JSON encoded, along
+	// with the corresponding deadline), so that the replacements are preferably scheduled onto the recovered cluster.
+	FailoverRestoringAnnotationKey = "liqo.io/failover-restoring"
+	// RebalancingEvictionsAnnotationKey is the annotation added to the virtual nodes hosting the pods evicted to rebalance the
+	// offloaded workloads, tracking the corresponding controllers (JSON encoded, along with the corresponding deadline),
+	// so that the replacements are not scheduled again onto the same node.
+	RebalancingEvictionsAnnotationKey = "liqo.io/rebalancing-evictions"
+)
+
+// LatencyBucketLabelKey is the label added to the virtual nodes to expose the latency towards the corresponding remote
+// cluster. The value is the upper bound (in milliseconds) of the latency bucket, or LatencyBucketUnboundedValue in case
+// the latency exceeds all bounds, so that it can be leveraged by node affinities (e.g., through the Lt operator).
+const LatencyBucketLabelKey = "liqo.io/latency-bucket"
+
+// LatencyBucketUnboundedValue is the value of the LatencyBucketLabelKey label in case the latency exceeds all bounds.
+const LatencyBucketUnboundedValue = "inf"
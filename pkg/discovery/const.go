@@ -32,6 +32,10 @@ type Type string
 const (
 	// LanDiscovery value.
 	LanDiscovery Type = "LAN"
+	// WanDiscovery value.
+	WanDiscovery Type = "WAN"
+	// CatalogDiscovery value.
+	CatalogDiscovery Type = "Catalog"
 	// ManualDiscovery value.
 	ManualDiscovery Type = "Manual"
 	// IncomingPeeringDiscovery value.
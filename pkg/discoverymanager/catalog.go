@@ -0,0 +1,220 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/liqotech/liqo/pkg/discoverymanager/utils"
+)
+
+const (
+	// CatalogConfigMapKey is the key of the ConfigMap containing the catalog.
+	CatalogConfigMapKey = "catalog"
+	// CatalogSignatureConfigMapKey is the key of the ConfigMap containing the signature of the catalog.
+	CatalogSignatureConfigMapKey = "signature"
+	// CatalogSignatureURLSuffix is the suffix appended to the catalog URL to retrieve its signature.
+	CatalogSignatureURLSuffix = ".sig"
+
+	// catalogMaxSize is the maximum size of the catalog (and of its signature) retrieved from the configured URL.
+	catalogMaxSize = 4 << 20
+	// catalogClockSkew is the tolerance on the issue time of the catalog, to account for clock differences.
+	catalogClockSkew = 5 * time.Minute
+)
+
+// CatalogConfig defines the configuration parameters for the discovery through a catalog of peerable clusters.
+type CatalogConfig struct {
+	// URL is the HTTP(S) URL the catalog is fetched from. The signature is fetched from the same URL, with the ".sig" suffix.
+	URL string
+	// ConfigMap is the name of the ConfigMap (in the Liqo namespace) containing the catalog and its signature.
+	ConfigMap string
+	// PublicKey is the public key used to verify the signature of the catalog.
+	PublicKey crypto.PublicKey
+
+	RefreshTime time.Duration
+}
+
+// IsEnabled returns whether the discovery through the catalog is enabled.
+func (config *CatalogConfig) IsEnabled() bool {
+	return config.URL != "" || config.ConfigMap != ""
+}
+
+// Catalog is the list of peerable clusters published by a central registry. The serial number (monotonically increased
+// by the registry upon each change), the issue and the expiration times are covered by the signature, to prevent the
+// replay of previous (and possibly revoked) catalogs.
+type Catalog struct {
+	Serial    uint64         `json:"serial" yaml:"serial"`
+	IssuedAt  time.Time      `json:"issuedAt" yaml:"issuedAt"`
+	ExpiresAt time.Time      `json:"expiresAt" yaml:"expiresAt"`
+	Clusters  []CatalogEntry `json:"clusters" yaml:"clusters"`
+}
+
+// CatalogEntry describes a peerable cluster listed in the catalog.
+type CatalogEntry struct {
+	ClusterID     string            `json:"clusterID" yaml:"clusterID"`
+	ClusterName   string            `json:"clusterName,omitempty" yaml:"clusterName,omitempty"`
+	AuthURL       string            `json:"authURL" yaml:"authURL"`
+	CAFingerprint string            `json:"caFingerprint,omitempty" yaml:"caFingerprint,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// LoadCatalogPublicKey loads the (PEM encoded) public key used to verify the signature of the catalog from the given file.
+func LoadCatalogPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the public key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("failed to decode the public key: invalid PEM block")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// ParseCatalog verifies the (base64 encoded) signature of the given catalog, and then parses it (either JSON or YAML).
+// Catalogs which are expired (or issued in the future) at the given time, as well as those whose serial is not greater
+// than the one of the last accepted catalog, are rejected.
+func ParseCatalog(data, signature []byte, publicKey crypto.PublicKey, now time.Time, lastSerial uint64) (*Catalog, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the catalog signature: %w", err)
+	}
+
+	if err = verifyCatalogSignature(data, sig, publicKey); err != nil {
+		return nil, err
+	}
+
+	var catalog Catalog
+	if err = yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse the catalog: %w", err)
+	}
+
+	if err = catalog.CheckFreshness(now); err != nil {
+		return nil, err
+	}
+	if catalog.Serial <= lastSerial {
+		return nil, fmt.Errorf("stale catalog: serial %d is not greater than the last accepted one (%d)", catalog.Serial, lastSerial)
+	}
+
+	for i := range catalog.Clusters {
+		if catalog.Clusters[i].ClusterID == "" || catalog.Clusters[i].AuthURL == "" {
+			return nil, fmt.Errorf("invalid catalog entry %d: both clusterID and authURL shall be specified", i)
+		}
+	}
+	return &catalog, nil
+}
+
+// CheckFreshness returns an error in case the catalog is expired, or not yet valid, at the given time.
+func (catalog *Catalog) CheckFreshness(now time.Time) error {
+	switch {
+	case catalog.IssuedAt.IsZero() || catalog.ExpiresAt.IsZero():
+		return errors.New("invalid catalog: both issuedAt and expiresAt shall be specified")
+	case now.Add(catalogClockSkew).Before(catalog.IssuedAt):
+		return fmt.Errorf("invalid catalog: issued in the future (%v)", catalog.IssuedAt.Format(time.RFC3339))
+	case !now.Before(catalog.ExpiresAt):
+		return fmt.Errorf("stale catalog: expired at %v", catalog.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// verifyCatalogSignature verifies the signature of the catalog. ECDSA and RSA (PKCS #1 v1.5) signatures are expected
+// to be computed over the SHA-256 digest of the catalog, while ed25519 ones over the catalog itself.
+func verifyCatalogSignature(data, signature []byte, publicKey crypto.PublicKey) error {
+	digest := sha256.Sum256(data)
+
+	var valid bool
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, signature)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+
+	if !valid {
+		return errors.New("invalid catalog signature")
+	}
+	return nil
+}
+
+// fetchCatalog retrieves the catalog (and its signature) from the configured source.
+func (discovery *Controller) fetchCatalog(ctx context.Context) (data, signature []byte, err error) {
+	if discovery.catalogConfig.ConfigMap != "" {
+		var cm v1.ConfigMap
+		key := types.NamespacedName{Namespace: discovery.namespace, Name: discovery.catalogConfig.ConfigMap}
+		if err = discovery.namespacedClient.Get(ctx, key, &cm); err != nil {
+			return nil, nil, fmt.Errorf("failed to retrieve the catalog configmap: %w", err)
+		}
+		return []byte(cm.Data[CatalogConfigMapKey]), []byte(cm.Data[CatalogSignatureConfigMapKey]), nil
+	}
+
+	if data, err = httpGetBody(ctx, discovery.catalogConfig.URL); err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve the catalog: %w", err)
+	}
+	if signature, err = httpGetBody(ctx, discovery.catalogConfig.URL+CatalogSignatureURLSuffix); err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve the catalog signature: %w", err)
+	}
+	return data, signature, nil
+}
+
+// httpGetBody performs an HTTP GET request towards the given URL, and returns the body of the response (up to catalogMaxSize).
+func httpGetBody(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: utils.HTTPRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, catalogMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > catalogMaxSize {
+		return nil, fmt.Errorf("response body exceeds the maximum size of %d bytes", catalogMaxSize)
+	}
+	return body, nil
+}
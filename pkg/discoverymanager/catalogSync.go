@@ -0,0 +1,228 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"crypto/sha256"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/auth"
+	discoveryPkg "github.com/liqotech/liqo/pkg/discovery"
+	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+)
+
+const (
+	// catalogLabelsAnnotation tracks the labels of a ForeignCluster which originate from the catalog,
+	// so that they can be removed once no longer present in the corresponding entry.
+	catalogLabelsAnnotation = "discovery.liqo.io/catalog-labels"
+	// reservedLabelsPrefix is the prefix of the labels managed by Liqo, which cannot be set through the catalog.
+	reservedLabelsPrefix = "discovery.liqo.io/"
+)
+
+func (discovery *Controller) startCatalogSync(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := discovery.syncCatalog(ctx); err != nil {
+			klog.Errorf("Failed to synchronize the ForeignClusters with the catalog: %v", err)
+		}
+	}, discovery.catalogConfig.RefreshTime)
+}
+
+// syncCatalog fetches the catalog and reconciles the ForeignClusters accordingly, creating or updating those
+// listed in the catalog, and deleting those previously discovered through the catalog and no longer present.
+func (discovery *Controller) syncCatalog(ctx context.Context) error {
+	data, signature, err := discovery.fetchCatalog(ctx)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := discovery.acceptCatalog(data, signature, time.Now())
+	if err != nil {
+		return err
+	}
+
+	listed := sets.NewString()
+	for i := range catalog.Clusters {
+		entry := &catalog.Clusters[i]
+		if entry.ClusterID == discovery.LocalCluster.ClusterID {
+			continue
+		}
+
+		listed.Insert(entry.ClusterID)
+		if err = discovery.enforceCatalogForeignCluster(ctx, entry); err != nil {
+			klog.Errorf("Failed to enforce the ForeignCluster for catalog entry %q: %v", entry.ClusterID, err)
+		}
+	}
+
+	var fcs discoveryv1alpha1.ForeignClusterList
+	if err = discovery.List(ctx, &fcs, client.MatchingLabels{
+		discoveryPkg.DiscoveryTypeLabel: string(discoveryPkg.CatalogDiscovery),
+	}); err != nil {
+		return err
+	}
+
+	for i := range fcs.Items {
+		if listed.Has(fcs.Items[i].Spec.ClusterIdentity.ClusterID) {
+			continue
+		}
+
+		klog.Infof("delete foreignCluster %v (no longer listed in the catalog)", fcs.Items[i].Name)
+		if err = discovery.Delete(ctx, &fcs.Items[i]); client.IgnoreNotFound(err) != nil {
+			klog.Error(err)
+		}
+	}
+	return nil
+}
+
+// acceptCatalog parses the given catalog, and records it as the last accepted one. A catalog identical to the last
+// accepted one (i.e., refetched without changes) is accepted again until its expiration, while different catalogs
+// are accepted only in case their serial is greater than the one of the last accepted catalog.
+func (discovery *Controller) acceptCatalog(data, signature []byte, now time.Time) (*Catalog, error) {
+	digest := sha256.Sum256(data)
+	if last := discovery.lastCatalog; last != nil && digest == discovery.lastCatalogDigest {
+		if err := last.CheckFreshness(now); err != nil {
+			return nil, err
+		}
+		return last, nil
+	}
+
+	var lastSerial uint64
+	if discovery.lastCatalog != nil {
+		lastSerial = discovery.lastCatalog.Serial
+	}
+
+	catalog, err := ParseCatalog(data, signature, discovery.catalogConfig.PublicKey, now, lastSerial)
+	if err != nil {
+		return nil, err
+	}
+
+	klog.Infof("Accepted the catalog with serial %d, expiring at %v", catalog.Serial, catalog.ExpiresAt.Format(time.RFC3339))
+	discovery.lastCatalog, discovery.lastCatalogDigest = catalog, digest
+	return catalog, nil
+}
+
+// enforceCatalogForeignCluster creates or updates the ForeignCluster corresponding to the given catalog entry.
+// ForeignClusters discovered through other means (except for incoming peerings) are not modified.
+func (discovery *Controller) enforceCatalogForeignCluster(ctx context.Context, entry *CatalogEntry) error {
+	fc, err := foreignclusterutils.GetForeignClusterByID(ctx, discovery.Client, entry.ClusterID)
+	switch {
+	case k8serror.IsNotFound(err):
+		identity := discoveryv1alpha1.ClusterIdentity{ClusterID: entry.ClusterID, ClusterName: entry.ClusterName}
+		if identity.ClusterName == "" {
+			identity.ClusterName = identity.ClusterID
+		}
+
+		fc = &discoveryv1alpha1.ForeignCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: foreignclusterutils.UniqueName(&identity),
+				Labels: map[string]string{
+					discoveryPkg.DiscoveryTypeLabel: string(discoveryPkg.CatalogDiscovery),
+					discoveryPkg.ClusterIDLabel:     identity.ClusterID,
+				},
+			},
+			Spec: discoveryv1alpha1.ForeignClusterSpec{
+				ClusterIdentity:        identity,
+				OutgoingPeeringEnabled: discoveryv1alpha1.PeeringEnabledAuto,
+				IncomingPeeringEnabled: discoveryv1alpha1.PeeringEnabledAuto,
+				InsecureSkipTLSVerify:  pointer.BoolPtr(true),
+			},
+		}
+		applyCatalogEntry(fc, entry)
+
+		if err = discovery.Create(ctx, fc); err != nil {
+			return err
+		}
+		klog.Infof("ForeignCluster %s created", fc.Spec.ClusterIdentity)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	discoveryType := foreignclusterutils.GetDiscoveryType(fc)
+	if discoveryType != discoveryPkg.CatalogDiscovery && !foreignclusterutils.HasHigherPriority(fc, discoveryPkg.CatalogDiscovery) {
+		klog.V(4).Infof("ForeignCluster %s already discovered through %v, skipping", fc.Spec.ClusterIdentity, discoveryType)
+		return nil
+	}
+
+	original := fc.DeepCopy()
+	if discoveryType != discoveryPkg.CatalogDiscovery {
+		// The cluster was previously discovered through an incoming peering, set the join flag accordingly.
+		foreignclusterutils.SetDiscoveryType(fc, discoveryPkg.CatalogDiscovery)
+		fc.Spec.OutgoingPeeringEnabled = discoveryv1alpha1.PeeringEnabledAuto
+	}
+	applyCatalogEntry(fc, entry)
+
+	if equality.Semantic.DeepEqual(original, fc) {
+		return nil
+	}
+	if err = discovery.Update(ctx, fc); err != nil {
+		return err
+	}
+	klog.Infof("ForeignCluster %s updated", fc.Spec.ClusterIdentity)
+	return nil
+}
+
+// applyCatalogEntry configures the given ForeignCluster according to the corresponding catalog entry.
+func applyCatalogEntry(fc *discoveryv1alpha1.ForeignCluster, entry *CatalogEntry) {
+	fc.Spec.ForeignAuthURL = entry.AuthURL
+	fc.Spec.CAFingerprint = auth.NormalizeCAFingerprint(entry.CAFingerprint)
+
+	labels := fc.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	annotations := fc.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	// Remove the labels previously configured through the catalog, and no longer present.
+	for _, key := range strings.Split(annotations[catalogLabelsAnnotation], ",") {
+		if _, found := entry.Labels[key]; !found {
+			delete(labels, key)
+		}
+	}
+
+	var keys []string
+	for key, value := range entry.Labels {
+		if strings.HasPrefix(key, reservedLabelsPrefix) {
+			continue
+		}
+		labels[key] = value
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		annotations[catalogLabelsAnnotation] = strings.Join(keys, ",")
+	} else {
+		delete(annotations, catalogLabelsAnnotation)
+	}
+
+	fc.SetLabels(labels)
+	fc.SetAnnotations(annotations)
+}
@@ -16,6 +16,7 @@ package discovery
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"net/http"
 	"sync"
@@ -60,13 +61,20 @@ type Controller struct {
 
 	mdnsServerAuth *zeroconf.Server
 	mdnsConfig     MDNSConfig
+	dnsConfig      DNSConfig
+	catalogConfig  CatalogConfig
+
+	// lastCatalog is the last accepted catalog, along with the digest of the corresponding data.
+	lastCatalog       *Catalog
+	lastCatalogDigest [sha256.Size]byte
 
 	insecureTransport *http.Transport
 }
 
 // NewDiscoveryCtrl returns a new discovery controller.
 func NewDiscoveryCtrl(cl, namespacedClient client.Client, namespace string,
-	localCluster discoveryv1alpha1.ClusterIdentity, config MDNSConfig, dialTCPTimeout time.Duration) *Controller {
+	localCluster discoveryv1alpha1.ClusterIdentity, config MDNSConfig, dnsConfig DNSConfig, catalogConfig CatalogConfig,
+	dialTCPTimeout time.Duration) *Controller {
 	return &Controller{
 		Client:           cl,
 		namespacedClient: namespacedClient,
@@ -75,6 +83,8 @@ func NewDiscoveryCtrl(cl, namespacedClient client.Client, namespace string,
 		LocalCluster: localCluster,
 
 		mdnsConfig:     config,
+		dnsConfig:      dnsConfig,
+		catalogConfig:  catalogConfig,
 		dialTCPTimeout: dialTCPTimeout,
 
 		insecureTransport: &http.Transport{IdleConnTimeout: 10 * time.Minute, TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
@@ -92,6 +102,14 @@ func (discovery *Controller) Start(ctx context.Context) error {
 		go discovery.startResolver(ctx)
 	}
 
+	if discovery.dnsConfig.IsEnabled() {
+		go discovery.startDNSResolver(ctx)
+	}
+
+	if discovery.catalogConfig.IsEnabled() {
+		go discovery.startCatalogSync(ctx)
+	}
+
 	go discovery.startGarbageCollector(ctx)
 
 	<-ctx.Done()
@@ -16,6 +16,10 @@ package discovery
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
 	"net"
 	"strconv"
 	"testing"
@@ -24,6 +28,7 @@ import (
 	"github.com/grandcat/zeroconf"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
 	"github.com/onsi/gomega/types"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -558,6 +563,34 @@ var _ = Describe("Discovery", func() {
 						expectedLength: Equal(0),
 					}),
 
+					Entry("garbage (WAN Discovery)", garbageCollectorTestcase{
+						fc: discoveryv1alpha1.ForeignCluster{
+							ObjectMeta: metav1.ObjectMeta{
+								Name: "foreign-cluster",
+								Labels: map[string]string{
+									discovery.DiscoveryTypeLabel: string(discovery.WanDiscovery),
+									discovery.ClusterIDLabel:     "foreign-cluster",
+								},
+								Annotations: map[string]string{
+									discovery.LastUpdateAnnotation: strconv.Itoa(int(time.Now().Unix()) - 600),
+								},
+							},
+							Spec: discoveryv1alpha1.ForeignClusterSpec{
+								ClusterIdentity: discoveryv1alpha1.ClusterIdentity{
+									ClusterID:   "foreign-cluster",
+									ClusterName: "ClusterTest2",
+								},
+								OutgoingPeeringEnabled: discoveryv1alpha1.PeeringEnabledAuto,
+								IncomingPeeringEnabled: discoveryv1alpha1.PeeringEnabledAuto,
+								ForeignAuthURL:         "https://example.com",
+								InsecureSkipTLSVerify:  pointer.BoolPtr(true),
+								TTL:                    300,
+							},
+						},
+
+						expectedLength: Equal(0),
+					}),
+
 					Entry("no garbage (Manual Discovery)", garbageCollectorTestcase{
 						fc: discoveryv1alpha1.ForeignCluster{
 							ObjectMeta: metav1.ObjectMeta{
@@ -588,6 +621,163 @@ var _ = Describe("Discovery", func() {
 				)
 			})
 
+			Context("DNS", func() {
+				var dnsServer testutil.DnsServer
+
+				BeforeEach(func() {
+					dnsServer = testutil.DnsServer{}
+					dnsServer.Serve()
+
+					discoveryCtrl.dnsConfig = DNSConfig{
+						Domains: []string{dnsServer.GetName()},
+						Service: "_liqo_auth._tcp",
+						Server:  dnsServer.GetAddr(),
+						TTL:     30 * time.Minute,
+					}
+				})
+
+				AfterEach(func() { dnsServer.Shutdown() })
+
+				It("should retrieve the clusters advertised in the domain", func() {
+					entries, err := discoveryCtrl.lookupDNS(dnsServer.GetName())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(entries).To(HaveLen(2))
+
+					Expect(entries[0].authData).To(PointTo(Equal(AuthData{address: "1.2.3.4", port: 1234, ttl: 1800})))
+					Expect(entries[0].clusterID).To(Equal("myliqo1-cluster-id"))
+					Expect(entries[1].authData).To(PointTo(Equal(AuthData{address: "4.3.2.1", port: 4321, ttl: 1800})))
+					Expect(entries[1].clusterID).To(BeEmpty())
+				})
+
+				It("should return no cluster if none is advertised in the domain", func() {
+					entries, err := discoveryCtrl.lookupDNS("not-existing.liqo.io.")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(entries).To(BeEmpty())
+				})
+
+				It("should create a ForeignCluster discovered through DNS", func() {
+					discoveryCtrl.updateForeignWAN(ctx, &discoveryData{
+						AuthData:    NewAuthData("1.2.3.4", 1234, 1800),
+						ClusterInfo: &auth.ClusterInfo{ClusterID: "foreign-cluster", ClusterName: "ClusterTest2"},
+					})
+
+					var fcs discoveryv1alpha1.ForeignClusterList
+					Expect(discoveryCtrl.List(ctx, &fcs)).To(Succeed())
+					Expect(fcs.Items).To(HaveLen(1))
+					Expect(foreignclusterutils.GetDiscoveryType(&fcs.Items[0])).To(Equal(discovery.WanDiscovery))
+					Expect(fcs.Items[0].Spec.ForeignAuthURL).To(Equal("https://1.2.3.4:1234"))
+					Expect(fcs.Items[0].Spec.TTL).To(Equal(1800))
+				})
+			})
+
+			Context("Catalog", func() {
+				var (
+					privateKey ed25519.PrivateKey
+					catalogCM  v1.ConfigMap
+				)
+
+				const catalogEntries = `clusters:
+- clusterID: foreign-cluster
+  clusterName: foreign-cluster-name
+  authURL: https://1.2.3.4:1234
+  caFingerprint: AA:BB:CC
+  labels:
+    region: europe
+- clusterID: local-cluster-id
+  authURL: https://4.3.2.1:4321
+`
+
+				forgeCatalog := func(serial uint64, expiresAt time.Time, entries string) string {
+					return fmt.Sprintf("serial: %d\nissuedAt: %s\nexpiresAt: %s\n%s", serial,
+						time.Now().Add(-time.Hour).Format(time.RFC3339), expiresAt.Format(time.RFC3339), entries)
+				}
+
+				catalogData := forgeCatalog(1, time.Now().Add(time.Hour), catalogEntries)
+
+				sign := func(data string) string {
+					return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(data)))
+				}
+
+				BeforeEach(func() {
+					var publicKey ed25519.PublicKey
+					var err error
+					publicKey, privateKey, err = ed25519.GenerateKey(rand.Reader)
+					Expect(err).ToNot(HaveOccurred())
+
+					discoveryCtrl.catalogConfig = CatalogConfig{ConfigMap: "catalog", PublicKey: publicKey}
+					catalogCM = v1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: discoveryCtrl.namespace},
+						Data:       map[string]string{CatalogConfigMapKey: catalogData, CatalogSignatureConfigMapKey: sign(catalogData)},
+					}
+					Expect(discoveryCtrl.Create(ctx, &catalogCM)).To(Succeed())
+				})
+
+				It("should create the ForeignClusters listed in the catalog", func() {
+					Expect(discoveryCtrl.syncCatalog(ctx)).To(Succeed())
+
+					var fcs discoveryv1alpha1.ForeignClusterList
+					Expect(discoveryCtrl.List(ctx, &fcs)).To(Succeed())
+					Expect(fcs.Items).To(HaveLen(1))
+					Expect(foreignclusterutils.GetDiscoveryType(&fcs.Items[0])).To(Equal(discovery.CatalogDiscovery))
+					Expect(fcs.Items[0].Spec.ClusterIdentity.ClusterID).To(Equal("foreign-cluster"))
+					Expect(fcs.Items[0].Spec.ForeignAuthURL).To(Equal("https://1.2.3.4:1234"))
+					Expect(fcs.Items[0].Spec.CAFingerprint).To(Equal("aabbcc"))
+					Expect(fcs.Items[0].Labels).To(HaveKeyWithValue("region", "europe"))
+				})
+
+				It("should delete the ForeignClusters no longer listed in the catalog", func() {
+					Expect(discoveryCtrl.syncCatalog(ctx)).To(Succeed())
+
+					empty := forgeCatalog(2, time.Now().Add(time.Hour), "clusters: []")
+					catalogCM.Data = map[string]string{CatalogConfigMapKey: empty, CatalogSignatureConfigMapKey: sign(empty)}
+					Expect(discoveryCtrl.Update(ctx, &catalogCM)).To(Succeed())
+					Expect(discoveryCtrl.syncCatalog(ctx)).To(Succeed())
+
+					var fcs discoveryv1alpha1.ForeignClusterList
+					Expect(discoveryCtrl.List(ctx, &fcs)).To(Succeed())
+					Expect(fcs.Items).To(BeEmpty())
+				})
+
+				It("should accept again an unchanged catalog", func() {
+					Expect(discoveryCtrl.syncCatalog(ctx)).To(Succeed())
+					Expect(discoveryCtrl.syncCatalog(ctx)).To(Succeed())
+				})
+
+				It("should reject a different catalog with a serial not greater than the last accepted one", func() {
+					Expect(discoveryCtrl.syncCatalog(ctx)).To(Succeed())
+
+					replayed := forgeCatalog(1, time.Now().Add(time.Hour), "clusters: []")
+					catalogCM.Data = map[string]string{CatalogConfigMapKey: replayed, CatalogSignatureConfigMapKey: sign(replayed)}
+					Expect(discoveryCtrl.Update(ctx, &catalogCM)).To(Succeed())
+					Expect(discoveryCtrl.syncCatalog(ctx)).ToNot(Succeed())
+
+					var fcs discoveryv1alpha1.ForeignClusterList
+					Expect(discoveryCtrl.List(ctx, &fcs)).To(Succeed())
+					Expect(fcs.Items).To(HaveLen(1))
+				})
+
+				It("should reject an expired catalog", func() {
+					expired := forgeCatalog(1, time.Now().Add(-time.Minute), catalogEntries)
+					catalogCM.Data = map[string]string{CatalogConfigMapKey: expired, CatalogSignatureConfigMapKey: sign(expired)}
+					Expect(discoveryCtrl.Update(ctx, &catalogCM)).To(Succeed())
+					Expect(discoveryCtrl.syncCatalog(ctx)).ToNot(Succeed())
+
+					var fcs discoveryv1alpha1.ForeignClusterList
+					Expect(discoveryCtrl.List(ctx, &fcs)).To(Succeed())
+					Expect(fcs.Items).To(BeEmpty())
+				})
+
+				It("should reject a catalog with an invalid signature", func() {
+					catalogCM.Data[CatalogSignatureConfigMapKey] = sign("tampered")
+					Expect(discoveryCtrl.Update(ctx, &catalogCM)).To(Succeed())
+					Expect(discoveryCtrl.syncCatalog(ctx)).ToNot(Succeed())
+
+					var fcs discoveryv1alpha1.ForeignClusterList
+					Expect(discoveryCtrl.List(ctx, &fcs)).To(Succeed())
+					Expect(fcs.Items).To(BeEmpty())
+				})
+			})
+
 			Context("mDNS", func() {
 
 				BeforeEach(func() {
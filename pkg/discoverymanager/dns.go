@@ -0,0 +1,270 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	discoveryPkg "github.com/liqotech/liqo/pkg/discovery"
+)
+
+const (
+	// resolvConfPath is the path of the file containing the configuration of the system DNS resolvers.
+	resolvConfPath = "/etc/resolv.conf"
+	// maxCNAMEChain is the maximum number of CNAME records followed while resolving an address.
+	maxCNAMEChain = 8
+	// txtClusterIDKey is the key of the TXT record specifying the cluster ID of the advertised cluster.
+	txtClusterIDKey = "cluster-id"
+)
+
+// DNSConfig defines the configuration parameters for the DNS discovery (i.e., across WANs).
+type DNSConfig struct {
+	// Domains are the domains whose DNS-SD records are looked up to discover the remote clusters.
+	Domains []string
+	// Service is the name of the service the DNS-SD records refer to (i.e., the same one advertised through mDNS).
+	Service string
+	// Server is the address of the DNS server to be contacted. If empty, the one configured in the system is used.
+	Server string
+
+	TTL                time.Duration
+	ResolveRefreshTime time.Duration
+}
+
+// IsEnabled returns whether the DNS discovery is enabled.
+func (config *DNSConfig) IsEnabled() bool {
+	return len(config.Domains) > 0
+}
+
+// dnsEntry represents a remote cluster advertised through DNS-SD records.
+type dnsEntry struct {
+	instance  string
+	authData  *AuthData
+	clusterID string
+}
+
+func (discovery *Controller) startDNSResolver(ctx context.Context) {
+	wait.UntilWithContext(ctx, discovery.resolveDNS, discovery.dnsConfig.ResolveRefreshTime)
+}
+
+// resolveDNS looks up the DNS-SD records of the configured domains, and creates or updates the corresponding ForeignClusters.
+func (discovery *Controller) resolveDNS(ctx context.Context) {
+	for _, domain := range discovery.dnsConfig.Domains {
+		entries, err := discovery.lookupDNS(domain)
+		if err != nil {
+			klog.Errorf("Failed to resolve the DNS records of domain %q: %v", domain, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			ids, ierr := discovery.getClusterInfo(ctx, entry.authData)
+			if ierr != nil {
+				continue
+			}
+
+			if entry.clusterID != "" && entry.clusterID != ids.ClusterID {
+				klog.Warningf("Cluster %q advertised in domain %q with ID %q, but %q retrieved from the authentication service",
+					entry.instance, domain, entry.clusterID, ids.ClusterID)
+				continue
+			}
+			if ids.ClusterID == discovery.LocalCluster.ClusterID || ids.ClusterID == "" {
+				continue
+			}
+
+			klog.V(4).Infof("update %s", entry.instance)
+			discovery.updateForeignWAN(ctx, &discoveryData{AuthData: entry.authData, ClusterInfo: ids})
+		}
+	}
+}
+
+// updateForeignWAN updates a ForeignCluster discovered through the DNS records.
+func (discovery *Controller) updateForeignWAN(ctx context.Context, data *discoveryData) {
+	if data.ClusterInfo.ClusterID == discovery.LocalCluster.ClusterID {
+		// is local cluster
+		return
+	}
+
+	err := retry.OnError(
+		retry.DefaultRetry,
+		func(err error) bool {
+			return k8serror.IsConflict(err) || k8serror.IsAlreadyExists(err)
+		},
+		func() error {
+			return createOrUpdate(ctx, data, discovery.Client, discoveryPkg.WanDiscovery, nil)
+		})
+	if err != nil {
+		klog.Error(err)
+	}
+}
+
+// lookupDNS retrieves the remote clusters advertised in the given domain, following the same service schema of the mDNS
+// advertisement: a PTR record for the service points to the instances, each one described by SRV (and optional TXT) records.
+func (discovery *Controller) lookupDNS(domain string) ([]*dnsEntry, error) {
+	server, err := discovery.dnsServer()
+	if err != nil {
+		return nil, err
+	}
+
+	service := dns.Fqdn(fmt.Sprintf("%s.%s", discovery.dnsConfig.Service, domain))
+	ptrs, err := queryDNS(server, service, dns.TypePTR)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*dnsEntry
+	for _, rr := range ptrs {
+		ptr, ok := rr.(*dns.PTR)
+		if !ok {
+			continue
+		}
+
+		entry, lerr := discovery.lookupDNSInstance(server, ptr.Ptr)
+		if lerr != nil {
+			klog.Warningf("Failed to resolve the DNS records of instance %q: %v", ptr.Ptr, lerr)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// lookupDNSInstance retrieves the information about the given DNS-SD instance.
+func (discovery *Controller) lookupDNSInstance(server, instance string) (*dnsEntry, error) {
+	records, err := queryDNS(server, instance, dns.TypeSRV)
+	if err != nil {
+		return nil, err
+	}
+
+	var srvs []*dns.SRV
+	for _, rr := range records {
+		if srv, ok := rr.(*dns.SRV); ok {
+			srvs = append(srvs, srv)
+		}
+	}
+	if len(srvs) == 0 {
+		return nil, fmt.Errorf("no SRV record found")
+	}
+
+	// Select the record with the highest priority (i.e., the lowest value).
+	sort.SliceStable(srvs, func(i, j int) bool { return srvs[i].Priority < srvs[j].Priority })
+	address, err := resolveDNSAddress(server, srvs[0].Target)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &dnsEntry{
+		instance: instance,
+		authData: NewAuthData(address, int(srvs[0].Port), uint32(discovery.dnsConfig.TTL.Seconds())),
+	}
+
+	// The TXT records are optional, hence errors are not fatal.
+	txts, err := queryDNS(server, instance, dns.TypeTXT)
+	if err != nil {
+		klog.V(4).Infof("Failed to retrieve the TXT records of instance %q: %v", instance, err)
+	}
+	for _, rr := range txts {
+		if txt, ok := rr.(*dns.TXT); ok {
+			for _, field := range txt.Txt {
+				if key, value, found := strings.Cut(field, "="); found && key == txtClusterIDKey {
+					entry.clusterID = value
+				}
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+// resolveDNSAddress returns the IP address corresponding to the given host name, following possible CNAME records.
+func resolveDNSAddress(server, host string) (string, error) {
+	name := host
+	for i := 0; i < maxCNAMEChain; i++ {
+		records, err := queryDNS(server, name, dns.TypeA)
+		if err != nil {
+			return "", err
+		}
+
+		var cname string
+		for _, rr := range records {
+			switch record := rr.(type) {
+			case *dns.A:
+				return record.A.String(), nil
+			case *dns.CNAME:
+				cname = record.Target
+			}
+		}
+
+		if cname == "" {
+			// Some servers do not return the CNAME records in response to A queries.
+			if records, err = queryDNS(server, name, dns.TypeCNAME); err != nil {
+				return "", err
+			}
+			for _, rr := range records {
+				if record, ok := rr.(*dns.CNAME); ok {
+					cname = record.Target
+				}
+			}
+		}
+
+		if cname == "" {
+			return "", fmt.Errorf("no address found for host %q", host)
+		}
+		name = cname
+	}
+
+	return "", fmt.Errorf("too many CNAME records for host %q", host)
+}
+
+// dnsServer returns the address of the DNS server to be contacted.
+func (discovery *Controller) dnsServer() (string, error) {
+	if discovery.dnsConfig.Server != "" {
+		return discovery.dnsConfig.Server, nil
+	}
+
+	config, err := dns.ClientConfigFromFile(resolvConfPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the DNS configuration: %w", err)
+	}
+	if len(config.Servers) == 0 {
+		return "", fmt.Errorf("no DNS server configured")
+	}
+	return net.JoinHostPort(config.Servers[0], config.Port), nil
+}
+
+// queryDNS performs a DNS query for the given name and type, and returns the corresponding answers.
+func queryDNS(server, name string, qtype uint16) ([]dns.RR, error) {
+	msg := &dns.Msg{}
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+
+	client := &dns.Client{}
+	response, _, err := client.Exchange(msg, server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %v records of %q: %w", dns.TypeToString[qtype], name, err)
+	}
+	if response.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("failed to query %v records of %q: %v", dns.TypeToString[qtype], name, dns.RcodeToString[response.Rcode])
+	}
+	return response.Answer, nil
+}
@@ -139,7 +139,7 @@ func checkUpdate(
 	if higherPriority {
 		// something is changed in ForeignCluster specs, update it
 		foreignclusterutils.SetDiscoveryType(fc, discoveryType)
-		if higherPriority && discoveryType == discoveryPkg.LanDiscovery {
+		if higherPriority && (discoveryType == discoveryPkg.LanDiscovery || discoveryType == discoveryPkg.WanDiscovery) {
 			// if the cluster was previously discovered with IncomingPeering discovery type, set join flag accordingly to LanDiscovery sets and set TTL
 			fc.Spec.OutgoingPeeringEnabled = v1alpha1.PeeringEnabledAuto
 			fc.Spec.TTL = int(data.AuthData.ttl)
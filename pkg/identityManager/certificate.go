@@ -18,20 +18,17 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"sort"
 	"strconv"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
-	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	"github.com/liqotech/liqo/pkg/auth"
 	"github.com/liqotech/liqo/pkg/discovery"
+	csrutil "github.com/liqotech/liqo/pkg/utils/csr"
 )
 
 // StoreIdentity stores the identity to authenticate with a remote cluster.
@@ -47,8 +44,9 @@ func (certManager *identityManager) StoreIdentity(ctx context.Context, remoteClu
 				certificateAvailableLabel: "true",
 			},
 			Annotations: map[string]string{
-				// one year starting from now
+				// one year starting from now, unless the actual expiration of the certificate is known
 				certificateExpireTimeAnnotation: fmt.Sprintf("%v", time.Now().AddDate(1, 0, 0).Unix()),
+				clusterNameAnnotation:           remoteCluster.ClusterName,
 			},
 		},
 		StringData: map[string]string{
@@ -73,6 +71,11 @@ func (certManager *identityManager) StoreIdentity(ctx context.Context, remoteClu
 		}
 
 		secret.Data[certificateSecretKey] = certificate
+		if parsed, err := csrutil.ParseCertificate(certificate); err == nil {
+			secret.Annotations[certificateExpireTimeAnnotation] = fmt.Sprintf("%v", parsed.NotAfter.Unix())
+		} else {
+			klog.Warningf("Failed to retrieve the expiration of the certificate for cluster %v: %v", remoteCluster.ClusterID, err)
+		}
 	}
 
 	// ApiServerCA may be empty if the remote cluster exposes the ApiServer with a certificate issued by "public" CAs
@@ -89,12 +92,46 @@ func (certManager *identityManager) StoreIdentity(ctx context.Context, remoteClu
 		secret.StringData[apiProxyURLSecretKey] = remoteProxyURL
 	}
 
-	if _, err := certManager.client.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
-		return fmt.Errorf("failed to create secret: %w", err)
+	if _, err := certManager.storage.StoreIdentity(ctx, remoteCluster, secret); err != nil {
+		return fmt.Errorf("failed to store identity: %w", err)
 	}
 	return nil
 }
 
+// GetCertificateIdentity returns the certificate currently used to authenticate with the remote cluster,
+// or nil if the identity is not certificate-based (e.g., AWS IAM).
+func (certManager *identityManager) GetCertificateIdentity(remoteCluster discoveryv1alpha1.ClusterIdentity,
+	namespace string) (*CertificateIdentity, error) {
+	var secret *v1.Secret
+	var err error
+
+	if namespace == "" {
+		secret, err = certManager.getSecret(remoteCluster)
+	} else {
+		secret, err = certManager.getSecretInNamespace(remoteCluster, namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, ok := secret.Data[certificateSecretKey]
+	if !ok || certManager.isAwsIdentity(secret) {
+		return nil, nil
+	}
+
+	parsed, err := csrutil.ParseCertificate(certificate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity secret %v/%v: %w", secret.Namespace, secret.Name, err)
+	}
+
+	return &CertificateIdentity{
+		Certificate: certificate,
+		PrivateKey:  secret.Data[privateKeySecretKey],
+		NotBefore:   parsed.NotBefore,
+		NotAfter:    parsed.NotAfter,
+	}, nil
+}
+
 // getSecret retrieves the identity secret given the clusterID.
 func (certManager *identityManager) getSecret(remoteCluster discoveryv1alpha1.ClusterIdentity) (*v1.Secret, error) {
 	namespace, err := certManager.namespaceManager.GetNamespace(context.TODO(), remoteCluster)
@@ -108,37 +145,7 @@ func (certManager *identityManager) getSecret(remoteCluster discoveryv1alpha1.Cl
 // getSecretInNamespace retrieves the identity secret in the given Namespace.
 func (certManager *identityManager) getSecretInNamespace(remoteCluster discoveryv1alpha1.ClusterIdentity,
 	namespace string) (*v1.Secret, error) {
-	labelSelector := metav1.LabelSelector{
-		MatchLabels: map[string]string{
-			localIdentitySecretLabel: "true",
-			discovery.ClusterIDLabel: remoteCluster.ClusterID,
-		},
-	}
-	secretList, err := certManager.client.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
-		LabelSelector: labels.Set(labelSelector.MatchLabels).String(),
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	secrets := secretList.Items
-	if nItems := len(secrets); nItems == 0 {
-		err = kerrors.NewNotFound(schema.GroupResource{
-			Group:    "v1",
-			Resource: "secrets",
-		}, fmt.Sprintf("Identity for cluster %v in namespace %v", remoteCluster.ClusterID, namespace))
-		return nil, err
-	}
-
-	// sort by reverse certificate expire time
-	sort.Slice(secrets, func(i, j int) bool {
-		time1 := getExpireTime(&secretList.Items[i])
-		time2 := getExpireTime(&secretList.Items[j])
-		return time1 > time2
-	})
-
-	// if there are multiple secrets, get the one with the certificate that will expire last
-	return &secrets[0], nil
+	return certManager.storage.GetIdentity(context.TODO(), remoteCluster, namespace)
 }
 
 // getExpireTime reads the expire time from the annotations of the secret.
@@ -52,7 +52,7 @@ type certificateIdentityProvider struct {
 // given the clusterid and the signingRequest.
 func (identityProvider *certificateIdentityProvider) GetRemoteCertificate(cluster discoveryv1alpha1.ClusterIdentity,
 	namespace, signingRequest string) (response *responsetypes.SigningRequestResponse, err error) {
-	secret, err := identityProvider.client.CoreV1().Secrets(namespace).Get(context.TODO(), remoteCertificateSecret, metav1.GetOptions{})
+	secret, err := identityProvider.client.CoreV1().Secrets(namespace).Get(context.TODO(), RemoteCertificateSecret, metav1.GetOptions{})
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			klog.V(4).Info(err)
@@ -68,7 +68,7 @@ func (identityProvider *certificateIdentityProvider) GetRemoteCertificate(cluste
 		err = kerrors.NewNotFound(schema.GroupResource{
 			Group:    "v1",
 			Resource: "secrets",
-		}, remoteCertificateSecret)
+		}, RemoteCertificateSecret)
 		return response, err
 	}
 
@@ -88,13 +88,45 @@ func (identityProvider *certificateIdentityProvider) GetRemoteCertificate(cluste
 		err = kerrors.NewNotFound(schema.GroupResource{
 			Group:    "v1",
 			Resource: "secrets",
-		}, remoteCertificateSecret)
+		}, RemoteCertificateSecret)
 		return response, err
 	}
 
 	return response, nil
 }
 
+// GetIssuedCertificate retrieves the last certificate issued to the given remote cluster, regardless of the signing request.
+func (identityProvider *certificateIdentityProvider) GetIssuedCertificate(cluster discoveryv1alpha1.ClusterIdentity,
+	namespace string) ([]byte, error) {
+	secret, err := identityProvider.client.CoreV1().Secrets(namespace).Get(context.TODO(), RemoteCertificateSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, ok := secret.Data[certificateSecretKey]
+	if !ok {
+		klog.Errorf("no %v key in secret %v/%v", certificateSecretKey, secret.Namespace, secret.Name)
+		return nil, kerrors.NewNotFound(schema.GroupResource{
+			Group:    "v1",
+			Resource: "secrets",
+		}, RemoteCertificateSecret)
+	}
+	return certificate, nil
+}
+
+// GetRevocation retrieves the information about the revocation of the identity granted to the given remote cluster.
+func (identityProvider *certificateIdentityProvider) GetRevocation(cluster discoveryv1alpha1.ClusterIdentity,
+	namespace string) (*Revocation, error) {
+	secret, err := identityProvider.client.CoreV1().Secrets(namespace).Get(context.TODO(), RemoteCertificateSecret, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return RevocationFromSecret(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return RevocationFromSecret(secret), nil
+}
+
 // ApproveSigningRequest approves a remote CertificateSigningRequest.
 // It creates a CertificateSigningRequest CR to be issued by the local cluster, and approves it.
 // This function will wait (with a timeout) for an available certificate before returning.
@@ -158,7 +190,8 @@ func (identityProvider *certificateIdentityProvider) ApproveSigningRequest(clust
 	return response, nil
 }
 
-// storeRemoteCertificate stores the issued certificate in a Secret in the TenantNamespace.
+// storeRemoteCertificate stores the issued certificate in a Secret in the TenantNamespace,
+// replacing the one previously issued (if any) in case of renewal.
 func (identityProvider *certificateIdentityProvider) storeRemoteCertificate(cluster discoveryv1alpha1.ClusterIdentity,
 	signingRequest, certificate []byte) (*v1.Secret, error) {
 	namespace, err := identityProvider.namespaceManager.GetNamespace(context.TODO(), cluster)
@@ -169,7 +202,7 @@ func (identityProvider *certificateIdentityProvider) storeRemoteCertificate(clus
 
 	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      remoteCertificateSecret,
+			Name:      RemoteCertificateSecret,
 			Namespace: namespace.Name,
 			Labels: map[string]string{
 				discovery.ClusterIDLabel: cluster.ClusterID,
@@ -181,10 +214,25 @@ func (identityProvider *certificateIdentityProvider) storeRemoteCertificate(clus
 		},
 	}
 
-	if secret, err = identityProvider.client.CoreV1().
-		Secrets(namespace.Name).Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+	secrets := identityProvider.client.CoreV1().Secrets(namespace.Name)
+	created, err := secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+	if err == nil {
+		return created, nil
+	}
+	if !kerrors.IsAlreadyExists(err) {
+		klog.Error(err)
+		return nil, err
+	}
+
+	existing, err := secrets.Get(context.TODO(), RemoteCertificateSecret, metav1.GetOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	existing.Data = secret.Data
+	if existing, err = secrets.Update(context.TODO(), existing, metav1.UpdateOptions{}); err != nil {
 		klog.Error(err)
 		return nil, err
 	}
-	return secret, nil
+	return existing, nil
 }
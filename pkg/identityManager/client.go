@@ -107,6 +107,8 @@ func buildConfigFromSecret(secret *v1.Secret, remoteCluster discoveryv1alpha1.Cl
 		return nil, err
 	}
 
+	// The API server CA has been advertised by the remote authentication service, through the connection verified against
+	// the pinned CA fingerprint. Hence, it is trusted as is, without pinning the API server CA fingerprint independently.
 	caData, ok := secret.Data[apiServerCaSecretKey]
 	if !ok {
 		// CAData may be nil if the remote cluster exposes the API Server with a trusted certificate
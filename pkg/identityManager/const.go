@@ -16,6 +16,9 @@ package identitymanager
 
 const defaultOrganization = "liqo.io"
 
+// RemoteClusterGroup is the group the identities granted to remote clusters belong to.
+const RemoteClusterGroup = defaultOrganization
+
 const (
 	localIdentitySecretLabel  = "discovery.liqo.io/local-identity"
 	remoteTenantCSRLabel      = "discovery.liqo.io/remote-tenant-csr"
@@ -31,11 +34,13 @@ const (
 
 const (
 	certificateExpireTimeAnnotation = "discovery.liqo.io/certificate-expire-time"
+	clusterNameAnnotation           = "discovery.liqo.io/cluster-name"
 )
 
 const (
-	identitySecretRoot      = "liqo-identity"
-	remoteCertificateSecret = "liqo-remote-certificate"
+	identitySecretRoot = "liqo-identity"
+	// RemoteCertificateSecret is the name of the secret storing the certificate issued to a remote cluster.
+	RemoteCertificateSecret = "liqo-remote-certificate"
 
 	privateKeySecretKey  = "private-key"
 	csrSecretKey         = "csr"
@@ -59,7 +59,22 @@ func (identityProvider *iamIdentityProvider) GetRemoteCertificate(cluster discov
 	return response, kerrors.NewNotFound(schema.GroupResource{
 		Group:    "v1",
 		Resource: "secrets",
-	}, remoteCertificateSecret)
+	}, RemoteCertificateSecret)
+}
+
+func (identityProvider *iamIdentityProvider) GetIssuedCertificate(cluster discoveryv1alpha1.ClusterIdentity,
+	namespace string) ([]byte, error) {
+	// this method has no meaning for this identity provider
+	return nil, kerrors.NewNotFound(schema.GroupResource{
+		Group:    "v1",
+		Resource: "secrets",
+	}, RemoteCertificateSecret)
+}
+
+func (identityProvider *iamIdentityProvider) GetRevocation(cluster discoveryv1alpha1.ClusterIdentity,
+	namespace string) (*Revocation, error) {
+	// identity revocation is not supported by this identity provider
+	return RevocationFromSecret(nil), nil
 }
 
 func (identityProvider *iamIdentityProvider) ApproveSigningRequest(cluster discoveryv1alpha1.ClusterIdentity,
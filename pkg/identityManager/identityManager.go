@@ -35,25 +35,26 @@ type identityManager struct {
 	client           kubernetes.Interface
 	localCluster     discoveryv1alpha1.ClusterIdentity
 	namespaceManager tenantnamespace.Manager
+	storage          IdentityStorage
 
 	iamTokenManager tokenManager
 }
 
-// NewCertificateIdentityReader gets a new certificate identity reader.
-func NewCertificateIdentityReader(client kubernetes.Interface,
-	localCluster discoveryv1alpha1.ClusterIdentity, namespaceManager tenantnamespace.Manager) IdentityReader {
-	return NewCertificateIdentityManager(client, localCluster, namespaceManager)
+// NewCertificateIdentityReader gets a new certificate identity reader, retrieving the identities from the given storage.
+func NewCertificateIdentityReader(client kubernetes.Interface, localCluster discoveryv1alpha1.ClusterIdentity,
+	namespaceManager tenantnamespace.Manager, storage IdentityStorage) IdentityReader {
+	return NewCertificateIdentityManager(client, localCluster, namespaceManager, storage)
 }
 
-// NewCertificateIdentityManager gets a new certificate identity manager.
-func NewCertificateIdentityManager(client kubernetes.Interface,
-	localCluster discoveryv1alpha1.ClusterIdentity, namespaceManager tenantnamespace.Manager) IdentityManager {
+// NewCertificateIdentityManager gets a new certificate identity manager, persisting the identities in the given storage.
+func NewCertificateIdentityManager(client kubernetes.Interface, localCluster discoveryv1alpha1.ClusterIdentity,
+	namespaceManager tenantnamespace.Manager, storage IdentityStorage) IdentityManager {
 	idProvider := &certificateIdentityProvider{
 		namespaceManager: namespaceManager,
 		client:           client,
 	}
 
-	return newIdentityManager(client, localCluster, namespaceManager, idProvider)
+	return newIdentityManager(client, localCluster, namespaceManager, storage, idProvider)
 }
 
 // NewCertificateIdentityProvider gets a new certificate identity approver.
@@ -70,26 +71,26 @@ func NewCertificateIdentityProvider(ctx context.Context, client kubernetes.Inter
 		csrWatcher:       csrWatcher,
 	}
 
-	return newIdentityManager(client, localCluster, namespaceManager, idProvider)
+	return newIdentityManager(client, localCluster, namespaceManager, NewSecretIdentityStorage(client), idProvider)
 }
 
 // NewIAMIdentityReader gets a new identity reader to handle IAM identities.
 func NewIAMIdentityReader(client kubernetes.Interface,
 	localCluster discoveryv1alpha1.ClusterIdentity, awsConfig *AwsConfig,
-	namespaceManager tenantnamespace.Manager) IdentityManager {
-	return NewIAMIdentityManager(client, localCluster, awsConfig, namespaceManager)
+	namespaceManager tenantnamespace.Manager, storage IdentityStorage) IdentityManager {
+	return NewIAMIdentityManager(client, localCluster, awsConfig, namespaceManager, storage)
 }
 
 // NewIAMIdentityManager gets a new identity manager to handle IAM identities.
 func NewIAMIdentityManager(client kubernetes.Interface,
 	localCluster discoveryv1alpha1.ClusterIdentity, awsConfig *AwsConfig,
-	namespaceManager tenantnamespace.Manager) IdentityManager {
+	namespaceManager tenantnamespace.Manager, storage IdentityStorage) IdentityManager {
 	idProvider := &iamIdentityProvider{
 		awsConfig: awsConfig,
 		client:    client,
 	}
 
-	return newIdentityManager(client, localCluster, namespaceManager, idProvider)
+	return newIdentityManager(client, localCluster, namespaceManager, storage, idProvider)
 }
 
 // NewIAMIdentityProvider gets a new identity approver to handle IAM identities.
@@ -102,15 +103,16 @@ func NewIAMIdentityProvider(client kubernetes.Interface,
 		localClusterID: localCluster.ClusterID,
 	}
 
-	return newIdentityManager(client, localCluster, namespaceManager, idProvider)
+	return newIdentityManager(client, localCluster, namespaceManager, NewSecretIdentityStorage(client), idProvider)
 }
 
 func newIdentityManager(client kubernetes.Interface,
 	localCluster discoveryv1alpha1.ClusterIdentity,
 	namespaceManager tenantnamespace.Manager,
+	storage IdentityStorage,
 	idProvider IdentityProvider) *identityManager {
 	iamTokenManager := &iamTokenManager{
-		client:                    client,
+		storage:                   storage,
 		availableClusterIDSecrets: map[string]types.NamespacedName{},
 		tokenFiles:                map[string]string{},
 	}
@@ -120,6 +122,7 @@ func newIdentityManager(client kubernetes.Interface,
 		client:           client,
 		localCluster:     localCluster,
 		namespaceManager: namespaceManager,
+		storage:          storage,
 
 		IdentityProvider: idProvider,
 
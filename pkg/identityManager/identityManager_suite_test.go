@@ -43,7 +43,7 @@ var (
 	cancel context.CancelFunc
 
 	cluster       testutil.Cluster
-	client        kubernetes.Interface
+	clientset     kubernetes.Interface
 	localCluster  discoveryv1alpha1.ClusterIdentity
 	remoteCluster discoveryv1alpha1.ClusterIdentity
 
@@ -93,10 +93,10 @@ var _ = BeforeSuite(func() {
 	cluster, _, err = testutil.NewTestCluster([]string{filepath.Join("..", "..", "deployments", "liqo", "crds")})
 	Expect(err).ToNot(HaveOccurred())
 
-	client = cluster.GetClient()
+	clientset = cluster.GetClient()
 
-	namespaceManager = tenantnamespace.NewManager(client)
-	identityMan = NewCertificateIdentityManager(cluster.GetClient(), localCluster, namespaceManager)
+	namespaceManager = tenantnamespace.NewManager(clientset)
+	identityMan = NewCertificateIdentityManager(cluster.GetClient(), localCluster, namespaceManager, NewSecretIdentityStorage(clientset))
 	identityProvider = NewCertificateIdentityProvider(ctx, cluster.GetClient(), localCluster, namespaceManager)
 
 	namespace, err = namespaceManager.CreateNamespace(ctx, remoteCluster)
@@ -107,7 +107,7 @@ var _ = BeforeSuite(func() {
 
 	// Certificate Secret Section
 	apiServerConfig := apiserver.Config{Address: "127.0.0.1", TrustedCA: false}
-	Expect(apiServerConfig.Complete(cluster.GetCfg(), client)).To(Succeed())
+	Expect(apiServerConfig.Complete(cluster.GetCfg(), clientset)).To(Succeed())
 
 	signingIdentityResponse := responsetypes.SigningRequestResponse{
 		ResponseType: responsetypes.SigningRequestResponseCertificate,
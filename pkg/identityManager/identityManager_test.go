@@ -15,8 +15,15 @@
 package identitymanager
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/pem"
+	"math/big"
 	"os"
+	"strconv"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -27,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/auth"
 	"github.com/liqotech/liqo/pkg/discovery"
 	idManTest "github.com/liqotech/liqo/pkg/identityManager/testUtils"
 	"github.com/liqotech/liqo/pkg/utils/csr"
@@ -47,7 +55,7 @@ var _ = Describe("IdentityManager", func() {
 
 		BeforeEach(func() {
 			stopChan = make(chan struct{})
-			idManTest.StartTestApprover(client, stopChan)
+			idManTest.StartTestApprover(clientset, stopChan)
 		})
 
 		AfterEach(func() {
@@ -100,7 +108,7 @@ var _ = Describe("IdentityManager", func() {
 		})
 
 		AfterEach(func() {
-			Expect(client.CoreV1().Secrets(namespace.Name).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{})).To(Succeed())
+			Expect(clientset.CoreV1().Secrets(namespace.Name).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{})).To(Succeed())
 		})
 
 		commonSecretChecks := func(secret *v1.Secret) {
@@ -149,7 +157,7 @@ var _ = Describe("IdentityManager", func() {
 			Expect(ok).To(BeTrue())
 
 			tokenManager := iamTokenManager{
-				client:                    idMan.client,
+				storage:                   idMan.storage,
 				availableClusterIDSecrets: map[string]types.NamespacedName{},
 				tokenFiles:                map[string]string{},
 			}
@@ -200,6 +208,68 @@ var _ = Describe("IdentityManager", func() {
 
 	})
 
+	Context("Identity renewal", func() {
+		var (
+			key, certificate []byte
+			response         *auth.CertificateIdentityResponse
+		)
+
+		BeforeEach(func() {
+			key, certificate = selfSignedIdentity(localCluster.ClusterID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+
+			response = &auth.CertificateIdentityResponse{}
+			*response = *secretIdentityResponse
+			response.Certificate = base64.StdEncoding.EncodeToString(certificate)
+		})
+
+		AfterEach(func() {
+			Expect(clientset.CoreV1().Secrets(namespace.Name).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{})).To(Succeed())
+		})
+
+		It("should store the expiration of the certificate and supersede the previous identity", func() {
+			Expect(identityMan.StoreIdentity(ctx, remoteCluster, namespace.Name, key, "", secretIdentityResponse)).To(Succeed())
+			Expect(identityMan.StoreIdentity(ctx, remoteCluster, namespace.Name, key, "", response)).To(Succeed())
+
+			secrets, err := clientset.CoreV1().Secrets(namespace.Name).List(ctx, metav1.ListOptions{
+				LabelSelector: localIdentitySecretLabel + "=true",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secrets.Items).To(HaveLen(1))
+
+			identity, err := identityMan.GetCertificateIdentity(remoteCluster, namespace.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(identity).ToNot(BeNil())
+			Expect(identity.Certificate).To(Equal(certificate))
+			Expect(identity.PrivateKey).To(Equal(key))
+			Expect(identity.NotAfter).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+			Expect(secrets.Items[0].GetAnnotations()).To(HaveKeyWithValue(
+				certificateExpireTimeAnnotation, strconv.FormatInt(identity.NotAfter.Unix(), 10)))
+		})
+
+		It("should accept a renewal request proving the possession of the issued certificate", func() {
+			_, csrBytes, err := csr.NewKeyAndRequest(localCluster.ClusterID)
+			Expect(err).ToNot(HaveOccurred())
+
+			request, err := auth.NewCertificateIdentityRenewalRequest(localCluster, "", csrBytes, certificate, key)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.IsRenewal()).To(BeTrue())
+			Expect(request.CheckRenewal(certificate, time.Now())).To(Succeed())
+
+			By("rejecting the request if the certificate does not match the issued one")
+			_, other := selfSignedIdentity(localCluster.ClusterID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+			Expect(request.CheckRenewal(other, time.Now())).ToNot(Succeed())
+
+			By("rejecting the request if the certificate already expired")
+			Expect(request.CheckRenewal(certificate, time.Now().Add(2*time.Hour))).ToNot(Succeed())
+
+			By("rejecting the request if the signature has not been generated with the certificate key")
+			otherKey, _ := selfSignedIdentity(localCluster.ClusterID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+			forged, err := auth.NewCertificateIdentityRenewalRequest(localCluster, "", csrBytes, certificate, otherKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(forged.CheckRenewal(certificate, time.Now())).ToNot(Succeed())
+		})
+	})
+
 	Context("Identity Provider", func() {
 
 		It("Certificate Identity Provider", func() {
@@ -218,7 +288,7 @@ var _ = Describe("IdentityManager", func() {
 				AwsSecretAccessKey: "Secret",
 				AwsRegion:          "region",
 				AwsClusterName:     "cluster-name",
-			}, namespaceManager)
+			}, namespaceManager, NewSecretIdentityStorage(clientset))
 
 			certIDManager, ok := idProvider.(*identityManager)
 			Expect(ok).To(BeTrue())
@@ -307,7 +377,7 @@ var _ = Describe("IdentityManager", func() {
 			Expect(ok).To(BeTrue())
 
 			tokenManager = iamTokenManager{
-				client:                    idMan.client,
+				storage:                   idMan.storage,
 				availableClusterIDSecrets: map[string]types.NamespacedName{},
 				tokenFiles:                map[string]string{},
 			}
@@ -386,3 +456,24 @@ var _ = Describe("IdentityManager", func() {
 	})
 
 })
+
+// selfSignedIdentity returns a PEM encoded private key and the corresponding self-signed certificate.
+func selfSignedIdentity(commonName string, notBefore, notAfter time.Time) (key, certificate []byte) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, public, private)
+	Expect(err).ToNot(HaveOccurred())
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(private)
+	Expect(err).ToNot(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -16,6 +16,7 @@ package identitymanager
 
 import (
 	"context"
+	"time"
 
 	"k8s.io/client-go/rest"
 
@@ -36,6 +37,17 @@ type IdentityManager interface {
 
 	StoreIdentity(ctx context.Context, remoteCluster discoveryv1alpha1.ClusterIdentity, namespace string, key []byte,
 		remoteProxyURL string, identityResponse *auth.CertificateIdentityResponse) error
+	// GetCertificateIdentity returns the certificate currently used to authenticate with the remote cluster,
+	// or nil if the identity is not certificate-based (e.g., AWS IAM).
+	GetCertificateIdentity(remoteCluster discoveryv1alpha1.ClusterIdentity, namespace string) (*CertificateIdentity, error)
+}
+
+// CertificateIdentity contains the information about the certificate used to authenticate with a remote cluster.
+type CertificateIdentity struct {
+	Certificate []byte
+	PrivateKey  []byte
+	NotBefore   time.Time
+	NotAfter    time.Time
 }
 
 // IdentityProvider provides the interface to retrieve and approve remote cluster identities.
@@ -44,4 +56,8 @@ type IdentityProvider interface {
 		namespace, signingRequest string) (response *responsetypes.SigningRequestResponse, err error)
 	ApproveSigningRequest(cluster discoveryv1alpha1.ClusterIdentity,
 		signingRequest string) (response *responsetypes.SigningRequestResponse, err error)
+	// GetIssuedCertificate retrieves the last certificate issued to the given remote cluster.
+	GetIssuedCertificate(cluster discoveryv1alpha1.ClusterIdentity, namespace string) ([]byte, error)
+	// GetRevocation retrieves the information about the revocation of the identity granted to the given remote cluster.
+	GetRevocation(cluster discoveryv1alpha1.ClusterIdentity, namespace string) (*Revocation, error)
 }
@@ -0,0 +1,76 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identitymanager
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/discovery"
+)
+
+// MigrateIdentities moves the identities from the source to the target storage, returning the number of
+// identities migrated. Identities are copied to the target storage unless it already contains a more recent
+// one for the same cluster, and they are then removed from the source storage. Migration is idempotent, hence
+// it can be safely retried in case of errors.
+func MigrateIdentities(ctx context.Context, source, target IdentityStorage) (int, error) {
+	identities, err := source.ListIdentities(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the identities to migrate: %w", err)
+	}
+
+	migrated := 0
+	for _, identity := range identities {
+		remoteCluster := identityCluster(identity)
+
+		current, getErr := target.GetIdentity(ctx, remoteCluster, identity.Namespace)
+		switch {
+		case getErr != nil && !kerrors.IsNotFound(getErr):
+			return migrated, fmt.Errorf("failed to retrieve the identity for cluster %v from the target storage: %w", remoteCluster.ClusterID, getErr)
+		case getErr == nil && getExpireTime(current) >= getExpireTime(identity):
+			klog.V(4).Infof("Identity for cluster %v in namespace %v already present in the target storage", remoteCluster.ClusterID, identity.Namespace)
+		default:
+			if _, storeErr := target.StoreIdentity(ctx, remoteCluster, identity); storeErr != nil {
+				return migrated, fmt.Errorf("failed to store the identity for cluster %v in the target storage: %w", remoteCluster.ClusterID, storeErr)
+			}
+		}
+
+		if err = source.DeleteIdentity(ctx, remoteCluster, identity.Namespace); err != nil {
+			return migrated, fmt.Errorf("failed to remove the identity for cluster %v from the source storage: %w", remoteCluster.ClusterID, err)
+		}
+
+		klog.Infof("Migrated the identity for cluster %v in namespace %v", remoteCluster.ClusterID, identity.Namespace)
+		migrated++
+	}
+	return migrated, nil
+}
+
+// identityCluster returns the identity of the remote cluster the given identity refers to. The cluster name
+// falls back to the cluster ID for the identities stored before the corresponding annotation was introduced.
+func identityCluster(identity *v1.Secret) discoveryv1alpha1.ClusterIdentity {
+	cluster := discoveryv1alpha1.ClusterIdentity{
+		ClusterID:   identity.Labels[discovery.ClusterIDLabel],
+		ClusterName: identity.Annotations[clusterNameAnnotation],
+	}
+	if cluster.ClusterName == "" {
+		cluster.ClusterName = cluster.ClusterID
+	}
+	return cluster
+}
@@ -0,0 +1,193 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identitymanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	csrutil "github.com/liqotech/liqo/pkg/utils/csr"
+	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+)
+
+const (
+	// identityRevokedAnnotation is the annotation set on the secret storing the certificate issued to a remote
+	// cluster, to signal that its identity is currently revoked (i.e., no further identity can be issued).
+	identityRevokedAnnotation = "discovery.liqo.io/identity-revoked"
+	// revokedCertificatesAnnotation is the annotation set on the secret storing the certificate issued to a remote
+	// cluster, to record the serial numbers of the revoked certificates (comma separated).
+	revokedCertificatesAnnotation = "discovery.liqo.io/revoked-certificates"
+	// revokedCredentialsAnnotation is the annotation set on the secret storing the certificate issued to a remote
+	// cluster, to record the credential IDs (i.e., the fingerprints) of the revoked certificates (comma separated).
+	revokedCredentialsAnnotation = "discovery.liqo.io/revoked-credentials"
+	// revokedUntilAnnotation is the annotation set on the secret storing the certificate issued to a remote cluster,
+	// to record the expiration time (RFC3339) of the last revoked certificate, until which it is accepted by the API server.
+	revokedUntilAnnotation = "discovery.liqo.io/revoked-until"
+
+	// CredentialIDExtraKey is the key of the user extra information where the API server exposes the ID of the
+	// credential used to authenticate the request (i.e., the fingerprint of the client certificate).
+	CredentialIDExtraKey = "authentication.kubernetes.io/credential-id"
+)
+
+// Revocation contains the information about the revocation of the identity granted to a remote cluster.
+type Revocation struct {
+	// Revoked is whether the identity of the remote cluster is currently revoked.
+	Revoked bool
+	// Certificates contains the serial numbers (hexadecimal) of the revoked certificates.
+	Certificates sets.String
+	// Credentials contains the credential IDs of the revoked certificates.
+	Credentials sets.String
+	// IssuedCertificate is the serial number (hexadecimal) of the certificate currently issued to the remote cluster.
+	IssuedCertificate string
+	// RevokedUntil is the expiration time of the last revoked certificate, until which it is accepted by the API server.
+	RevokedUntil time.Time
+}
+
+// IsCertificateRevoked returns whether the certificate with the given serial number (hexadecimal) has been revoked.
+func (r *Revocation) IsCertificateRevoked(serial string) bool {
+	return r.Certificates.Has(serial)
+}
+
+// IsCredentialRevoked returns whether the certificate with the given credential ID has been revoked.
+func (r *Revocation) IsCredentialRevoked(credentialID string) bool {
+	return r.Credentials.Has(credentialID)
+}
+
+// IsIssuedCertificateRevoked returns whether the certificate currently issued to the remote cluster has been revoked.
+// This is the case when the revocation has been lifted, but no new identity has been issued since then.
+func (r *Revocation) IsIssuedCertificateRevoked() bool {
+	return r.IssuedCertificate != "" && r.IsCertificateRevoked(r.IssuedCertificate)
+}
+
+// HasValidRevokedCertificates returns whether any of the revoked certificates has not yet expired at the given time,
+// hence it is still accepted by the API server, which does not support the revocation of client certificates.
+func (r *Revocation) HasValidRevokedCertificates(now time.Time) bool {
+	return now.Before(r.RevokedUntil)
+}
+
+// RevocationFromSecret returns the revocation information stored in the secret containing the certificate issued to a remote cluster.
+func RevocationFromSecret(secret *v1.Secret) *Revocation {
+	revocation := &Revocation{Certificates: sets.NewString(), Credentials: sets.NewString()}
+	if secret == nil {
+		return revocation
+	}
+
+	_, revocation.Revoked = secret.GetAnnotations()[identityRevokedAnnotation]
+	if serials := secret.GetAnnotations()[revokedCertificatesAnnotation]; serials != "" {
+		revocation.Certificates.Insert(strings.Split(serials, ",")...)
+	}
+	if credentials := secret.GetAnnotations()[revokedCredentialsAnnotation]; credentials != "" {
+		revocation.Credentials.Insert(strings.Split(credentials, ",")...)
+	}
+	if until, err := time.Parse(time.RFC3339, secret.GetAnnotations()[revokedUntilAnnotation]); err == nil {
+		revocation.RevokedUntil = until
+	}
+	if certificate, err := csrutil.ParseCertificate(secret.Data[certificateSecretKey]); err == nil {
+		revocation.IssuedCertificate = SerialNumber(certificate)
+	}
+	return revocation
+}
+
+// GetClusterRevocation retrieves the information about the revocation of the identity granted to the remote cluster
+// associated with the given ForeignCluster, from the secret storing the certificate issued to it.
+func GetClusterRevocation(ctx context.Context, cl client.Reader, foreignCluster *discoveryv1alpha1.ForeignCluster) (*Revocation, error) {
+	if foreignCluster.Status.TenantNamespace.Local == "" {
+		return RevocationFromSecret(nil), nil
+	}
+
+	var secret v1.Secret
+	key := types.NamespacedName{Namespace: foreignCluster.Status.TenantNamespace.Local, Name: RemoteCertificateSecret}
+	if err := cl.Get(ctx, key, &secret); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return RevocationFromSecret(nil), nil
+		}
+		return nil, fmt.Errorf("failed to retrieve secret %q: %w", key, err)
+	}
+	return RevocationFromSecret(&secret), nil
+}
+
+// IsIdentityRevoked returns whether the identity granted to the remote cluster associated with the given ForeignCluster
+// is currently revoked. Besides when explicitly requested through the ForeignCluster, this is also the case as long as
+// any of the revoked certificates is not yet expired, since the API server keeps accepting it, and it is associated with
+// the same user as any new one: lifting the revocation does not restore the permissions until all of them expired.
+func IsIdentityRevoked(ctx context.Context, cl client.Reader, foreignCluster *discoveryv1alpha1.ForeignCluster) (bool, *Revocation, error) {
+	revocation, err := GetClusterRevocation(ctx, cl, foreignCluster)
+	if err != nil {
+		return false, nil, err
+	}
+	return foreignclusterutils.IsIdentityRevoked(foreignCluster) || revocation.IsIssuedCertificateRevoked() ||
+		revocation.HasValidRevokedCertificates(time.Now()), revocation, nil
+}
+
+// SetRevocation marks the identity stored in the given secret (i.e., the one containing the certificate issued to a
+// remote cluster) as revoked or not. When revoking, the serial number of the certificate is also recorded, so that
+// it cannot be leveraged anymore, even after the revocation is lifted. It returns the serial number of the issued
+// certificate (if any), and whether the secret has been modified.
+func SetRevocation(secret *v1.Secret, revoked bool) (serial string, changed bool) {
+	revocation := RevocationFromSecret(secret)
+
+	var credential string
+	var expiration time.Time
+	if certificate, err := csrutil.ParseCertificate(secret.Data[certificateSecretKey]); err == nil {
+		serial, credential, expiration = SerialNumber(certificate), CredentialID(certificate), certificate.NotAfter
+	}
+
+	if revocation.Revoked == revoked && (!revoked || serial == "" || (revocation.IsCertificateRevoked(serial) &&
+		revocation.IsCredentialRevoked(credential) && !revocation.RevokedUntil.Before(expiration))) {
+		return serial, false
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+
+	if !revoked {
+		delete(secret.Annotations, identityRevokedAnnotation)
+		return serial, true
+	}
+
+	secret.Annotations[identityRevokedAnnotation] = "true"
+	if serial != "" {
+		revocation.Certificates.Insert(serial)
+		secret.Annotations[revokedCertificatesAnnotation] = strings.Join(revocation.Certificates.List(), ",")
+		revocation.Credentials.Insert(credential)
+		secret.Annotations[revokedCredentialsAnnotation] = strings.Join(revocation.Credentials.List(), ",")
+		if revocation.RevokedUntil.Before(expiration) {
+			secret.Annotations[revokedUntilAnnotation] = expiration.UTC().Format(time.RFC3339)
+		}
+	}
+	return serial, true
+}
+
+// SerialNumber returns the hexadecimal representation of the serial number of the given certificate.
+func SerialNumber(certificate *x509.Certificate) string {
+	return strings.ToUpper(certificate.SerialNumber.Text(16))
+}
+
+// CredentialID returns the ID the API server associates with the credential represented by the given client
+// certificate, which is exposed to admission webhooks through the CredentialIDExtraKey user extra information.
+func CredentialID(certificate *x509.Certificate) string {
+	return fmt.Sprintf("X509SHA256=%x", sha256.Sum256(certificate.Raw))
+}
@@ -0,0 +1,164 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identitymanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/discovery"
+)
+
+// secretIdentityStorage stores the identities as Secrets in the tenant namespaces.
+type secretIdentityStorage struct {
+	client kubernetes.Interface
+}
+
+// NewSecretIdentityStorage returns a new identity storage leveraging Kubernetes Secrets.
+func NewSecretIdentityStorage(client kubernetes.Interface) IdentityStorage {
+	return &secretIdentityStorage{client: client}
+}
+
+// StoreIdentity persists a new identity for the given remote cluster, superseding the previous ones (if any).
+func (storage *secretIdentityStorage) StoreIdentity(ctx context.Context,
+	remoteCluster discoveryv1alpha1.ClusterIdentity, identity *v1.Secret) (*v1.Secret, error) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: identitySecretRoot + "-",
+			Namespace:    identity.Namespace,
+			Labels:       identity.Labels,
+			Annotations:  identity.Annotations,
+		},
+		StringData: identity.StringData,
+		Data:       identity.Data,
+	}
+
+	created, err := storage.client.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	// The newly created secret supersedes the previous ones (if any), which can be removed. Since the identity
+	// secret is selected based on the expiration time, the new one is already in use even if the removal fails.
+	return created, storage.deleteSupersededSecrets(ctx, remoteCluster, created)
+}
+
+// GetIdentity retrieves the current identity for the given remote cluster, stored in the given namespace.
+func (storage *secretIdentityStorage) GetIdentity(ctx context.Context,
+	remoteCluster discoveryv1alpha1.ClusterIdentity, namespace string) (*v1.Secret, error) {
+	secretList, err := storage.client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{localIdentitySecretLabel: "true", discovery.ClusterIDLabel: remoteCluster.ClusterID}.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := secretList.Items
+	if nItems := len(secrets); nItems == 0 {
+		err = kerrors.NewNotFound(schema.GroupResource{
+			Group:    "v1",
+			Resource: "secrets",
+		}, fmt.Sprintf("Identity for cluster %v in namespace %v", remoteCluster.ClusterID, namespace))
+		return nil, err
+	}
+
+	// if there are multiple secrets, get the one with the certificate that will expire last
+	return latestIdentity(secrets), nil
+}
+
+// ListIdentities retrieves the current identities for all the remote clusters.
+func (storage *secretIdentityStorage) ListIdentities(ctx context.Context) ([]*v1.Secret, error) {
+	secretList, err := storage.client.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{localIdentitySecretLabel: "true"}.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identity secrets: %w", err)
+	}
+
+	// group the secrets by namespace and remote cluster, and keep only the current one for each group
+	groups := map[string][]v1.Secret{}
+	for i := range secretList.Items {
+		key := secretList.Items[i].Namespace + "/" + secretList.Items[i].Labels[discovery.ClusterIDLabel]
+		groups[key] = append(groups[key], secretList.Items[i])
+	}
+
+	identities := make([]*v1.Secret, 0, len(groups))
+	for _, secrets := range groups {
+		identities = append(identities, latestIdentity(secrets))
+	}
+	return identities, nil
+}
+
+// DeleteIdentity removes the identities for the given remote cluster, stored in the given namespace.
+func (storage *secretIdentityStorage) DeleteIdentity(ctx context.Context,
+	remoteCluster discoveryv1alpha1.ClusterIdentity, namespace string) error {
+	secrets, err := storage.client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{localIdentitySecretLabel: "true", discovery.ClusterIDLabel: remoteCluster.ClusterID}.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list identity secrets: %w", err)
+	}
+
+	for i := range secrets.Items {
+		err = storage.client.CoreV1().Secrets(namespace).Delete(ctx, secrets.Items[i].Name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete identity secret %v/%v: %w", namespace, secrets.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteSupersededSecrets deletes the identity secrets for the given cluster, except for the current one.
+func (storage *secretIdentityStorage) deleteSupersededSecrets(ctx context.Context,
+	remoteCluster discoveryv1alpha1.ClusterIdentity, current *v1.Secret) error {
+	secrets, err := storage.client.CoreV1().Secrets(current.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{localIdentitySecretLabel: "true", discovery.ClusterIDLabel: remoteCluster.ClusterID}.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list identity secrets: %w", err)
+	}
+
+	for i := range secrets.Items {
+		if secrets.Items[i].Name == current.Name {
+			continue
+		}
+
+		err = storage.client.CoreV1().Secrets(current.Namespace).Delete(ctx, secrets.Items[i].Name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete superseded identity secret %v/%v: %w", current.Namespace, secrets.Items[i].Name, err)
+		}
+		klog.V(4).Infof("Deleted superseded identity secret %v/%v", current.Namespace, secrets.Items[i].Name)
+	}
+	return nil
+}
+
+// latestIdentity returns the identity secret with the certificate that will expire last.
+func latestIdentity(secrets []v1.Secret) *v1.Secret {
+	// sort by reverse certificate expire time
+	sort.Slice(secrets, func(i, j int) bool {
+		return getExpireTime(&secrets[i]) > getExpireTime(&secrets[j])
+	})
+	return &secrets[0]
+}
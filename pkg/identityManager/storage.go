@@ -0,0 +1,177 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identitymanager
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	argsutils "github.com/liqotech/liqo/pkg/utils/args"
+)
+
+// IdentityStorage abstracts the backend storing the identities used to authenticate with the remote clusters.
+// Independently of the backend, identities are represented as Secret objects, whose data contains the
+// credentials and whose annotations contain the associated metadata (e.g., the expiration time).
+type IdentityStorage interface {
+	// StoreIdentity persists a new identity for the given remote cluster, superseding the previous ones (if any).
+	StoreIdentity(ctx context.Context, remoteCluster discoveryv1alpha1.ClusterIdentity, identity *v1.Secret) (*v1.Secret, error)
+	// GetIdentity retrieves the current identity for the given remote cluster, stored in the given namespace.
+	GetIdentity(ctx context.Context, remoteCluster discoveryv1alpha1.ClusterIdentity, namespace string) (*v1.Secret, error)
+	// ListIdentities retrieves the current identities for all the remote clusters.
+	ListIdentities(ctx context.Context) ([]*v1.Secret, error)
+	// DeleteIdentity removes the identities for the given remote cluster, stored in the given namespace.
+	DeleteIdentity(ctx context.Context, remoteCluster discoveryv1alpha1.ClusterIdentity, namespace string) error
+}
+
+const (
+	// StorageBackendSecret is the backend storing the identities as Secrets in the tenant namespaces.
+	StorageBackendSecret = "secret"
+	// StorageBackendVault is the backend storing the identities in a Vault KV (version 2) secrets engine.
+	StorageBackendVault = "vault"
+
+	// VaultTokenSecretKey is the key of the Secret containing the token to authenticate with Vault.
+	VaultTokenSecretKey = "token"
+	// VaultCASecretKey is the (optional) key of the Secret containing the CA to validate the Vault certificate.
+	VaultCASecretKey = "ca.crt"
+)
+
+var (
+	storageBackend   = argsutils.NewEnum([]string{StorageBackendSecret, StorageBackendVault}, StorageBackendSecret)
+	vaultAddress     string
+	vaultMountPath   = "secret"
+	vaultPathPrefix  = "liqo/identities"
+	vaultTokenSecret string
+	vaultTimeout     = 10 * time.Second
+	vaultRenewPeriod = 5 * time.Minute
+)
+
+// InitStorageFlags initializes the flags to configure the backend storing the identities.
+func InitStorageFlags(flagset *flag.FlagSet) {
+	if flagset == nil {
+		flagset = flag.CommandLine
+	}
+
+	flagset.Var(storageBackend, "identity-storage-backend",
+		"The backend storing the identities to authenticate with the remote clusters (secret, vault)")
+	flagset.StringVar(&vaultAddress, "identity-storage-vault-address", vaultAddress, "The address of the Vault server storing the identities")
+	flagset.StringVar(&vaultMountPath, "identity-storage-vault-mount-path", vaultMountPath,
+		"The mount path of the Vault KV (version 2) secrets engine")
+	flagset.StringVar(&vaultPathPrefix, "identity-storage-vault-path-prefix", vaultPathPrefix,
+		"The path prefix the identities are stored under in Vault")
+	flagset.StringVar(&vaultTokenSecret, "identity-storage-vault-token-secret", vaultTokenSecret,
+		"The Secret (in the namespace/name form) containing the token to authenticate with Vault and, optionally, its CA")
+	flagset.DurationVar(&vaultTimeout, "identity-storage-vault-timeout", vaultTimeout, "The timeout of the requests towards Vault")
+	flagset.DurationVar(&vaultRenewPeriod, "identity-storage-vault-token-renew-period", vaultRenewPeriod,
+		"The period the Vault token is reloaded from the Secret (to pick up rotated tokens) and renewed with, if renewable (disabled if zero)")
+}
+
+// StorageArgs returns the command line arguments reproducing the configuration of the identity storage,
+// to be propagated to the components (e.g., the virtual kubelet) reading the identities.
+func StorageArgs() []string {
+	if storageBackend.Value == StorageBackendSecret {
+		return nil
+	}
+
+	return []string{
+		fmt.Sprintf("--identity-storage-backend=%s", storageBackend.Value),
+		fmt.Sprintf("--identity-storage-vault-address=%s", vaultAddress),
+		fmt.Sprintf("--identity-storage-vault-mount-path=%s", vaultMountPath),
+		fmt.Sprintf("--identity-storage-vault-path-prefix=%s", vaultPathPrefix),
+		fmt.Sprintf("--identity-storage-vault-token-secret=%s", vaultTokenSecret),
+		fmt.Sprintf("--identity-storage-vault-timeout=%s", vaultTimeout),
+		fmt.Sprintf("--identity-storage-vault-token-renew-period=%s", vaultRenewPeriod),
+	}
+}
+
+// NewIdentityStorageFromFlags returns the identity storage configured through the command line parameters.
+func NewIdentityStorageFromFlags(ctx context.Context, client kubernetes.Interface) (IdentityStorage, error) {
+	return NewIdentityStorageForBackend(ctx, client, storageBackend.Value)
+}
+
+// NewIdentityStorageForBackend returns the identity storage for the given backend. The configuration
+// of the backend, if any, is retrieved from the command line parameters.
+func NewIdentityStorageForBackend(ctx context.Context, client kubernetes.Interface, backend string) (IdentityStorage, error) {
+	switch backend {
+	case StorageBackendSecret:
+		return NewSecretIdentityStorage(client), nil
+	case StorageBackendVault:
+		config, err := vaultConfigFromFlags(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		return NewVaultIdentityStorage(ctx, config)
+	default:
+		return nil, fmt.Errorf("unknown identity storage backend %q", backend)
+	}
+}
+
+// vaultConfigFromFlags returns the Vault configuration obtained from the command line parameters,
+// retrieving the authentication token (and the CA, if present) from the referenced Secret. The token
+// is then periodically reloaded from the same Secret, so that rotated tokens are picked up.
+func vaultConfigFromFlags(ctx context.Context, client kubernetes.Interface) (*VaultConfig, error) {
+	if vaultAddress == "" {
+		return nil, fmt.Errorf("the Vault address is required when using the %v identity storage backend", StorageBackendVault)
+	}
+
+	namespace, name, found := strings.Cut(vaultTokenSecret, "/")
+	if !found || namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid Vault token secret %q, expected the namespace/name form", vaultTokenSecret)
+	}
+
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the Vault token secret %v/%v: %w", namespace, name, err)
+	}
+
+	token, err := vaultTokenFromSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VaultConfig{
+		Address:    vaultAddress,
+		MountPath:  vaultMountPath,
+		PathPrefix: vaultPathPrefix,
+		Token:      token,
+		CAData:     secret.Data[VaultCASecretKey],
+		Timeout:    vaultTimeout,
+
+		TokenLoader: func(ctx context.Context) (string, error) {
+			current, gerr := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if gerr != nil {
+				return "", fmt.Errorf("failed to retrieve the Vault token secret %v/%v: %w", namespace, name, gerr)
+			}
+			return vaultTokenFromSecret(current)
+		},
+		RenewPeriod: vaultRenewPeriod,
+	}, nil
+}
+
+// vaultTokenFromSecret returns the token to authenticate with Vault stored in the given Secret.
+func vaultTokenFromSecret(secret *v1.Secret) (string, error) {
+	token, ok := secret.Data[VaultTokenSecretKey]
+	if !ok || len(token) == 0 {
+		return "", fmt.Errorf("key %v not found in Vault token secret %v/%v", VaultTokenSecretKey, secret.Namespace, secret.Name)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
@@ -0,0 +1,294 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identitymanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/discovery"
+)
+
+// fakeVault is a minimal stand-in of the Vault KV (version 2) secrets engine, mounted at the secret path.
+type fakeVault struct {
+	mutex    sync.Mutex
+	token    string
+	entries  map[string][]map[string]interface{}
+	renewals int
+}
+
+func (fv *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != fv.token {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	operation, entry, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/secret/"), "/")
+	var body map[string]interface{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	writeJSON := func(data interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+
+	switch {
+	case r.URL.Path == "/v1/auth/token/lookup-self" && r.Method == http.MethodGet:
+		writeJSON(map[string]interface{}{"renewable": true, "ttl": 60})
+	case r.URL.Path == "/v1/auth/token/renew-self" && r.Method == http.MethodPost:
+		fv.renewals++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"lease_duration": 3600}})
+	case operation == "data" && r.Method == http.MethodPost:
+		fv.entries[entry] = append(fv.entries[entry], body["data"].(map[string]interface{}))
+		writeJSON(map[string]interface{}{"version": len(fv.entries[entry])})
+	case operation == "data" && r.Method == http.MethodGet:
+		versions, ok := fv.entries[entry]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(map[string]interface{}{"data": versions[len(versions)-1]})
+	case operation == "destroy" && r.Method == http.MethodPost:
+		for _, version := range body["versions"].([]interface{}) {
+			fv.entries[entry][int(version.(float64))-1] = nil
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case operation == "metadata" && r.Method == http.MethodDelete:
+		delete(fv.entries, entry)
+		w.WriteHeader(http.StatusNoContent)
+	case operation == "metadata" && r.Method == "LIST":
+		keys := map[string]struct{}{}
+		for key := range fv.entries {
+			if strings.HasPrefix(key, entry+"/") {
+				child := strings.TrimPrefix(key, entry+"/")
+				if first, _, nested := strings.Cut(child, "/"); nested {
+					keys[first+"/"] = struct{}{}
+				} else {
+					keys[child] = struct{}{}
+				}
+			}
+		}
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		list := make([]string, 0, len(keys))
+		for key := range keys {
+			list = append(list, key)
+		}
+		sort.Strings(list)
+		writeJSON(map[string]interface{}{"keys": list})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// recordingIdentityStorage wraps an IdentityStorage, recording the remote clusters the identities are stored for.
+type recordingIdentityStorage struct {
+	IdentityStorage
+	clusters []discoveryv1alpha1.ClusterIdentity
+}
+
+func (storage *recordingIdentityStorage) StoreIdentity(ctx context.Context,
+	remoteCluster discoveryv1alpha1.ClusterIdentity, identity *v1.Secret) (*v1.Secret, error) {
+	storage.clusters = append(storage.clusters, remoteCluster)
+	return storage.IdentityStorage.StoreIdentity(ctx, remoteCluster, identity)
+}
+
+var _ = Describe("IdentityStorage", func() {
+	var (
+		vault          *fakeVault
+		server         *httptest.Server
+		vaultStorage   IdentityStorage
+		secretStorage  IdentityStorage
+		storageCluster discoveryv1alpha1.ClusterIdentity
+	)
+
+	const storageNamespace = "liqo-tenant-storage"
+
+	forgeIdentity := func(key string, expiration time.Time) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: storageNamespace,
+				Labels: map[string]string{
+					localIdentitySecretLabel:  "true",
+					discovery.ClusterIDLabel:  storageCluster.ClusterID,
+					certificateAvailableLabel: "true",
+				},
+				Annotations: map[string]string{
+					certificateExpireTimeAnnotation: fmt.Sprintf("%v", expiration.Unix()),
+					clusterNameAnnotation:           storageCluster.ClusterName,
+				},
+			},
+			StringData: map[string]string{namespaceSecretKey: "remote-namespace"},
+			Data:       map[string][]byte{privateKeySecretKey: []byte(key)},
+		}
+	}
+
+	BeforeEach(func() {
+		storageCluster = discoveryv1alpha1.ClusterIdentity{ClusterID: "storage-cluster-id", ClusterName: "storage-cluster-name"}
+		vault = &fakeVault{token: "vault-token", entries: map[string][]map[string]interface{}{}}
+		server = httptest.NewServer(vault)
+
+		var err error
+		vaultStorage, err = NewVaultIdentityStorage(ctx, &VaultConfig{
+			Address: server.URL, MountPath: "secret", PathPrefix: "liqo/identities", Token: "vault-token", Timeout: time.Second,
+			TokenLoader: func(context.Context) (string, error) { return "rotated-token", nil },
+		})
+		Expect(err).ToNot(HaveOccurred())
+		secretStorage = NewSecretIdentityStorage(fake.NewSimpleClientset())
+	})
+
+	AfterEach(func() { server.Close() })
+
+	Context("the Vault backend", func() {
+		It("should store and retrieve the identities", func() {
+			_, err := vaultStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("first", time.Now().Add(time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+
+			identity, err := vaultStorage.GetIdentity(ctx, storageCluster, storageNamespace)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(identity.Namespace).To(Equal(storageNamespace))
+			Expect(identity.Labels).To(HaveKeyWithValue(discovery.ClusterIDLabel, storageCluster.ClusterID))
+			Expect(identity.Annotations).To(HaveKey(certificateExpireTimeAnnotation))
+			Expect(identity.Data).To(HaveKeyWithValue(privateKeySecretKey, []byte("first")))
+			Expect(identity.Data).To(HaveKeyWithValue(namespaceSecretKey, []byte("remote-namespace")))
+		})
+
+		It("should supersede the previous identities", func() {
+			_, err := vaultStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("first", time.Now().Add(time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = vaultStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("second", time.Now().Add(2*time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+
+			identity, err := vaultStorage.GetIdentity(ctx, storageCluster, storageNamespace)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(identity.Data).To(HaveKeyWithValue(privateKeySecretKey, []byte("second")))
+
+			versions := vault.entries["liqo/identities/"+storageNamespace+"/"+storageCluster.ClusterID]
+			Expect(versions).To(HaveLen(2))
+			Expect(versions[0]).To(BeNil())
+		})
+
+		It("should list and delete the identities", func() {
+			_, err := vaultStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("first", time.Now().Add(time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+
+			identities, err := vaultStorage.ListIdentities(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(identities).To(HaveLen(1))
+
+			Expect(vaultStorage.DeleteIdentity(ctx, storageCluster, storageNamespace)).To(Succeed())
+			_, err = vaultStorage.GetIdentity(ctx, storageCluster, storageNamespace)
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+
+			identities, err = vaultStorage.ListIdentities(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(identities).To(BeEmpty())
+		})
+
+		It("should fail if the token is not valid", func() {
+			vault.token = "another-token"
+			_, err := vaultStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("first", time.Now().Add(time.Hour)))
+			Expect(err).To(MatchError(ContainSubstring("permission denied")))
+		})
+
+		It("should reload the rotated token and renew it", func() {
+			vault.token = "rotated-token"
+			vaultStorage.(*vaultIdentityStorage).renewToken(ctx)
+			Expect(vault.renewals).To(Equal(1))
+
+			_, err := vaultStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("first", time.Now().Add(time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("the migration between backends", func() {
+		It("should move the identities from the Secret to the Vault backend", func() {
+			_, err := secretStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("first", time.Now().Add(time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+
+			migrated, err := MigrateIdentities(ctx, secretStorage, vaultStorage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(migrated).To(Equal(1))
+
+			identity, err := vaultStorage.GetIdentity(ctx, storageCluster, storageNamespace)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(identity.Data).To(HaveKeyWithValue(privateKeySecretKey, []byte("first")))
+
+			_, err = secretStorage.GetIdentity(ctx, storageCluster, storageNamespace)
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should preserve the name of the remote cluster", func() {
+			_, err := secretStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("first", time.Now().Add(time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+
+			target := &recordingIdentityStorage{IdentityStorage: vaultStorage}
+			_, err = MigrateIdentities(ctx, secretStorage, target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.clusters).To(ConsistOf(storageCluster))
+		})
+
+		It("should fall back to the cluster ID for the identities without the cluster name", func() {
+			identity := forgeIdentity("first", time.Now().Add(time.Hour))
+			delete(identity.Annotations, clusterNameAnnotation)
+			_, err := secretStorage.StoreIdentity(ctx, storageCluster, identity)
+			Expect(err).ToNot(HaveOccurred())
+
+			target := &recordingIdentityStorage{IdentityStorage: vaultStorage}
+			_, err = MigrateIdentities(ctx, secretStorage, target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.clusters).To(ConsistOf(discoveryv1alpha1.ClusterIdentity{
+				ClusterID: storageCluster.ClusterID, ClusterName: storageCluster.ClusterID}))
+		})
+
+		It("should not overwrite more recent identities in the target backend", func() {
+			_, err := vaultStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("recent", time.Now().Add(2*time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = secretStorage.StoreIdentity(ctx, storageCluster, forgeIdentity("stale", time.Now().Add(time.Hour)))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = MigrateIdentities(ctx, secretStorage, vaultStorage)
+			Expect(err).ToNot(HaveOccurred())
+
+			identity, err := vaultStorage.GetIdentity(ctx, storageCluster, storageNamespace)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(identity.Data).To(HaveKeyWithValue(privateKeySecretKey, []byte("recent")))
+		})
+	})
+})
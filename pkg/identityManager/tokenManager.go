@@ -27,10 +27,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
@@ -44,7 +42,7 @@ type tokenManager interface {
 }
 
 type iamTokenManager struct {
-	client                    kubernetes.Interface
+	storage                   IdentityStorage
 	availableClusterIDSecrets map[string]types.NamespacedName
 	availableTokenMutex       sync.Mutex
 
@@ -76,7 +74,7 @@ func (tokMan *iamTokenManager) start(ctx context.Context) {
 
 func (tokMan *iamTokenManager) refreshToken(ctx context.Context, remoteCluster discoveryv1alpha1.ClusterIdentity,
 	namespacedName types.NamespacedName) error {
-	secret, err := tokMan.client.CoreV1().Secrets(namespacedName.Namespace).Get(ctx, namespacedName.Name, metav1.GetOptions{})
+	secret, err := tokMan.storage.GetIdentity(ctx, remoteCluster, namespacedName.Namespace)
 	if err != nil {
 		klog.Errorf("[%v] %v", remoteCluster.ClusterName, err)
 		return err
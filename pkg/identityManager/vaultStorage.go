@@ -0,0 +1,378 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identitymanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/discovery"
+)
+
+// VaultConfig contains the configuration to store the identities in a Vault KV (version 2) secrets engine.
+type VaultConfig struct {
+	// Address is the address of the Vault server (e.g., https://vault.example.com:8200).
+	Address string
+	// MountPath is the path the KV secrets engine is mounted at.
+	MountPath string
+	// PathPrefix is the path, relative to the mount path, the identities are stored under.
+	PathPrefix string
+	// Token is the token used to authenticate with Vault.
+	Token string
+	// CAData contains the (optional) PEM-encoded CA used to validate the certificate of the Vault server.
+	CAData []byte
+	// Timeout is the timeout of the requests towards Vault.
+	Timeout time.Duration
+
+	// TokenLoader (optional) reloads the token used to authenticate with Vault, so that rotated tokens are picked up.
+	TokenLoader func(ctx context.Context) (string, error)
+	// RenewPeriod is the period the token is reloaded and renewed with, if renewable (disabled if zero).
+	RenewPeriod time.Duration
+}
+
+// errVaultNotFound is returned when the requested path does not exist in Vault.
+var errVaultNotFound = errors.New("not found")
+
+// vaultIdentity is the representation of an identity stored in Vault.
+type vaultIdentity struct {
+	// Data contains the base64-encoded data of the identity.
+	Data map[string]string `json:"data"`
+	// Annotations contains the metadata associated with the identity.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// vaultIdentityStorage stores the identities in a Vault KV (version 2) secrets engine, at the
+// <mount path>/<path prefix>/<namespace>/<cluster ID> path. Each identity is stored as a new version
+// of the same entry, and the previous versions are destroyed to remove the superseded credentials.
+type vaultIdentityStorage struct {
+	config *VaultConfig
+	client *http.Client
+
+	tokenMutex sync.RWMutex
+	token      string
+}
+
+// NewVaultIdentityStorage returns a new identity storage leveraging a Vault KV (version 2) secrets engine.
+// In case a renew period is configured, the token is periodically reloaded and renewed until the context is canceled.
+func NewVaultIdentityStorage(ctx context.Context, config *VaultConfig) (IdentityStorage, error) {
+	if _, err := url.Parse(config.Address); err != nil {
+		return nil, fmt.Errorf("invalid Vault address %q: %w", config.Address, err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(config.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(config.CAData) {
+			return nil, fmt.Errorf("failed to parse the Vault CA")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	storage := &vaultIdentityStorage{
+		config: config,
+		client: &http.Client{Transport: transport, Timeout: config.Timeout},
+		token:  config.Token,
+	}
+
+	if config.RenewPeriod > 0 {
+		go wait.UntilWithContext(ctx, storage.renewToken, config.RenewPeriod)
+	}
+	return storage, nil
+}
+
+// renewToken reloads the token used to authenticate with Vault (if a loader is configured), and then renews it in
+// case it is renewable, so that it does not expire while in use.
+func (storage *vaultIdentityStorage) renewToken(ctx context.Context) {
+	if storage.config.TokenLoader != nil {
+		token, err := storage.config.TokenLoader(ctx)
+		if err != nil {
+			klog.Errorf("Failed to reload the Vault token: %v", err)
+		} else if token != storage.currentToken() {
+			storage.tokenMutex.Lock()
+			storage.token = token
+			storage.tokenMutex.Unlock()
+			klog.Info("Reloaded the rotated Vault token")
+		}
+	}
+
+	var lookup struct {
+		Data struct {
+			Renewable bool `json:"renewable"`
+			TTL       int  `json:"ttl"`
+		} `json:"data"`
+	}
+	if err := storage.do(ctx, http.MethodGet, "/v1/auth/token/lookup-self", nil, &lookup); err != nil {
+		klog.Errorf("Failed to look up the Vault token: %v", err)
+		return
+	}
+	if !lookup.Data.Renewable {
+		return
+	}
+
+	var renewal struct {
+		Auth struct {
+			LeaseDuration int `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := storage.do(ctx, http.MethodPost, "/v1/auth/token/renew-self", map[string]interface{}{}, &renewal); err != nil {
+		klog.Errorf("Failed to renew the Vault token: %v", err)
+		return
+	}
+	klog.V(4).Infof("Renewed the Vault token, valid for %v", time.Duration(renewal.Auth.LeaseDuration)*time.Second)
+}
+
+// currentToken returns the token currently used to authenticate with Vault.
+func (storage *vaultIdentityStorage) currentToken() string {
+	storage.tokenMutex.RLock()
+	defer storage.tokenMutex.RUnlock()
+	return storage.token
+}
+
+// StoreIdentity persists a new identity for the given remote cluster, superseding the previous ones (if any).
+func (storage *vaultIdentityStorage) StoreIdentity(ctx context.Context,
+	remoteCluster discoveryv1alpha1.ClusterIdentity, identity *v1.Secret) (*v1.Secret, error) {
+	stored := vaultIdentity{Data: map[string]string{}, Annotations: identity.Annotations}
+	for key, value := range identity.Data {
+		stored.Data[key] = base64.StdEncoding.EncodeToString(value)
+	}
+	// Consistently with the API server, the values in StringData take precedence over the ones in Data.
+	for key, value := range identity.StringData {
+		stored.Data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	var response struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	entry := storage.entryPath(identity.Namespace, remoteCluster.ClusterID)
+	if err := storage.do(ctx, http.MethodPost, storage.apiPath("data", entry),
+		map[string]interface{}{"data": stored}, &response); err != nil {
+		return nil, fmt.Errorf("failed to store the identity for cluster %v in Vault: %w", remoteCluster.ClusterID, err)
+	}
+
+	// The newly stored version supersedes the previous one (if any), which is destroyed. Since the latest version
+	// is the one in use, the new identity is already in use even if the removal fails.
+	created := storage.forgeIdentity(identity.Namespace, remoteCluster.ClusterID, &stored)
+	if response.Data.Version > 1 {
+		if err := storage.do(ctx, http.MethodPost, storage.apiPath("destroy", entry),
+			map[string]interface{}{"versions": []int{response.Data.Version - 1}}, nil); err != nil {
+			return created, fmt.Errorf("failed to destroy the superseded identity for cluster %v in Vault: %w", remoteCluster.ClusterID, err)
+		}
+		klog.V(4).Infof("Destroyed superseded identity for cluster %v in Vault (version %d)", remoteCluster.ClusterID, response.Data.Version-1)
+	}
+	return created, nil
+}
+
+// GetIdentity retrieves the current identity for the given remote cluster, stored in the given namespace.
+func (storage *vaultIdentityStorage) GetIdentity(ctx context.Context,
+	remoteCluster discoveryv1alpha1.ClusterIdentity, namespace string) (*v1.Secret, error) {
+	identity, err := storage.read(ctx, namespace, remoteCluster.ClusterID)
+	if errors.Is(err, errVaultNotFound) {
+		return nil, kerrors.NewNotFound(schema.GroupResource{
+			Group:    "v1",
+			Resource: "secrets",
+		}, fmt.Sprintf("Identity for cluster %v in namespace %v", remoteCluster.ClusterID, namespace))
+	}
+	return identity, err
+}
+
+// ListIdentities retrieves the current identities for all the remote clusters.
+func (storage *vaultIdentityStorage) ListIdentities(ctx context.Context) ([]*v1.Secret, error) {
+	namespaces, err := storage.list(ctx, storage.config.PathPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the identities in Vault: %w", err)
+	}
+
+	var identities []*v1.Secret
+	for _, namespace := range namespaces {
+		clusterIDs, listErr := storage.list(ctx, path.Join(storage.config.PathPrefix, namespace))
+		if listErr != nil {
+			return nil, fmt.Errorf("failed to list the identities in Vault: %w", listErr)
+		}
+
+		for _, clusterID := range clusterIDs {
+			identity, readErr := storage.read(ctx, namespace, clusterID)
+			if errors.Is(readErr, errVaultNotFound) {
+				// The entry has been removed in the meanwhile, or its latest version has been deleted.
+				continue
+			}
+			if readErr != nil {
+				return nil, fmt.Errorf("failed to retrieve the identity for cluster %v in Vault: %w", clusterID, readErr)
+			}
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+// DeleteIdentity removes the identities for the given remote cluster, stored in the given namespace.
+func (storage *vaultIdentityStorage) DeleteIdentity(ctx context.Context,
+	remoteCluster discoveryv1alpha1.ClusterIdentity, namespace string) error {
+	// Deleting the metadata permanently removes all the versions of the given entry.
+	err := storage.do(ctx, http.MethodDelete, storage.apiPath("metadata", storage.entryPath(namespace, remoteCluster.ClusterID)), nil, nil)
+	if err != nil && !errors.Is(err, errVaultNotFound) {
+		return fmt.Errorf("failed to delete the identity for cluster %v in Vault: %w", remoteCluster.ClusterID, err)
+	}
+	return nil
+}
+
+// read retrieves the latest version of the identity stored for the given namespace and cluster.
+func (storage *vaultIdentityStorage) read(ctx context.Context, namespace, clusterID string) (*v1.Secret, error) {
+	var response struct {
+		Data struct {
+			Data *vaultIdentity `json:"data"`
+		} `json:"data"`
+	}
+	if err := storage.do(ctx, http.MethodGet, storage.apiPath("data", storage.entryPath(namespace, clusterID)), nil, &response); err != nil {
+		return nil, err
+	}
+
+	// The data is null in case the latest version has been deleted.
+	if response.Data.Data == nil {
+		return nil, errVaultNotFound
+	}
+	return storage.forgeIdentity(namespace, clusterID, response.Data.Data), nil
+}
+
+// list returns the (non-recursive) list of entries stored under the given path, without the trailing slashes.
+func (storage *vaultIdentityStorage) list(ctx context.Context, entry string) ([]string, error) {
+	var response struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	err := storage.do(ctx, "LIST", storage.apiPath("metadata", entry), nil, &response)
+	if errors.Is(err, errVaultNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(response.Data.Keys))
+	for i := range response.Data.Keys {
+		keys[i] = strings.TrimSuffix(response.Data.Keys[i], "/")
+	}
+	return keys, nil
+}
+
+// forgeIdentity converts the identity stored in Vault to the corresponding Secret representation.
+func (storage *vaultIdentityStorage) forgeIdentity(namespace, clusterID string, identity *vaultIdentity) *v1.Secret {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      identitySecretRoot + "-" + clusterID,
+			Namespace: namespace,
+			Labels: map[string]string{
+				localIdentitySecretLabel:  "true",
+				discovery.ClusterIDLabel:  clusterID,
+				certificateAvailableLabel: "true",
+			},
+			Annotations: identity.Annotations,
+		},
+		Data: map[string][]byte{},
+	}
+
+	for key, value := range identity.Data {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			klog.Warningf("Failed to decode key %v of the identity for cluster %v in Vault: %v", key, clusterID, err)
+			continue
+		}
+		secret.Data[key] = decoded
+	}
+	return secret
+}
+
+// entryPath returns the path of the entry storing the identity for the given namespace and cluster.
+func (storage *vaultIdentityStorage) entryPath(namespace, clusterID string) string {
+	return path.Join(storage.config.PathPrefix, namespace, clusterID)
+}
+
+// apiPath returns the API path to perform the given operation (e.g., data, metadata) on the given entry.
+func (storage *vaultIdentityStorage) apiPath(operation, entry string) string {
+	return path.Join("/v1", storage.config.MountPath, operation, entry)
+}
+
+// do performs the given request towards Vault, decoding the response in the given output object (if not nil).
+func (storage *vaultIdentityStorage) do(ctx context.Context, method, apiPath string, input, output interface{}) error {
+	var body io.Reader
+	if input != nil {
+		encoded, err := json.Marshal(input)
+		if err != nil {
+			return fmt.Errorf("failed to encode the request: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(storage.config.Address, "/")+apiPath, body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-Vault-Token", storage.currentToken())
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := storage.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return errVaultNotFound
+	case response.StatusCode == http.StatusNoContent:
+		return nil
+	case response.StatusCode < 200 || response.StatusCode >= 300:
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if err = json.NewDecoder(response.Body).Decode(&vaultErr); err == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("unexpected status %d: %v", response.StatusCode, strings.Join(vaultErr.Errors, ", "))
+		}
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	if output == nil {
+		return nil
+	}
+	if err = json.NewDecoder(response.Body).Decode(output); err != nil {
+		return fmt.Errorf("failed to decode the response: %w", err)
+	}
+	return nil
+}
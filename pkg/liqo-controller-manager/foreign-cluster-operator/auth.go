@@ -22,26 +22,40 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	"github.com/liqotech/liqo/pkg/auth"
 	"github.com/liqotech/liqo/pkg/discovery"
 	"github.com/liqotech/liqo/pkg/discoverymanager/utils"
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
 	"github.com/liqotech/liqo/pkg/utils/authenticationtoken"
 	csrutil "github.com/liqotech/liqo/pkg/utils/csr"
 	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
 	peeringconditionsutils "github.com/liqotech/liqo/pkg/utils/peeringConditions"
 )
 
+// DefaultIdentityRenewalFraction is the default fraction of the certificate lifetime after which the identity is renewed.
+const DefaultIdentityRenewalFraction = 0.7
+
 const (
 	identityAcceptedReason  = "IdentityAccepted"
 	identityAcceptedMessage = "The Identity has been correctly accepted by the remote cluster"
+	// identityAcceptedExpirationMessage is the message used when the expiration of the identity is known.
+	identityAcceptedExpirationMessage = "The Identity has been correctly accepted by the remote cluster (expiring at %v)"
+
+	identityRenewalFailedReason  = "IdentityRenewalFailed"
+	identityRenewalFailedMessage = "Failed to renew the Identity, which expires at %v: %v"
+
+	identityExpiredReason  = "IdentityExpired"
+	identityExpiredMessage = "The Identity expired at %v, and a new one cannot be obtained: %v"
 
 	identityDeniedEmptyTokenReason  = "IdentityEmptyDenied"
 	identityDeniedEmptyTokenMessage = "The remote cluster requires cluster authentication to be enabled: %v"
@@ -51,6 +65,9 @@ const (
 
 	identityErrorReason  = "IdentityError"
 	identityErrorMessage = "Cannot ensure identity: %v"
+
+	caFingerprintMismatchReason  = "CAFingerprintMismatch"
+	caFingerprintMismatchMessage = "The CA of the remote authentication service changed, refusing to trust it: %v"
 )
 
 type identityDeniedError struct{ msg string }
@@ -62,13 +79,14 @@ type identityEmptyDeniedError struct{ msg string }
 func (err identityEmptyDeniedError) Error() string { return err.msg }
 
 // ensureRemoteIdentity tries to fetch the remote identity from the secret, if it is not found
-// it creates a new identity and sends it to the remote cluster.
+// it creates a new identity and sends it to the remote cluster. In case the identity is approaching
+// its expiration, it is renewed. The returned duration corresponds to the time before the next renewal.
 func (r *ForeignClusterReconciler) ensureRemoteIdentity(ctx context.Context,
-	foreignCluster *discoveryv1alpha1.ForeignCluster) (err error) {
+	foreignCluster *discoveryv1alpha1.ForeignCluster) (renewal time.Duration, err error) {
 	status := discoveryv1alpha1.PeeringConditionStatusError
 	var reason, message string
 	defer func() {
-		if status == discoveryv1alpha1.PeeringConditionStatusError {
+		if status == discoveryv1alpha1.PeeringConditionStatusError && reason == "" {
 			reason = identityErrorReason
 			message = fmt.Sprintf(identityErrorMessage, err)
 		}
@@ -77,21 +95,50 @@ func (r *ForeignClusterReconciler) ensureRemoteIdentity(ctx context.Context,
 
 	_, err = r.IdentityManager.GetConfig(foreignCluster.Spec.ClusterIdentity, foreignCluster.Status.TenantNamespace.Local)
 	if err != nil && !kerrors.IsNotFound(err) {
-		return err
+		return 0, err
 	}
 
-	if err != nil {
+	var identity *identitymanager.CertificateIdentity
+	if err == nil {
+		identity, err = r.IdentityManager.GetCertificateIdentity(
+			foreignCluster.Spec.ClusterIdentity, foreignCluster.Status.TenantNamespace.Local)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case err != nil:
+		// The identity does not exist yet, hence request a new one.
 		if err = r.validateIdentity(ctx, foreignCluster); err != nil {
-			if errors.Is(err, identityEmptyDeniedError{}) {
-				status = discoveryv1alpha1.PeeringConditionStatusEmptyDenied
-				reason = identityDeniedEmptyTokenReason
-				message = fmt.Sprintf(identityDeniedEmptyTokenMessage, err)
-			} else if errors.Is(err, identityDeniedError{}) {
-				status = discoveryv1alpha1.PeeringConditionStatusDenied
-				reason = identityDeniedReason
-				message = fmt.Sprintf(identityDeniedMessage, err)
+			status, reason, message = identityValidationFailureStatus(err)
+			return 0, err
+		}
+	case identity != nil && now.After(identity.NotAfter):
+		// The identity already expired, and it can no longer be renewed. Hence, request a new one.
+		klog.Warningf("[%v] The identity expired at %v, requesting a new one", foreignCluster.Spec.ClusterIdentity, identity.NotAfter)
+		if err = r.validateIdentity(ctx, foreignCluster); err != nil {
+			status, reason, message = identityValidationFailureStatus(err)
+			if status == discoveryv1alpha1.PeeringConditionStatusError {
+				reason = identityExpiredReason
+				message = fmt.Sprintf(identityExpiredMessage, identity.NotAfter.Format(time.RFC3339), err)
+			}
+			return 0, err
+		}
+	case identity != nil && !now.Before(r.renewalTime(identity)):
+		// The identity is approaching its expiration, hence renew it.
+		klog.Infof("[%v] The identity expires at %v, renewing it", foreignCluster.Spec.ClusterIdentity, identity.NotAfter)
+		if err = r.renewIdentity(ctx, foreignCluster, identity); err != nil {
+			// The current identity is still valid, hence the peering is not affected for the time being.
+			klog.Errorf("[%v] Failed to renew the identity: %v", foreignCluster.Spec.ClusterIdentity, err)
+			status = discoveryv1alpha1.PeeringConditionStatusEstablished
+			reason = identityRenewalFailedReason
+			message = fmt.Sprintf(identityRenewalFailedMessage, identity.NotAfter.Format(time.RFC3339), err)
+			if isCAFingerprintMismatch(err) {
+				status, reason, message = identityValidationFailureStatus(err)
 			}
-			return err
+			return r.ResyncPeriod, nil
 		}
 	}
 
@@ -99,7 +146,46 @@ func (r *ForeignClusterReconciler) ensureRemoteIdentity(ctx context.Context,
 	reason = identityAcceptedReason
 	message = identityAcceptedMessage
 
-	return nil
+	// Retrieve the current identity, to surface its expiration and compute the next renewal.
+	identity, err = r.IdentityManager.GetCertificateIdentity(
+		foreignCluster.Spec.ClusterIdentity, foreignCluster.Status.TenantNamespace.Local)
+	if err != nil {
+		klog.Warningf("[%v] Failed to retrieve the identity expiration: %v", foreignCluster.Spec.ClusterIdentity, err)
+		return 0, nil
+	}
+	if identity != nil {
+		message = fmt.Sprintf(identityAcceptedExpirationMessage, identity.NotAfter.Format(time.RFC3339))
+		return r.renewalTime(identity).Sub(now), nil
+	}
+	return 0, nil
+}
+
+// identityValidationFailureStatus returns the status of the authentication condition, given the identity validation error.
+func identityValidationFailureStatus(err error) (status discoveryv1alpha1.PeeringConditionStatusType, reason, message string) {
+	switch {
+	case errors.Is(err, identityEmptyDeniedError{}):
+		return discoveryv1alpha1.PeeringConditionStatusEmptyDenied,
+			identityDeniedEmptyTokenReason, fmt.Sprintf(identityDeniedEmptyTokenMessage, err)
+	case errors.Is(err, identityDeniedError{}):
+		return discoveryv1alpha1.PeeringConditionStatusDenied,
+			identityDeniedReason, fmt.Sprintf(identityDeniedMessage, err)
+	case isCAFingerprintMismatch(err):
+		return discoveryv1alpha1.PeeringConditionStatusError,
+			caFingerprintMismatchReason, fmt.Sprintf(caFingerprintMismatchMessage, err)
+	default:
+		return discoveryv1alpha1.PeeringConditionStatusError, "", ""
+	}
+}
+
+// renewalTime returns the time after which the given identity shall be renewed.
+func (r *ForeignClusterReconciler) renewalTime(identity *identitymanager.CertificateIdentity) time.Time {
+	fraction := r.IdentityRenewalFraction
+	if fraction <= 0 || fraction >= 1 {
+		fraction = DefaultIdentityRenewalFraction
+	}
+
+	lifetime := identity.NotAfter.Sub(identity.NotBefore)
+	return identity.NotBefore.Add(time.Duration(float64(lifetime) * fraction))
 }
 
 // fetchRemoteTenantNamespace fetches the remote tenant namespace name form the local identity secret
@@ -136,6 +222,33 @@ func (r *ForeignClusterReconciler) validateIdentity(ctx context.Context, fc *dis
 	}
 
 	request := auth.NewCertificateIdentityRequest(r.HomeCluster, localToken, token, csr)
+	return r.requestIdentity(ctx, fc, request, key)
+}
+
+// renewIdentity sends an HTTP request to renew the identity for the remote cluster (Certificate),
+// proving the possession of the current one.
+func (r *ForeignClusterReconciler) renewIdentity(ctx context.Context, fc *discoveryv1alpha1.ForeignCluster,
+	identity *identitymanager.CertificateIdentity) error {
+	key, csr, err := csrutil.NewKeyAndRequest(r.HomeCluster.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to create create identity: %w", err)
+	}
+
+	localToken, err := auth.GetToken(ctx, r.Client, r.LiqoNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve authentication token: %w", err)
+	}
+
+	request, err := auth.NewCertificateIdentityRenewalRequest(r.HomeCluster, localToken, csr, identity.Certificate, identity.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to create renewal request: %w", err)
+	}
+	return r.requestIdentity(ctx, fc, request, key)
+}
+
+// requestIdentity sends the given identity request to the remote cluster, and stores the resulting identity.
+func (r *ForeignClusterReconciler) requestIdentity(ctx context.Context, fc *discoveryv1alpha1.ForeignCluster,
+	request *auth.CertificateIdentityRequest, key []byte) error {
 	responseBytes, err := r.sendIdentityRequest(ctx, request, fc)
 	if err != nil {
 		return fmt.Errorf("failed to send identity request: %w", err)
@@ -146,7 +259,7 @@ func (r *ForeignClusterReconciler) validateIdentity(ctx context.Context, fc *dis
 		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	if err = r.IdentityManager.StoreIdentity(ctx, remoteCluster, fc.Status.TenantNamespace.Local,
+	if err = r.IdentityManager.StoreIdentity(ctx, fc.Spec.ClusterIdentity, fc.Status.TenantNamespace.Local,
 		key, fc.Spec.ForeignProxyURL, &response); err != nil {
 		return fmt.Errorf("failed to store identity: %w", err)
 	}
@@ -163,14 +276,17 @@ func (r *ForeignClusterReconciler) sendIdentityRequest(ctx context.Context, requ
 	}
 	klog.V(8).Infof("[%v] Sending json request: %v", fc.Spec.ClusterIdentity.ClusterID, string(jsonRequest))
 
-	resp, err := sendRequest(ctx,
-		r.transport(foreignclusterutils.InsecureSkipTLSVerify(fc)),
+	transport, observed := r.authTransport(fc)
+	resp, err := sendRequest(ctx, transport,
 		fmt.Sprintf("%s%s", fc.Spec.ForeignAuthURL, request.GetPath()),
 		bytes.NewBuffer(jsonRequest))
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err = r.trustCAFingerprint(ctx, fc, observed); err != nil {
+		return nil, err
+	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
@@ -197,7 +313,7 @@ func (r *ForeignClusterReconciler) sendIdentityRequest(ctx context.Context, requ
 }
 
 func sendRequest(ctx context.Context, transport *http.Transport, url string, payload *bytes.Buffer) (*http.Response, error) {
-	client := &http.Client{
+	httpClient := &http.Client{
 		Transport: transport,
 		Timeout:   utils.HTTPRequestTimeout,
 	}
@@ -207,16 +323,96 @@ func sendRequest(ctx context.Context, transport *http.Transport, url string, pay
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "text/plain")
-	return client.Do(req)
+	return httpClient.Do(req)
+}
+
+// authTransport returns the transport to contact the remote authentication service. In case a CA fingerprint has been
+// established (either pinned or trusted on first use), the remote certificate chain is verified against it, as well as
+// against the one announced for the next rotation, and the certificate must be issued for the host of the remote URL. Otherwise, if the TLS verification is disabled, any certificate is
+// accepted. In both cases, the fingerprint of the CA the remote certificate chain is rooted in is returned.
+func (r *ForeignClusterReconciler) authTransport(fc *discoveryv1alpha1.ForeignCluster) (transport *http.Transport, observed *string) {
+	fingerprints := foreignclusterutils.TrustedCAFingerprints(fc)
+	if len(fingerprints) == 0 && !foreignclusterutils.InsecureSkipTLSVerify(fc) {
+		return r.SecureTransport, nil
+	}
+
+	observed = new(string)
+	transport = r.InsecureTransport.Clone()
+	transport.TLSClientConfig = auth.PinnedTLSConfig(observed, foreignclusterutils.AuthServerName(fc), fingerprints...)
+	return transport, observed
+}
+
+// trustCAFingerprint records in the status the fingerprint of the CA of the remote authentication service, which is
+// trusted for subsequent connections. It is either the pinned one, the one observed during the first connection, or
+// the one previously announced by the remote authentication service, in case it has been rotated. In the latter case,
+// the pinned fingerprint (if any) is updated as well, since it no longer matches the served CA.
+func (r *ForeignClusterReconciler) trustCAFingerprint(ctx context.Context, fc *discoveryv1alpha1.ForeignCluster, observed *string) error {
+	switch {
+	case observed == nil || *observed == "":
+		return nil
+	case fc.Status.NextCAFingerprint != "" && *observed == fc.Status.NextCAFingerprint:
+		klog.Infof("[%v] The remote authentication service switched to the announced CA with fingerprint %v", fc.Spec.ClusterIdentity, *observed)
+		fc.Status.CAFingerprint, fc.Status.NextCAFingerprint = *observed, ""
+		if fc.Spec.CAFingerprint != "" {
+			return r.updatePinnedCAFingerprint(ctx, fc, *observed)
+		}
+	case fc.Status.CAFingerprint == "" && fc.Spec.CAFingerprint == "":
+		klog.Infof("[%v] Trusting the remote authentication service CA with fingerprint %v", fc.Spec.ClusterIdentity, *observed)
+		fc.Status.CAFingerprint = *observed
+	case fc.Spec.CAFingerprint != "":
+		fc.Status.CAFingerprint = auth.NormalizeCAFingerprint(fc.Spec.CAFingerprint)
+	}
+	return nil
 }
 
-// transport returns the correct transport to be used for a given request.
-func (r *ForeignClusterReconciler) transport(insecureSkipTLSVerify bool) *http.Transport {
-	if insecureSkipTLSVerify {
-		return r.InsecureTransport
+// updatePinnedCAFingerprint updates the CA fingerprint pinned in the ForeignCluster spec. The resource is patched through
+// a copy, to preserve the status modifications which are still to be applied.
+func (r *ForeignClusterReconciler) updatePinnedCAFingerprint(ctx context.Context, fc *discoveryv1alpha1.ForeignCluster, fingerprint string) error {
+	patched := fc.DeepCopy()
+	patched.Spec.CAFingerprint = fingerprint
+	if err := r.Client.Patch(ctx, patched, client.MergeFrom(fc)); err != nil {
+		return fmt.Errorf("failed to update the pinned CA fingerprint: %w", err)
+	}
+	fc.Spec.CAFingerprint, fc.ResourceVersion = patched.Spec.CAFingerprint, patched.ResourceVersion
+	return nil
+}
+
+// refreshRemoteCAFingerprint retrieves the fingerprint of the CA the remote authentication service is going to switch to,
+// if any, which is then trusted in addition to the current one. The announcement is received through the connection
+// verified against the currently trusted CA, hence it cannot be forged. Failures are not fatal, as the announcement is
+// retrieved again upon the next resync.
+func (r *ForeignClusterReconciler) refreshRemoteCAFingerprint(ctx context.Context, fc *discoveryv1alpha1.ForeignCluster) error {
+	if foreignclusterutils.CAFingerprint(fc) == "" {
+		// The CA is still to be established, hence there is no rotation to track.
+		return nil
+	}
+
+	transport, observed := r.authTransport(fc)
+	ids, err := utils.GetClusterInfo(ctx, transport, fc.Spec.ForeignAuthURL)
+	if err != nil {
+		klog.Warningf("[%v] Failed to retrieve the CA announced by the remote authentication service: %v", fc.Spec.ClusterIdentity, err)
+		return nil
 	}
 
-	return r.SecureTransport
+	if err = r.trustCAFingerprint(ctx, fc, observed); err != nil {
+		return err
+	}
+
+	next := auth.NormalizeCAFingerprint(ids.NextCAFingerprint)
+	if next == fc.Status.CAFingerprint {
+		next = ""
+	}
+	if next != fc.Status.NextCAFingerprint && next != "" {
+		klog.Infof("[%v] The remote authentication service announced the rotation to the CA with fingerprint %v", fc.Spec.ClusterIdentity, next)
+	}
+	fc.Status.NextCAFingerprint = next
+	return nil
+}
+
+// isCAFingerprintMismatch returns whether the given error originates from a mismatch of the remote CA fingerprint.
+func isCAFingerprintMismatch(err error) bool {
+	var mismatch *auth.CAFingerprintMismatchError
+	return errors.As(err, &mismatch)
 }
 
 // getAuthTokenSecretPredicate returns the predicate to select the secrets containing authentication tokens
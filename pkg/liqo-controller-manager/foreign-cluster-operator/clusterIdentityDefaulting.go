@@ -21,7 +21,6 @@ import (
 
 	"github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	"github.com/liqotech/liqo/pkg/discoverymanager/utils"
-	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
 )
 
 // check if the ForeignCluster CR does not have a value in one of the required fields (Namespace and ClusterID)
@@ -35,7 +34,8 @@ func (r *ForeignClusterReconciler) needsClusterIdentityDefaulting(fc *v1alpha1.F
 // Cluster.ClusterID, Cluster.ClusterName.
 func (r *ForeignClusterReconciler) clusterIdentityDefaulting(ctx context.Context, fc *v1alpha1.ForeignCluster) error {
 	klog.V(4).Infof("Defaulting Cluster values for ForeignCluster %v", fc.Name)
-	ids, err := utils.GetClusterInfo(ctx, r.transport(foreignclusterutils.InsecureSkipTLSVerify(fc)), fc.Spec.ForeignAuthURL)
+	transport, _ := r.authTransport(fc)
+	ids, err := utils.GetClusterInfo(ctx, transport, fc.Spec.ForeignAuthURL)
 	if err != nil {
 		klog.Error(err)
 		return err
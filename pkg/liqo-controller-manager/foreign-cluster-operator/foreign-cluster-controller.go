@@ -93,6 +93,8 @@ type ForeignClusterReconciler struct {
 
 	NamespaceManager tenantnamespace.Manager
 	IdentityManager  identitymanager.IdentityManager
+	// IdentityRenewalFraction is the fraction of the certificate lifetime after which the identity is renewed.
+	IdentityRenewalFraction float64
 
 	PeeringPermission peeringRoles.PeeringPermission
 
@@ -167,7 +169,7 @@ func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		tracer.Step("Validated foreign cluster", trace.Field{Key: "requeuing", Value: true})
 		if err != nil {
 			klog.Error(err)
-			r.setForeignClusterStatusOnAuthUnavailable(&foreignCluster)
+			r.setForeignClusterStatusOnAuthUnavailable(&foreignCluster, err)
 			updateStatus()
 		}
 		return res, err
@@ -213,8 +215,16 @@ func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	// Add the foreigncluster to the map once the local tenant namespace has been created.
 	r.ForeignClusters.Store(foreignCluster.Status.TenantNamespace.Local, foreignCluster.GetName())
 
+	// track the rotation of the CA of the remote authentication service
+	if err = r.refreshRemoteCAFingerprint(ctx, &foreignCluster); err != nil {
+		klog.Error(err)
+		return ctrl.Result{}, err
+	}
+	tracer.Step("Refreshed the remote authentication service CA")
+
 	// ensure the existence of an identity to operate in the remote cluster remote cluster
-	if err = r.ensureRemoteIdentity(ctx, &foreignCluster); err != nil {
+	identityRenewal, err := r.ensureRemoteIdentity(ctx, &foreignCluster)
+	if err != nil {
 		klog.Errorf("Failed to ensure identity for remote cluster %q: %v", foreignCluster.Spec.ClusterIdentity, err)
 		return ctrl.Result{}, err
 	}
@@ -286,13 +296,27 @@ func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	tracer.Step("Performed ForeignCluster garbage collection")
 
 	klog.V(4).Infof("ForeignCluster %s successfully reconciled", foreignCluster.Name)
+	requeueAfter := r.ResyncPeriod
+	if identityRenewal > 0 && identityRenewal < requeueAfter {
+		// Make sure the identity is renewed in time, even if the resync period is longer.
+		requeueAfter = identityRenewal
+	}
 	return ctrl.Result{
 		Requeue:      true,
-		RequeueAfter: r.ResyncPeriod,
+		RequeueAfter: requeueAfter,
 	}, nil
 }
 
-func (r *ForeignClusterReconciler) setForeignClusterStatusOnAuthUnavailable(foreignCluster *discoveryv1alpha1.ForeignCluster) {
+func (r *ForeignClusterReconciler) setForeignClusterStatusOnAuthUnavailable(foreignCluster *discoveryv1alpha1.ForeignCluster, err error) {
+	if isCAFingerprintMismatch(err) {
+		peeringconditionsutils.EnsureStatus(foreignCluster,
+			discoveryv1alpha1.AuthenticationStatusCondition,
+			discoveryv1alpha1.PeeringConditionStatusError,
+			caFingerprintMismatchReason,
+			fmt.Sprintf(caFingerprintMismatchMessage, err))
+		return
+	}
+
 	peeringconditionsutils.EnsureStatus(foreignCluster,
 		discoveryv1alpha1.AuthenticationStatusCondition,
 		discoveryv1alpha1.PeeringConditionStatusError,
@@ -412,6 +436,11 @@ func (r *ForeignClusterReconciler) checkIncomingPeeringStatus(ctx context.Contex
 	if err != nil {
 		return fmt.Errorf("reading peering phase from namespace %s: %w", localNamespace, err)
 	}
+
+	// The incoming peering is denied in case the identity of the remote cluster has been revoked.
+	if foreignclusterutils.IsIdentityRevoked(foreignCluster) && status != discoveryv1alpha1.PeeringConditionStatusDisconnecting {
+		status, reason, message = discoveryv1alpha1.PeeringConditionStatusDenied, identityRevokedReason, identityRevokedMessage
+	}
 	peeringconditionsutils.EnsureStatus(foreignCluster,
 		discoveryv1alpha1.IncomingPeeringCondition, status, reason, message)
 	return nil
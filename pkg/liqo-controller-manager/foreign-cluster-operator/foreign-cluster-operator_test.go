@@ -133,7 +133,8 @@ var _ = Describe("ForeignClusterOperator", func() {
 		Expect(authSvc).ToNot(BeNil())
 
 		namespaceManager := tenantnamespace.NewManager(cluster.GetClient())
-		identityManagerCtrl := identitymanager.NewCertificateIdentityManager(cluster.GetClient(), homeCluster, namespaceManager)
+		identityManagerCtrl := identitymanager.NewCertificateIdentityManager(cluster.GetClient(), homeCluster, namespaceManager,
+			identitymanager.NewSecretIdentityStorage(cluster.GetClient()))
 
 		foreignCluster := discoveryv1alpha1.ClusterIdentity{
 			ClusterID:   "foreign-cluster-id",
@@ -1114,6 +1115,92 @@ var _ = Describe("ForeignClusterOperator", func() {
 
 	})
 
+	Context("Test Permission Profile", func() {
+
+		var (
+			defaultBasic    rbacv1.ClusterRole
+			restrictedBasic rbacv1.ClusterRole
+			fc              discoveryv1alpha1.ForeignCluster
+		)
+
+		roleBindingNames := func() []string {
+			var roleBindingList rbacv1.RoleBindingList
+			Expect(controller.Client.List(ctx, &roleBindingList)).To(Succeed())
+
+			names := []string{}
+			for i := range roleBindingList.Items {
+				if roleBindingList.Items[i].DeletionTimestamp.IsZero() {
+					names = append(names, roleBindingList.Items[i].Name)
+				}
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			defaultBasic = rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "liqo-default-basic"}}
+			restrictedBasic = rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{
+				Name: "liqo-restricted-basic",
+				Labels: map[string]string{
+					"auth.liqo.io/remote-peering-permissions":     "basic",
+					peeringroles.RemotePermissionsProfileLabelKey: "restricted",
+				},
+			}}
+			Expect(controller.Client.Create(ctx, &defaultBasic)).To(Succeed())
+			Expect(controller.Client.Create(ctx, &restrictedBasic)).To(Succeed())
+
+			controller.PeeringPermission = peeringroles.PeeringPermission{Basic: []*rbacv1.ClusterRole{&defaultBasic}}
+
+			fc = discoveryv1alpha1.ForeignCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "foreign-cluster-name"},
+				Spec: discoveryv1alpha1.ForeignClusterSpec{
+					ClusterIdentity: discoveryv1alpha1.ClusterIdentity{
+						ClusterID:   "foreign-cluster-id",
+						ClusterName: "foreign-cluster-name",
+					},
+				},
+				Status: discoveryv1alpha1.ForeignClusterStatus{
+					TenantNamespace: discoveryv1alpha1.TenantNamespaceType{Local: tenantNamespace.Name},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			var roleBindingList rbacv1.RoleBindingList
+			Expect(controller.Client.List(ctx, &roleBindingList)).To(Succeed())
+			for i := range roleBindingList.Items {
+				Expect(controller.Client.Delete(ctx, &roleBindingList.Items[i])).To(Succeed())
+			}
+
+			Expect(controller.Client.Delete(ctx, &defaultBasic)).To(Succeed())
+			Expect(controller.Client.Delete(ctx, &restrictedBasic)).To(Succeed())
+		})
+
+		It("should grant the ClusterRoles of the selected profile, and revoke the default ones", func() {
+			Expect(controller.ensurePermission(ctx, &fc)).To(Succeed())
+			Expect(fc.Status.Permissions.ClusterRoles).To(ConsistOf(defaultBasic.Name))
+			Eventually(roleBindingNames, timeout, interval).Should(ContainElement("liqo-binding-liqo-default-basic"))
+
+			fc.Spec.PermissionProfile = "restricted"
+			Eventually(func() error { return controller.ensurePermission(ctx, &fc) }, timeout, interval).Should(Succeed())
+			Expect(fc.Status.Permissions.Profile).To(Equal("restricted"))
+			Expect(fc.Status.Permissions.ClusterRoles).To(ConsistOf(restrictedBasic.Name))
+			Eventually(roleBindingNames, timeout, interval).Should(And(
+				ContainElement("liqo-binding-liqo-restricted-basic"),
+				Not(ContainElement("liqo-binding-liqo-default-basic")),
+			))
+		})
+
+		It("should revoke all the permissions if the selected profile is invalid", func() {
+			Expect(controller.ensurePermission(ctx, &fc)).To(Succeed())
+
+			fc.Spec.PermissionProfile = "not-existing"
+			Expect(controller.ensurePermission(ctx, &fc)).ToNot(Succeed())
+			Expect(fc.Status.Permissions.Message).To(ContainSubstring("not found"))
+			Expect(fc.Status.Permissions.ClusterRoles).To(BeEmpty())
+			Eventually(roleBindingNames, timeout, interval).ShouldNot(ContainElement("liqo-binding-liqo-default-basic"))
+		})
+	})
+
 	Context("Test isClusterProcessable", func() {
 
 		It("multiple ForeignClusters with the same clusterID", func() {
@@ -1343,6 +1430,24 @@ var _ = Describe("PeeringPolicy", func() {
 				expected: BeTrue(),
 			}),
 
+			Entry("peering automatic with catalog discovery", isPeeringEnabledTestcase{
+				foreignCluster: discoveryv1alpha1.ForeignCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "foreign-cluster-name",
+						Labels: map[string]string{
+							discovery.DiscoveryTypeLabel: string(discovery.CatalogDiscovery),
+							discovery.ClusterIDLabel:     "foreign-cluster-id",
+						},
+					},
+					Spec: discoveryv1alpha1.ForeignClusterSpec{
+						OutgoingPeeringEnabled: discoveryv1alpha1.PeeringEnabledAuto,
+						IncomingPeeringEnabled: discoveryv1alpha1.PeeringEnabledAuto,
+						InsecureSkipTLSVerify:  pointer.BoolPtr(true),
+					},
+				},
+				expected: BeTrue(),
+			}),
+
 			Entry("foreign cluster with deletion timestamp set", isPeeringEnabledTestcase{
 				foreignCluster: discoveryv1alpha1.ForeignCluster{
 					ObjectMeta: metav1.ObjectMeta{
@@ -65,7 +65,7 @@ func (r *ForeignClusterReconciler) isOutgoingPeeringEnabled(ctx context.Context,
 
 		discoveryType := foreignclusterutils.GetDiscoveryType(foreignCluster)
 		switch discoveryType {
-		case discovery.LanDiscovery:
+		case discovery.LanDiscovery, discovery.WanDiscovery, discovery.CatalogDiscovery:
 			return true, nil
 		case discovery.ManualDiscovery, discovery.IncomingPeeringDiscovery:
 			return false, nil
@@ -17,12 +17,16 @@ package foreignclusteroperator
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
+	peeringRoles "github.com/liqotech/liqo/pkg/peering-roles"
 	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
 )
 
@@ -30,61 +34,132 @@ func (r *ForeignClusterReconciler) ensurePermission(ctx context.Context, foreign
 	remoteCluster := foreignCluster.Spec.ClusterIdentity
 	peeringPhase := foreignclusterutils.GetPeeringPhase(foreignCluster)
 
-	if _, err = r.NamespaceManager.BindClusterRoles(ctx, remoteCluster, r.PeeringPermission.Basic...); err != nil {
+	// Enforce the revocation of the remote identity, which removes all the permissions, if requested.
+	revoked, err := r.ensureIdentityRevocation(ctx, foreignCluster)
+	if err != nil {
 		klog.Error(err)
 		return err
 	}
+	if revoked {
+		return nil
+	}
+
+	permission, err := r.getPeeringPermission(ctx, foreignCluster)
+	if err != nil {
+		// The selected profile cannot be granted: remove the permissions previously granted, rather than keeping those of a different profile.
+		klog.Errorf("[%v] Invalid peering permission profile: %v", remoteCluster, err)
+		if uerr := r.unbindStalePermissions(ctx, foreignCluster, nil); uerr != nil {
+			klog.Error(uerr)
+			return uerr
+		}
+		foreignCluster.Status.Permissions.Profile = foreignCluster.Spec.PermissionProfile
+		foreignCluster.Status.Permissions.Message = err.Error()
+		return err
+	}
+
+	if _, err = r.NamespaceManager.BindClusterRoles(ctx, remoteCluster, permission.Basic...); err != nil {
+		klog.Error(err)
+		return err
+	}
+	granted := append([]*rbacv1.ClusterRole{}, permission.Basic...)
 
 	switch peeringPhase {
 	case consts.PeeringPhaseNone, consts.PeeringPhaseAuthenticated:
 		if err = r.NamespaceManager.UnbindClusterRoles(ctx, remoteCluster,
-			clusterRolesToNames(r.PeeringPermission.Outgoing)...); err != nil {
+			clusterRolesToNames(permission.Outgoing)...); err != nil {
 			klog.Error(err)
 			return err
 		}
 		if err = r.NamespaceManager.UnbindClusterRoles(ctx, remoteCluster,
-			clusterRolesToNames(r.PeeringPermission.Incoming)...); err != nil {
+			clusterRolesToNames(permission.Incoming)...); err != nil {
 			klog.Error(err)
 			return err
 		}
 	case consts.PeeringPhaseOutgoing:
 		if _, err = r.NamespaceManager.BindClusterRoles(ctx, remoteCluster,
-			r.PeeringPermission.Outgoing...); err != nil {
+			permission.Outgoing...); err != nil {
 			klog.Error(err)
 			return err
 		}
 		if err = r.NamespaceManager.UnbindClusterRoles(ctx, remoteCluster,
-			clusterRolesToNames(r.PeeringPermission.Incoming)...); err != nil {
+			clusterRolesToNames(permission.Incoming)...); err != nil {
 			klog.Error(err)
 			return err
 		}
+		granted = append(granted, permission.Outgoing...)
 	case consts.PeeringPhaseIncoming:
 		if err = r.NamespaceManager.UnbindClusterRoles(ctx, remoteCluster,
-			clusterRolesToNames(r.PeeringPermission.Outgoing)...); err != nil {
+			clusterRolesToNames(permission.Outgoing)...); err != nil {
 			klog.Error(err)
 			return err
 		}
 		if _, err = r.NamespaceManager.BindClusterRoles(ctx, remoteCluster,
-			r.PeeringPermission.Incoming...); err != nil {
+			permission.Incoming...); err != nil {
 			klog.Error(err)
 			return err
 		}
+		granted = append(granted, permission.Incoming...)
 	case consts.PeeringPhaseBidirectional:
 		if _, err = r.NamespaceManager.BindClusterRoles(ctx, remoteCluster,
-			r.PeeringPermission.Outgoing...); err != nil {
+			permission.Outgoing...); err != nil {
 			klog.Error(err)
 			return err
 		}
 		if _, err = r.NamespaceManager.BindClusterRoles(ctx, remoteCluster,
-			r.PeeringPermission.Incoming...); err != nil {
+			permission.Incoming...); err != nil {
 			klog.Error(err)
 			return err
 		}
+		granted = append(granted, permission.Outgoing...)
+		granted = append(granted, permission.Incoming...)
 	default:
 		err = fmt.Errorf("invalid PeeringPhase %v", peeringPhase)
 		klog.Error(err)
 		return err
 	}
+
+	// Remove the permissions granted by a different profile (e.g., in case the selected profile changed).
+	grantedNames := clusterRolesToNames(granted)
+	if err = r.unbindStalePermissions(ctx, foreignCluster, grantedNames); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	sort.Strings(grantedNames)
+	foreignCluster.Status.Permissions = discoveryv1alpha1.PeeringPermissionStatus{
+		Profile:      foreignCluster.Spec.PermissionProfile,
+		ClusterRoles: grantedNames,
+	}
+	return nil
+}
+
+// getPeeringPermission returns the PeeringPermission corresponding to the profile selected by the given ForeignCluster.
+func (r *ForeignClusterReconciler) getPeeringPermission(ctx context.Context,
+	foreignCluster *discoveryv1alpha1.ForeignCluster) (*peeringRoles.PeeringPermission, error) {
+	profile := foreignCluster.Spec.PermissionProfile
+	if profile == "" {
+		return &r.PeeringPermission, nil
+	}
+
+	var clusterRoles rbacv1.ClusterRoleList
+	if err := r.Client.List(ctx, &clusterRoles, client.MatchingLabels{peeringRoles.RemotePermissionsProfileLabelKey: profile}); err != nil {
+		return nil, fmt.Errorf("failed to retrieve the ClusterRoles of peering permission profile %q: %w", profile, err)
+	}
+	return peeringRoles.NewPeeringPermissionFromProfile(profile, clusterRoles.Items)
+}
+
+// unbindStalePermissions removes the bindings towards the ClusterRoles previously granted to the remote cluster (either
+// recorded in the status or belonging to the default profile), except for the given ones.
+func (r *ForeignClusterReconciler) unbindStalePermissions(ctx context.Context,
+	foreignCluster *discoveryv1alpha1.ForeignCluster, granted []string) error {
+	stale := sets.NewString(foreignCluster.Status.Permissions.ClusterRoles...)
+	stale.Insert(r.PeeringPermission.ClusterRoleNames()...)
+	stale.Delete(granted...)
+
+	if err := r.NamespaceManager.UnbindClusterRoles(ctx, foreignCluster.Spec.ClusterIdentity, stale.List()...); err != nil {
+		return err
+	}
+	foreignCluster.Status.Permissions.ClusterRoles = granted
 	return nil
 }
 
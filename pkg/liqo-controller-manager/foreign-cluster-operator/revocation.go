@@ -0,0 +1,177 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package foreignclusteroperator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
+	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+)
+
+const (
+	identityRevokedReason  = "IdentityRevoked"
+	identityRevokedMessage = "The identity granted to the remote cluster has been revoked"
+)
+
+// ensureIdentityRevocation enforces the revocation status of the identity granted to the remote cluster,
+// as specified in the ForeignCluster. In case of revocation, it records the serial number of the certificate
+// issued to the remote cluster and removes all the associated permissions. It returns whether the identity is
+// currently revoked, which is still the case after lifting the revocation, until all the revoked certificates expired.
+func (r *ForeignClusterReconciler) ensureIdentityRevocation(ctx context.Context, foreignCluster *discoveryv1alpha1.ForeignCluster) (bool, error) {
+	revoked := foreignclusterutils.IsIdentityRevoked(foreignCluster)
+
+	var secret corev1.Secret
+	key := types.NamespacedName{Namespace: foreignCluster.Status.TenantNamespace.Local, Name: identitymanager.RemoteCertificateSecret}
+	if err := r.Client.Get(ctx, key, &secret); client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("failed to retrieve secret %q: %w", key, err)
+	} else if err == nil {
+		serial, changed := identitymanager.SetRevocation(&secret, revoked)
+		if changed {
+			if err = r.Client.Update(ctx, &secret); err != nil {
+				return false, fmt.Errorf("failed to update secret %q: %w", key, err)
+			}
+			klog.Infof("[%v] Identity revocation set to %t", foreignCluster.Spec.ClusterIdentity, revoked)
+		}
+
+		if revoked && serial != "" {
+			recordRevokedCertificate(foreignCluster, serial)
+		}
+
+		// The revoked certificates are still accepted by the API server until expiration, and they are associated with the
+		// same user as any new one. Hence, keep the permissions removed (and no new identity is issued) until all of them expired.
+		if revocation := identitymanager.RevocationFromSecret(&secret); !revoked &&
+			(revocation.IsIssuedCertificateRevoked() || revocation.HasValidRevokedCertificates(time.Now())) {
+			klog.V(4).Infof("[%v] Keeping permissions removed until the revoked certificates expire (%v)",
+				foreignCluster.Spec.ClusterIdentity, revocation.RevokedUntil)
+			revoked = true
+		}
+	}
+
+	if !revoked {
+		return false, nil
+	}
+
+	// Remove the permissions granted in the tenant namespace and at the cluster level.
+	if err := r.deleteTenantBindings(ctx, foreignCluster); err != nil {
+		return true, err
+	}
+
+	// Remove the permissions granted in the namespaces hosting the workloads offloaded by the remote cluster.
+	return true, r.deleteRemoteNamespacesBindings(ctx, foreignCluster)
+}
+
+// deleteTenantBindings removes all the permissions granted to the remote cluster in the tenant namespace and at the cluster level
+// (including the access to the node stats), regardless of the component which created them (e.g., the permission profile).
+// The bindings referring only to the remote cluster are deleted, while the remote cluster is removed from the subjects of the others.
+func (r *ForeignClusterReconciler) deleteTenantBindings(ctx context.Context, foreignCluster *discoveryv1alpha1.ForeignCluster) error {
+	clusterID := foreignCluster.Spec.ClusterIdentity.ClusterID
+
+	if tenantNamespace := foreignCluster.Status.TenantNamespace.Local; tenantNamespace != "" {
+		var bindings rbacv1.RoleBindingList
+		if err := r.Client.List(ctx, &bindings, client.InNamespace(tenantNamespace)); err != nil {
+			return fmt.Errorf("failed to list RoleBindings in namespace %q: %w", tenantNamespace, err)
+		}
+		for i := range bindings.Items {
+			binding := &bindings.Items[i]
+			if err := r.unbindCluster(ctx, "RoleBinding", binding, &binding.Subjects, clusterID); err != nil {
+				return fmt.Errorf("failed to remove RoleBinding %q: %w", klog.KObj(binding), err)
+			}
+		}
+	}
+
+	var clusterBindings rbacv1.ClusterRoleBindingList
+	if err := r.Client.List(ctx, &clusterBindings); err != nil {
+		return fmt.Errorf("failed to list ClusterRoleBindings: %w", err)
+	}
+	for i := range clusterBindings.Items {
+		binding := &clusterBindings.Items[i]
+		if err := r.unbindCluster(ctx, "ClusterRoleBinding", binding, &binding.Subjects, clusterID); err != nil {
+			return fmt.Errorf("failed to remove ClusterRoleBinding %q: %w", binding.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// unbindCluster ensures the given binding (of the given kind), whose subjects are provided, does no longer refer to the user associated with
+// the given remote cluster, either deleting it (if it refers only to that user), or removing the user from its subjects.
+func (r *ForeignClusterReconciler) unbindCluster(ctx context.Context, kind string, binding client.Object,
+	subjects *[]rbacv1.Subject, clusterID string) error {
+	var others []rbacv1.Subject
+	for i := range *subjects {
+		if (*subjects)[i].Kind != rbacv1.UserKind || (*subjects)[i].Name != clusterID {
+			others = append(others, (*subjects)[i])
+		}
+	}
+
+	switch {
+	case len(others) == len(*subjects):
+		return nil
+	case len(others) == 0:
+		if err := r.Client.Delete(ctx, binding); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		klog.Infof("[%v] %v %q deleted, as the identity has been revoked", clusterID, kind, klog.KObj(binding))
+	default:
+		*subjects = others
+		if err := r.Client.Update(ctx, binding); err != nil {
+			return err
+		}
+		klog.Infof("[%v] Removed from the subjects of %v %q, as the identity has been revoked", clusterID, kind, klog.KObj(binding))
+	}
+	return nil
+}
+
+// deleteRemoteNamespacesBindings deletes the RoleBindings granting permissions to the remote cluster in the namespaces
+// hosting the workloads it offloaded, which are named after the corresponding tenant namespace.
+func (r *ForeignClusterReconciler) deleteRemoteNamespacesBindings(ctx context.Context, foreignCluster *discoveryv1alpha1.ForeignCluster) error {
+	var namespaces corev1.NamespaceList
+	if err := r.Client.List(ctx, &namespaces, client.MatchingLabels{
+		consts.RemoteClusterID: foreignCluster.Spec.ClusterIdentity.ClusterID}); err != nil {
+		return fmt.Errorf("failed to list remote namespaces: %w", err)
+	}
+
+	for i := range namespaces.Items {
+		binding := rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespaces.Items[i].Name, Name: foreignCluster.Status.TenantNamespace.Local}}
+		if err := r.Client.Delete(ctx, &binding); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete RoleBinding %q: %w", klog.KObj(&binding), err)
+		}
+	}
+	return nil
+}
+
+// recordRevokedCertificate adds the given serial number to the list of revoked certificates, if not already present.
+func recordRevokedCertificate(foreignCluster *discoveryv1alpha1.ForeignCluster, serial string) {
+	for i := range foreignCluster.Status.RevokedCertificates {
+		if foreignCluster.Status.RevokedCertificates[i].SerialNumber == serial {
+			return
+		}
+	}
+
+	foreignCluster.Status.RevokedCertificates = append(foreignCluster.Status.RevokedCertificates,
+		discoveryv1alpha1.RevokedCertificate{SerialNumber: serial, RevocationTime: metav1.Now()})
+}
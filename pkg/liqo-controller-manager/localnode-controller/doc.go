@@ -0,0 +1,18 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package localnodectrl contains a controller that labels the local (i.e., non virtual) nodes with the identity
+// of the local cluster, so that the replicas can be spread across the local and the remote clusters through
+// topology spread constraints keyed on the cluster identity label (which virtual nodes carry as well).
+package localnodectrl
@@ -0,0 +1,73 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localnodectrl
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+)
+
+// LocalNodeReconciler labels the local nodes with the identity of the local cluster.
+type LocalNodeReconciler struct {
+	client.Client
+	LocalClusterID string
+}
+
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;patch
+
+// Reconcile enforces the cluster identity label on the given local node.
+func (r *LocalNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var node corev1.Node
+	if err := r.Get(ctx, req.NamespacedName, &node); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if utils.IsVirtualNode(&node) || node.Labels[liqoconst.ClusterIdentityLabelKey] == r.LocalClusterID {
+		return ctrl.Result{}, nil
+	}
+
+	original := node.DeepCopy()
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	node.Labels[liqoconst.ClusterIdentityLabelKey] = r.LocalClusterID
+	if err := r.Patch(ctx, &node, client.MergeFrom(original)); err != nil {
+		klog.Errorf("Failed to label node %q with the local cluster identity: %v", node.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	klog.V(4).Infof("Node %q labeled with the local cluster identity", node.Name)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers a new LocalNodeReconciler, watching the local nodes.
+func (r *LocalNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	local := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[liqoconst.TypeLabel] != liqoconst.TypeNode
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).Named("localnode").
+		For(&corev1.Node{}, builder.WithPredicates(local)).
+		Complete(r)
+}
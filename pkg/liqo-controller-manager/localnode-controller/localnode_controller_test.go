@@ -0,0 +1,74 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localnodectrl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubectl/pkg/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Local node labeling", func() {
+	const localClusterID = "local-cluster-id"
+
+	var (
+		ctx        context.Context
+		reconciler *LocalNodeReconciler
+	)
+
+	forgeNode := func(name string, labels map[string]string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	reconcile := func(name string) map[string]string {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+		Expect(err).ToNot(HaveOccurred())
+
+		var node corev1.Node
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: name}, &node)).To(Succeed())
+		return node.Labels
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			forgeNode("local", map[string]string{"foo": "bar"}),
+			forgeNode("virtual", map[string]string{liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.ClusterIdentityLabelKey: "remote-cluster-id"}),
+		).Build()
+		reconciler = &LocalNodeReconciler{Client: cl, LocalClusterID: localClusterID}
+	})
+
+	It("should label the local nodes with the local cluster identity", func() {
+		Expect(reconcile("local")).To(Equal(map[string]string{"foo": "bar", liqoconst.ClusterIdentityLabelKey: localClusterID}))
+	})
+
+	It("should not modify the virtual nodes", func() {
+		Expect(reconcile("virtual")).To(HaveKeyWithValue(liqoconst.ClusterIdentityLabelKey, "remote-cluster-id"))
+	})
+
+	It("should ignore the nodes not found", func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "missing"}})
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -17,6 +17,9 @@ package namespacemapctrl
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -32,8 +35,12 @@ import (
 
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
 	"github.com/liqotech/liqo/pkg/utils"
 	liqoerrors "github.com/liqotech/liqo/pkg/utils/errors"
+	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+	"github.com/liqotech/liqo/pkg/utils/namespacemapping"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 )
 
 // createNamespace creates a new namespace associated with a NamespaceMap. It returns whether a possible error
@@ -73,16 +80,35 @@ func (r *NamespaceMapReconciler) createNamespace(ctx context.Context, name, orig
 		klog.Infof("Namespace %q successfully created", klog.KObj(&namespace))
 	}
 
-	// Check whether this namespace is controlled by the NamespaceMap controller.
-	if value, ok := namespace.Annotations[liqoconst.RemoteNamespaceManagedByAnnotationKey]; !ok || value != nmID {
+	// Check whether this namespace is controlled by the NamespaceMap controller, or it has been previously orphaned
+	// by the same NamespaceMap (i.e., retained upon removal), hence it can be adopted again.
+	if namespace.Annotations[liqoconst.RemoteNamespaceManagedByAnnotationKey] != nmID &&
+		namespace.Annotations[liqoconst.RemoteNamespaceOrphanedByAnnotationKey] != nmID {
 		err := fmt.Errorf("namespace %q already exists and it is not managed by NamespaceMap %q", name, nmID)
 		return false, err
 	}
 
+	if err = r.enforceNamespaceMetadata(ctx, &namespace, origin, nmID, nm.Spec.Options[originName]); err != nil {
+		return true, err
+	}
+
 	// Make sure the appropriate role binding is present in the namespace for virtual kubelet operations.
 	// The rolebinding is named after the tenant namespace name, since that is guaranteed to be unique.
 	// This will simplify the support for remote namespaces associated with multiple origins.
 	binding := rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: name, Name: nm.GetNamespace()}}
+
+	// Do not grant any permission in case the identity of the origin cluster has been revoked.
+	revoked, err := r.isIdentityRevoked(ctx, origin)
+	if err != nil {
+		return true, err
+	}
+	if revoked {
+		if err = r.Delete(ctx, &binding); client.IgnoreNotFound(err) != nil {
+			return true, fmt.Errorf("failed to delete role binding %q: %w", klog.KObj(&binding), err)
+		}
+		klog.V(4).Infof("Skipping RoleBinding %q enforcement, as the identity of cluster %q has been revoked", klog.KObj(&binding), origin)
+		return true, nil
+	}
 	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, &binding, func() error {
 		binding.Annotations = labels.Merge(binding.GetAnnotations(), map[string]string{
 			liqoconst.RemoteNamespaceManagedByAnnotationKey: nmID})
@@ -102,6 +128,154 @@ func (r *NamespaceMapReconciler) createNamespace(ctx context.Context, name, orig
 	return true, nil
 }
 
+// enforceStatsClusterRoleBinding ensures the cluster role binding granting the origin cluster access to the summary API of the nodes
+// (required to retrieve the stats of the offloaded pods) is present if granted is true, the access is enabled by the local cluster
+// and the identity of the origin cluster has not been revoked, and that it is absent otherwise.
+func (r *NamespaceMapReconciler) enforceStatsClusterRoleBinding(ctx context.Context, nm *vkv1alpha1.NamespaceMap, granted bool) error {
+	// The label is guaranteed to exist, since it is part of the filter predicate.
+	origin := nm.Labels[liqoconst.ReplicationOriginLabel]
+	nmID, err := cache.MetaNamespaceKeyFunc(nm)
+	utilruntime.Must(err)
+
+	// The cluster role binding is named after the origin cluster, since a single NamespaceMap exists for each of them.
+	binding := rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: liqoconst.RemoteStatsClusterRoleName + "-" + origin}}
+
+	if granted = granted && r.GrantNodeStats; granted {
+		revoked, revokedErr := r.isIdentityRevoked(ctx, origin)
+		if revokedErr != nil {
+			return revokedErr
+		}
+		granted = !revoked
+	}
+
+	if !granted {
+		if err = r.Delete(ctx, &binding); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete cluster role binding %q: %w", binding.GetName(), err)
+		}
+		return nil
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, &binding, func() error {
+		binding.Annotations = labels.Merge(binding.GetAnnotations(), map[string]string{
+			liqoconst.RemoteNamespaceManagedByAnnotationKey: nmID})
+
+		if binding.CreationTimestamp.IsZero() {
+			binding.Subjects = []rbacv1.Subject{{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: origin}}
+			binding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: liqoconst.RemoteStatsClusterRoleName}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enforce cluster role binding %q: %w", binding.GetName(), err)
+	}
+
+	klog.V(utils.FromResult(result)).Infof("ClusterRoleBinding %q successfully enforced (with %v operation)", binding.GetName(), result)
+	return nil
+}
+
+// enforceNamespaceMetadata ensures the namespace is marked as managed by the given NamespaceMap, and that it carries
+// the labels and annotations propagated from the local namespace, as well as the retention marker, if requested.
+func (r *NamespaceMapReconciler) enforceNamespaceMetadata(ctx context.Context, namespace *corev1.Namespace, origin, nmID string,
+	options vkv1alpha1.RemoteNamespaceOptions) error {
+	original := namespace.DeepCopy()
+	if namespace.Labels == nil {
+		namespace.Labels = map[string]string{}
+	}
+	if namespace.Annotations == nil {
+		namespace.Annotations = map[string]string{}
+	}
+
+	namespace.Annotations[liqoconst.RemoteNamespaceManagedByAnnotationKey] = nmID
+	delete(namespace.Annotations, liqoconst.RemoteNamespaceOrphanedByAnnotationKey)
+	delete(namespace.Annotations, liqoconst.RemoteNamespaceOrphanedAtAnnotationKey)
+	delete(namespace.Labels, liqoconst.RemoteNamespaceOrphanedLabelKey)
+	namespace.Labels[liqoconst.RemoteClusterID] = origin
+
+	// The propagated labels are further restricted not to lower the Pod Security Admission level below the local default.
+	propagatedLabels := namespacemapping.FilterPodSecurityLabels(
+		namespacemapping.FilterPropagatedMetadata(options.Labels, r.AllowedLabels), r.MinimumPodSecurityLevel)
+	enforcePropagatedMetadata(namespace.Labels, namespace.Annotations,
+		liqoconst.RemoteNamespacePropagatedLabelsAnnotationKey, propagatedLabels)
+	enforcePropagatedMetadata(namespace.Annotations, namespace.Annotations,
+		liqoconst.RemoteNamespacePropagatedAnnotationsAnnotationKey,
+		namespacemapping.FilterPropagatedMetadata(options.Annotations, r.AllowedAnnotations))
+
+	// The retention is honored only if allowed by the local cluster, which deletes the namespaces otherwise.
+	if options.Retain && r.AllowRetention {
+		namespace.Annotations[liqoconst.RemoteNamespaceRetainAnnotationKey] = "true"
+	} else {
+		delete(namespace.Annotations, liqoconst.RemoteNamespaceRetainAnnotationKey)
+	}
+
+	if labels.Equals(original.Labels, namespace.Labels) && labels.Equals(original.Annotations, namespace.Annotations) {
+		return nil
+	}
+
+	if err := r.Patch(ctx, namespace, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to enforce the metadata of namespace %q: %w", namespace.GetName(), err)
+	}
+
+	klog.Infof("Metadata of namespace %q successfully enforced", namespace.GetName())
+	return nil
+}
+
+// enforcePropagatedMetadata ensures the given metadata (i.e., labels or annotations) includes the propagated entries,
+// and removes those previously propagated but no longer requested, whose keys are tracked through the given annotation.
+// The propagated entries shall be already filtered, since they originate from a different cluster.
+func enforcePropagatedMetadata(metadata, annotations map[string]string, trackingKey string, propagated map[string]string) {
+	for _, key := range strings.Split(annotations[trackingKey], ",") {
+		if _, found := propagated[key]; key != "" && !found {
+			delete(metadata, key)
+		}
+	}
+
+	if len(propagated) == 0 {
+		delete(annotations, trackingKey)
+		return
+	}
+
+	keys := make([]string, 0, len(propagated))
+	for key, value := range propagated {
+		metadata[key] = value
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	annotations[trackingKey] = strings.Join(keys, ",")
+}
+
+// enforceResourceQuota ensures the ResourceQuota limiting the resources consumed by the origin cluster in the given
+// namespace matches the one requested through the NamespaceMap, and that it is removed in case no quota is requested.
+func (r *NamespaceMapReconciler) enforceResourceQuota(ctx context.Context, name, originName string, nm *vkv1alpha1.NamespaceMap) error {
+	nmID, err := cache.MetaNamespaceKeyFunc(nm)
+	utilruntime.Must(err)
+
+	// The resource quota is named after the tenant namespace name, since that is guaranteed to be unique.
+	quota := corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Namespace: name, Name: nm.GetNamespace()}}
+
+	hard, found := nm.Spec.Quotas[originName]
+	if !found || len(hard) == 0 {
+		if err = r.Delete(ctx, &quota); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete resource quota %q: %w", klog.KObj(&quota), err)
+		}
+		return nil
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, &quota, func() error {
+		quota.Annotations = labels.Merge(quota.GetAnnotations(), map[string]string{
+			liqoconst.RemoteNamespaceManagedByAnnotationKey: nmID})
+		quota.Spec.Hard = hard.DeepCopy()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enforce resource quota %q: %w", klog.KObj(&quota), err)
+	}
+
+	klog.V(utils.FromResult(result)).Infof("ResourceQuota %q successfully enforced (with %v operation)", klog.KObj(&quota), result)
+	return nil
+}
+
 // For every entry of DesiredMapping create remote Namespace if it has not already being created.
 // ensureNamespacesExistence tries to create all the remote namespaces requested in DesiredMapping (NamespaceMap->Spec->DesiredMapping).
 func (r *NamespaceMapReconciler) ensureNamespacesExistence(ctx context.Context, nm *vkv1alpha1.NamespaceMap) error {
@@ -118,6 +292,9 @@ func (r *NamespaceMapReconciler) ensureNamespacesExistence(ctx context.Context,
 
 			klog.Errorf("Namespace enforcement failure: %v", creationError)
 			err = creationError
+		} else if quotaError := r.enforceResourceQuota(ctx, destinationName, originName, nm); quotaError != nil {
+			klog.Errorf("Namespace enforcement failure: %v", quotaError)
+			err = quotaError
 		}
 
 		nm.Status.CurrentMapping[originName] = vkv1alpha1.RemoteNamespaceStatus{RemoteNamespace: destinationName, Phase: phase}
@@ -126,8 +303,12 @@ func (r *NamespaceMapReconciler) ensureNamespacesExistence(ctx context.Context,
 	return err
 }
 
-// deleteNamespace removes an existing namespace associated with a NamespaceMap, and returns whether it still exists or not.
-func (r *NamespaceMapReconciler) deleteNamespace(ctx context.Context, namespaceName, nmID string) (existing bool, err error) {
+// deleteNamespace removes an existing namespace associated with a NamespaceMap (or orphans it, in case it is requested
+// to be retained), and returns whether it is still associated with the NamespaceMap or not.
+func (r *NamespaceMapReconciler) deleteNamespace(ctx context.Context, namespaceName string, nm *vkv1alpha1.NamespaceMap) (existing bool, err error) {
+	nmID, err := cache.MetaNamespaceKeyFunc(nm)
+	utilruntime.Must(err)
+
 	var namespace corev1.Namespace
 	if err = r.Get(ctx, types.NamespacedName{Name: namespaceName}, &namespace); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -148,6 +329,14 @@ func (r *NamespaceMapReconciler) deleteNamespace(ctx context.Context, namespaceN
 		return true, nil
 	}
 
+	// Orphan the namespace, rather than deleting it, in case it is requested to be retained (and the retention is allowed).
+	if _, retain := namespace.Annotations[liqoconst.RemoteNamespaceRetainAnnotationKey]; retain && r.AllowRetention {
+		if err = r.orphanNamespace(ctx, &namespace, nm); err != nil {
+			return true, err
+		}
+		return false, nil
+	}
+
 	if err = r.Delete(ctx, &namespace); err != nil {
 		return true, fmt.Errorf("failed to delete namespace %q: %w", namespaceName, err)
 	}
@@ -156,20 +345,72 @@ func (r *NamespaceMapReconciler) deleteNamespace(ctx context.Context, namespaceN
 	return true, nil
 }
 
+// orphanNamespace releases a namespace associated with a NamespaceMap, which is requested to be retained upon removal.
+// The offloaded pods are terminated, and the permissions granted to the origin cluster are revoked, while the other
+// resources contained in the namespace are preserved. The namespace is labeled as orphaned, to be easily discoverable,
+// and marked with the orphaning time, so that it can be reclaimed once the configured TTL expires.
+func (r *NamespaceMapReconciler) orphanNamespace(ctx context.Context, namespace *corev1.Namespace, nm *vkv1alpha1.NamespaceMap) error {
+	// The label is guaranteed to exist, since it is part of the filter predicate.
+	origin := nm.Labels[liqoconst.ReplicationOriginLabel]
+	nmID, err := cache.MetaNamespaceKeyFunc(nm)
+	utilruntime.Must(err)
+
+	var shadowpods vkv1alpha1.ShadowPodList
+	if err = r.List(ctx, &shadowpods, client.InNamespace(namespace.GetName()),
+		client.MatchingLabels{forge.LiqoOriginClusterIDKey: origin}); err != nil {
+		return fmt.Errorf("failed to retrieve the shadow pods in namespace %q: %w", namespace.GetName(), err)
+	}
+
+	for i := range shadowpods.Items {
+		if err = r.Delete(ctx, &shadowpods.Items[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete shadow pod %q: %w", klog.KObj(&shadowpods.Items[i]), err)
+		}
+	}
+
+	// The role binding and the resource quota are named after the tenant namespace name.
+	binding := rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: namespace.GetName(), Name: nm.GetNamespace()}}
+	if err = r.Delete(ctx, &binding); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete role binding %q: %w", klog.KObj(&binding), err)
+	}
+
+	quota := corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Namespace: namespace.GetName(), Name: nm.GetNamespace()}}
+	if err = r.Delete(ctx, &quota); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete resource quota %q: %w", klog.KObj(&quota), err)
+	}
+
+	// Keep track of the NamespaceMap the namespace was associated with, to allow for its adoption in case it is offloaded again.
+	original := namespace.DeepCopy()
+	delete(namespace.Annotations, liqoconst.RemoteNamespaceManagedByAnnotationKey)
+	delete(namespace.Annotations, liqoconst.RemoteNamespaceRetainAnnotationKey)
+	delete(namespace.Labels, liqoconst.RemoteClusterID)
+	namespace.Annotations[liqoconst.RemoteNamespaceOrphanedByAnnotationKey] = nmID
+	namespace.Annotations[liqoconst.RemoteNamespaceOrphanedAtAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+	if namespace.Labels == nil {
+		namespace.Labels = map[string]string{}
+	}
+	namespace.Labels[liqoconst.RemoteNamespaceOrphanedLabelKey] = liqoconst.RemoteNamespaceOrphanedLabelValue
+
+	if err = r.Patch(ctx, namespace, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to orphan namespace %q: %w", namespace.GetName(), err)
+	}
+
+	klog.Infof("Namespace %q correctly orphaned, as requested to be retained", namespace.GetName())
+	return nil
+}
+
 // If DesiredMapping field has less entries than CurrentMapping, is necessary to remove some remote namespaces.
 // This function checks if remote namespaces requested to be deleted are really deleted.
 func (r *NamespaceMapReconciler) ensureNamespacesDeletion(ctx context.Context, nm *vkv1alpha1.NamespaceMap) error {
-	nmID, err := cache.MetaNamespaceKeyFunc(nm)
-	utilruntime.Must(err)
+	var err error
 
 	for originName, destinationStatus := range nm.Status.CurrentMapping {
 		if _, ok := nm.Spec.DesiredMapping[originName]; ok && nm.DeletionTimestamp.IsZero() {
 			continue
 		}
 
-		existing, deletionError := r.deleteNamespace(ctx, destinationStatus.RemoteNamespace, nmID)
-		if err != nil {
-			klog.Errorf("Namespace enforcement failure: %v", err)
+		existing, deletionError := r.deleteNamespace(ctx, destinationStatus.RemoteNamespace, nm)
+		if deletionError != nil {
+			klog.Errorf("Namespace enforcement failure: %v", deletionError)
 			err = deletionError
 			continue
 		}
@@ -196,6 +437,7 @@ func (r *NamespaceMapReconciler) EnsureNamespaces(ctx context.Context, nm *vkv1a
 
 	errorCreationPhase := r.ensureNamespacesExistence(ctx, nm)
 	errorDeletionPhase := r.ensureNamespacesDeletion(ctx, nm)
+	errorStatsPhase := r.enforceStatsClusterRoleBinding(ctx, nm, len(nm.Spec.DesiredMapping) > 0)
 
 	if err := r.Status().Update(ctx, nm); err != nil {
 		klog.Errorf("Failed to update the status of NamespaceMap %q: %v", klog.KObj(nm), err)
@@ -209,6 +451,9 @@ func (r *NamespaceMapReconciler) EnsureNamespaces(ctx context.Context, nm *vkv1a
 	if errorDeletionPhase != nil {
 		return fmt.Errorf("failed deleting remote namespaces: %w", errorDeletionPhase)
 	}
+	if errorStatsPhase != nil {
+		return errorStatsPhase
+	}
 
 	return nil
 }
@@ -226,9 +471,25 @@ func (r *NamespaceMapReconciler) NamespaceMapDeletionProcess(ctx context.Context
 
 	// If the NamespaceMap status is empty, then it is possible to remove the finalizer.
 	if errorDeletionPhase == nil && len(nm.Status.CurrentMapping) == 0 {
+		if err := r.enforceStatsClusterRoleBinding(ctx, nm, false); err != nil {
+			return err
+		}
 		return r.RemoveNamespaceMapControllerFinalizer(ctx, nm)
 	}
 
 	// Return the deletion phase error (if any), to ensure the process is retried.
 	return errorDeletionPhase
 }
+
+// isIdentityRevoked returns whether the identity granted to the given remote cluster is currently revoked.
+func (r *NamespaceMapReconciler) isIdentityRevoked(ctx context.Context, clusterID string) (bool, error) {
+	fc, err := foreignclusterutils.GetForeignClusterByID(ctx, r.Client, clusterID)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve ForeignCluster for cluster %q: %w", clusterID, err)
+	}
+	revoked, _, err := identitymanager.IsIdentityRevoked(ctx, r.Client, fc)
+	return revoked, err
+}
@@ -40,14 +40,32 @@ import (
 // NamespaceMapReconciler creates remote namespaces and updates NamespaceMaps Status.
 type NamespaceMapReconciler struct {
 	client.Client
+
+	// AllowedLabels and AllowedAnnotations are the keys of the labels and annotations the remote clusters are allowed
+	// to propagate to the namespaces created on their behalf, possibly terminating with the "*" wildcard (none, if empty).
+	AllowedLabels      []string
+	AllowedAnnotations []string
+	// MinimumPodSecurityLevel is the Pod Security Admission level enforced by default in the local cluster. The propagated
+	// labels configuring a less restrictive level are discarded (defaults to privileged, if empty).
+	MinimumPodSecurityLevel string
+	// GrantNodeStats enables granting the remote clusters access to the summary API of the local nodes, to retrieve the
+	// stats of their offloaded pods. It is disabled by default, as the access is not restricted to the pods of each cluster.
+	GrantNodeStats bool
+	// AllowRetention enables the remote clusters to request their namespaces to be orphaned, rather than deleted, upon removal.
+	// It is disabled by default, as the orphaned namespaces (and the resources they contain) keep consuming local resources.
+	AllowRetention bool
 }
 
 // cluster-role
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles,verbs=bind,resourceNames=liqo-virtual-kubelet-remote-stats
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=discovery.liqo.io,resources=foreignclusters,verbs=get;list;watch
 // +kubebuilder:rbac:groups=virtualkubelet.liqo.io,resources=namespacemaps,verbs=get;watch;list;update;patch;create;delete
 // +kubebuilder:rbac:groups=virtualkubelet.liqo.io,resources=namespacemaps/finalizers,verbs=get;update;patch
+// +kubebuilder:rbac:groups=virtualkubelet.liqo.io,resources=shadowpods,verbs=get;list;watch;delete
 
 // needed to approve the certificates
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=get;list;watch
@@ -116,5 +134,7 @@ func (r *NamespaceMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		// https://kubernetes.io/docs/concepts/overview/working-with-objects/owners-dependents/.
 		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(enqueuer)).
 		Watches(&source.Kind{Type: &rbacv1.RoleBinding{}}, handler.EnqueueRequestsFromMapFunc(enqueuer)).
+		Watches(&source.Kind{Type: &rbacv1.ClusterRoleBinding{}}, handler.EnqueueRequestsFromMapFunc(enqueuer)).
+		Watches(&source.Kind{Type: &corev1.ResourceQuota{}}, handler.EnqueueRequestsFromMapFunc(enqueuer)).
 		Complete(r)
 }
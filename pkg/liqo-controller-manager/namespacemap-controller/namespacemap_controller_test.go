@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -29,10 +30,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	ctrlutils "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/discovery"
 	namespacemapctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/namespacemap-controller"
 	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 )
 
 var _ = Describe("Enforcement logic", func() {
@@ -40,6 +44,7 @@ var _ = Describe("Enforcement logic", func() {
 		ctx           context.Context
 		clientBuilder fake.ClientBuilder
 		reconciler    namespacemapctrl.NamespaceMapReconciler
+		allowed       namespacemapctrl.NamespaceMapReconciler
 
 		nm  vkv1alpha1.NamespaceMap
 		err error
@@ -48,6 +53,7 @@ var _ = Describe("Enforcement logic", func() {
 	BeforeEach(func() {
 		ctx = context.Background()
 		clientBuilder = fake.ClientBuilder{}
+		allowed = namespacemapctrl.NamespaceMapReconciler{}
 		nm = vkv1alpha1.NamespaceMap{ObjectMeta: metav1.ObjectMeta{
 			Name: "name", Namespace: "tenant-namespace",
 			Labels: map[string]string{liqoconst.ReplicationOriginLabel: "origin"}},
@@ -55,7 +61,8 @@ var _ = Describe("Enforcement logic", func() {
 	})
 
 	JustBeforeEach(func() {
-		reconciler = namespacemapctrl.NamespaceMapReconciler{Client: clientBuilder.WithObjects(&nm).Build()}
+		reconciler = allowed
+		reconciler.Client = clientBuilder.WithObjects(&nm).Build()
 		_, err = reconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: client.ObjectKeyFromObject(&nm)})
 	})
 
@@ -103,6 +110,28 @@ var _ = Describe("Enforcement logic", func() {
 				Describe("perform checks", func() { SuccessWhenBody() })
 			})
 
+			When("the access to the node stats is not granted", func() {
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should ensure the stats clusterrolebinding is not present", func() {
+					var binding rbacv1.ClusterRoleBinding
+					err := reconciler.Get(ctx, types.NamespacedName{Name: liqoconst.RemoteStatsClusterRoleName + "-origin"}, &binding)
+					Expect(err).To(BeNotFound())
+				})
+			})
+
+			When("the access to the node stats is granted", func() {
+				BeforeEach(func() { allowed.GrantNodeStats = true })
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should correctly ensure the stats clusterrolebinding is present", func() {
+					var binding rbacv1.ClusterRoleBinding
+					Expect(reconciler.Get(ctx, types.NamespacedName{Name: liqoconst.RemoteStatsClusterRoleName + "-origin"}, &binding)).To(Succeed())
+					Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: "origin"}))
+					Expect(binding.RoleRef).To(Equal(
+						rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: liqoconst.RemoteStatsClusterRoleName}))
+				})
+			})
+
 			When("the namespace already exists and it is managed by the NamespaceMap", func() {
 				BeforeEach(func() {
 					namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-remote",
@@ -118,6 +147,172 @@ var _ = Describe("Enforcement logic", func() {
 				Describe("perform checks", func() { SuccessWhenBody() })
 			})
 
+			When("the identity of the origin cluster has been revoked", func() {
+				BeforeEach(func() {
+					fc := discoveryv1alpha1.ForeignCluster{
+						ObjectMeta: metav1.ObjectMeta{Name: "origin", Labels: map[string]string{discovery.ClusterIDLabel: "origin"}},
+						Spec: discoveryv1alpha1.ForeignClusterSpec{
+							ClusterIdentity:        discoveryv1alpha1.ClusterIdentity{ClusterID: "origin"},
+							RevokeIncomingIdentity: true,
+						},
+					}
+					binding := rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: "namespace-remote", Name: "tenant-namespace"}}
+					clientBuilder.WithObjects(&fc, &binding)
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should correctly ensure the namespace is present", func() {
+					var namespace corev1.Namespace
+					Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+				})
+				It("should ensure the rolebinding is not present", func() {
+					var binding rbacv1.RoleBinding
+					err := reconciler.Get(ctx, types.NamespacedName{Namespace: "namespace-remote", Name: "tenant-namespace"}, &binding)
+					Expect(err).To(BeNotFound())
+				})
+				It("should ensure the stats clusterrolebinding is not present", func() {
+					var binding rbacv1.ClusterRoleBinding
+					err := reconciler.Get(ctx, types.NamespacedName{Name: liqoconst.RemoteStatsClusterRoleName + "-origin"}, &binding)
+					Expect(err).To(BeNotFound())
+				})
+			})
+
+			When("a quota is requested for the namespace", func() {
+				BeforeEach(func() {
+					nm.Spec.Quotas = map[string]corev1.ResourceList{"namespace": {corev1.ResourceCPU: resource.MustParse("2")}}
+				})
+
+				Describe("perform checks", func() { SuccessWhenBody() })
+				It("should correctly ensure the resource quota is present", func() {
+					var quota corev1.ResourceQuota
+					Expect(reconciler.Get(ctx, types.NamespacedName{Namespace: "namespace-remote", Name: "tenant-namespace"}, &quota)).To(Succeed())
+					Expect(quota.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("2")))
+					Expect(quota.GetAnnotations()).To(HaveKeyWithValue(liqoconst.RemoteNamespaceManagedByAnnotationKey, "tenant-namespace/name"))
+				})
+			})
+
+			When("a quota is no longer requested for the namespace", func() {
+				BeforeEach(func() {
+					quota := corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Namespace: "namespace-remote", Name: "tenant-namespace"}}
+					clientBuilder.WithObjects(&quota)
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should ensure the resource quota is not present", func() {
+					var quota corev1.ResourceQuota
+					err := reconciler.Get(ctx, types.NamespacedName{Namespace: "namespace-remote", Name: "tenant-namespace"}, &quota)
+					Expect(err).To(BeNotFound())
+				})
+			})
+
+			When("the namespace has been previously orphaned by the NamespaceMap", func() {
+				BeforeEach(func() {
+					namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-remote",
+						Annotations: map[string]string{
+							liqoconst.RemoteNamespaceOrphanedByAnnotationKey:   "tenant-namespace/name",
+							liqoconst.RemoteNamespaceOrphanedAtAnnotationKey:   "2023-01-01T00:00:00Z",
+							liqoconst.RemoteNamespaceOriginalNameAnnotationKey: "namespace",
+						},
+						Labels: map[string]string{liqoconst.RemoteNamespaceOrphanedLabelKey: liqoconst.RemoteNamespaceOrphanedLabelValue},
+					}}
+					clientBuilder.WithObjects(&namespace)
+				})
+
+				Describe("perform checks", func() { SuccessWhenBody() })
+				It("should remove the orphaned marks", func() {
+					var namespace corev1.Namespace
+					Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+					Expect(namespace.GetAnnotations()).ToNot(HaveKey(liqoconst.RemoteNamespaceOrphanedByAnnotationKey))
+					Expect(namespace.GetAnnotations()).ToNot(HaveKey(liqoconst.RemoteNamespaceOrphanedAtAnnotationKey))
+					Expect(namespace.GetLabels()).ToNot(HaveKey(liqoconst.RemoteNamespaceOrphanedLabelKey))
+				})
+			})
+
+			When("metadata propagation and retention are requested for the namespace", func() {
+				BeforeEach(func() {
+					nm.Spec.Options = map[string]vkv1alpha1.RemoteNamespaceOptions{"namespace": {
+						Labels:      map[string]string{"pod-security.kubernetes.io/enforce": "restricted", liqoconst.RemoteClusterID: "foo"},
+						Annotations: map[string]string{"cost-center": "42"},
+						Retain:      true,
+					}}
+					allowed.AllowedLabels = []string{"pod-security.kubernetes.io/*"}
+					allowed.AllowedAnnotations = []string{"cost-center"}
+					allowed.AllowRetention = true
+
+					namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-remote",
+						Annotations: map[string]string{
+							liqoconst.RemoteNamespaceManagedByAnnotationKey:        "tenant-namespace/name",
+							liqoconst.RemoteNamespaceOriginalNameAnnotationKey:     "namespace",
+							liqoconst.RemoteNamespacePropagatedLabelsAnnotationKey: "pod-security.kubernetes.io/warn",
+						},
+						Labels: map[string]string{liqoconst.RemoteClusterID: "origin", "pod-security.kubernetes.io/warn": "baseline"},
+					}}
+					clientBuilder.WithObjects(&namespace)
+				})
+
+				Describe("perform checks", func() { SuccessWhenBody() })
+				It("should correctly propagate the labels", func() {
+					var namespace corev1.Namespace
+					Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+					Expect(namespace.GetLabels()).To(HaveKeyWithValue("pod-security.kubernetes.io/enforce", "restricted"))
+					Expect(namespace.GetLabels()).ToNot(HaveKey("pod-security.kubernetes.io/warn"))
+					Expect(namespace.GetAnnotations()).To(HaveKeyWithValue(
+						liqoconst.RemoteNamespacePropagatedLabelsAnnotationKey, "pod-security.kubernetes.io/enforce"))
+				})
+				It("should correctly propagate the annotations", func() {
+					var namespace corev1.Namespace
+					Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+					Expect(namespace.GetAnnotations()).To(HaveKeyWithValue("cost-center", "42"))
+					Expect(namespace.GetAnnotations()).To(HaveKeyWithValue(liqoconst.RemoteNamespacePropagatedAnnotationsAnnotationKey, "cost-center"))
+				})
+				It("should mark the namespace to be retained", func() {
+					var namespace corev1.Namespace
+					Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+					Expect(namespace.GetAnnotations()).To(HaveKeyWithValue(liqoconst.RemoteNamespaceRetainAnnotationKey, "true"))
+				})
+
+				When("the retention is not allowed", func() {
+					BeforeEach(func() { allowed.AllowRetention = false })
+
+					Describe("perform checks", func() { SuccessWhenBody() })
+					It("should not mark the namespace to be retained", func() {
+						var namespace corev1.Namespace
+						Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+						Expect(namespace.GetAnnotations()).ToNot(HaveKey(liqoconst.RemoteNamespaceRetainAnnotationKey))
+					})
+				})
+
+				When("the propagated metadata is not allowed", func() {
+					BeforeEach(func() { allowed = namespacemapctrl.NamespaceMapReconciler{} })
+
+					Describe("perform checks", func() { SuccessWhenBody() })
+					It("should not propagate any label or annotation", func() {
+						var namespace corev1.Namespace
+						Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+						Expect(namespace.GetLabels()).ToNot(HaveKey("pod-security.kubernetes.io/enforce"))
+						Expect(namespace.GetLabels()).ToNot(HaveKey("pod-security.kubernetes.io/warn"))
+						Expect(namespace.GetAnnotations()).ToNot(HaveKey("cost-center"))
+						Expect(namespace.GetAnnotations()).ToNot(HaveKey(liqoconst.RemoteNamespacePropagatedLabelsAnnotationKey))
+						Expect(namespace.GetAnnotations()).ToNot(HaveKey(liqoconst.RemoteNamespacePropagatedAnnotationsAnnotationKey))
+					})
+				})
+
+				When("the propagated labels lower the Pod Security Admission level below the default one", func() {
+					BeforeEach(func() {
+						nm.Spec.Options["namespace"].Labels["pod-security.kubernetes.io/enforce"] = "privileged"
+						allowed.MinimumPodSecurityLevel = "baseline"
+					})
+
+					Describe("perform checks", func() { SuccessWhenBody() })
+					It("should discard them", func() {
+						var namespace corev1.Namespace
+						Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+						Expect(namespace.GetLabels()).ToNot(HaveKey("pod-security.kubernetes.io/enforce"))
+						Expect(namespace.GetAnnotations()).ToNot(HaveKey(liqoconst.RemoteNamespacePropagatedLabelsAnnotationKey))
+					})
+				})
+			})
+
 			When("the namespace already exists but it is not managed by the NamespaceMap", func() {
 				BeforeEach(func() {
 					namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-remote"}}
@@ -223,6 +418,72 @@ var _ = Describe("Enforcement logic", func() {
 				})
 			})
 
+			When("the namespace exists and it is requested to be retained", func() {
+				BeforeEach(func() {
+					namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-remote",
+						Annotations: map[string]string{
+							liqoconst.RemoteNamespaceManagedByAnnotationKey:    "tenant-namespace/name",
+							liqoconst.RemoteNamespaceOriginalNameAnnotationKey: "namespace",
+							liqoconst.RemoteNamespaceRetainAnnotationKey:       "true",
+						},
+						Labels: map[string]string{liqoconst.RemoteClusterID: "origin"},
+					}}
+					binding := rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: "namespace-remote", Name: "tenant-namespace"}}
+					shadowpod := vkv1alpha1.ShadowPod{ObjectMeta: metav1.ObjectMeta{Namespace: "namespace-remote", Name: "shadow",
+						Labels: map[string]string{forge.LiqoOriginClusterIDKey: "origin"}}}
+					other := vkv1alpha1.ShadowPod{ObjectMeta: metav1.ObjectMeta{Namespace: "namespace-remote", Name: "other",
+						Labels: map[string]string{forge.LiqoOriginClusterIDKey: "other"}}}
+					clientBuilder.WithObjects(&namespace, &binding, &shadowpod, &other)
+					allowed.AllowRetention = true
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should not delete the namespace, and mark it as orphaned", func() {
+					var namespace corev1.Namespace
+					Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(Succeed())
+					Expect(namespace.GetAnnotations()).To(HaveKeyWithValue(liqoconst.RemoteNamespaceOrphanedByAnnotationKey, "tenant-namespace/name"))
+					Expect(namespace.GetAnnotations()).To(HaveKey(liqoconst.RemoteNamespaceOrphanedAtAnnotationKey))
+					Expect(namespace.GetLabels()).To(HaveKeyWithValue(liqoconst.RemoteNamespaceOrphanedLabelKey, liqoconst.RemoteNamespaceOrphanedLabelValue))
+					Expect(namespace.GetAnnotations()).ToNot(HaveKey(liqoconst.RemoteNamespaceManagedByAnnotationKey))
+					Expect(namespace.GetAnnotations()).ToNot(HaveKey(liqoconst.RemoteNamespaceRetainAnnotationKey))
+					Expect(namespace.GetLabels()).ToNot(HaveKey(liqoconst.RemoteClusterID))
+				})
+				It("should ensure the rolebinding is not present", func() {
+					var binding rbacv1.RoleBinding
+					err := reconciler.Get(ctx, types.NamespacedName{Namespace: "namespace-remote", Name: "tenant-namespace"}, &binding)
+					Expect(err).To(BeNotFound())
+				})
+				It("should delete only the shadow pods of the origin cluster", func() {
+					var shadowpod vkv1alpha1.ShadowPod
+					Expect(reconciler.Get(ctx, types.NamespacedName{Namespace: "namespace-remote", Name: "shadow"}, &shadowpod)).To(BeNotFound())
+					Expect(reconciler.Get(ctx, types.NamespacedName{Namespace: "namespace-remote", Name: "other"}, &shadowpod)).To(Succeed())
+				})
+				It("should correctly update the NamespaceMap status", func() {
+					var updated vkv1alpha1.NamespaceMap
+					Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(&nm), &updated)).To(Succeed())
+					Expect(updated.Status.CurrentMapping).ToNot(HaveKey("namespace"))
+				})
+			})
+
+			When("the namespace exists and it is requested to be retained, but the retention is not allowed", func() {
+				BeforeEach(func() {
+					namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-remote",
+						Annotations: map[string]string{
+							liqoconst.RemoteNamespaceManagedByAnnotationKey:    "tenant-namespace/name",
+							liqoconst.RemoteNamespaceOriginalNameAnnotationKey: "namespace",
+							liqoconst.RemoteNamespaceRetainAnnotationKey:       "true",
+						},
+					}}
+					clientBuilder.WithObjects(&namespace)
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should delete the namespace", func() {
+					var namespace corev1.Namespace
+					Expect(reconciler.Get(ctx, types.NamespacedName{Name: "namespace-remote"}, &namespace)).To(BeNotFound())
+				})
+			})
+
 			When("the namespace exists but it is not managed by the NamespaceMap", func() {
 				BeforeEach(func() {
 					namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-remote"}}
@@ -266,9 +527,16 @@ var _ = Describe("Enforcement logic", func() {
 					"first":  {RemoteNamespace: "first-remote", Phase: vkv1alpha1.MappingTerminating},
 					"second": {RemoteNamespace: "second-remote", Phase: vkv1alpha1.MappingTerminating},
 				}
+				binding := rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: liqoconst.RemoteStatsClusterRoleName + "-origin"}}
+				clientBuilder.WithObjects(&binding)
 			})
 
 			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should ensure the stats clusterrolebinding is not present", func() {
+				var binding rbacv1.ClusterRoleBinding
+				err := reconciler.Get(ctx, types.NamespacedName{Name: liqoconst.RemoteStatsClusterRoleName + "-origin"}, &binding)
+				Expect(err).To(BeNotFound())
+			})
 			It("should have removed the finalizer", func() {
 				var updated vkv1alpha1.NamespaceMap
 				Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(&nm), &updated)).To(BeNotFound())
@@ -0,0 +1,101 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespacemapctrl
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+// OrphanedNamespaceReconciler deletes the remote namespaces orphaned upon removal (i.e., retained on behalf of a remote
+// cluster), once the given TTL has expired since they have been orphaned, unless they are adopted again in the meanwhile.
+type OrphanedNamespaceReconciler struct {
+	client.Client
+
+	// TTL is the period after which the orphaned namespaces are deleted.
+	TTL time.Duration
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;patch;delete
+
+// Reconcile deletes the given orphaned namespace, in case its TTL has expired.
+func (r *OrphanedNamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var namespace corev1.Namespace
+	if err := r.Get(ctx, req.NamespacedName, &namespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("Namespace %q does not exist anymore", req.Name)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve namespace %q: %v", req.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	if namespace.Labels[liqoconst.RemoteNamespaceOrphanedLabelKey] != liqoconst.RemoteNamespaceOrphanedLabelValue ||
+		!namespace.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// The TTL starts from now in case the orphaning time is missing or invalid (e.g., since manually modified).
+	orphaned, err := time.Parse(time.RFC3339, namespace.Annotations[liqoconst.RemoteNamespaceOrphanedAtAnnotationKey])
+	if err != nil {
+		klog.Warningf("Invalid %q annotation on namespace %q, resetting it: %v", liqoconst.RemoteNamespaceOrphanedAtAnnotationKey, namespace.Name, err)
+		original := namespace.DeepCopy()
+		if namespace.Annotations == nil {
+			namespace.Annotations = map[string]string{}
+		}
+		namespace.Annotations[liqoconst.RemoteNamespaceOrphanedAtAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+		if err = r.Patch(ctx, &namespace, client.MergeFrom(original)); err != nil {
+			klog.Errorf("Failed to reset the orphaning time of namespace %q: %v", namespace.Name, err)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.TTL}, nil
+	}
+
+	if remaining := time.Until(orphaned.Add(r.TTL)); remaining > 0 {
+		klog.V(4).Infof("Orphaned namespace %q to be deleted in %s", namespace.Name, remaining.Round(time.Second))
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	if err = r.Delete(ctx, &namespace); client.IgnoreNotFound(err) != nil {
+		klog.Errorf("Failed to delete orphaned namespace %q: %v", namespace.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	klog.Infof("Orphaned namespace %q correctly marked for termination, as retained for longer than %s", namespace.Name, r.TTL)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager monitors the orphaned namespaces.
+func (r *OrphanedNamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	filter := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetLabels()[liqoconst.RemoteNamespaceOrphanedLabelKey] == liqoconst.RemoteNamespaceOrphanedLabelValue
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("orphaned-namespace").
+		For(&corev1.Namespace{}, builder.WithPredicates(filter)).
+		Complete(r)
+}
@@ -0,0 +1,106 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespacemapctrl_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	namespacemapctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/namespacemap-controller"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("Orphaned namespaces reclaiming", func() {
+	const name = "namespace-remote"
+
+	var (
+		ctx        context.Context
+		namespace  *corev1.Namespace
+		reconciler *namespacemapctrl.OrphanedNamespaceReconciler
+		result     controllerruntime.Result
+		err        error
+	)
+
+	orphaned := func(since string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name,
+			Labels:      map[string]string{liqoconst.RemoteNamespaceOrphanedLabelKey: liqoconst.RemoteNamespaceOrphanedLabelValue},
+			Annotations: map[string]string{liqoconst.RemoteNamespaceOrphanedAtAnnotationKey: since},
+		}}
+	}
+
+	get := func() error {
+		var ns corev1.Namespace
+		return reconciler.Get(ctx, types.NamespacedName{Name: name}, &ns)
+	}
+
+	JustBeforeEach(func() {
+		ctx = context.Background()
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(namespace).Build()
+		reconciler = &namespacemapctrl.OrphanedNamespaceReconciler{Client: cl, TTL: time.Hour}
+		result, err = reconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: client.ObjectKeyFromObject(namespace)})
+	})
+
+	When("the TTL of the orphaned namespace has expired", func() {
+		BeforeEach(func() { namespace = orphaned(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)) })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should delete the namespace", func() { Expect(get()).To(BeNotFound()) })
+	})
+
+	When("the TTL of the orphaned namespace has not yet expired", func() {
+		BeforeEach(func() { namespace = orphaned(time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339)) })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not delete the namespace", func() { Expect(get()).To(Succeed()) })
+		It("should requeue the namespace once the TTL expires", func() {
+			Expect(result.RequeueAfter).To(BeNumerically("~", 30*time.Minute, time.Minute))
+		})
+	})
+
+	When("the orphaning time is invalid", func() {
+		BeforeEach(func() { namespace = orphaned("invalid") })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not delete the namespace", func() { Expect(get()).To(Succeed()) })
+		It("should reset the orphaning time", func() {
+			var ns corev1.Namespace
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: name}, &ns)).To(Succeed())
+			Expect(time.Parse(time.RFC3339, ns.Annotations[liqoconst.RemoteNamespaceOrphanedAtAnnotationKey])).To(
+				BeTemporally("~", time.Now(), time.Minute))
+			Expect(result.RequeueAfter).To(Equal(time.Hour))
+		})
+	})
+
+	When("the namespace is no longer orphaned", func() {
+		BeforeEach(func() {
+			namespace = orphaned(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339))
+			namespace.Labels = nil
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not delete the namespace", func() { Expect(get()).To(Succeed()) })
+	})
+})
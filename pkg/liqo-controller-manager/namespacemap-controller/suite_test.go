@@ -21,6 +21,7 @@ import (
 	. "github.com/onsi/gomega"
 	"k8s.io/client-go/kubernetes/scheme"
 
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	"github.com/liqotech/liqo/pkg/utils/testutil"
 )
@@ -32,6 +33,7 @@ func TestNamespacemapController(t *testing.T) {
 
 var _ = BeforeSuite(func() {
 	Expect(vkv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+	Expect(discoveryv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
 
 	testutil.LogsToGinkgoWriter()
 })
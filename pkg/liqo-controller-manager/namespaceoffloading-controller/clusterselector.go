@@ -21,7 +21,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	k8shelper "k8s.io/component-helpers/scheduling/corev1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -29,6 +28,8 @@ import (
 	mapsv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	"github.com/liqotech/liqo/internal/crdReplicator/reflection"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	liqoutils "github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
 )
 
 func (r *NamespaceOffloadingReconciler) enforceClusterSelector(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading,
@@ -43,9 +44,22 @@ func (r *NamespaceOffloadingReconciler) enforceClusterSelector(ctx context.Conte
 		return fmt.Errorf("the number of virtual nodes (%v) does not match that of NamespaceMaps (%v)", len(virtualNodes.Items), len(clusterIDMap))
 	}
 
+	remoteNamespaceName, nameErr := r.remoteNamespaceName(ctx, nsoff)
+	if nameErr != nil {
+		r.Recorder.Eventf(nsoff, corev1.EventTypeWarning, "Invalid", "Failed to compute the remote namespace name: %v", nameErr)
+		return fmt.Errorf("failed to compute the remote namespace name: %w", nameErr)
+	}
+
+	options, optionsErr := r.remoteNamespaceOptions(ctx, nsoff)
+	if optionsErr != nil {
+		return fmt.Errorf("failed to compute the remote namespace options: %w", optionsErr)
+	}
+
 	var returnErr error
+	var hosted map[string][]*corev1.Pod
+	migrations := map[string]int32{}
 	for i := range virtualNodes.Items {
-		match, err := matchNodeSelectorTerms(&virtualNodes.Items[i], &nsoff.Spec.ClusterSelector)
+		match, err := liqoutils.MatchNodeSelectorTerms(&virtualNodes.Items[i], &nsoff.Spec.ClusterSelector)
 		if err != nil {
 			r.Recorder.Eventf(nsoff, corev1.EventTypeWarning, "Invalid", "Invalid ClusterSelector: %v", err)
 			// We end the processing here, as this error will be triggered for all the virtual nodes.
@@ -53,21 +67,43 @@ func (r *NamespaceOffloadingReconciler) enforceClusterSelector(ctx context.Conte
 		}
 
 		if match {
-			if err = addDesiredMapping(ctx, r.Client, nsoff.Namespace, r.remoteNamespaceName(nsoff),
+			if err = addDesiredMapping(ctx, r.Client, nsoff.Namespace, remoteNamespaceName, nsoff.Spec.RemoteClusterQuota, options,
 				clusterIDMap[virtualNodes.Items[i].Labels[liqoconst.RemoteClusterID]]); err != nil {
 				returnErr = fmt.Errorf("failed to configure all desired mappings")
 				continue
 			}
 		} else {
+			clusterID := virtualNodes.Items[i].Labels[liqoconst.RemoteClusterID]
+			nm := clusterIDMap[clusterID]
+
+			// Retain the existing mappings until the pods hosted by the remote cluster are migrated, depending on the migration policy.
+			if _, mapped := nm.Spec.DesiredMapping[nsoff.Namespace]; mapped && retainsDeselectedClusters(nsoff) {
+				if hosted == nil {
+					if hosted, err = r.hostedPods(ctx, nsoff.Namespace); err != nil {
+						// Do not remove the mapping, as it is not possible to determine whether any pod is still hosted.
+						returnErr = fmt.Errorf("failed to configure all desired mappings: %w", err)
+						continue
+					}
+				}
+
+				if remaining := r.enforceMigrationPolicy(ctx, nsoff, clusterID, hosted[clusterID]); remaining > 0 {
+					migrations[clusterID] = remaining
+					continue
+				}
+			}
+
 			// Ensure old mappings are removed in case the cluster selector is updated.
-			if err = removeDesiredMapping(ctx, r.Client, nsoff.Namespace,
-				clusterIDMap[virtualNodes.Items[i].Labels[liqoconst.RemoteClusterID]]); err != nil {
+			if err = removeDesiredMapping(ctx, r.Client, nsoff.Namespace, nm); err != nil {
 				returnErr = fmt.Errorf("failed to configure all desired mappings")
 				continue
 			}
 		}
 	}
 
+	nsoff.Status.PendingMigrations = nil
+	if len(migrations) > 0 {
+		nsoff.Status.PendingMigrations = distribution.ToClusterReplicas(migrations)
+	}
 	return returnErr
 }
 
@@ -93,12 +129,3 @@ func (r *NamespaceOffloadingReconciler) getClusterIDMap(ctx context.Context) (ma
 	}
 	return clusterIDMap, nil
 }
-
-func matchNodeSelectorTerms(node *corev1.Node, selector *corev1.NodeSelector) (bool, error) {
-	// Shortcircuit the matching logic, to always return a positive outcome in case no selector is specified.
-	if len(selector.NodeSelectorTerms) == 0 {
-		return true, nil
-	}
-
-	return k8shelper.MatchNodeSelectorTerms(node, selector)
-}
@@ -32,10 +32,11 @@ func (r *NamespaceOffloadingReconciler) deletionLogic(ctx context.Context,
 	if err := r.enforceSchedulingLabelAbsence(ctx, nsoff.Namespace); err != nil {
 		return err
 	}
-	// 2 - remove the involved DesiredMapping from the NamespaceMap.
+	// 2 - remove the involved DesiredMapping from the NamespaceMap, regardless of any pending migration.
 	if err := removeDesiredMappings(ctx, r.Client, nsoff.Namespace, clusterIDMap); err != nil {
 		return err
 	}
+	nsoff.Status.PendingMigrations = nil
 	// 3 - check if all remote namespaces associated with this NamespaceOffloading resource are really deleted.
 	if len(nsoff.Status.RemoteNamespacesConditions) != 0 {
 		err := fmt.Errorf("waiting for remote namespaces deletion")
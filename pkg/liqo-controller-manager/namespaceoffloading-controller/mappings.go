@@ -17,11 +17,17 @@ package nsoffctrl
 import (
 	"context"
 	"fmt"
+	"reflect"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	quotav1 "k8s.io/apiserver/pkg/quota/v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
 	mapsv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/namespacemapping"
 )
 
 // Removes right entry from one NamespaceMap, if present.
@@ -29,6 +35,8 @@ func removeDesiredMapping(ctx context.Context, c client.Client, localName string
 	if _, ok := nm.Spec.DesiredMapping[localName]; ok {
 		original := nm.DeepCopy()
 		delete(nm.Spec.DesiredMapping, localName)
+		delete(nm.Spec.Quotas, localName)
+		delete(nm.Spec.Options, localName)
 		if err := c.Patch(ctx, nm, client.MergeFrom(original)); err != nil {
 			klog.Errorf("Unable to remove entry for namespace %q from NamespaceMap %q: %v", localName, nm.GetName(), err)
 			return err
@@ -53,15 +61,35 @@ func removeDesiredMappings(ctx context.Context, c client.Client, localName strin
 	return nil
 }
 
-// Adds right entry on one NamespaceMap, if it isn't already there.
-func addDesiredMapping(ctx context.Context, c client.Client, localName, remoteName string, nm *mapsv1alpha1.NamespaceMap) error {
+// Adds right entry (and the corresponding quota and options, if any) on one NamespaceMap, if it isn't already there.
+func addDesiredMapping(ctx context.Context, c client.Client, localName, remoteName string,
+	quota corev1.ResourceList, options *mapsv1alpha1.RemoteNamespaceOptions, nm *mapsv1alpha1.NamespaceMap) error {
 	if nm.Spec.DesiredMapping == nil {
 		nm.Spec.DesiredMapping = map[string]string{}
 	}
 
-	if current, ok := nm.Spec.DesiredMapping[localName]; !ok || current != remoteName {
+	current, ok := nm.Spec.DesiredMapping[localName]
+	currentOptions, hasOptions := nm.Spec.Options[localName]
+	optionsChanged := hasOptions != (options != nil) || (options != nil && !reflect.DeepEqual(currentOptions, *options))
+	if !ok || current != remoteName || !quotav1.Equals(nm.Spec.Quotas[localName], quota) || optionsChanged {
 		original := nm.DeepCopy()
 		nm.Spec.DesiredMapping[localName] = remoteName
+		if len(quota) > 0 {
+			if nm.Spec.Quotas == nil {
+				nm.Spec.Quotas = map[string]corev1.ResourceList{}
+			}
+			nm.Spec.Quotas[localName] = quota
+		} else {
+			delete(nm.Spec.Quotas, localName)
+		}
+		if options != nil {
+			if nm.Spec.Options == nil {
+				nm.Spec.Options = map[string]mapsv1alpha1.RemoteNamespaceOptions{}
+			}
+			nm.Spec.Options[localName] = *options
+		} else {
+			delete(nm.Spec.Options, localName)
+		}
 		if err := c.Patch(ctx, nm, client.MergeFrom(original)); err != nil {
 			klog.Errorf("Unable to add entry for namespace %q to NamespaceMap %q: %v", localName, nm.GetName(), err)
 			return err
@@ -71,3 +99,17 @@ func addDesiredMapping(ctx context.Context, c client.Client, localName, remoteNa
 	}
 	return nil
 }
+
+// remoteNamespaceOptions returns the options to be enforced on the remote namespaces, depending on the
+// RemoteNamespaceRetentionPolicy and on the metadata of the local namespace to be propagated.
+func (r *NamespaceOffloadingReconciler) remoteNamespaceOptions(ctx context.Context,
+	nsoff *offv1alpha1.NamespaceOffloading) (*mapsv1alpha1.RemoteNamespaceOptions, error) {
+	namespace := &corev1.Namespace{}
+	if len(nsoff.Spec.PropagatedLabels) > 0 || len(nsoff.Spec.PropagatedAnnotations) > 0 {
+		if err := r.Get(ctx, types.NamespacedName{Name: nsoff.Namespace}, namespace); err != nil {
+			return nil, fmt.Errorf("failed to retrieve namespace %q: %w", nsoff.Namespace, err)
+		}
+	}
+
+	return namespacemapping.RemoteNamespaceOptions(nsoff, namespace), nil
+}
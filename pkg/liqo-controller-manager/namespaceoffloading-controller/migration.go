@@ -0,0 +1,116 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsoffctrl
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+// retainsDeselectedClusters returns whether the remote namespaces associated with clusters no longer selected
+// shall be retained until the pods they host are migrated, according to the configured migration policy.
+func retainsDeselectedClusters(nsoff *offv1alpha1.NamespaceOffloading) bool {
+	return nsoff.Spec.MigrationPolicy == offv1alpha1.DrainMigrationPolicyType ||
+		nsoff.Spec.MigrationPolicy == offv1alpha1.RetainMigrationPolicyType
+}
+
+// isMigrationPending returns whether the given remote cluster is no longer selected, but still hosts pods to be migrated.
+func isMigrationPending(nsoff *offv1alpha1.NamespaceOffloading, clusterID string) bool {
+	for i := range nsoff.Status.PendingMigrations {
+		if nsoff.Status.PendingMigrations[i].ClusterID == clusterID {
+			return true
+		}
+	}
+	return false
+}
+
+// hostedPods returns the pods of the given namespace which are not yet terminated, grouped by the remote cluster hosting them.
+func (r *NamespaceOffloadingReconciler) hostedPods(ctx context.Context, namespace string) (map[string][]*corev1.Pod, error) {
+	var pods corev1.PodList
+	if err := r.APIReader.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %q: %w", namespace, err)
+	}
+
+	hosted := map[string][]*corev1.Pod{}
+	resolver := distribution.NewClusterResolver(r.Client, r.LocalCluster.ClusterID)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		clusterID, found, err := resolver.ClusterForPod(ctx, pod)
+		if err != nil {
+			return nil, err
+		}
+		if found && clusterID != r.LocalCluster.ClusterID {
+			hosted[clusterID] = append(hosted[clusterID], pod)
+		}
+	}
+	return hosted, nil
+}
+
+// enforceMigrationPolicy handles the pods hosted by a remote cluster which is no longer selected, according to the
+// configured migration policy, evicting them in case of the Drain policy. Evictions violating the PodDisruptionBudgets
+// are retried at the next resync. It returns the number of pods still hosted by the remote cluster.
+func (r *NamespaceOffloadingReconciler) enforceMigrationPolicy(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading,
+	clusterID string, pods []*corev1.Pod) int32 {
+	pending := isMigrationPending(nsoff, clusterID)
+	switch {
+	case len(pods) == 0 && pending:
+		r.Recorder.Eventf(nsoff, corev1.EventTypeNormal, "MigrationCompleted",
+			"All pods hosted by remote cluster %q have been migrated", clusterID)
+	case len(pods) > 0 && !pending:
+		r.Recorder.Eventf(nsoff, corev1.EventTypeNormal, "MigrationStarted",
+			"Remote cluster %q is no longer selected: retaining the remote namespace until %d pod(s) are migrated (%v policy)",
+			clusterID, len(pods), nsoff.Spec.MigrationPolicy)
+	}
+
+	if nsoff.Spec.MigrationPolicy != offv1alpha1.DrainMigrationPolicyType {
+		return int32(len(pods))
+	}
+
+	for _, pod := range pods {
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.GetName(), Namespace: pod.GetNamespace()}}
+		if err := r.SubResource("eviction").Create(ctx, pod, eviction); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			// The eviction is denied in case it would violate a PodDisruptionBudget, and it will be retried later.
+			klog.Warningf("Failed to evict pod %q from remote cluster %q: %v", klog.KObj(pod), clusterID, err)
+			r.Recorder.Eventf(nsoff, corev1.EventTypeWarning, "EvictionFailed",
+				"Failed to evict pod %q from remote cluster %q: %v", pod.GetName(), clusterID, err)
+			continue
+		}
+
+		klog.Infof("Pod %q evicted from remote cluster %q, as no longer selected", klog.KObj(pod), clusterID)
+	}
+
+	return int32(len(pods))
+}
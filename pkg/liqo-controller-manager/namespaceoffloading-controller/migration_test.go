@@ -0,0 +1,108 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsoffctrl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	mapsv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Migration policy", func() {
+	var (
+		mctx       context.Context
+		reconciler *NamespaceOffloadingReconciler
+		recorder   *record.FakeRecorder
+		nsoffm     *offv1alpha1.NamespaceOffloading
+	)
+
+	pod := func(name, node string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespaceName},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	BeforeEach(func() {
+		mctx = context.Background()
+		recorder = record.NewFakeRecorder(10)
+		nsoffm = &offv1alpha1.NamespaceOffloading{
+			ObjectMeta: metav1.ObjectMeta{Name: liqoconst.DefaultNamespaceOffloadingName, Namespace: namespaceName},
+			Spec:       offv1alpha1.NamespaceOffloadingSpec{MigrationPolicy: offv1alpha1.RetainMigrationPolicyType},
+		}
+
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: virtualNode1Name, Labels: map[string]string{
+			liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: remoteCluster1.ClusterID}}}
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(node,
+			pod("running", virtualNode1Name, corev1.PodRunning), pod("completed", virtualNode1Name, corev1.PodSucceeded),
+			pod("pending", "", corev1.PodPending)).Build()
+		reconciler = &NamespaceOffloadingReconciler{Client: cl, APIReader: cl, Recorder: recorder, LocalCluster: localCluster}
+	})
+
+	It("should retain the remote namespaces only with the Drain and Retain policies", func() {
+		Expect(retainsDeselectedClusters(nsoffm)).To(BeTrue())
+		nsoffm.Spec.MigrationPolicy = offv1alpha1.DrainMigrationPolicyType
+		Expect(retainsDeselectedClusters(nsoffm)).To(BeTrue())
+		nsoffm.Spec.MigrationPolicy = offv1alpha1.ImmediateMigrationPolicyType
+		Expect(retainsDeselectedClusters(nsoffm)).To(BeFalse())
+	})
+
+	It("should group the pods not yet terminated by the remote cluster hosting them", func() {
+		hosted, err := reconciler.hostedPods(mctx, namespaceName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hosted).To(HaveLen(1))
+		Expect(hosted[remoteCluster1.ClusterID]).To(HaveLen(1))
+		Expect(hosted[remoteCluster1.ClusterID][0].GetName()).To(Equal("running"))
+	})
+
+	It("should report the start and the completion of the migration", func() {
+		hosted, err := reconciler.hostedPods(mctx, namespaceName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reconciler.enforceMigrationPolicy(mctx, nsoffm, remoteCluster1.ClusterID, hosted[remoteCluster1.ClusterID])).To(BeNumerically("==", 1))
+		Expect(recorder.Events).To(Receive(ContainSubstring("MigrationStarted")))
+
+		nsoffm.Status.PendingMigrations = []offv1alpha1.ClusterReplicas{{ClusterID: remoteCluster1.ClusterID, Replicas: 1}}
+		Expect(reconciler.enforceMigrationPolicy(mctx, nsoffm, remoteCluster1.ClusterID, nil)).To(BeNumerically("==", 0))
+		Expect(recorder.Events).To(Receive(ContainSubstring("MigrationCompleted")))
+	})
+
+	It("should not account the remote clusters with pending migrations in the global status", func() {
+		nm := &mapsv1alpha1.NamespaceMap{
+			ObjectMeta: metav1.ObjectMeta{Name: remoteCluster1.ClusterName, Labels: map[string]string{liqoconst.RemoteClusterID: remoteCluster1.ClusterID}},
+			Spec:       mapsv1alpha1.NamespaceMapSpec{DesiredMapping: map[string]string{namespaceName: namespaceName}},
+			Status: mapsv1alpha1.NamespaceMapStatus{CurrentMapping: map[string]mapsv1alpha1.RemoteNamespaceStatus{
+				namespaceName: {RemoteNamespace: namespaceName, Phase: mapsv1alpha1.MappingAccepted}}},
+		}
+		nsoffm.Status.PendingMigrations = []offv1alpha1.ClusterReplicas{{ClusterID: remoteCluster1.ClusterID, Replicas: 1}}
+
+		required, ready, failed := setRemoteConditionsForEveryCluster(nsoffm, map[string]*mapsv1alpha1.NamespaceMap{remoteCluster1.ClusterID: nm})
+		Expect(required).To(BeNumerically("==", 0))
+		Expect(ready).To(BeNumerically("==", 0))
+		Expect(failed).To(BeNumerically("==", 0))
+		Expect(nsoffm.Status.RemoteNamespacesConditions[remoteCluster1.ClusterName]).To(ContainElement(And(
+			HaveField("Type", offv1alpha1.NamespaceOffloadingRequired), HaveField("Reason", "MigrationPending"))))
+	})
+})
@@ -16,7 +16,9 @@ package nsoffctrl
 
 import (
 	"context"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -43,6 +45,8 @@ type NamespaceOffloadingReconciler struct {
 	client.Client
 	Recorder     record.EventRecorder
 	LocalCluster discoveryv1alpha1.ClusterIdentity
+	// APIReader is a non-cached reader, used to retrieve the pods (which are only partially cached by the manager).
+	APIReader client.Reader
 
 	// namespaces tracks the set of namespaces for which a NamespaceOffloading resource exists.
 	namespaces *syncset.SyncSet
@@ -50,6 +54,10 @@ type NamespaceOffloadingReconciler struct {
 
 const (
 	namespaceOffloadingControllerFinalizer = "namespaceoffloading-controller.liqo.io/finalizer"
+
+	// podsStatusResyncPeriod is the period the replica distribution, the remote quota usage and the pending migrations
+	// are refreshed with, as pods are not watched.
+	podsStatusResyncPeriod = 30 * time.Second
 )
 
 // cluster-role
@@ -59,6 +67,8 @@ const (
 // +kubebuilder:rbac:groups=virtualkubelet.liqo.io,resources=namespacemaps,verbs=get;list;watch;patch;update
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile implements the NamespaceOffloading reconciliation logic.
@@ -113,13 +123,19 @@ func (r *NamespaceOffloadingReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
+	if len(nsoff.Spec.ReplicaWeights) > 0 || len(nsoff.Spec.RemoteClusterQuota) > 0 || len(nsoff.Status.PendingMigrations) > 0 {
+		// Periodically reconcile the resource, to keep the replica distribution and the quota usage reported in the status up-to-date,
+		// as well as to complete the pending migrations.
+		result.RequeueAfter = podsStatusResyncPeriod
+	}
+
 	switch nsoff.Spec.PodOffloadingStrategy {
 	case offv1alpha1.LocalAndRemotePodOffloadingStrategyType, offv1alpha1.RemotePodOffloadingStrategyType:
 		// If the offloading policy includes remote clusters, then ensure the corresponding namespace has the liqo scheduling label.
-		return ctrl.Result{}, r.enforceSchedulingLabelPresence(ctx, nsoff.Namespace)
+		return result, r.enforceSchedulingLabelPresence(ctx, nsoff.Namespace)
 	default:
 		// Otherwise, ensure the label is not present.
-		return ctrl.Result{}, r.enforceSchedulingLabelAbsence(ctx, nsoff.Namespace)
+		return result, r.enforceSchedulingLabelAbsence(ctx, nsoff.Namespace)
 	}
 }
 
@@ -134,9 +150,20 @@ func (r *NamespaceOffloadingReconciler) SetupWithManager(mgr ctrl.Manager) error
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&offv1alpha1.NamespaceOffloading{}, builder.WithPredicates(filter)).
 		Watches(&source.Kind{Type: &mapsv1alpha1.NamespaceMap{}}, r.namespaceMapHandlers()).
+		// Watch the namespaces, to propagate the modifications of their labels and annotations to the remote namespaces.
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(namespaceEnqueuer),
+			builder.WithPredicates(predicate.Or(predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
 		Complete(r)
 }
 
+// namespaceEnqueuer maps a namespace to the NamespaceOffloading possibly associated with it.
+func namespaceEnqueuer(obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name:      liqoconst.DefaultNamespaceOffloadingName,
+		Namespace: obj.GetName(),
+	}}}
+}
+
 func (r *NamespaceOffloadingReconciler) namespaceMapHandlers() handler.EventHandler {
 	enqueue := func(rli workqueue.RateLimitingInterface, namespace string) {
 		rli.Add(reconcile.Request{NamespacedName: types.NamespacedName{
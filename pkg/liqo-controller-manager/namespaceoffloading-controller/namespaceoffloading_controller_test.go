@@ -366,6 +366,39 @@ var _ = Describe("Namespace controller", func() {
 
 	})
 
+	It("Create a NamespaceOffloading resource with the Template strategy", func() {
+		var nm vkv1alpha1.NamespaceMap
+		remoteNamespaceName := fmt.Sprintf("tenant-%s-%s", localCluster.ClusterName, namespaceName)
+		nsoff = &offv1alpha1.NamespaceOffloading{
+			ObjectMeta: metav1.ObjectMeta{Name: liqoconst.DefaultNamespaceOffloadingName, Namespace: namespaceName},
+			Spec: offv1alpha1.NamespaceOffloadingSpec{
+				NamespaceMappingStrategy: offv1alpha1.TemplateNameMappingStrategyType,
+				NamespaceMappingTemplate: "tenant-{{ .ClusterName }}-{{ .Namespace }}",
+				PodOffloadingStrategy:    offv1alpha1.LocalAndRemotePodOffloadingStrategyType,
+			},
+		}
+
+		By(fmt.Sprintf("Create NamespaceOffloading resource in Namespace %q", namespaceName))
+		Expect(cl.Create(ctx, nsoff)).To(Succeed())
+
+		By("Check the NamespaceMaps of the virtual nodes")
+		for _, obj := range []*vkv1alpha1.NamespaceMap{nm1, nm2, nm3} {
+			Eventually(func() map[string]string {
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(obj), &nm)).To(Succeed())
+				return nm.Spec.DesiredMapping
+			}).Should(HaveKeyWithValue(namespaceName, remoteNamespaceName))
+		}
+
+		By("Check the remote namespace name in the NamespaceOffloading status")
+		Eventually(func() string {
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(nsoff), nsoff)).To(Succeed())
+			return nsoff.Status.RemoteNamespaceName
+		}).Should(Equal(remoteNamespaceName))
+
+		By("Delete NamespaceOffloading resource")
+		Expect(cl.Delete(ctx, nsoff)).To(Succeed())
+	})
+
 	It("Create a NamespaceOffloading resource with a wrong clusterSelector", func() {
 		var nm vkv1alpha1.NamespaceMap
 		nsoff = &offv1alpha1.NamespaceOffloading{
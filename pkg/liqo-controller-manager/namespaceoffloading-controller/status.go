@@ -20,17 +20,23 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
 	mapsv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
-	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+	"github.com/liqotech/liqo/pkg/utils/namespacemapping"
 )
 
 // enforceStatus realigns the status of the NamespaceOffloading, depending on that of the NamespaceMaps.
 func (r *NamespaceOffloadingReconciler) enforceStatus(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading,
 	nsmaps map[string]*mapsv1alpha1.NamespaceMap) error {
-	nsoff.Status.RemoteNamespaceName = r.remoteNamespaceName(nsoff)
+	if remoteNamespaceName, err := r.remoteNamespaceName(ctx, nsoff); err == nil {
+		nsoff.Status.RemoteNamespaceName = remoteNamespaceName
+	}
 
 	// Update the observed generation.
 	nsoff.Status.ObservedGeneration = nsoff.Generation
@@ -44,6 +50,16 @@ func (r *NamespaceOffloadingReconciler) enforceStatus(ctx context.Context, nsoff
 	// Configure the global status given the conditions.
 	setNamespaceOffloadingStatus(nsoff, required, ready, failed)
 
+	// Report the actual distribution of the replicas, in case weights are specified.
+	if err := r.setReplicaDistribution(ctx, nsoff); err != nil {
+		klog.Errorf("Failed to compute the replica distribution for NamespaceOffloading %q: %v", klog.KObj(nsoff), err)
+	}
+
+	// Report the resources requested on each remote cluster, in case a quota is specified.
+	if err := r.setRemoteQuotaUsage(ctx, nsoff); err != nil {
+		klog.Errorf("Failed to compute the remote quota usage for NamespaceOffloading %q: %v", klog.KObj(nsoff), err)
+	}
+
 	// Update the status just once at the end of the logic.
 	if err := r.Status().Update(ctx, nsoff); err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
@@ -52,13 +68,67 @@ func (r *NamespaceOffloadingReconciler) enforceStatus(ctx context.Context, nsoff
 	return nil
 }
 
-// remoteNamespaceName returns the remapped name corresponding to a given namespace.
-func (r *NamespaceOffloadingReconciler) remoteNamespaceName(nsoff *offv1alpha1.NamespaceOffloading) string {
-	if nsoff.Spec.NamespaceMappingStrategy == offv1alpha1.EnforceSameNameMappingStrategyType {
-		return nsoff.Namespace
+// setReplicaDistribution computes the number of running pods of the namespace hosted by each cluster,
+// in case weights are specified, and clears the corresponding status field otherwise.
+func (r *NamespaceOffloadingReconciler) setReplicaDistribution(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading) error {
+	if len(nsoff.Spec.ReplicaWeights) == 0 || !nsoff.GetDeletionTimestamp().IsZero() {
+		nsoff.Status.ReplicaDistribution = nil
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := r.APIReader.List(ctx, &pods, client.InNamespace(nsoff.Namespace)); err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	current, err := distribution.NewClusterResolver(r.Client, r.LocalCluster.ClusterID).CurrentReplicas(ctx, pods.Items, nil)
+	if err != nil {
+		return err
+	}
+
+	nsoff.Status.ReplicaDistribution = distribution.ToClusterReplicas(current)
+	return nil
+}
+
+// setRemoteQuotaUsage computes the amount of resources requested by the pods of the namespace on each remote cluster,
+// in case a quota is specified, and clears the corresponding status field otherwise.
+func (r *NamespaceOffloadingReconciler) setRemoteQuotaUsage(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading) error {
+	if len(nsoff.Spec.RemoteClusterQuota) == 0 || !nsoff.GetDeletionTimestamp().IsZero() {
+		nsoff.Status.RemoteQuotaUsage = nil
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := r.APIReader.List(ctx, &pods, client.InNamespace(nsoff.Namespace)); err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	usage, err := distribution.NewClusterResolver(r.Client, r.LocalCluster.ClusterID).CurrentUsage(ctx, pods.Items)
+	if err != nil {
+		return err
+	}
+
+	nsoff.Status.RemoteQuotaUsage = distribution.ToClusterResourceUsage(usage, nsoff.Spec.RemoteClusterQuota)
+	return nil
+}
+
+// remoteNamespaceName returns the remapped name corresponding to a given namespace. Once computed, the name
+// is retrieved from the status, to prevent it from changing (e.g., in case the namespace labels are modified).
+func (r *NamespaceOffloadingReconciler) remoteNamespaceName(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading) (string, error) {
+	if nsoff.Status.RemoteNamespaceName != "" {
+		return nsoff.Status.RemoteNamespaceName, nil
 	}
 
-	return nsoff.Namespace + "-" + foreignclusterutils.UniqueName(&r.LocalCluster)
+	var labels map[string]string
+	if nsoff.Spec.NamespaceMappingStrategy == offv1alpha1.TemplateNameMappingStrategyType {
+		namespace := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: nsoff.Namespace}, namespace); err != nil {
+			return "", fmt.Errorf("failed to retrieve namespace %q: %w", nsoff.Namespace, err)
+		}
+		labels = namespace.Labels
+	}
+
+	return namespacemapping.RemoteNamespaceName(nsoff, labels, &r.LocalCluster)
 }
 
 // ensureRemoteConditionsConsistence checks for every remote condition of the NamespaceOffloading resource that the
@@ -89,7 +159,9 @@ func setRemoteConditionsForEveryCluster(nsoff *offv1alpha1.NamespaceOffloading,
 	for _, nsmap := range nsmaps {
 		// Get the information for the NamespaceOffloadingRequired condition.
 		_, requested := nsmap.Spec.DesiredMapping[nsoff.Namespace]
-		if requested {
+		// The remote namespace is retained only to migrate the pods it hosts, hence it does not account for the global status.
+		migrating := requested && isMigrationPending(nsoff, nsmap.Labels[liqoconst.RemoteClusterID])
+		if requested && !migrating {
 			requestedCount++
 		}
 
@@ -97,9 +169,9 @@ func setRemoteConditionsForEveryCluster(nsoff *offv1alpha1.NamespaceOffloading,
 		var phase mapsv1alpha1.MappingPhase
 		if mapping, ok := nsmap.Status.CurrentMapping[nsoff.Namespace]; ok {
 			phase = mapping.Phase
-			if phase == mapsv1alpha1.MappingAccepted {
+			if phase == mapsv1alpha1.MappingAccepted && !migrating {
 				readyCount++
-			} else if phase == mapsv1alpha1.MappingCreationLoopBackOff {
+			} else if phase == mapsv1alpha1.MappingCreationLoopBackOff && !migrating {
 				failedCount++
 			}
 		}
@@ -109,7 +181,11 @@ func setRemoteConditionsForEveryCluster(nsoff *offv1alpha1.NamespaceOffloading,
 			delete(nsoff.Status.RemoteNamespacesConditions, nsmap.GetName())
 		} else {
 			// Otherwise, set the appropriate conditions.
-			setRemoteCondition(nsoff, nsmap.GetName(), nsoffRequiredCondition(requested))
+			if migrating {
+				setRemoteCondition(nsoff, nsmap.GetName(), nsoffMigratingCondition())
+			} else {
+				setRemoteCondition(nsoff, nsmap.GetName(), nsoffRequiredCondition(requested))
+			}
 			if requested || phase != "" {
 				setRemoteCondition(nsoff, nsmap.GetName(), nsoffReadyCondition(phase))
 			}
@@ -158,6 +234,18 @@ func nsoffRequiredCondition(required bool) *offv1alpha1.RemoteNamespaceCondition
 	return condition
 }
 
+// nsoffMigratingCondition returns a condition stating that the namespace shall no longer be offloaded to the remote cluster,
+// but the remote namespace is retained until the pods it hosts are migrated.
+func nsoffMigratingCondition() *offv1alpha1.RemoteNamespaceCondition {
+	return &offv1alpha1.RemoteNamespaceCondition{
+		Type:               offv1alpha1.NamespaceOffloadingRequired,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "MigrationPending",
+		Message:            "The remote cluster is no longer selected, and the remote namespace is retained until the hosted pods are migrated",
+	}
+}
+
 // nsoffRequiredCondition returns a condition stating the offloading status, based on the corresponding NamespaceMap.
 func nsoffReadyCondition(phase mapsv1alpha1.MappingPhase) *offv1alpha1.RemoteNamespaceCondition {
 	condition := &offv1alpha1.RemoteNamespaceCondition{Type: offv1alpha1.NamespaceReady, LastTransitionTime: metav1.Now()}
@@ -154,6 +154,7 @@ var _ = BeforeSuite(func() {
 		Client:       k8sManager.GetClient(),
 		Recorder:     k8sManager.GetEventRecorderFor("namespaceoffloading-controller"),
 		LocalCluster: localCluster,
+		APIReader:    k8sManager.GetAPIReader(),
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
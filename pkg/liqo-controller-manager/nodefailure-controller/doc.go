@@ -16,5 +16,8 @@
 // ensure offloaded pods running on a failed node are evicted and rescheduled
 // on a healthy node, preventing them to remain in a terminating state indefinitely.
 // This feature can be useful in case of remote node failure to guarantee better
-// service continuity and to have the expected pods workload on the remote cluster
+// service continuity and to have the expected pods workload on the remote cluster.
+// Additionally, it contains a controller that enforces the failover policies configured through the
+// NamespaceOffloading resources, rescheduling the pods hosted by remote clusters unavailable for longer
+// than a grace period, and restoring the original distribution once they are back.
 package nodefailurectrl
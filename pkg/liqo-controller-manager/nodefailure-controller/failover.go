@@ -0,0 +1,538 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodefailurectrl
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+var (
+	// failoverActive reports whether the failover process is currently active for a given remote cluster.
+	failoverActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "liqo_failover_active",
+		Help: "Whether the failover process is currently active for the given remote cluster.",
+	}, []string{"cluster_id"})
+	// failoverEvictedPods counts the pods evicted from the virtual nodes subject to failover.
+	failoverEvictedPods = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "liqo_failover_evicted_pods_total",
+		Help: "The total number of pods evicted from the virtual node of the given remote cluster, due to its failure.",
+	}, []string{"cluster_id"})
+	// failoverRestoredPods counts the pods evicted to restore the original distribution once a remote cluster is back.
+	failoverRestoredPods = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "liqo_failover_restored_pods_total",
+		Help: "The total number of pods rescheduled to restore the original distribution, once the given remote cluster is back.",
+	}, []string{"cluster_id"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(failoverActive, failoverEvictedPods, failoverRestoredPods)
+}
+
+const (
+	// FailoverRestoreWindow is the period during which the replacements of the pods rescheduled to restore the original
+	// distribution are preferably scheduled onto the recovered cluster.
+	FailoverRestoreWindow = 2 * time.Minute
+	// failoverRetryPeriod is the period after which the evictions previously denied (or failed) are retried.
+	failoverRetryPeriod = 30 * time.Second
+)
+
+const (
+	// FailoverStartedReason is the reason of the event generated when the failover process starts.
+	FailoverStartedReason = "FailoverStarted"
+	// FailoverEvictedReason is the reason of the event generated when a pod is evicted due to the failover process.
+	FailoverEvictedReason = "FailoverEvicted"
+	// FailoverCompletedReason is the reason of the event generated when the remote cluster is back.
+	FailoverCompletedReason = "FailoverCompleted"
+	// FailoverRestoredReason is the reason of the event generated when a pod is rescheduled to restore the original distribution.
+	FailoverRestoredReason = "FailoverRestored"
+)
+
+// FailoverReconciler cordons the virtual nodes whose remote cluster is unavailable for longer than the grace period,
+// and evicts the pods they host (limited to the ones managed by a controller, and living in namespaces configured with
+// the Reschedule failover policy), so that they get rescheduled either locally or on other remote clusters.
+// The pods of StatefulSets, which require at most one instance of each replica, are evicted only once the remote
+// cluster is fenced off, that is the virtual node is tainted as out-of-service (as for non-graceful node shutdowns).
+// Once the remote cluster is back, the virtual node is uncordoned, and the original distribution restored.
+type FailoverReconciler struct {
+	client.Client
+	// APIReader is a non-cached reader, used to retrieve the pods (which are only partially cached by the manager).
+	APIReader   client.Reader
+	Recorder    record.EventRecorder
+	GracePeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=namespaceoffloadings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile virtual nodes, enforcing the failover policies.
+func (r *FailoverReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var node corev1.Node
+	if err := r.Get(ctx, client.ObjectKey{Name: req.Name}, &node); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("node %s not found", req.Name)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("an error occurred while getting node %s: %v", req.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	clusterID, found := utils.GetNodeClusterID(&node)
+	if !utils.IsVirtualNode(&node) || !found {
+		return ctrl.Result{}, nil
+	}
+
+	_, active := node.Labels[consts.FailoverLabelKey]
+	if utils.IsNodeReady(&node) {
+		_, evicted := node.Annotations[consts.FailoverEvictionsAnnotationKey]
+		_, restoring := node.Annotations[consts.FailoverRestoringAnnotationKey]
+		if !active && !evicted && !restoring {
+			return ctrl.Result{}, nil
+		}
+		return r.recover(ctx, &node, clusterID)
+	}
+
+	// Wait for the grace period to expire, before starting the failover process.
+	if remaining := r.GracePeriod - time.Since(notReadySince(&node)); remaining > 0 {
+		klog.V(4).Infof("virtual node %s not ready, failover in %s if not recovered", node.Name, remaining.Round(time.Second))
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	return r.failover(ctx, &node, clusterID)
+}
+
+// failover cordons the given virtual node, and evicts the pods it hosts according to the failover policies. The pods are
+// evicted through the Eviction API, honoring their termination grace period and the PodDisruptionBudgets, and the node
+// is requeued in case of evictions denied or failed. Pods are forcefully deleted only once the remote cluster is fenced
+// off, as no longer able to run them, hence allowing the replacement of those (e.g., of StatefulSets) bound to their identity.
+func (r *FailoverReconciler) failover(ctx context.Context, node *corev1.Node, clusterID string) (ctrl.Result, error) {
+	original := node.DeepCopy()
+
+	if _, active := node.Labels[consts.FailoverLabelKey]; !active {
+		node.Labels[consts.FailoverLabelKey] = consts.FailoverCordonedValue
+		if node.Spec.Unschedulable {
+			node.Labels[consts.FailoverLabelKey] = consts.FailoverAlreadyCordonedValue
+		}
+		node.Spec.Unschedulable = true
+
+		r.Recorder.Eventf(node, corev1.EventTypeWarning, FailoverStartedReason,
+			"Remote cluster %q unavailable for longer than %s: virtual node cordoned", clusterID, r.GracePeriod)
+		klog.Warningf("remote cluster %q unavailable for longer than %s: starting failover for virtual node %s", clusterID, r.GracePeriod, node.Name)
+		failoverActive.WithLabelValues(clusterID).Set(1)
+	}
+
+	evictions, err := failoverEvictions(node)
+	if err != nil {
+		klog.Warningf("invalid %q annotation on node %s: %v", consts.FailoverEvictionsAnnotationKey, node.Name, err)
+		evictions = map[string]int{}
+	}
+
+	var pods corev1.PodList
+	if err = r.APIReader.List(ctx, &pods, client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector(nodeNameField, node.Name)}); err != nil {
+		klog.Errorf("unable to list pods on node %s: %v", node.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	fenced := isFenced(node)
+	policies := map[string]offv1alpha1.FailoverPolicyType{}
+	var pending int
+	var evictErr error
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		// Pods not managed by any controller would not be recreated, and are left untouched.
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil {
+			continue
+		}
+
+		policy, policyErr := r.failoverPolicy(ctx, pod.Namespace, policies)
+		if policyErr != nil {
+			return ctrl.Result{}, policyErr
+		}
+		if policy != offv1alpha1.RescheduleFailoverPolicyType {
+			continue
+		}
+
+		// The replacements of StatefulSet pods share their identity, hence they could run concurrently with the original
+		// ones in case of network partitions, unless the remote cluster is known to be no longer running them.
+		if owner.Kind == "StatefulSet" && !fenced {
+			klog.V(4).Infof("pod %q on failed node %s not evicted, as part of a StatefulSet and the node is not fenced", klog.KObj(pod), node.Name)
+			continue
+		}
+
+		terminating := !pod.DeletionTimestamp.IsZero()
+		if terminating && !fenced {
+			// The pod has already been evicted, and it is waiting for the termination to be confirmed.
+			continue
+		}
+
+		evicted, podErr := r.evict(ctx, pod, fenced)
+		if podErr != nil {
+			klog.Errorf("unable to evict pod %q from failed node %s: %v", klog.KObj(pod), node.Name, podErr)
+			evictErr = podErr
+		}
+		if !evicted {
+			pending++
+			continue
+		}
+
+		// The pods already terminating have been accounted for when first evicted.
+		if !terminating {
+			evictions[evictionKey(pod.Namespace, owner.UID)]++
+			r.Recorder.Eventf(pod, corev1.EventTypeWarning, FailoverEvictedReason,
+				"Evicted from virtual node %s, as remote cluster %q is unavailable", node.Name, clusterID)
+			klog.Infof("pod %q evicted from failed node %s", klog.KObj(pod), node.Name)
+			failoverEvictedPods.WithLabelValues(clusterID).Inc()
+		}
+	}
+
+	if len(evictions) > 0 {
+		encoded, marshalErr := json.Marshal(evictions)
+		if marshalErr != nil {
+			return ctrl.Result{}, marshalErr
+		}
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[consts.FailoverEvictionsAnnotationKey] = string(encoded)
+	}
+
+	if err = r.Patch(ctx, node, client.MergeFrom(original)); err != nil {
+		klog.Errorf("unable to update node %s: %v", node.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	// Retry the evictions denied (e.g., by a PodDisruptionBudget) or failed, once the successful ones have been recorded.
+	if evictErr != nil {
+		return ctrl.Result{}, evictErr
+	}
+	if pending > 0 {
+		klog.V(4).Infof("%d evictions from failed node %s still pending", pending, node.Name)
+		return ctrl.Result{RequeueAfter: failoverRetryPeriod}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// evict evicts the given pod from a failed virtual node, returning whether the pod has been evicted (or no longer exists).
+// The pod is forcefully deleted in case the remote cluster is fenced off, as it cannot confirm the termination,
+// while it is evicted through the Eviction API otherwise, with the evictions denied by a PodDisruptionBudget not
+// returning an error, so that they are retried later.
+func (r *FailoverReconciler) evict(ctx context.Context, pod *corev1.Pod, fenced bool) (bool, error) {
+	if fenced {
+		if err := client.IgnoreNotFound(r.Delete(ctx, pod, client.GracePeriodSeconds(0))); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.GetName(), Namespace: pod.GetNamespace()}}
+	err := r.SubResource("eviction").Create(ctx, pod, eviction)
+	switch {
+	case err == nil, apierrors.IsNotFound(err):
+		return true, nil
+	case apierrors.IsTooManyRequests(err):
+		klog.V(4).Infof("eviction of pod %q denied by a PodDisruptionBudget: %v", klog.KObj(pod), err)
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// recover uncordons the given virtual node, and restores the original distribution of the evicted pods. The pods
+// are evicted honoring the PodDisruptionBudgets, and the ones whose eviction is denied are retried later on.
+func (r *FailoverReconciler) recover(ctx context.Context, node *corev1.Node, clusterID string) (ctrl.Result, error) {
+	evictions, err := failoverEvictions(node)
+	if err != nil {
+		klog.Warningf("invalid %q annotation on node %s: %v", consts.FailoverEvictionsAnnotationKey, node.Name, err)
+		evictions = map[string]int{}
+	}
+
+	restoring, err := distribution.FailoverRestoring(node)
+	if err != nil {
+		klog.Warningf("invalid %q annotation on node %s: %v", consts.FailoverRestoringAnnotationKey, node.Name, err)
+		restoring = map[string]int64{}
+	}
+
+	// Iterate over the evictions in a deterministic order.
+	keys := make([]string, 0, len(evictions))
+	for key := range evictions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	policies := map[string]offv1alpha1.FailoverPolicyType{}
+	for _, key := range keys {
+		namespace, _, _ := strings.Cut(key, "/")
+		policy, policyErr := r.failoverPolicy(ctx, namespace, policies)
+		if policyErr != nil {
+			return ctrl.Result{}, policyErr
+		}
+		if policy != offv1alpha1.RescheduleFailoverPolicyType {
+			delete(evictions, key)
+			continue
+		}
+		restoring[key] = time.Now().Add(FailoverRestoreWindow).Unix()
+	}
+
+	// Mark the controllers whose pods are about to be rescheduled, before evicting them, so that the replacements
+	// are preferably scheduled onto the recovered cluster.
+	original := node.DeepCopy()
+	if err = setFailoverAnnotation(node, consts.FailoverRestoringAnnotationKey, restoring); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = r.Patch(ctx, node, client.MergeFrom(original)); err != nil {
+		klog.Errorf("unable to update node %s: %v", node.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	for _, key := range keys {
+		count, found := evictions[key]
+		if !found {
+			continue
+		}
+
+		namespace, uid, _ := strings.Cut(key, "/")
+		restored, restoreErr := r.restore(ctx, node, clusterID, namespace, types.UID(uid), count)
+		if restoreErr != nil {
+			return ctrl.Result{}, restoreErr
+		}
+
+		if evictions[key] = count - restored; evictions[key] <= 0 {
+			delete(evictions, key)
+		}
+	}
+
+	original = node.DeepCopy()
+	_, active := node.Labels[consts.FailoverLabelKey]
+	if node.Labels[consts.FailoverLabelKey] == consts.FailoverCordonedValue {
+		node.Spec.Unschedulable = false
+	}
+	delete(node.Labels, consts.FailoverLabelKey)
+
+	// Remove the marks of the controllers whose restoration window is expired.
+	now := time.Now()
+	next := time.Duration(0)
+	for key, deadline := range restoring {
+		if remaining := time.Unix(deadline, 0).Sub(now); remaining > 0 {
+			next = minPositive(next, remaining)
+			continue
+		}
+		delete(restoring, key)
+	}
+
+	if err = setFailoverAnnotation(node, consts.FailoverEvictionsAnnotationKey, evictions); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = setFailoverAnnotation(node, consts.FailoverRestoringAnnotationKey, restoring); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err = r.Patch(ctx, node, client.MergeFrom(original)); err != nil {
+		klog.Errorf("unable to update node %s: %v", node.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	if active {
+		r.Recorder.Eventf(node, corev1.EventTypeNormal, FailoverCompletedReason, "Remote cluster %q available again: virtual node restored", clusterID)
+		klog.Infof("remote cluster %q available again: failover completed for virtual node %s", clusterID, node.Name)
+		failoverActive.WithLabelValues(clusterID).Set(0)
+	}
+
+	// Retry the evictions previously denied (e.g., by a PodDisruptionBudget).
+	if len(evictions) > 0 {
+		klog.V(4).Infof("restoration of the original distribution for virtual node %s still pending: %v", node.Name, evictions)
+		next = minPositive(next, failoverRetryPeriod)
+	}
+	return ctrl.Result{RequeueAfter: next}, nil
+}
+
+// restore evicts the most recent pods controlled by the given owner (up to the number of pods previously evicted),
+// so that they can be rescheduled according to the original distribution, now that the remote cluster is back.
+// It returns the number of pods actually evicted, as the evictions violating a PodDisruptionBudget are denied.
+func (r *FailoverReconciler) restore(ctx context.Context, node *corev1.Node, clusterID, namespace string, owner types.UID, count int) (int, error) {
+	var pods corev1.PodList
+	if err := r.APIReader.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+		klog.Errorf("unable to list pods in namespace %q: %v", namespace, err)
+		return 0, err
+	}
+
+	// The pods not yet scheduled are not considered, as possibly the replacements of the ones already evicted.
+	candidates := make([]*corev1.Pod, 0, count)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if controller := metav1.GetControllerOf(pod); controller != nil && controller.UID == owner &&
+			distribution.IsActive(pod) && pod.Spec.NodeName != "" && pod.Spec.NodeName != node.Name {
+			candidates = append(candidates, pod)
+		}
+	}
+
+	// Prefer the most recent pods, as the ones most likely created to replace the evicted ones.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[j].CreationTimestamp.Before(&candidates[i].CreationTimestamp)
+	})
+
+	var restored int
+	for i := 0; i < len(candidates) && restored < count; i++ {
+		eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: candidates[i].GetName(), Namespace: candidates[i].GetNamespace()}}
+		if err := r.SubResource("eviction").Create(ctx, candidates[i], eviction); err != nil {
+			switch {
+			case apierrors.IsNotFound(err):
+				continue
+			case apierrors.IsTooManyRequests(err):
+				// The eviction is denied in case it would violate a PodDisruptionBudget, and it will be retried later.
+				klog.V(4).Infof("eviction of pod %q denied by a PodDisruptionBudget: %v", klog.KObj(candidates[i]), err)
+				return restored, nil
+			default:
+				klog.Errorf("unable to evict pod %q to restore the original distribution: %v", klog.KObj(candidates[i]), err)
+				return restored, err
+			}
+		}
+
+		restored++
+		r.Recorder.Eventf(candidates[i], corev1.EventTypeNormal, FailoverRestoredReason,
+			"Rescheduled to restore the original distribution, as remote cluster %q is available again", clusterID)
+		klog.Infof("pod %q evicted to restore the original distribution", klog.KObj(candidates[i]))
+		failoverRestoredPods.WithLabelValues(clusterID).Inc()
+	}
+
+	return restored, nil
+}
+
+// failoverPolicy returns the failover policy configured for the given namespace, caching the result.
+func (r *FailoverReconciler) failoverPolicy(ctx context.Context, namespace string,
+	cache map[string]offv1alpha1.FailoverPolicyType) (offv1alpha1.FailoverPolicyType, error) {
+	if policy, found := cache[namespace]; found {
+		return policy, nil
+	}
+
+	var nsoff offv1alpha1.NamespaceOffloading
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: consts.DefaultNamespaceOffloadingName}, &nsoff)
+	switch {
+	case apierrors.IsNotFound(err):
+		cache[namespace] = offv1alpha1.NoneFailoverPolicyType
+	case err != nil:
+		klog.Errorf("unable to retrieve the NamespaceOffloading in namespace %q: %v", namespace, err)
+		return "", err
+	default:
+		cache[namespace] = nsoff.Spec.FailoverPolicy
+	}
+
+	return cache[namespace], nil
+}
+
+// isFenced returns whether the remote cluster of the given virtual node has been fenced off, that is the node has been
+// tainted as out-of-service, confirming that the pods it hosts are no longer running.
+func isFenced(node *corev1.Node) bool {
+	for i := range node.Spec.Taints {
+		if node.Spec.Taints[i].Key == corev1.TaintNodeOutOfService {
+			return true
+		}
+	}
+	return false
+}
+
+// notReadySince returns the time since when the given node is not ready.
+func notReadySince(node *corev1.Node) time.Time {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == corev1.NodeReady {
+			return node.Status.Conditions[i].LastTransitionTime.Time
+		}
+	}
+	return node.CreationTimestamp.Time
+}
+
+// evictionKey returns the key identifying the pods controlled by a given owner in the evictions annotation.
+func evictionKey(namespace string, owner types.UID) string {
+	return distribution.OwnerKey(namespace, owner)
+}
+
+// failoverEvictions returns the number of pods evicted for each controller, as stored in the node annotation.
+func failoverEvictions(node *corev1.Node) (map[string]int, error) {
+	evictions := map[string]int{}
+	value, found := node.Annotations[consts.FailoverEvictionsAnnotationKey]
+	if !found {
+		return evictions, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &evictions); err != nil {
+		return map[string]int{}, err
+	}
+	return evictions, nil
+}
+
+// setFailoverAnnotation sets the given annotation to the JSON encoding of the given value, or removes it if empty.
+func setFailoverAnnotation[T any](node *corev1.Node, key string, value map[string]T) error {
+	if len(value) == 0 {
+		delete(node.Annotations, key)
+		return nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[key] = string(encoded)
+	return nil
+}
+
+// minPositive returns the minimum between the two durations, ignoring the non-positive ones.
+func minPositive(a, b time.Duration) time.Duration {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// SetupWithManager registers a new controller enforcing the failover policies on virtual nodes.
+func (r *FailoverReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	filter := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetLabels()[consts.TypeLabel] == consts.TypeNode
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("virtualnode-failover").
+		For(&corev1.Node{}, builder.WithPredicates(filter)).
+		Complete(r)
+}
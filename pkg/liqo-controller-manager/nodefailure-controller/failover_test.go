@@ -0,0 +1,331 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodefailurectrl
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// evictingClient wraps a fake client, implementing the eviction subresource (not supported by the fake client) as a
+// deletion of the given pod, unless evictions are configured to be denied (e.g., as violating a PodDisruptionBudget).
+type evictingClient struct {
+	client.WithWatch
+	deny bool
+}
+
+func (c *evictingClient) SubResource(subResource string) client.SubResourceClient {
+	return &evictionClient{SubResourceClient: c.WithWatch.SubResource(subResource), parent: c}
+}
+
+type evictionClient struct {
+	client.SubResourceClient
+	parent *evictingClient
+}
+
+func (c *evictionClient) Create(ctx context.Context, obj, _ client.Object, _ ...client.SubResourceCreateOption) error {
+	if c.parent.deny {
+		return apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	}
+	return c.parent.Delete(ctx, obj)
+}
+
+var _ = Describe("FailoverController", func() {
+	const (
+		nodeName    = "liqo-remote"
+		clusterID   = "remote-cluster-id"
+		rescheduled = "rescheduled"
+		untouched   = "untouched"
+	)
+
+	var (
+		ctx        context.Context
+		err        error
+		result     ctrl.Result
+		fakeClient *evictingClient
+		denied     bool
+		recorder   *record.FakeRecorder
+		node       *corev1.Node
+		objects    []client.Object
+
+		owner = metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", UID: "uid", Controller: pointer.Bool(true)}
+		req   = ctrl.Request{NamespacedName: types.NamespacedName{Name: nodeName}}
+
+		newVirtualNode = func(ready bool, since time.Time) *corev1.Node {
+			status := corev1.ConditionFalse
+			if ready {
+				status = corev1.ConditionTrue
+			}
+			return &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: nodeName, Labels: map[string]string{
+					consts.TypeLabel: consts.TypeNode, consts.RemoteClusterID: clusterID}},
+				Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(since)},
+				}},
+			}
+		}
+
+		newPod = func(name, namespace, node string, owned bool, created time.Time) *corev1.Pod {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: metav1.NewTime(created)},
+				Spec:       corev1.PodSpec{NodeName: node},
+			}
+			if owned {
+				pod.OwnerReferences = []metav1.OwnerReference{owner}
+			}
+			return pod
+		}
+
+		newNamespaceOffloading = func(namespace string, policy offv1alpha1.FailoverPolicyType) *offv1alpha1.NamespaceOffloading {
+			return &offv1alpha1.NamespaceOffloading{
+				ObjectMeta: metav1.ObjectMeta{Name: consts.DefaultNamespaceOffloadingName, Namespace: namespace},
+				Spec:       offv1alpha1.NamespaceOffloadingSpec{FailoverPolicy: policy},
+			}
+		}
+
+		exists = func(name, namespace string) bool {
+			var pod corev1.Pod
+			return fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &pod) == nil
+		}
+
+		getNode = func() *corev1.Node {
+			var updated corev1.Node
+			Expect(fakeClient.Get(ctx, req.NamespacedName, &updated)).To(Succeed())
+			return &updated
+		}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		denied = false
+		objects = []client.Object{
+			newNamespaceOffloading(rescheduled, offv1alpha1.RescheduleFailoverPolicyType),
+			newNamespaceOffloading(untouched, offv1alpha1.NoneFailoverPolicyType),
+		}
+	})
+
+	JustBeforeEach(func() {
+		fakeClient = &evictingClient{deny: denied, WithWatch: fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithIndex(&corev1.Pod{}, nodeNameField, extractNodeNameFromPod).
+			WithObjects(append(objects, node)...).Build()}
+		recorder = record.NewFakeRecorder(100)
+
+		r := &FailoverReconciler{Client: fakeClient, APIReader: fakeClient, Recorder: recorder, GracePeriod: time.Minute}
+		result, err = r.Reconcile(ctx, req)
+	})
+
+	When("the virtual node is not ready since less than the grace period", func() {
+		BeforeEach(func() {
+			node = newVirtualNode(false, time.Now().Add(-10*time.Second))
+			objects = append(objects, newPod("pod", rescheduled, nodeName, true, time.Now()))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should requeue the node after the remaining grace period", func() {
+			Expect(result.RequeueAfter).To(BeNumerically("~", 50*time.Second, 5*time.Second))
+		})
+		It("should not cordon the node", func() { Expect(getNode().Spec.Unschedulable).To(BeFalse()) })
+		It("should not evict the pod", func() { Expect(exists("pod", rescheduled)).To(BeTrue()) })
+	})
+
+	When("the virtual node is not ready since more than the grace period", func() {
+		BeforeEach(func() {
+			node = newVirtualNode(false, time.Now().Add(-10*time.Minute))
+			objects = append(objects,
+				newPod("owned", rescheduled, nodeName, true, time.Now()),
+				newPod("standalone", rescheduled, nodeName, false, time.Now()),
+				newPod("other-policy", untouched, nodeName, true, time.Now()),
+				newPod("other-node", rescheduled, "local-node", true, time.Now()),
+			)
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should cordon the node, and mark it as subject to failover", func() {
+			updated := getNode()
+			Expect(updated.Spec.Unschedulable).To(BeTrue())
+			Expect(updated.Labels).To(HaveKeyWithValue(consts.FailoverLabelKey, consts.FailoverCordonedValue))
+			Expect(updated.Annotations).To(HaveKeyWithValue(consts.FailoverEvictionsAnnotationKey, `{"rescheduled/uid":1}`))
+		})
+		It("should evict the pods managed by a controller, in namespaces with the Reschedule policy", func() {
+			Expect(exists("owned", rescheduled)).To(BeFalse())
+			Expect(exists("standalone", rescheduled)).To(BeTrue())
+			Expect(exists("other-policy", untouched)).To(BeTrue())
+			Expect(exists("other-node", rescheduled)).To(BeTrue())
+		})
+		It("should record the corresponding events", func() {
+			Expect(recorder.Events).To(Receive(ContainSubstring(FailoverStartedReason)))
+			Expect(recorder.Events).To(Receive(ContainSubstring(FailoverEvictedReason)))
+		})
+	})
+
+	When("the virtual node hosts the pods of a StatefulSet", func() {
+		BeforeEach(func() {
+			node = newVirtualNode(false, time.Now().Add(-10*time.Minute))
+			stateful := newPod("stateful", rescheduled, nodeName, false, time.Now())
+			stateful.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "sts",
+				UID: "sts-uid", Controller: pointer.Bool(true)}}
+			objects = append(objects, stateful)
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not evict the pod, as the node is not fenced", func() { Expect(exists("stateful", rescheduled)).To(BeTrue()) })
+
+		When("the virtual node is fenced", func() {
+			BeforeEach(func() {
+				node.Spec.Taints = []corev1.Taint{{Key: corev1.TaintNodeOutOfService, Value: "nodeshutdown", Effect: corev1.TaintEffectNoExecute}}
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should delete the pod", func() { Expect(exists("stateful", rescheduled)).To(BeFalse()) })
+			It("should record the eviction", func() {
+				Expect(getNode().Annotations).To(HaveKeyWithValue(consts.FailoverEvictionsAnnotationKey, `{"rescheduled/sts-uid":1}`))
+			})
+		})
+	})
+
+	When("the evictions from the failed node are denied by a PodDisruptionBudget", func() {
+		BeforeEach(func() {
+			denied = true
+			node = newVirtualNode(false, time.Now().Add(-10*time.Minute))
+			objects = append(objects, newPod("owned", rescheduled, nodeName, true, time.Now()))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not evict the pod", func() { Expect(exists("owned", rescheduled)).To(BeTrue()) })
+		It("should cordon the node, without recording any eviction", func() {
+			updated := getNode()
+			Expect(updated.Spec.Unschedulable).To(BeTrue())
+			Expect(updated.Annotations).ToNot(HaveKey(consts.FailoverEvictionsAnnotationKey))
+		})
+		It("should requeue the node to retry the eviction", func() { Expect(result.RequeueAfter).To(Equal(failoverRetryPeriod)) })
+	})
+
+	When("the pods on the failed node are already terminating", func() {
+		BeforeEach(func() {
+			node = newVirtualNode(false, time.Now().Add(-10*time.Minute))
+			node.Labels[consts.FailoverLabelKey] = consts.FailoverCordonedValue
+			node.Annotations = map[string]string{consts.FailoverEvictionsAnnotationKey: `{"rescheduled/uid":1}`}
+			terminating := newPod("terminating", rescheduled, nodeName, true, time.Now())
+			terminating.Finalizers = []string{"test"}
+			terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			objects = append(objects, terminating)
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not account for the eviction again", func() {
+			Expect(getNode().Annotations).To(HaveKeyWithValue(consts.FailoverEvictionsAnnotationKey, `{"rescheduled/uid":1}`))
+		})
+		It("should not requeue the node", func() { Expect(result).To(Equal(ctrl.Result{})) })
+	})
+
+	When("the virtual node was already cordoned", func() {
+		BeforeEach(func() {
+			node = newVirtualNode(false, time.Now().Add(-10*time.Minute))
+			node.Spec.Unschedulable = true
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should remember the node was already cordoned", func() {
+			Expect(getNode().Labels).To(HaveKeyWithValue(consts.FailoverLabelKey, consts.FailoverAlreadyCordonedValue))
+		})
+	})
+
+	When("the virtual node is ready again", func() {
+		BeforeEach(func() {
+			node = newVirtualNode(true, time.Now())
+			node.Spec.Unschedulable = true
+			node.Labels[consts.FailoverLabelKey] = consts.FailoverCordonedValue
+			node.Annotations = map[string]string{consts.FailoverEvictionsAnnotationKey: `{"rescheduled/uid":1}`}
+			objects = append(objects,
+				newPod("older", rescheduled, "local-node", true, time.Now().Add(-time.Hour)),
+				newPod("newer", rescheduled, "local-node", true, time.Now()),
+				newPod("pending", rescheduled, "", true, time.Now().Add(time.Minute)),
+			)
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should uncordon the node, and remove the failover marks", func() {
+			updated := getNode()
+			Expect(updated.Spec.Unschedulable).To(BeFalse())
+			Expect(updated.Labels).ToNot(HaveKey(consts.FailoverLabelKey))
+			Expect(updated.Annotations).ToNot(HaveKey(consts.FailoverEvictionsAnnotationKey))
+		})
+		It("should evict the most recent scheduled pods, to restore the original distribution", func() {
+			Expect(exists("older", rescheduled)).To(BeTrue())
+			Expect(exists("newer", rescheduled)).To(BeFalse())
+			Expect(exists("pending", rescheduled)).To(BeTrue())
+		})
+		It("should mark the controllers whose replacements shall prefer the recovered cluster", func() {
+			Expect(getNode().Annotations).To(HaveKeyWithValue(consts.FailoverRestoringAnnotationKey, ContainSubstring("rescheduled/uid")))
+		})
+		It("should requeue the node once the restoration window expires", func() {
+			Expect(result.RequeueAfter).To(BeNumerically("~", FailoverRestoreWindow, time.Second))
+		})
+		It("should record the corresponding events", func() {
+			Expect(recorder.Events).To(Receive(ContainSubstring(FailoverRestoredReason)))
+			Expect(recorder.Events).To(Receive(ContainSubstring(FailoverCompletedReason)))
+		})
+
+		When("the eviction is denied by a PodDisruptionBudget", func() {
+			BeforeEach(func() { denied = true })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not evict the pods", func() { Expect(exists("newer", rescheduled)).To(BeTrue()) })
+			It("should uncordon the node, but retain the pending evictions", func() {
+				updated := getNode()
+				Expect(updated.Spec.Unschedulable).To(BeFalse())
+				Expect(updated.Labels).ToNot(HaveKey(consts.FailoverLabelKey))
+				Expect(updated.Annotations).To(HaveKeyWithValue(consts.FailoverEvictionsAnnotationKey, `{"rescheduled/uid":1}`))
+			})
+			It("should requeue the node to retry the eviction", func() {
+				Expect(result.RequeueAfter).To(Equal(failoverRetryPeriod))
+			})
+		})
+
+		When("the restoration window is expired", func() {
+			BeforeEach(func() {
+				node.Labels = map[string]string{consts.TypeLabel: consts.TypeNode, consts.RemoteClusterID: clusterID}
+				node.Annotations = map[string]string{consts.FailoverRestoringAnnotationKey: `{"rescheduled/uid":1}`}
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should remove the restoration marks", func() {
+				Expect(getNode().Annotations).ToNot(HaveKey(consts.FailoverRestoringAnnotationKey))
+			})
+			It("should not requeue the node", func() { Expect(result).To(Equal(ctrl.Result{})) })
+		})
+
+		When("the node was already cordoned before the failover", func() {
+			BeforeEach(func() { node.Labels[consts.FailoverLabelKey] = consts.FailoverAlreadyCordonedValue })
+			It("should not uncordon the node", func() { Expect(getNode().Spec.Unschedulable).To(BeTrue()) })
+		})
+	})
+})
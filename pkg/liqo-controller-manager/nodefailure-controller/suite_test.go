@@ -22,6 +22,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/kubectl/pkg/scheme"
 
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
 	"github.com/liqotech/liqo/pkg/utils/testutil"
 )
 
@@ -33,4 +34,5 @@ func TestNodeFailureController(t *testing.T) {
 var _ = BeforeSuite(func() {
 	testutil.LogsToGinkgoWriter()
 	Expect(corev1.AddToScheme(scheme.Scheme)).To(Succeed())
+	Expect(offv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
 })
@@ -0,0 +1,101 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offloadingoverridectrl
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// OffloadingOverrideReason is the reason of the events generated for pods subject to workload-level offloading overrides.
+const OffloadingOverrideReason = "OffloadingOverride"
+
+// OffloadingOverrideReconciler reports the workload-level offloading overrides applied to pods.
+type OffloadingOverrideReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	// reader is a dedicated reader, backed by a cache including only the pods with pending overrides.
+	reader client.Reader
+}
+
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile records the event describing the offloading override applied to the given pod, and marks it as reported.
+func (r *OffloadingOverrideReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pod corev1.Pod
+	if err := r.reader.Get(ctx, req.NamespacedName, &pod); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("pod %q not found", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("an error occurred while getting pod %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if pod.Labels[consts.OffloadingOverrideLabelKey] != consts.OffloadingOverridePendingValue {
+		return ctrl.Result{}, nil
+	}
+
+	r.Recorder.Eventf(&pod, corev1.EventTypeNormal, OffloadingOverrideReason,
+		"Workload-level offloading override applied: %s", pod.Annotations[consts.OffloadingOverrideAnnotationKey])
+
+	original := pod.DeepCopy()
+	pod.Labels[consts.OffloadingOverrideLabelKey] = consts.OffloadingOverrideReportedValue
+	if err := r.Patch(ctx, &pod, client.MergeFrom(original)); err != nil {
+		klog.Errorf("unable to mark the offloading override of pod %q as reported: %v", klog.KObj(&pod), err)
+		return ctrl.Result{}, err
+	}
+
+	klog.V(4).Infof("offloading override of pod %q reported", klog.KObj(&pod))
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers a new controller to report the offloading overrides. Since the manager cache
+// is restricted to a subset of pods, a dedicated cache is set up to watch those subject to pending overrides.
+func (r *OffloadingOverrideReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	selector := labels.SelectorFromSet(labels.Set{consts.OffloadingOverrideLabelKey: consts.OffloadingOverridePendingValue})
+	podCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:            mgr.GetScheme(),
+		Mapper:            mgr.GetRESTMapper(),
+		SelectorsByObject: cache.SelectorsByObject{&corev1.Pod{}: {Label: selector}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = mgr.Add(podCache); err != nil {
+		return err
+	}
+	r.reader = podCache
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("offloadingoverride").
+		Watches(source.NewKindWithCache(&corev1.Pod{}, podCache), &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
@@ -0,0 +1,93 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offloadingoverridectrl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubectl/pkg/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("OffloadingOverrideController", func() {
+	const (
+		ns      = "default"
+		podName = "test-pod"
+	)
+
+	var (
+		ctx        context.Context
+		err        error
+		fakeClient client.WithWatch
+		recorder   *record.FakeRecorder
+		reconciler *OffloadingOverrideReconciler
+		pod        *corev1.Pod
+
+		req = ctrl.Request{NamespacedName: types.NamespacedName{Name: podName, Namespace: ns}}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        podName,
+			Namespace:   ns,
+			Labels:      map[string]string{consts.OffloadingOverrideLabelKey: consts.OffloadingOverridePendingValue},
+			Annotations: map[string]string{consts.OffloadingOverrideAnnotationKey: `pod offloading strategy "Local"`},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(pod).Build()
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &OffloadingOverrideReconciler{Client: fakeClient, Recorder: recorder, reader: fakeClient}
+		_, err = reconciler.Reconcile(ctx, req)
+	})
+
+	When("the override has not yet been reported", func() {
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should record the corresponding event", func() {
+			Expect(recorder.Events).To(Receive(And(ContainSubstring(OffloadingOverrideReason), ContainSubstring(`"Local"`))))
+		})
+		It("should mark the override as reported", func() {
+			var updated corev1.Pod
+			Expect(fakeClient.Get(ctx, req.NamespacedName, &updated)).To(Succeed())
+			Expect(updated.Labels).To(HaveKeyWithValue(consts.OffloadingOverrideLabelKey, consts.OffloadingOverrideReportedValue))
+		})
+	})
+
+	When("the override has already been reported", func() {
+		BeforeEach(func() { pod.Labels[consts.OffloadingOverrideLabelKey] = consts.OffloadingOverrideReportedValue })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not record any event", func() { Expect(recorder.Events).ToNot(Receive()) })
+	})
+
+	When("the pod does not exist", func() {
+		BeforeEach(func() { pod.Name = "other" })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not record any event", func() { Expect(recorder.Events).ToNot(Receive()) })
+	})
+})
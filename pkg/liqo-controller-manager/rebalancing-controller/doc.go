@@ -0,0 +1,19 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rebalancingctrl contains a controller that periodically evaluates the pods offloaded to remote clusters
+// against a set of rebalancing policies (i.e., overloaded remote clusters, high latency towards remote clusters,
+// and free capacity in the local cluster), and evicts the ones managed by a controller, so that they get
+// rescheduled elsewhere. Evictions honor PodDisruptionBudgets, and are limited to a maximum number per interval.
+package rebalancingctrl
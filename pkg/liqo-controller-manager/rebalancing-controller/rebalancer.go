@@ -0,0 +1,483 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebalancingctrl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+var (
+	// rebalancedPods counts the pods evicted from the virtual nodes to rebalance the offloaded workloads.
+	rebalancedPods = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "liqo_rebalancing_evicted_pods_total",
+		Help: "The total number of pods evicted from the virtual node of the given remote cluster, to rebalance the offloaded workloads.",
+	}, []string{"cluster_id", "reason"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(rebalancedPods)
+}
+
+// Reason identifies the rebalancing policy triggering the eviction of a pod.
+type Reason string
+
+const (
+	// OverloadedReason identifies pods evicted since the remote cluster hosting them is overloaded,
+	// i.e., the resources requested by the hosted pods exceed the threshold of the ones currently offered.
+	OverloadedReason Reason = "Overloaded"
+	// HighLatencyReason identifies pods evicted since the latency towards the remote cluster hosting them exceeds the threshold.
+	HighLatencyReason Reason = "HighLatency"
+	// LocalCapacityReason identifies pods evicted since the local cluster has enough free capacity to host them.
+	LocalCapacityReason Reason = "LocalCapacityAvailable"
+
+	// RebalancedReason is the reason of the event generated when a pod is evicted to rebalance the offloaded workloads.
+	RebalancedReason = "Rebalanced"
+
+	// RebalancingWindow is the period during which the replacements of the evicted pods are prevented from being
+	// scheduled onto the same virtual node.
+	RebalancingWindow = 2 * time.Minute
+)
+
+// nodeNameField is the field selector key identifying the node hosting a pod.
+const nodeNameField = "spec.nodeName"
+
+// Rebalancer periodically evaluates the pods offloaded to remote clusters against the rebalancing policies,
+// and evicts the ones managed by a controller, so that they get rescheduled elsewhere. A pod is considered for
+// eviction only if an alternative node (either local or virtual, depending on the pod offloading strategy of the
+// corresponding namespace) with enough free resources to host it exists, although the final placement is still
+// up to the scheduler. To prevent the replacements from being scheduled onto the same virtual node, the controllers
+// of the evicted pods are marked on the node before the eviction, and excluded by the pod mutating webhook.
+// Evictions honor PodDisruptionBudgets, and are limited to a maximum number per interval.
+type Rebalancer struct {
+	client.Client
+	// APIReader is a non-cached reader, used to retrieve the pods (which are only partially cached by the manager).
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+
+	// Interval is the period between two subsequent evaluations.
+	Interval time.Duration
+	// MaxMovesPerInterval is the maximum number of pods evicted during each evaluation.
+	MaxMovesPerInterval int
+	// OverloadThreshold is the percentage of the resources offered by a remote cluster (i.e., the allocatable resources
+	// of the corresponding virtual node) above which the cluster is considered overloaded. Zero disables the policy.
+	OverloadThreshold int
+	// LatencyThreshold is the latency towards a remote cluster above which the pods it hosts are moved elsewhere.
+	// Zero disables the policy.
+	LatencyThreshold time.Duration
+	// ReclaimLocalCapacity is whether pods allowed to run locally are moved back once the local cluster has free capacity.
+	ReclaimLocalCapacity bool
+}
+
+// move represents the planned eviction of a pod.
+type move struct {
+	pod       *corev1.Pod
+	clusterID string
+	reason    Reason
+}
+
+// candidateNode represents a node which could host the evicted pods, tracking its free resources.
+type candidateNode struct {
+	node      *corev1.Node
+	virtual   bool
+	clusterID string
+	free      corev1.ResourceList
+}
+
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups=net.liqo.io,resources=tunnelendpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=namespaceoffloadings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Start periodically rebalances the offloaded pods, until the context is canceled.
+func (r *Rebalancer) Start(ctx context.Context) error {
+	klog.Infof("Starting the rebalancing of offloaded pods, every %s", r.Interval)
+	wait.UntilWithContext(ctx, r.rebalance, r.Interval)
+	return nil
+}
+
+// rebalance evicts the pods selected by the rebalancing policies, up to the maximum number of moves per interval.
+func (r *Rebalancer) rebalance(ctx context.Context) {
+	moves, err := r.plan(ctx)
+	if err != nil {
+		klog.Errorf("Failed to evaluate the rebalancing of offloaded pods: %v", err)
+		return
+	}
+
+	var performed int
+	for _, m := range moves {
+		if performed >= r.MaxMovesPerInterval {
+			klog.V(4).Infof("Maximum number of moves (%d) reached, postponing the remaining ones", r.MaxMovesPerInterval)
+			break
+		}
+
+		// Mark the controller of the pod on the hosting node before evicting it, so that the replacement is not scheduled there again.
+		if err = r.avoidSourceNode(ctx, m.pod); err != nil {
+			klog.Warningf("Failed to mark the eviction of pod %q on node %q: %v", klog.KObj(m.pod), m.pod.Spec.NodeName, err)
+			continue
+		}
+
+		eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: m.pod.GetName(), Namespace: m.pod.GetNamespace()}}
+		if err = r.SubResource("eviction").Create(ctx, m.pod, eviction); err != nil {
+			// The eviction is denied in case it would violate a PodDisruptionBudget, and it will be reconsidered later.
+			if apierrors.IsTooManyRequests(err) {
+				klog.V(4).Infof("Eviction of pod %q denied by a PodDisruptionBudget: %v", klog.KObj(m.pod), err)
+				continue
+			}
+			klog.Warningf("Failed to evict pod %q from remote cluster %q: %v", klog.KObj(m.pod), m.clusterID, err)
+			continue
+		}
+
+		performed++
+		r.Recorder.Eventf(m.pod, corev1.EventTypeNormal, RebalancedReason,
+			"Evicted from remote cluster %q to rebalance the offloaded workloads (reason: %s)", m.clusterID, m.reason)
+		klog.Infof("Pod %q evicted from remote cluster %q to rebalance the offloaded workloads (reason: %s)", klog.KObj(m.pod), m.clusterID, m.reason)
+		rebalancedPods.WithLabelValues(m.clusterID, string(m.reason)).Inc()
+	}
+}
+
+// plan returns the pods to be evicted according to the rebalancing policies, sorted by priority (i.e., the ones hosted
+// by overloaded remote clusters first, then those hosted by high latency clusters, and finally those that could be
+// moved back to the local cluster). Pods are included only if an alternative node with enough free resources exists.
+func (r *Rebalancer) plan(ctx context.Context) ([]move, error) {
+	var nodes corev1.NodeList
+	if err := r.List(ctx, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	latencies, err := r.latencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Iterate over the nodes in a deterministic order.
+	sort.Slice(nodes.Items, func(i, j int) bool { return nodes.Items[i].Name < nodes.Items[j].Name })
+
+	// Classify the virtual nodes according to the rebalancing policies, and collect the candidate destinations.
+	// The active pods are retrieved only for the nodes either subject to rebalancing or eligible to host the evicted pods.
+	reasons := map[string]Reason{}
+	hosted := map[string][]*corev1.Pod{}
+	requested := map[string]corev1.ResourceList{}
+	var destinations []*candidateNode
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		clusterID, found := utils.GetNodeClusterID(node)
+		virtual := utils.IsVirtualNode(node) && found
+		eligible := !node.Spec.Unschedulable && utils.IsNodeReady(node)
+		if !virtual && !eligible {
+			continue
+		}
+
+		if hosted[node.Name], requested[node.Name], err = r.hostedPods(ctx, node.Name); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case virtual && r.overloaded(node, requested[node.Name]):
+			reasons[node.Name] = OverloadedReason
+		case virtual && r.LatencyThreshold > 0 && latencies[clusterID] > r.LatencyThreshold:
+			reasons[node.Name] = HighLatencyReason
+		case eligible:
+			// Only the nodes not subject to any rebalancing policy are eligible to host the evicted pods.
+			destinations = append(destinations, &candidateNode{node: node, virtual: virtual, clusterID: clusterID,
+				free: distribution.FreeResources(node.Status.Allocatable, requested[node.Name])})
+		}
+	}
+
+	strategies := map[string]offv1alpha1.PodOffloadingStrategyType{}
+	var overloaded, latency, local []move
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		clusterID, found := utils.GetNodeClusterID(node)
+		if !utils.IsVirtualNode(node) || !found || !utils.IsNodeReady(node) {
+			continue
+		}
+
+		reason, selected := reasons[node.Name]
+		if !selected && !r.ReclaimLocalCapacity {
+			continue
+		}
+
+		// Pods with the lower requests are moved first, as the ones most likely to be rescheduled successfully.
+		candidates := rebalanceablePods(hosted[node.Name], clusterID)
+		sort.SliceStable(candidates, func(i, j int) bool { return lessRequests(candidates[i], candidates[j]) })
+
+		// In case of overload, pods are moved only until the remote cluster is no longer overloaded.
+		remaining := requested[node.Name].DeepCopy()
+		for _, pod := range candidates {
+			if reason == OverloadedReason && !r.overloaded(node, remaining) {
+				break
+			}
+
+			// Pods not requesting any resource are moved only in case of high latency, as they would not reduce
+			// the overload, and there would be no guarantee that they fit into the local cluster.
+			requests := distribution.PodRequests(pod)
+			if reason != HighLatencyReason && requests.Cpu().IsZero() && requests.Memory().IsZero() {
+				continue
+			}
+
+			strategy, err := r.podOffloadingStrategy(ctx, pod.Namespace, strategies)
+			if err != nil {
+				return nil, err
+			}
+
+			// When no rebalancing policy applies to the remote cluster, pods are moved only back to the local cluster.
+			allowLocal := strategy != offv1alpha1.RemotePodOffloadingStrategyType
+			allowRemote := selected && strategy != offv1alpha1.LocalPodOffloadingStrategyType
+			if !selected && strategy != offv1alpha1.LocalAndRemotePodOffloadingStrategyType {
+				continue
+			}
+
+			if !reserveDestination(destinations, pod, requests, allowLocal, allowRemote) {
+				continue
+			}
+
+			distribution.SubtractRequests(remaining, requests)
+			switch reason {
+			case OverloadedReason:
+				overloaded = append(overloaded, move{pod: pod, clusterID: clusterID, reason: OverloadedReason})
+			case HighLatencyReason:
+				latency = append(latency, move{pod: pod, clusterID: clusterID, reason: HighLatencyReason})
+			default:
+				local = append(local, move{pod: pod, clusterID: clusterID, reason: LocalCapacityReason})
+			}
+		}
+	}
+
+	return append(append(overloaded, latency...), local...), nil
+}
+
+// hostedPods returns the active pods hosted by the given node, and the resources they request. Pods are retrieved through
+// the spec.nodeName field selector, which is indexed by the API server (and served from its watch cache), rather than
+// listing all the pods of the cluster, since the manager cache includes only a subset of them.
+func (r *Rebalancer) hostedPods(ctx context.Context, nodeName string) ([]*corev1.Pod, corev1.ResourceList, error) {
+	var pods corev1.PodList
+	if err := r.APIReader.List(ctx, &pods, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(nodeNameField, nodeName),
+		Raw:           &metav1.ListOptions{ResourceVersion: "0"},
+	}); err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods on node %q: %w", nodeName, err)
+	}
+
+	hosted := make([]*corev1.Pod, 0, len(pods.Items))
+	requested := corev1.ResourceList{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !distribution.IsActive(pod) {
+			continue
+		}
+
+		hosted = append(hosted, pod)
+		distribution.AddRequests(requested, distribution.PodRequests(pod))
+	}
+	return hosted, requested, nil
+}
+
+// avoidSourceNode marks the controller of the given pod on the virtual node hosting it, so that the pod mutating webhook
+// prevents the replacement from being scheduled onto the same node until the rebalancing window expires.
+// The expired marks are removed in the meanwhile.
+func (r *Rebalancer) avoidSourceNode(ctx context.Context, pod *corev1.Pod) error {
+	var node corev1.Node
+	if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+		return fmt.Errorf("failed to retrieve node: %w", err)
+	}
+
+	evictions, err := distribution.RebalancingEvictions(&node)
+	if err != nil {
+		klog.Warningf("Invalid %q annotation on node %q, overwriting it: %v", consts.RebalancingEvictionsAnnotationKey, node.Name, err)
+	}
+
+	now := time.Now()
+	for key, deadline := range evictions {
+		if !now.Before(time.Unix(deadline, 0)) {
+			delete(evictions, key)
+		}
+	}
+
+	// The pods considered for rebalancing are guaranteed to be managed by a controller.
+	evictions[distribution.OwnerKey(pod.Namespace, metav1.GetControllerOf(pod).UID)] = now.Add(RebalancingWindow).Unix()
+	encoded, err := json.Marshal(evictions)
+	if err != nil {
+		return err
+	}
+
+	original := node.DeepCopy()
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[consts.RebalancingEvictionsAnnotationKey] = string(encoded)
+	return r.Patch(ctx, &node, client.MergeFrom(original))
+}
+
+// overloaded returns whether the resources requested by the pods hosted by the given virtual node exceed
+// the threshold of the ones currently offered by the corresponding remote cluster.
+func (r *Rebalancer) overloaded(node *corev1.Node, requested corev1.ResourceList) bool {
+	if r.OverloadThreshold <= 0 {
+		return false
+	}
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		allocatable, found := node.Status.Allocatable[name]
+		if !found {
+			continue
+		}
+
+		threshold := resource.NewMilliQuantity(allocatable.MilliValue()*int64(r.OverloadThreshold)/100, allocatable.Format)
+		if used := requested[name]; used.Cmp(*threshold) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// latencies returns the latency towards each remote cluster, as reported by the corresponding TunnelEndpoint.
+func (r *Rebalancer) latencies(ctx context.Context) (map[string]time.Duration, error) {
+	latencies := map[string]time.Duration{}
+	if r.LatencyThreshold <= 0 {
+		return latencies, nil
+	}
+
+	var tunnels netv1alpha1.TunnelEndpointList
+	if err := r.List(ctx, &tunnels); err != nil {
+		return nil, fmt.Errorf("failed to list tunnel endpoints: %w", err)
+	}
+
+	for i := range tunnels.Items {
+		tunnel := &tunnels.Items[i]
+		if tunnel.Status.Connection.Latency.Value == "" {
+			continue
+		}
+
+		latency, err := time.ParseDuration(tunnel.Status.Connection.Latency.Value)
+		if err != nil {
+			klog.Warningf("Unable to parse the latency of tunnel endpoint %q: %v", klog.KObj(tunnel), err)
+			continue
+		}
+		latencies[tunnel.Spec.ClusterIdentity.ClusterID] = latency
+	}
+	return latencies, nil
+}
+
+// podOffloadingStrategy returns the pod offloading strategy configured for the given namespace, caching the result.
+func (r *Rebalancer) podOffloadingStrategy(ctx context.Context, namespace string,
+	cache map[string]offv1alpha1.PodOffloadingStrategyType) (offv1alpha1.PodOffloadingStrategyType, error) {
+	if strategy, found := cache[namespace]; found {
+		return strategy, nil
+	}
+
+	var nsoff offv1alpha1.NamespaceOffloading
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: consts.DefaultNamespaceOffloadingName}, &nsoff)
+	switch {
+	case apierrors.IsNotFound(err):
+		// The namespace is no longer offloaded, hence the pods it contains can only be rescheduled locally.
+		cache[namespace] = offv1alpha1.LocalPodOffloadingStrategyType
+	case err != nil:
+		return "", fmt.Errorf("failed to retrieve the NamespaceOffloading in namespace %q: %w", namespace, err)
+	default:
+		cache[namespace] = nsoff.Spec.PodOffloadingStrategy
+	}
+
+	return cache[namespace], nil
+}
+
+// rebalanceablePods returns the pods hosted by the given remote cluster that can be evicted to rebalance the offloaded
+// workloads, i.e., the ones managed by a controller (excluding DaemonSets). Pods assigned to the hosting cluster by the
+// weighted replica distribution are excluded, as their replacements would be steered back to the same cluster, while
+// those which ended up elsewhere (the assignment being just a preference) are moved as any other pod.
+func rebalanceablePods(pods []*corev1.Pod, clusterID string) []*corev1.Pod {
+	candidates := make([]*corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.Kind == "DaemonSet" {
+			continue
+		}
+
+		if pod.Labels[consts.ReplicaTargetClusterLabelKey] == clusterID {
+			continue
+		}
+		candidates = append(candidates, pod)
+	}
+	return candidates
+}
+
+// reserveDestination looks for a node with enough free resources to host the given pod (local nodes are preferred),
+// and reserves them. Only the nodes matching the required node affinity of the pod, and whose scheduling taints are
+// tolerated, are considered. It returns false in case no suitable node is found.
+func reserveDestination(destinations []*candidateNode, pod *corev1.Pod, requests corev1.ResourceList, allowLocal, allowRemote bool) bool {
+	affinity := nodeaffinity.GetRequiredNodeAffinity(pod)
+	for _, virtual := range []bool{false, true} {
+		if (!virtual && !allowLocal) || (virtual && !allowRemote) {
+			continue
+		}
+
+		for _, destination := range destinations {
+			if destination.virtual != virtual || len(distribution.ExceededResources(destination.free, nil, requests)) > 0 {
+				continue
+			}
+
+			if match, err := affinity.Match(destination.node); err != nil || !match || !toleratesTaints(pod, destination.node) {
+				continue
+			}
+
+			distribution.SubtractRequests(destination.free, requests)
+			return true
+		}
+	}
+	return false
+}
+
+// toleratesTaints returns whether the given pod tolerates the taints of the given node preventing the scheduling of pods.
+func toleratesTaints(pod *corev1.Pod, node *corev1.Node) bool {
+	_, untolerated := corev1helpers.FindMatchingUntoleratedTaint(node.Spec.Taints, pod.Spec.Tolerations, func(taint *corev1.Taint) bool {
+		return taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute
+	})
+	return !untolerated
+}
+
+// lessRequests returns whether the first pod requests less resources than the second one (cpu first, then memory).
+func lessRequests(first, second *corev1.Pod) bool {
+	firstRequests, secondRequests := distribution.PodRequests(first), distribution.PodRequests(second)
+	if cmp := firstRequests.Cpu().Cmp(*secondRequests.Cpu()); cmp != 0 {
+		return cmp < 0
+	}
+	return firstRequests.Memory().Cmp(*secondRequests.Memory()) < 0
+}
@@ -0,0 +1,285 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebalancingctrl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+var _ = Describe("Rebalancer", func() {
+	const (
+		namespace = "foo"
+		localNode = "local-node"
+	)
+
+	var (
+		ctx        context.Context
+		rebalancer *Rebalancer
+		objects    []client.Object
+		strategy   offv1alpha1.PodOffloadingStrategyType
+		moves      []move
+		err        error
+	)
+
+	node := func(name, clusterID, cpu string) *corev1.Node {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse("16Gi"), corev1.ResourcePods: resource.MustParse("110")},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		}
+		if clusterID != "" {
+			node.Labels[consts.TypeLabel] = consts.TypeNode
+			node.Labels[consts.RemoteClusterID] = clusterID
+		}
+		return node
+	}
+
+	pod := func(name, nodeName, cpu string, controlled bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{NodeName: nodeName, Containers: []corev1.Container{{Name: "c", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}}}}},
+		}
+		if controlled {
+			pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs",
+				UID: types.UID("rs-uid"), Controller: pointer.Bool(true)}}
+		}
+		return pod
+	}
+
+	tunnel := func(clusterID, latency string) *netv1alpha1.TunnelEndpoint {
+		return &netv1alpha1.TunnelEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Name: "tep-" + clusterID, Namespace: "liqo-tenant-" + clusterID},
+			Spec:       netv1alpha1.TunnelEndpointSpec{ClusterIdentity: discoveryv1alpha1.ClusterIdentity{ClusterID: clusterID}},
+			Status: netv1alpha1.TunnelEndpointStatus{Connection: netv1alpha1.Connection{
+				Latency: netv1alpha1.ConnectionLatency{Value: latency}}},
+		}
+	}
+
+	movedPods := func() []string {
+		var names []string
+		for _, m := range moves {
+			names = append(names, m.pod.Name)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		rebalancer = &Rebalancer{MaxMovesPerInterval: 5, OverloadThreshold: 100}
+		strategy = offv1alpha1.LocalAndRemotePodOffloadingStrategyType
+		objects = []client.Object{node(localNode, "", "2"), node("liqo-remote-1", "remote-1", "2"), node("liqo-remote-2", "remote-2", "2")}
+	})
+
+	JustBeforeEach(func() {
+		nsoff := &offv1alpha1.NamespaceOffloading{
+			ObjectMeta: metav1.ObjectMeta{Name: consts.DefaultNamespaceOffloadingName, Namespace: namespace},
+			Spec:       offv1alpha1.NamespaceOffloadingSpec{PodOffloadingStrategy: strategy},
+		}
+
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(append(objects, nsoff)...).
+			WithIndex(&corev1.Pod{}, nodeNameField, func(obj client.Object) []string { return []string{obj.(*corev1.Pod).Spec.NodeName} }).
+			Build()
+		rebalancer.Client, rebalancer.APIReader = cl, cl
+		moves, err = rebalancer.plan(ctx)
+	})
+
+	When("no rebalancing policy applies", func() {
+		BeforeEach(func() { objects = append(objects, pod("pod-1", "liqo-remote-1", "1", true)) })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not move any pod", func() { Expect(moves).To(BeEmpty()) })
+	})
+
+	When("a remote cluster is overloaded", func() {
+		BeforeEach(func() {
+			objects = append(objects, pod("pod-1", "liqo-remote-1", "1", true), pod("pod-2", "liqo-remote-1", "1", true),
+				pod("pod-3", "liqo-remote-1", "500m", true), pod("pod-4", "liqo-remote-1", "500m", false))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should move the controlled pods, until the cluster is no longer overloaded", func() {
+			Expect(movedPods()).To(ConsistOf("pod-3", "pod-1"))
+			for _, m := range moves {
+				Expect(m.clusterID).To(Equal("remote-1"))
+				Expect(m.reason).To(Equal(OverloadedReason))
+			}
+		})
+
+		When("the overload policy is disabled", func() {
+			BeforeEach(func() { rebalancer.OverloadThreshold = 0 })
+			It("should not move any pod", func() { Expect(moves).To(BeEmpty()) })
+		})
+
+		When("no alternative node has enough free resources", func() {
+			BeforeEach(func() {
+				objects = append(objects, pod("local-pod", localNode, "2", false), pod("remote-2-pod", "liqo-remote-2", "2", false))
+			})
+			It("should not move any pod", func() { Expect(moves).To(BeEmpty()) })
+		})
+	})
+
+	When("the latency towards a remote cluster is too high", func() {
+		BeforeEach(func() {
+			rebalancer.LatencyThreshold = 100 * time.Millisecond
+			objects = append(objects, tunnel("remote-1", "250ms"), tunnel("remote-2", "20ms"),
+				pod("pod-1", "liqo-remote-1", "500m", true), pod("pod-2", "liqo-remote-2", "500m", true))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should move the pods hosted by the high latency cluster", func() {
+			Expect(moves).To(HaveLen(1))
+			Expect(moves[0].pod.Name).To(Equal("pod-1"))
+			Expect(moves[0].reason).To(Equal(HighLatencyReason))
+		})
+
+		When("the pods are constrained to run remotely", func() {
+			BeforeEach(func() {
+				strategy = offv1alpha1.RemotePodOffloadingStrategyType
+				objects = append(objects, pod("remote-2-pod", "liqo-remote-2", "1500m", false))
+			})
+			It("should not move pods if no other remote cluster can host them", func() { Expect(moves).To(BeEmpty()) })
+		})
+	})
+
+	When("the local cluster has free capacity", func() {
+		BeforeEach(func() {
+			objects = append(objects, pod("pod-1", "liqo-remote-1", "500m", true), pod("pod-2", "liqo-remote-1", "0", true))
+		})
+
+		It("should not move any pod, if the policy is disabled", func() { Expect(moves).To(BeEmpty()) })
+
+		When("the policy is enabled", func() {
+			BeforeEach(func() { rebalancer.ReclaimLocalCapacity = true })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should move back the pods requesting resources", func() {
+				Expect(moves).To(HaveLen(1))
+				Expect(moves[0].pod.Name).To(Equal("pod-1"))
+				Expect(moves[0].reason).To(Equal(LocalCapacityReason))
+			})
+
+			When("the namespace is configured with the Remote strategy", func() {
+				BeforeEach(func() { strategy = offv1alpha1.RemotePodOffloadingStrategyType })
+				It("should not move any pod", func() { Expect(moves).To(BeEmpty()) })
+			})
+
+			When("the pods are assigned to the hosting cluster by the replica distribution", func() {
+				BeforeEach(func() {
+					for _, object := range objects {
+						if p, ok := object.(*corev1.Pod); ok {
+							p.Labels = map[string]string{consts.ReplicaTargetClusterLabelKey: "remote-1"}
+						}
+					}
+				})
+				It("should not move any pod", func() { Expect(moves).To(BeEmpty()) })
+			})
+
+			When("the pods are assigned to a different cluster by the replica distribution", func() {
+				BeforeEach(func() {
+					for _, object := range objects {
+						if p, ok := object.(*corev1.Pod); ok {
+							p.Labels = map[string]string{consts.ReplicaTargetClusterLabelKey: "remote-2"}
+						}
+					}
+				})
+				It("should move them as any other pod", func() { Expect(movedPods()).To(ConsistOf("pod-1")) })
+			})
+
+			When("the pods require a node affinity not matched by the local nodes", func() {
+				BeforeEach(func() {
+					for _, object := range objects {
+						if p, ok := object.(*corev1.Pod); ok {
+							p.Spec.NodeSelector = map[string]string{consts.TypeLabel: consts.TypeNode}
+						}
+					}
+				})
+				It("should not move any pod", func() { Expect(moves).To(BeEmpty()) })
+			})
+
+			When("the local nodes are tainted", func() {
+				taint := corev1.Taint{Key: "dedicated", Value: "infra", Effect: corev1.TaintEffectNoSchedule}
+
+				BeforeEach(func() {
+					for _, object := range objects {
+						if n, ok := object.(*corev1.Node); ok && n.Name == localNode {
+							n.Spec.Taints = []corev1.Taint{taint}
+						}
+					}
+				})
+
+				It("should not move the pods not tolerating the taints", func() { Expect(moves).To(BeEmpty()) })
+
+				When("the pods tolerate the taints", func() {
+					BeforeEach(func() {
+						for _, object := range objects {
+							if p, ok := object.(*corev1.Pod); ok {
+								p.Spec.Tolerations = []corev1.Toleration{{Key: taint.Key, Operator: corev1.TolerationOpExists}}
+							}
+						}
+					})
+					It("should move back the pods requesting resources", func() { Expect(movedPods()).To(ConsistOf("pod-1")) })
+				})
+			})
+		})
+	})
+
+	Describe("the marking of the evicted pods", func() {
+		var evicted *corev1.Pod
+
+		BeforeEach(func() {
+			evicted = pod("pod-1", "liqo-remote-1", "1", true)
+			source := objects[1].(*corev1.Node)
+			source.Annotations = map[string]string{consts.RebalancingEvictionsAnnotationKey: fmt.Sprintf(
+				`{"%s/expired":%d,"%s/other":%d}`, namespace, time.Now().Add(-time.Minute).Unix(), namespace, time.Now().Add(time.Minute).Unix())}
+			objects = append(objects, evicted)
+		})
+
+		JustBeforeEach(func() { err = rebalancer.avoidSourceNode(ctx, evicted) })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should mark the controller of the pod on the hosting node, removing the expired marks", func() {
+			var node corev1.Node
+			Expect(rebalancer.Get(ctx, types.NamespacedName{Name: "liqo-remote-1"}, &node)).To(Succeed())
+			evictions, decodeErr := distribution.RebalancingEvictions(&node)
+			Expect(decodeErr).ToNot(HaveOccurred())
+			Expect(evictions).To(HaveLen(2))
+			Expect(evictions).To(HaveKey(namespace + "/other"))
+			Expect(evictions).To(HaveKeyWithValue(namespace+"/rs-uid", BeNumerically(">", time.Now().Unix())))
+		})
+	})
+})
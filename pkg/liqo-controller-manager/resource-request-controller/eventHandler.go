@@ -50,7 +50,8 @@ func getForeignClusterEventHandler(c client.Client) handler.EventHandler {
 			}
 
 			remoteCluster := newForeignCluster.Spec.ClusterIdentity
-			if oldForeignCluster.Spec.IncomingPeeringEnabled != newForeignCluster.Spec.IncomingPeeringEnabled {
+			if oldForeignCluster.Spec.IncomingPeeringEnabled != newForeignCluster.Spec.IncomingPeeringEnabled ||
+				oldForeignCluster.Spec.RevokeIncomingIdentity != newForeignCluster.Spec.RevokeIncomingIdentity {
 				resourceRequest, err := GetResourceRequest(ctx, c, remoteCluster.ClusterID)
 				if err != nil {
 					klog.Errorf("[%s] failed to list resource requests: %s\n", remoteCluster.ClusterName, err)
@@ -15,7 +15,10 @@
 package resourcerequestoperator
 
 import (
+	"context"
+
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
 	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
 )
 
@@ -30,16 +33,25 @@ const (
 // getResourceRequestPhase returns the phase associated with a resource request. It is:
 // * "Deleting" if the deletion timestamp is set or the related offer has been withdrawn.
 // * "Allow" if the incoming peering is enabled in the ForeignCluster or through the command line parameter.
-// * "Deny" in the other cases (no ForeignCluster, incoming peering disabled, ...)
-func (r *ResourceRequestReconciler) getResourceRequestPhase(
+// * "Deny" in the other cases (no ForeignCluster, incoming peering disabled, identity revoked, ...)
+func (r *ResourceRequestReconciler) getResourceRequestPhase(ctx context.Context,
 	foreignCluster *discoveryv1alpha1.ForeignCluster,
 	resourceRequest *discoveryv1alpha1.ResourceRequest) (resourceRequestPhase, error) {
 	if !resourceRequest.GetDeletionTimestamp().IsZero() || !resourceRequest.Spec.WithdrawalTimestamp.IsZero() {
 		return deletingResourceRequestPhase, nil
 	}
 
-	if foreignclusterutils.AllowIncomingPeering(foreignCluster, r.EnableIncomingPeering) {
-		return allowResourceRequestPhase, nil
+	if !foreignclusterutils.AllowIncomingPeering(foreignCluster, r.EnableIncomingPeering) {
+		return denyResourceRequestPhase, nil
+	}
+
+	// The identity is still considered revoked until a new certificate is issued, even if the revocation has been lifted.
+	revoked, _, err := identitymanager.IsIdentityRevoked(ctx, r.Client, foreignCluster)
+	if err != nil {
+		return denyResourceRequestPhase, err
+	}
+	if revoked {
+		return denyResourceRequestPhase, nil
 	}
-	return denyResourceRequestPhase, nil
+	return allowResourceRequestPhase, nil
 }
@@ -62,7 +62,7 @@ var _ = Describe("Resource Phase", func() {
 					},
 				}
 
-				phase, err := controller.getResourceRequestPhase(foreignCluster, c.resourceRequest)
+				phase, err := controller.getResourceRequestPhase(ctx, foreignCluster, c.resourceRequest)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(phase).To(c.expectedResult)
 			},
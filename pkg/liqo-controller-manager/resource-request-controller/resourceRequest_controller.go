@@ -76,7 +76,7 @@ func (r *ResourceRequestReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	}
 
 	var resourceReqPhase resourceRequestPhase
-	resourceReqPhase, err = r.getResourceRequestPhase(foreignCluster, &resourceRequest)
+	resourceReqPhase, err = r.getResourceRequestPhase(ctx, foreignCluster, &resourceRequest)
 	if err != nil {
 		klog.Errorf("%s -> Error getting the ResourceRequest Phase: %s", remoteCluster.ClusterName, err)
 		return ctrl.Result{}, err
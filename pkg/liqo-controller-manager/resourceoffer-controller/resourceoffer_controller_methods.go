@@ -26,6 +26,7 @@ import (
 
 	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
+	argsutils "github.com/liqotech/liqo/pkg/utils/args"
 	foreigncluster "github.com/liqotech/liqo/pkg/utils/foreignCluster"
 	"github.com/liqotech/liqo/pkg/vkMachinery/forge"
 )
@@ -116,10 +117,22 @@ func (r *ResourceOfferReconciler) createVirtualKubeletDeployment(
 	klog.V(5).Infof("[%v] ClusterRoleBinding %s reconciled: %s",
 		remoteClusterIdentity.ClusterName, vkClusterRoleBinding.Name, op)
 
+	// configure the priority class mapping, if specified for the given peer
+	vkOpts := r.virtualKubeletOpts
+	if mapping, found := remoteCluster.GetAnnotations()[consts.PriorityClassMappingAnnotationKey]; found {
+		peerOpts := *r.virtualKubeletOpts
+		peerOpts.PriorityClassMapping = argsutils.StringMap{}
+		if err := peerOpts.PriorityClassMapping.Set(mapping); err != nil {
+			klog.Warningf("[%v] Ignoring invalid priority class mapping %q: %v", remoteClusterIdentity.ClusterName, mapping, err)
+		} else {
+			vkOpts = &peerOpts
+		}
+	}
+
 	// forge the virtual Kubelet
 	vkDeployment, err := forge.VirtualKubeletDeployment(
 		&r.cluster, &remoteClusterIdentity, namespace, r.liqoNamespace,
-		r.virtualKubeletOpts, resourceOffer)
+		vkOpts, resourceOffer)
 	if err != nil {
 		klog.Error(err)
 		return err
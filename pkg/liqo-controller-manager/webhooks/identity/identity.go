@@ -0,0 +1,94 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identitywh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
+	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+)
+
+type identitywh struct {
+	client client.Client
+}
+
+// New returns a new webhook denying the requests issued by remote clusters whose identity has been revoked.
+func New(cl client.Client) *webhook.Admission {
+	return &webhook.Admission{Handler: &identitywh{client: cl}}
+}
+
+// Handle implements the revoked identities webhook logic.
+//
+//nolint:gocritic // The signature of this method is imposed by controller runtime.
+func (w *identitywh) Handle(ctx context.Context, req admission.Request) admission.Response {
+	// Remote clusters authenticate through certificates belonging to the Liqo organization, whose
+	// common name corresponds to their cluster ID. Hence, ignore the requests issued by other users.
+	if !isRemoteCluster(req.UserInfo.Groups) {
+		return admission.Allowed("")
+	}
+
+	clusterID := req.UserInfo.Username
+	fc, err := foreignclusterutils.GetForeignClusterByID(ctx, w.client, clusterID)
+	if kerrors.IsNotFound(err) {
+		return admission.Allowed("")
+	}
+	if err != nil {
+		klog.Errorf("Failed retrieving ForeignCluster for cluster %q: %v", clusterID, err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	revoked, revocation, err := identitymanager.IsIdentityRevoked(ctx, w.client, fc)
+	if err != nil {
+		klog.Errorf("Failed retrieving the identity revocation for cluster %q: %v", clusterID, err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	// The API server does not expose the serial number of the client certificate, but recent versions provide its
+	// fingerprint, which allows to reject the revoked certificates even once a new identity has been issued.
+	if revoked || isCredentialRevoked(revocation, req.UserInfo.Extra) {
+		klog.Warningf("Denying %v operation on %v %q issued by cluster %q, as its identity has been revoked",
+			req.Operation, req.Resource.Resource, req.Name, clusterID)
+		return admission.Denied(fmt.Sprintf("the identity of cluster %q has been revoked", clusterID))
+	}
+	return admission.Allowed("")
+}
+
+func isRemoteCluster(groups []string) bool {
+	for _, group := range groups {
+		if group == identitymanager.RemoteClusterGroup {
+			return true
+		}
+	}
+	return false
+}
+
+func isCredentialRevoked(revocation *identitymanager.Revocation, extra map[string]authenticationv1.ExtraValue) bool {
+	for _, credentialID := range extra[identitymanager.CredentialIDExtraKey] {
+		if revocation.IsCredentialRevoked(credentialID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identitywh
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/discovery"
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
+	csrutil "github.com/liqotech/liqo/pkg/utils/csr"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+func TestIdentityWebhook(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Identity Webhook Suite")
+}
+
+var _ = BeforeSuite(func() {
+	testutil.LogsToGinkgoWriter()
+})
+
+var _ = Describe("Revoked identities webhook", func() {
+	const clusterID = "remote-cluster-id"
+
+	var (
+		ctx      context.Context
+		fc       discoveryv1alpha1.ForeignCluster
+		secret   corev1.Secret
+		groups   []string
+		extra    map[string]authenticationv1.ExtraValue
+		response admission.Response
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		groups = []string{identitymanager.RemoteClusterGroup, "system:authenticated"}
+		extra = nil
+		fc = discoveryv1alpha1.ForeignCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "remote", Labels: map[string]string{discovery.ClusterIDLabel: clusterID}},
+			Spec:       discoveryv1alpha1.ForeignClusterSpec{ClusterIdentity: discoveryv1alpha1.ClusterIdentity{ClusterID: clusterID}},
+			Status: discoveryv1alpha1.ForeignClusterStatus{
+				TenantNamespace: discoveryv1alpha1.TenantNamespaceType{Local: "liqo-tenant-remote"},
+			},
+		}
+
+		certificate, err := testutil.FakeSelfSignedCertificate(clusterID)
+		Expect(err).ToNot(HaveOccurred())
+		secret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: identitymanager.RemoteCertificateSecret, Namespace: fc.Status.TenantNamespace.Local},
+			Data:       map[string][]byte{"certificate": certificate},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(discoveryv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+		webhook := identitywh{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&fc, &secret).Build()}
+		response = webhook.Handle(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			UserInfo:  authenticationv1.UserInfo{Username: clusterID, Groups: groups, Extra: extra},
+		}})
+	})
+
+	When("the identity of the remote cluster is valid", func() {
+		It("should allow the request", func() { Expect(response.Allowed).To(BeTrue()) })
+	})
+
+	When("the identity of the remote cluster has been revoked", func() {
+		BeforeEach(func() { fc.Spec.RevokeIncomingIdentity = true })
+		It("should deny the request", func() { Expect(response.Allowed).To(BeFalse()) })
+
+		When("the request is not issued by a remote cluster", func() {
+			BeforeEach(func() { groups = []string{"system:authenticated"} })
+			It("should allow the request", func() { Expect(response.Allowed).To(BeTrue()) })
+		})
+	})
+
+	When("the revocation has been lifted", func() {
+		var credentialID string
+
+		BeforeEach(func() {
+			certificate, err := csrutil.ParseCertificate(secret.Data["certificate"])
+			Expect(err).ToNot(HaveOccurred())
+			credentialID = identitymanager.CredentialID(certificate)
+
+			identitymanager.SetRevocation(&secret, true)
+			identitymanager.SetRevocation(&secret, false)
+		})
+
+		When("no new identity has been issued since then", func() {
+			It("should deny the request", func() { Expect(response.Allowed).To(BeFalse()) })
+		})
+
+		When("the revoked certificates are still valid", func() {
+			BeforeEach(func() { delete(secret.Data, "certificate") })
+			It("should deny the request", func() { Expect(response.Allowed).To(BeFalse()) })
+		})
+
+		When("a new identity has been issued once the revoked certificates expired", func() {
+			BeforeEach(func() {
+				delete(secret.Data, "certificate")
+				secret.Annotations["discovery.liqo.io/revoked-until"] = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+			})
+			It("should allow the request", func() { Expect(response.Allowed).To(BeTrue()) })
+
+			When("the request is authenticated through the revoked certificate", func() {
+				BeforeEach(func() {
+					extra = map[string]authenticationv1.ExtraValue{identitymanager.CredentialIDExtraKey: {credentialID}}
+				})
+				It("should deny the request", func() { Expect(response.Allowed).To(BeFalse()) })
+			})
+		})
+	})
+})
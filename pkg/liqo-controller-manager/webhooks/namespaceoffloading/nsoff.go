@@ -16,25 +16,38 @@ package nsoffwh
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	mapsv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	"github.com/liqotech/liqo/internal/crdReplicator/reflection"
 	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/namespacemapping"
 )
 
 type nsoffwh struct {
-	decoder *admission.Decoder
+	client       client.Client
+	localCluster discoveryv1alpha1.ClusterIdentity
+	decoder      *admission.Decoder
 }
 
 // New returns a new NamespaceOffloadingWebhook instance.
-func New() *webhook.Admission {
-	return &webhook.Admission{Handler: &nsoffwh{}}
+func New(cl client.Client, localCluster discoveryv1alpha1.ClusterIdentity) *webhook.Admission {
+	return &webhook.Admission{Handler: &nsoffwh{client: cl, localCluster: localCluster}}
 }
 
 // InjectDecoder injects the decoder - this method is used by controller runtime.
@@ -67,8 +80,48 @@ func (w *nsoffwh) Handle(ctx context.Context, req admission.Request) admission.R
 		return admission.Denied("NamespaceOffloading name must match " + consts.DefaultNamespaceOffloadingName)
 	}
 
+	if nsoff.Spec.NamespaceMappingStrategy != offv1alpha1.TemplateNameMappingStrategyType && nsoff.Spec.NamespaceMappingTemplate != "" {
+		return admission.Denied(fmt.Sprintf("The NamespaceMappingTemplate can be specified only with the %v NamespaceMappingStrategy",
+			offv1alpha1.TemplateNameMappingStrategyType))
+	}
+
+	if len(nsoff.Spec.ReplicaWeights) > 0 && nsoff.Spec.PodOffloadingStrategy == offv1alpha1.LocalPodOffloadingStrategyType {
+		return admission.Denied(fmt.Sprintf("The ReplicaWeights cannot be specified with the %v PodOffloadingStrategy",
+			offv1alpha1.LocalPodOffloadingStrategyType))
+	}
+
+	if len(nsoff.Spec.ReplicaWeights) > 0 {
+		warnings = append(warnings, replicaWeightsWarnings(nsoff, w.localCluster.ClusterID)...)
+
+		unselected, weightsErr := w.validateReplicaWeights(ctx, nsoff)
+		if weightsErr != nil {
+			klog.Errorf("Failed validating the ReplicaWeights: %v", weightsErr)
+			return admission.Errored(http.StatusInternalServerError, weightsErr)
+		}
+		if len(unselected) > 0 {
+			return admission.Denied(fmt.Sprintf("The ReplicaWeights refer to remote clusters not selected by the ClusterSelector: %v", unselected))
+		}
+	}
+
+	if err = validateRemoteClusterQuota(nsoff.Spec.RemoteClusterQuota); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	if len(nsoff.Spec.RemoteClusterQuota) > 0 && nsoff.Spec.PodOffloadingStrategy == offv1alpha1.LocalPodOffloadingStrategyType {
+		warnings = append(warnings, fmt.Sprintf("The RemoteClusterQuota has no effect with the %v PodOffloadingStrategy",
+			offv1alpha1.LocalPodOffloadingStrategyType))
+	}
+
+	if err = namespacemapping.ValidatePropagatedKeys(nsoff.Spec.PropagatedLabels); err != nil {
+		return admission.Denied(fmt.Sprintf("Invalid PropagatedLabels: %v", err))
+	}
+
+	if err = namespacemapping.ValidatePropagatedKeys(nsoff.Spec.PropagatedAnnotations); err != nil {
+		return admission.Denied(fmt.Sprintf("Invalid PropagatedAnnotations: %v", err))
+	}
+
 	if req.Operation != admissionv1.Update {
-		return admission.Allowed("")
+		return w.validateRemoteNamespaceName(ctx, nsoff).WithWarnings(warnings...)
 	}
 
 	// In case of updates, validate the modified fields.
@@ -82,6 +135,10 @@ func (w *nsoffwh) Handle(ctx context.Context, req admission.Request) admission.R
 		return admission.Denied("The NamespaceMappingStrategy value cannot be modified after creation")
 	}
 
+	if old.Spec.NamespaceMappingTemplate != nsoff.Spec.NamespaceMappingTemplate {
+		return admission.Denied("The NamespaceMappingTemplate value cannot be modified after creation")
+	}
+
 	if nsoff.Spec.PodOffloadingStrategy != offv1alpha1.LocalAndRemotePodOffloadingStrategyType &&
 		old.Spec.PodOffloadingStrategy != nsoff.Spec.PodOffloadingStrategy {
 		const msg = "The PodOffloadingStrategy was mutated to a more restrictive setting: existing pods violating this policy might still be running"
@@ -90,3 +147,125 @@ func (w *nsoffwh) Handle(ctx context.Context, req admission.Request) admission.R
 
 	return admission.Allowed("").WithWarnings(warnings...)
 }
+
+// replicaWeightsWarnings returns the warnings concerning possibly unintended ReplicaWeights configurations.
+func replicaWeightsWarnings(nsoff *offv1alpha1.NamespaceOffloading, localClusterID string) []string {
+	var warnings []string
+	var total int32
+	for i := range nsoff.Spec.ReplicaWeights {
+		total += nsoff.Spec.ReplicaWeights[i].Weight
+		if nsoff.Spec.ReplicaWeights[i].ClusterID == localClusterID &&
+			nsoff.Spec.PodOffloadingStrategy == offv1alpha1.RemotePodOffloadingStrategyType {
+			warnings = append(warnings, fmt.Sprintf("The weight of the local cluster is ignored with the %v PodOffloadingStrategy",
+				offv1alpha1.RemotePodOffloadingStrategyType))
+		}
+	}
+
+	if total == 0 {
+		warnings = append(warnings, "All ReplicaWeights are zero: the distribution of the pods is left to the scheduler")
+	}
+	return warnings
+}
+
+// validateReplicaWeights returns the remote clusters referred to by the ReplicaWeights, which are not selected by the ClusterSelector,
+// that is none of the corresponding virtual nodes matches it. The clusters not (yet) associated with any virtual node are not considered,
+// as well as the ClusterSelector in case it is invalid (the corresponding error being reported by the NamespaceOffloading controller).
+func (w *nsoffwh) validateReplicaWeights(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading) ([]string, error) {
+	var nodes corev1.NodeList
+	if err := w.client.List(ctx, &nodes, client.MatchingLabels{consts.TypeLabel: consts.TypeNode}); err != nil {
+		return nil, fmt.Errorf("failed to list the virtual nodes: %w", err)
+	}
+
+	selected := make(map[string]bool, len(nodes.Items))
+	for i := range nodes.Items {
+		clusterID, found := utils.GetNodeClusterID(&nodes.Items[i])
+		if !found {
+			continue
+		}
+
+		match, err := utils.MatchNodeSelectorTerms(&nodes.Items[i], &nsoff.Spec.ClusterSelector)
+		selected[clusterID] = selected[clusterID] || match || err != nil
+	}
+
+	var unselected []string
+	for i := range nsoff.Spec.ReplicaWeights {
+		clusterID := nsoff.Spec.ReplicaWeights[i].ClusterID
+		if match, found := selected[clusterID]; found && !match {
+			unselected = append(unselected, clusterID)
+		}
+	}
+	return unselected, nil
+}
+
+// validateRemoteClusterQuota checks that the RemoteClusterQuota refers only to supported resources, with non-negative quantities.
+func validateRemoteClusterQuota(quota corev1.ResourceList) error {
+	for name, quantity := range quota {
+		switch name {
+		case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods:
+		default:
+			return fmt.Errorf("the RemoteClusterQuota supports only the %q, %q and %q resources (found %q)",
+				corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods, name)
+		}
+
+		if quantity.Sign() < 0 {
+			return fmt.Errorf("the RemoteClusterQuota for resource %q cannot be negative", name)
+		}
+	}
+	return nil
+}
+
+// validateRemoteNamespaceName checks that the NamespaceMappingStrategy produces a valid remote namespace name, which
+// does not collide with those already assigned to other namespaces. The check is performed regardless of the strategy,
+// since a name assigned through the DefaultName and EnforceSameName strategies can collide with a Template-generated one.
+func (w *nsoffwh) validateRemoteNamespaceName(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading) admission.Response {
+	// The namespace labels are required only in case of the Template strategy.
+	var namespaceLabels map[string]string
+	if nsoff.Spec.NamespaceMappingStrategy == offv1alpha1.TemplateNameMappingStrategyType {
+		namespace := &corev1.Namespace{}
+		if err := w.client.Get(ctx, types.NamespacedName{Name: nsoff.Namespace}, namespace); err != nil {
+			klog.Errorf("Failed retrieving Namespace %q: %v", nsoff.Namespace, err)
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		namespaceLabels = namespace.Labels
+	}
+
+	remoteName, err := namespacemapping.RemoteNamespaceName(nsoff, namespaceLabels, &w.localCluster)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	// Build the selector to consider only local NamespaceMaps.
+	metals := reflection.LocalResourcesLabelSelector()
+	selector, err := metav1.LabelSelectorAsSelector(&metals)
+	utilruntime.Must(err)
+
+	var nsmaps mapsv1alpha1.NamespaceMapList
+	if err = w.client.List(ctx, &nsmaps, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		klog.Errorf("Failed retrieving NamespaceMaps: %v", err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	for i := range nsmaps.Items {
+		for local, remote := range nsmaps.Items[i].Spec.DesiredMapping {
+			if local != nsoff.Namespace && remote == remoteName {
+				return admission.Denied(fmt.Sprintf("The remote namespace name %q is already assigned to namespace %q (NamespaceMap %q)",
+					remoteName, local, nsmaps.Items[i].GetName()))
+			}
+		}
+	}
+
+	// Additionally check the NamespaceOffloadings not (yet) associated with any remote cluster.
+	var nsoffs offv1alpha1.NamespaceOffloadingList
+	if err = w.client.List(ctx, &nsoffs); err != nil {
+		klog.Errorf("Failed retrieving NamespaceOffloadings: %v", err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	for i := range nsoffs.Items {
+		if nsoffs.Items[i].Namespace != nsoff.Namespace && nsoffs.Items[i].Status.RemoteNamespaceName == remoteName {
+			return admission.Denied(fmt.Sprintf("The remote namespace name %q is already assigned to namespace %q", remoteName, nsoffs.Items[i].Namespace))
+		}
+	}
+
+	return admission.Allowed("")
+}
@@ -0,0 +1,181 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+// replicaTargetMaxSkew is the maximum skew of the topology spread constraint steering the pods towards the target cluster.
+const replicaTargetMaxSkew = 1
+
+const (
+	// podIndexLabel is the label set by recent Kubernetes versions on the pods of StatefulSets, storing their ordinal index.
+	podIndexLabel = "apps.kubernetes.io/pod-index"
+	// jobCompletionIndexLabel is the label set by recent Kubernetes versions on the pods of indexed Jobs, storing their completion index.
+	jobCompletionIndexLabel = "batch.kubernetes.io/job-completion-index"
+)
+
+// eligibleWeights returns the subset of weights referring to the clusters allowed by the given pod offloading strategy,
+// and not explicitly excluded (e.g., since currently subject to failover, or exceeding the quota).
+func eligibleWeights(nsoff *offv1alpha1.NamespaceOffloading, localClusterID string, excluded map[string]bool) []offv1alpha1.ClusterWeight {
+	weights := make([]offv1alpha1.ClusterWeight, 0, len(nsoff.Spec.ReplicaWeights))
+	for i := range nsoff.Spec.ReplicaWeights {
+		clusterID := nsoff.Spec.ReplicaWeights[i].ClusterID
+		if excluded[clusterID] || (nsoff.Spec.PodOffloadingStrategy == offv1alpha1.RemotePodOffloadingStrategyType && clusterID == localClusterID) {
+			continue
+		}
+		weights = append(weights, nsoff.Spec.ReplicaWeights[i])
+	}
+	return weights
+}
+
+// excludedClusters returns the set of remote clusters which shall not be selected as the target of the replicas, that is
+// the ones currently subject to failover, and the ones not allowed by the (possibly overridden) ClusterSelector.
+// The failover state takes precedence, hence a cluster with at least one virtual node subject to failover is excluded.
+func (w *podwh) excludedClusters(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading) (map[string]bool, error) {
+	var nodes corev1.NodeList
+	if err := w.client.List(ctx, &nodes, client.MatchingLabels{liqoconst.TypeLabel: liqoconst.TypeNode}); err != nil {
+		return nil, fmt.Errorf("failed to list the virtual nodes: %w", err)
+	}
+
+	excluded := make(map[string]bool, len(nodes.Items))
+	selected := make(map[string]bool, len(nodes.Items))
+	for i := range nodes.Items {
+		clusterID, found := utils.GetNodeClusterID(&nodes.Items[i])
+		if !found {
+			continue
+		}
+
+		if _, failover := nodes.Items[i].Labels[liqoconst.FailoverLabelKey]; failover {
+			excluded[clusterID] = true
+			continue
+		}
+
+		// An invalid ClusterSelector is reported by the NamespaceOffloading controller, hence it is not considered here.
+		match, err := utils.MatchNodeSelectorTerms(&nodes.Items[i], &nsoff.Spec.ClusterSelector)
+		selected[clusterID] = selected[clusterID] || match || err != nil
+	}
+
+	// The remote clusters not associated with any (suitable) virtual node cannot host the pod.
+	for i := range nsoff.Spec.ReplicaWeights {
+		if clusterID := nsoff.Spec.ReplicaWeights[i].ClusterID; clusterID != w.localClusterID && !selected[clusterID] {
+			excluded[clusterID] = true
+		}
+	}
+	return excluded, nil
+}
+
+// siblingsSelector returns the label selector matching the pods created from the same template of the given one.
+// The labels set on a per-pod basis (either by Liqo or by the controllers) are not considered.
+func siblingsSelector(pod *corev1.Pod) map[string]string {
+	selector := map[string]string{}
+	for key, value := range pod.Labels {
+		switch key {
+		case liqoconst.ReplicaTargetClusterLabelKey, liqoconst.OffloadingOverrideLabelKey,
+			appsv1.StatefulSetPodNameLabel, appsv1.ControllerRevisionHashLabelKey, podIndexLabel, jobCompletionIndexLabel:
+			continue
+		}
+		selector[key] = value
+	}
+	return selector
+}
+
+// replicaTargetConstraint returns the topology spread constraint steering the pod towards the given target cluster.
+// The constraint spreads, across the clusters (as identified by the cluster identity label carried by both the local
+// and the virtual nodes), the sibling pods assigned to a different target: since these pods concentrate in the other
+// clusters, the scheduler favors the one hosting the fewest of them, that is the target cluster.
+func replicaTargetConstraint(pod *corev1.Pod, target string) corev1.TopologySpreadConstraint {
+	return corev1.TopologySpreadConstraint{
+		MaxSkew:           replicaTargetMaxSkew,
+		TopologyKey:       liqoconst.ClusterIdentityLabelKey,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: siblingsSelector(pod),
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key: liqoconst.ReplicaTargetClusterLabelKey, Operator: metav1.LabelSelectorOpNotIn, Values: []string{target},
+			}},
+		},
+	}
+}
+
+// enforceReplicaDistribution steers the pod towards a target cluster, in case a weighted distribution of the replicas
+// is configured. The target cluster is selected at random, with a probability proportional to the configured weights,
+// and enforced through a soft topology spread constraint keyed on the cluster identity label, so that the pod can still
+// be scheduled onto the other allowed clusters in case the target one is not able to host it (e.g., since it is full).
+// The clusters whose quota would be exceeded by the pod (according to the given violations), as well as those excluded
+// by the ClusterSelector, are not considered as possible targets. The resulting distribution is statistical, hence it
+// converges towards the configured weights as the number of replicas grows, without the need to account for the other pods.
+func (w *podwh) enforceReplicaDistribution(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading,
+	pod *corev1.Pod, violations map[string][]corev1.ResourceName) error {
+	if len(nsoff.Spec.ReplicaWeights) == 0 || nsoff.Spec.PodOffloadingStrategy == offv1alpha1.LocalPodOffloadingStrategyType {
+		return nil
+	}
+
+	// Pods not managed by any controller are not subject to the replica distribution.
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil
+	}
+
+	excluded, err := w.excludedClusters(ctx, nsoff)
+	if err != nil {
+		return err
+	}
+	for clusterID, exceeded := range violations {
+		if len(exceeded) > 0 {
+			excluded[clusterID] = true
+		}
+	}
+
+	target, found := distribution.WeightedCluster(eligibleWeights(nsoff, w.localClusterID, excluded), w.random)
+	if !found {
+		return nil
+	}
+
+	klog.V(4).Infof("Pod controlled by %s %q in namespace %q steered towards cluster %q", owner.Kind, owner.Name, nsoff.Namespace, target)
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[liqoconst.ReplicaTargetClusterLabelKey] = target
+	pod.Spec.TopologySpreadConstraints = append(pod.Spec.TopologySpreadConstraints, replicaTargetConstraint(pod, target))
+	return nil
+}
+
+// fillPodWithThePreferredNodeSelectorTerm adds the given term, with the given weight, to the ones preferred during scheduling by the pod.
+func fillPodWithThePreferredNodeSelectorTerm(term corev1.NodeSelectorTerm, weight int32, pod *corev1.Pod) {
+	if pod.Spec.Affinity == nil {
+		pod.Spec.Affinity = &corev1.Affinity{}
+	}
+	if pod.Spec.Affinity.NodeAffinity == nil {
+		pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+
+	pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.PreferredSchedulingTerm{Weight: weight, Preference: term})
+}
@@ -0,0 +1,79 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+// failoverRestorePreferenceWeight is the weight of the preferred node affinity term steering the replacements of the pods
+// rescheduled to restore the original distribution towards the recovered cluster.
+const failoverRestorePreferenceWeight = 100
+
+// preferRestoredCluster steers the pod towards the remote cluster which has recovered from a failure, in case it replaces
+// one of the pods rescheduled to restore the original distribution (i.e., it is controlled by an owner marked on the
+// corresponding virtual node). The preference is enforced through a preferred node affinity term, so that the pod
+// can still be scheduled elsewhere in case the recovered cluster is not able to host it.
+func (w *podwh) preferRestoredCluster(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading, pod *corev1.Pod) error {
+	if nsoff.Spec.PodOffloadingStrategy == offv1alpha1.LocalPodOffloadingStrategyType {
+		return nil
+	}
+
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil
+	}
+
+	var nodes corev1.NodeList
+	if err := w.client.List(ctx, &nodes, client.MatchingLabels{liqoconst.TypeLabel: liqoconst.TypeNode}); err != nil {
+		return fmt.Errorf("failed to list the virtual nodes: %w", err)
+	}
+
+	key := distribution.OwnerKey(nsoff.Namespace, owner.UID)
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if _, found := node.Annotations[liqoconst.FailoverRestoringAnnotationKey]; !found {
+			continue
+		}
+
+		restoring, err := distribution.FailoverRestoring(node)
+		if err != nil {
+			klog.Warningf("Invalid %q annotation on node %s: %v", liqoconst.FailoverRestoringAnnotationKey, node.Name, err)
+			continue
+		}
+
+		clusterID, found := utils.GetNodeClusterID(node)
+		if deadline, restored := restoring[key]; found && restored && time.Now().Before(time.Unix(deadline, 0)) {
+			klog.V(4).Infof("Pod controlled by %s %q in namespace %q steered towards the recovered cluster %q",
+				owner.Kind, owner.Name, nsoff.Namespace, clusterID)
+			fillPodWithThePreferredNodeSelectorTerm(distribution.NodeSelectorForCluster(clusterID, w.localClusterID).NodeSelectorTerms[0],
+				failoverRestorePreferenceWeight, pod)
+			return nil
+		}
+	}
+	return nil
+}
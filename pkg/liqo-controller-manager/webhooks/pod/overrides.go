@@ -0,0 +1,130 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+// isNarrowerStrategy returns whether the pod offloading strategy requested for a given workload
+// does not widen the set of nodes allowed by the namespace strategy.
+func isNarrowerStrategy(namespace, workload offv1alpha1.PodOffloadingStrategyType) bool {
+	switch {
+	case workload == namespace, workload == offv1alpha1.LocalPodOffloadingStrategyType:
+		return true
+	case namespace == offv1alpha1.LocalAndRemotePodOffloadingStrategyType:
+		return workload == offv1alpha1.RemotePodOffloadingStrategyType
+	default:
+		return false
+	}
+}
+
+// nodeSelectorRequirementsFromSelector converts a label selector into the equivalent set of NodeSelectorRequirements.
+func nodeSelectorRequirementsFromSelector(selector labels.Selector) ([]corev1.NodeSelectorRequirement, error) {
+	requirements, _ := selector.Requirements()
+	converted := make([]corev1.NodeSelectorRequirement, 0, len(requirements))
+	for i := range requirements {
+		var operator corev1.NodeSelectorOperator
+		switch requirements[i].Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			operator = corev1.NodeSelectorOpIn
+		case selection.NotEquals, selection.NotIn:
+			operator = corev1.NodeSelectorOpNotIn
+		case selection.Exists:
+			operator = corev1.NodeSelectorOpExists
+		case selection.DoesNotExist:
+			operator = corev1.NodeSelectorOpDoesNotExist
+		case selection.GreaterThan:
+			operator = corev1.NodeSelectorOpGt
+		case selection.LessThan:
+			operator = corev1.NodeSelectorOpLt
+		default:
+			return nil, fmt.Errorf("unsupported operator %q", requirements[i].Operator())
+		}
+
+		converted = append(converted, corev1.NodeSelectorRequirement{
+			Key:      requirements[i].Key(),
+			Operator: operator,
+			Values:   requirements[i].Values().List(),
+		})
+	}
+	return converted, nil
+}
+
+// applyWorkloadOverrides returns the NamespaceOffloading to be enforced for the given pod, starting from the one of
+// the namespace and narrowing it according to the overrides specified through the pod annotations. It additionally
+// returns a human-readable description of the applied overrides (empty if none is present). An error is returned in case
+// the overrides are malformed, or they would widen the constraints configured at the namespace level.
+func applyWorkloadOverrides(nsoff *offv1alpha1.NamespaceOffloading, pod *corev1.Pod) (*offv1alpha1.NamespaceOffloading, string, error) {
+	strategy, strategyFound := pod.Annotations[liqoconst.PodOffloadingStrategyAnnotationKey]
+	selector, selectorFound := pod.Annotations[liqoconst.PodClusterSelectorAnnotationKey]
+	if !strategyFound && !selectorFound {
+		return nsoff, "", nil
+	}
+
+	effective := nsoff.DeepCopy()
+	var description []string
+
+	if strategyFound {
+		workload := offv1alpha1.PodOffloadingStrategyType(strategy)
+		switch workload {
+		case offv1alpha1.LocalPodOffloadingStrategyType, offv1alpha1.RemotePodOffloadingStrategyType,
+			offv1alpha1.LocalAndRemotePodOffloadingStrategyType:
+		default:
+			return nil, "", fmt.Errorf("invalid %q annotation: unknown pod offloading strategy %q",
+				liqoconst.PodOffloadingStrategyAnnotationKey, strategy)
+		}
+
+		if !isNarrowerStrategy(nsoff.Spec.PodOffloadingStrategy, workload) {
+			return nil, "", fmt.Errorf("invalid %q annotation: pod offloading strategy %q would widen the namespace one (%q)",
+				liqoconst.PodOffloadingStrategyAnnotationKey, workload, nsoff.Spec.PodOffloadingStrategy)
+		}
+
+		effective.Spec.PodOffloadingStrategy = workload
+		description = append(description, fmt.Sprintf("pod offloading strategy %q (namespace: %q)", workload, nsoff.Spec.PodOffloadingStrategy))
+	}
+
+	if selectorFound {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid %q annotation: %w", liqoconst.PodClusterSelectorAnnotationKey, err)
+		}
+		requirements, err := nodeSelectorRequirementsFromSelector(parsed)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid %q annotation: %w", liqoconst.PodClusterSelectorAnnotationKey, err)
+		}
+
+		// The additional requirements are appended to every term of the namespace selector, hence
+		// they can only restrict the set of matching virtual nodes (i.e., the terms are in AND).
+		if len(requirements) > 0 && len(effective.Spec.ClusterSelector.NodeSelectorTerms) == 0 {
+			effective.Spec.ClusterSelector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+		}
+		for i := range effective.Spec.ClusterSelector.NodeSelectorTerms {
+			term := &effective.Spec.ClusterSelector.NodeSelectorTerms[i]
+			term.MatchExpressions = append(term.MatchExpressions, requirements...)
+		}
+		description = append(description, fmt.Sprintf("additional cluster selector %q", parsed.String()))
+	}
+
+	return effective, strings.Join(description, ", "), nil
+}
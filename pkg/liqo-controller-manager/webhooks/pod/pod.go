@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math/rand"
 	"net/http"
 
 	corev1 "k8s.io/api/core/v1"
@@ -28,21 +29,34 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
 )
 
 // cluster-role
 // +kubebuilder:rbac:groups=offloading.liqo.io,resources=namespaceoffloadings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 
 type podwh struct {
 	client  client.Client
 	decoder *admission.Decoder
+
+	// reader is a non-cached reader, used to retrieve the pods (which are only partially cached by the manager).
+	reader         client.Reader
+	localClusterID string
+
+	// random returns a pseudo-random number in [0,n), and it is used to select the target cluster of the replicas.
+	random func(n int64) int64
 }
 
 // New returns a new PodWebhook instance.
-func New(cl client.Client) *webhook.Admission {
-	return &webhook.Admission{Handler: &podwh{client: cl}}
+func New(cl client.Client, reader client.Reader, localCluster discoveryv1alpha1.ClusterIdentity) *webhook.Admission {
+	return &webhook.Admission{Handler: &podwh{
+		client: cl, reader: reader, localClusterID: localCluster.ClusterID,
+		random: rand.Int63n, // A weak random generator is sufficient.
+	}}
 }
 
 // InjectDecoder injects the decoder - this method is used by controller runtime.
@@ -90,9 +104,56 @@ func (w *podwh) Handle(ctx context.Context, req admission.Request) admission.Res
 		return admission.Errored(http.StatusInternalServerError, errors.New("failed retrieving NamespaceOffloading"))
 	}
 
+	// Narrow the constraints imposed by the NamespaceOffloading according to the workload-level overrides, if any.
+	nsoff, override, err := applyWorkloadOverrides(nsoff, pod)
+	if err != nil {
+		klog.Warningf("Rejecting pod %q in namespace %q: %v", pod.GetName(), req.Namespace, err)
+		return admission.Denied(err.Error())
+	}
+
+	if override != "" {
+		// Mark the pod, so that the override is reported through an event once the pod has been created.
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[liqoconst.OffloadingOverrideLabelKey] = liqoconst.OffloadingOverridePendingValue
+		pod.Annotations[liqoconst.OffloadingOverrideAnnotationKey] = override
+	}
+
 	if err = mutatePod(nsoff, pod); err != nil {
 		return admission.Errored(http.StatusInternalServerError, errors.New("failed constructing pod mutation"))
 	}
 
+	// Retrieve the remote clusters whose quota would be exceeded by the pod, in case a quota is configured.
+	violations, err := w.quotaViolations(ctx, nsoff, pod)
+	if err != nil {
+		klog.Errorf("Failed computing the remote cluster quota usage for pod in namespace %q: %v", req.Namespace, err)
+		return admission.Errored(http.StatusInternalServerError, errors.New("failed computing the remote cluster quota usage"))
+	}
+
+	// Assign the pod to a target cluster, in case a weighted distribution of the replicas is configured.
+	if err = w.enforceReplicaDistribution(ctx, nsoff, pod, violations); err != nil {
+		klog.Errorf("Failed enforcing the replica distribution for pod in namespace %q: %v", req.Namespace, err)
+		return admission.Errored(http.StatusInternalServerError, errors.New("failed enforcing the replica distribution"))
+	}
+
+	// Steer the pod towards the recovered cluster, in case it replaces one rescheduled to restore the original distribution.
+	if err = w.preferRestoredCluster(ctx, nsoff, pod); err != nil {
+		klog.Errorf("Failed enforcing the failover restoration for pod in namespace %q: %v", req.Namespace, err)
+		return admission.Errored(http.StatusInternalServerError, errors.New("failed enforcing the failover restoration"))
+	}
+
+	// Prevent the pod from being scheduled onto the remote clusters its predecessor has just been evicted from.
+	if err = w.avoidRebalancedClusters(ctx, nsoff, pod); err != nil {
+		klog.Errorf("Failed enforcing the rebalancing constraints for pod in namespace %q: %v", req.Namespace, err)
+		return admission.Errored(http.StatusInternalServerError, errors.New("failed enforcing the rebalancing constraints"))
+	}
+
+	// Prevent the pod from being offloaded to the remote clusters whose quota would be exceeded.
+	if reason := enforceRemoteClusterQuota(nsoff, pod, violations); reason != "" {
+		klog.Warningf("Rejecting pod %q in namespace %q: %v", pod.GetName(), req.Namespace, reason)
+		return admission.Denied(reason)
+	}
+
 	return w.CreatePatchResponse(&req, pod)
 }
@@ -15,14 +15,20 @@
 package pod
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
@@ -279,4 +285,356 @@ var _ = Describe("Webhook", func() {
 			Expect(*podTest.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(Equal(oldPodNodeSelector))
 		})
 	})
+
+	Context("6 - Check the workload-level offloading overrides", func() {
+		podWithAnnotations := func(annotations map[string]string) *corev1.Pod {
+			return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "test", Annotations: annotations}}
+		}
+
+		It("Should return the namespace constraints in case no override is present", func() {
+			namespaceOffloading := testutils.GetNamespaceOffloading(offv1alpha1.RemotePodOffloadingStrategyType)
+			effective, description, err := applyWorkloadOverrides(&namespaceOffloading, podWithAnnotations(nil))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(description).To(BeEmpty())
+			Expect(effective).To(PointTo(Equal(namespaceOffloading)))
+		})
+
+		DescribeTable("Overriding the pod offloading strategy",
+			func(namespace, workload offv1alpha1.PodOffloadingStrategyType, allowed bool) {
+				namespaceOffloading := testutils.GetNamespaceOffloading(namespace)
+				effective, description, err := applyWorkloadOverrides(&namespaceOffloading,
+					podWithAnnotations(map[string]string{liqoconst.PodOffloadingStrategyAnnotationKey: string(workload)}))
+				if !allowed {
+					Expect(err).To(HaveOccurred())
+					return
+				}
+				Expect(err).ToNot(HaveOccurred())
+				Expect(description).To(ContainSubstring(string(workload)))
+				Expect(effective.Spec.PodOffloadingStrategy).To(Equal(workload))
+				Expect(namespaceOffloading.Spec.PodOffloadingStrategy).To(Equal(namespace))
+			},
+			Entry("Local to Local", offv1alpha1.LocalPodOffloadingStrategyType, offv1alpha1.LocalPodOffloadingStrategyType, true),
+			Entry("Local to Remote", offv1alpha1.LocalPodOffloadingStrategyType, offv1alpha1.RemotePodOffloadingStrategyType, false),
+			Entry("Local to LocalAndRemote", offv1alpha1.LocalPodOffloadingStrategyType,
+				offv1alpha1.LocalAndRemotePodOffloadingStrategyType, false),
+			Entry("Remote to Local", offv1alpha1.RemotePodOffloadingStrategyType, offv1alpha1.LocalPodOffloadingStrategyType, true),
+			Entry("Remote to LocalAndRemote", offv1alpha1.RemotePodOffloadingStrategyType,
+				offv1alpha1.LocalAndRemotePodOffloadingStrategyType, false),
+			Entry("LocalAndRemote to Local", offv1alpha1.LocalAndRemotePodOffloadingStrategyType,
+				offv1alpha1.LocalPodOffloadingStrategyType, true),
+			Entry("LocalAndRemote to Remote", offv1alpha1.LocalAndRemotePodOffloadingStrategyType,
+				offv1alpha1.RemotePodOffloadingStrategyType, true),
+			Entry("Unknown strategy", offv1alpha1.LocalAndRemotePodOffloadingStrategyType,
+				offv1alpha1.PodOffloadingStrategyType("Elsewhere"), false),
+		)
+
+		It("Should append the additional cluster selector to every term of the namespace one", func() {
+			namespaceOffloading := testutils.GetNamespaceOffloading(offv1alpha1.RemotePodOffloadingStrategyType)
+			effective, description, err := applyWorkloadOverrides(&namespaceOffloading,
+				podWithAnnotations(map[string]string{liqoconst.PodClusterSelectorAnnotationKey: "zone in (z1,z2),!spot"}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(description).To(ContainSubstring("additional cluster selector"))
+
+			terms := effective.Spec.ClusterSelector.NodeSelectorTerms
+			Expect(terms).To(HaveLen(len(namespaceOffloading.Spec.ClusterSelector.NodeSelectorTerms)))
+			for i := range terms {
+				Expect(terms[i].MatchExpressions).To(HaveLen(len(namespaceOffloading.Spec.ClusterSelector.NodeSelectorTerms[i].MatchExpressions) + 2))
+				Expect(terms[i].MatchExpressions).To(ContainElements(
+					corev1.NodeSelectorRequirement{Key: "spot", Operator: corev1.NodeSelectorOpDoesNotExist, Values: []string{}},
+					corev1.NodeSelectorRequirement{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"z1", "z2"}},
+				))
+			}
+		})
+
+		It("Should create a new term in case the namespace selector is empty", func() {
+			namespaceOffloading := testutils.GetNamespaceOffloading(offv1alpha1.LocalAndRemotePodOffloadingStrategyType)
+			namespaceOffloading.Spec.ClusterSelector = corev1.NodeSelector{}
+			effective, _, err := applyWorkloadOverrides(&namespaceOffloading,
+				podWithAnnotations(map[string]string{liqoconst.PodClusterSelectorAnnotationKey: "zone=z1"}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(effective.Spec.ClusterSelector.NodeSelectorTerms).To(ConsistOf(corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"z1"}}},
+			}))
+		})
+
+		It("Should reject a malformed cluster selector", func() {
+			namespaceOffloading := testutils.GetNamespaceOffloading(offv1alpha1.RemotePodOffloadingStrategyType)
+			_, _, err := applyWorkloadOverrides(&namespaceOffloading,
+				podWithAnnotations(map[string]string{liqoconst.PodClusterSelectorAnnotationKey: "zone in z1"}))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("7 - Check the enforcement of the replica distribution", func() {
+		const localClusterID = "local-cluster-id"
+
+		var (
+			ctx     context.Context
+			webhook *podwh
+			nsoff   offv1alpha1.NamespaceOffloading
+			owner   metav1.OwnerReference
+			pod     *corev1.Pod
+		)
+
+		newPod := func(name string) *corev1.Pod {
+			return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "test", OwnerReferences: []metav1.OwnerReference{owner},
+			}}
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			owner = metav1.OwnerReference{Kind: "ReplicaSet", Name: "rs", UID: "uid", Controller: pointer.Bool(true)}
+			nsoff = testutils.GetNamespaceOffloading(offv1alpha1.LocalAndRemotePodOffloadingStrategyType)
+			nsoff.Spec.ReplicaWeights = []offv1alpha1.ClusterWeight{{ClusterID: localClusterID, Weight: 50}, {ClusterID: "remote", Weight: 50}}
+
+			virtualNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "remote", Labels: map[string]string{
+				liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: "remote", "zone": "z1"}}}
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(virtualNode).Build()
+			// By default, the last eligible cluster is selected as target.
+			webhook = &podwh{client: cl, reader: cl, localClusterID: localClusterID, random: func(n int64) int64 { return n - 1 }}
+			pod = newPod("new")
+			pod.Labels = map[string]string{"app": "test"}
+		})
+
+		It("Should steer the pod towards the target cluster through a topology spread constraint", func() {
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, nil)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue(liqoconst.ReplicaTargetClusterLabelKey, "remote"))
+			Expect(pod.Spec.Affinity).To(BeNil())
+			Expect(pod.Spec.TopologySpreadConstraints).To(ConsistOf(corev1.TopologySpreadConstraint{
+				MaxSkew: replicaTargetMaxSkew, TopologyKey: liqoconst.ClusterIdentityLabelKey, WhenUnsatisfiable: corev1.ScheduleAnyway,
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "test"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key: liqoconst.ReplicaTargetClusterLabelKey, Operator: metav1.LabelSelectorOpNotIn, Values: []string{"remote"}}},
+				},
+			}))
+		})
+
+		It("Should select the target cluster proportionally to the weights", func() {
+			var drawn int64
+			webhook.random = func(n int64) int64 { drawn = n; return 49 }
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, nil)).To(Succeed())
+			Expect(drawn).To(BeNumerically("==", 100))
+			Expect(pod.Labels).To(HaveKeyWithValue(liqoconst.ReplicaTargetClusterLabelKey, localClusterID))
+		})
+
+		It("Should not steer the pod towards clusters excluded by the ClusterSelector", func() {
+			nsoff.Spec.ClusterSelector = corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"z2"}}},
+			}}}
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, nil)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue(liqoconst.ReplicaTargetClusterLabelKey, localClusterID))
+		})
+
+		It("Should not steer the pod towards clusters without virtual nodes", func() {
+			nsoff.Spec.ReplicaWeights = append(nsoff.Spec.ReplicaWeights, offv1alpha1.ClusterWeight{ClusterID: "unknown", Weight: 1000})
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, nil)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue(liqoconst.ReplicaTargetClusterLabelKey, "remote"))
+		})
+
+		It("Should ignore the weight of the local cluster with the Remote strategy", func() {
+			nsoff.Spec.ReplicaWeights = []offv1alpha1.ClusterWeight{{ClusterID: localClusterID, Weight: 100}, {ClusterID: "remote", Weight: 1}}
+			nsoff.Spec.PodOffloadingStrategy = offv1alpha1.RemotePodOffloadingStrategyType
+			webhook.random = func(int64) int64 { return 0 }
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, nil)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue(liqoconst.ReplicaTargetClusterLabelKey, "remote"))
+		})
+
+		It("Should not assign the pod to clusters subject to failover", func() {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "virtual-node", Labels: map[string]string{
+				liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: "remote",
+				liqoconst.FailoverLabelKey: liqoconst.FailoverCordonedValue,
+			}}}
+			Expect(webhook.client.Create(ctx, node)).To(Succeed())
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, nil)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue(liqoconst.ReplicaTargetClusterLabelKey, localClusterID))
+		})
+
+		It("Should steer the replacements of the restored pods towards the recovered cluster", func() {
+			deadline := time.Now().Add(time.Minute).Unix()
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "recovered", Labels: map[string]string{
+				liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: "recovered"},
+				Annotations: map[string]string{liqoconst.FailoverRestoringAnnotationKey: fmt.Sprintf(`{"%s/uid":%d}`, nsoff.Namespace, deadline)},
+			}}
+			Expect(webhook.client.Create(ctx, node)).To(Succeed())
+			Expect(webhook.preferRestoredCluster(ctx, &nsoff, pod)).To(Succeed())
+			Expect(pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(ConsistOf(
+				corev1.PreferredSchedulingTerm{Weight: failoverRestorePreferenceWeight, Preference: corev1.NodeSelectorTerm{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpIn, Values: []string{"recovered"}}}}},
+			))
+		})
+
+		It("Should not steer the pods once the restoration window is expired", func() {
+			deadline := time.Now().Add(-time.Minute).Unix()
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "recovered", Labels: map[string]string{
+				liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: "recovered"},
+				Annotations: map[string]string{liqoconst.FailoverRestoringAnnotationKey: fmt.Sprintf(`{"%s/uid":%d}`, nsoff.Namespace, deadline)},
+			}}
+			Expect(webhook.client.Create(ctx, node)).To(Succeed())
+			Expect(webhook.preferRestoredCluster(ctx, &nsoff, pod)).To(Succeed())
+			Expect(pod.Spec.Affinity).To(BeNil())
+		})
+
+		It("Should prevent the replacements of the rebalanced pods from being scheduled onto the same cluster", func() {
+			deadline := time.Now().Add(time.Minute).Unix()
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rebalanced", Labels: map[string]string{
+				liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: "rebalanced"},
+				Annotations: map[string]string{liqoconst.RebalancingEvictionsAnnotationKey: fmt.Sprintf(`{"%s/uid":%d}`, nsoff.Namespace, deadline)},
+			}}
+			Expect(webhook.client.Create(ctx, node)).To(Succeed())
+			Expect(webhook.avoidRebalancedClusters(ctx, &nsoff, pod)).To(Succeed())
+			Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(ConsistOf(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"rebalanced"}}}},
+			))
+		})
+
+		It("Should not constrain the pods once the rebalancing window is expired", func() {
+			deadline := time.Now().Add(-time.Minute).Unix()
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rebalanced", Labels: map[string]string{
+				liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: "rebalanced"},
+				Annotations: map[string]string{liqoconst.RebalancingEvictionsAnnotationKey: fmt.Sprintf(`{"%s/uid":%d}`, nsoff.Namespace, deadline)},
+			}}
+			Expect(webhook.client.Create(ctx, node)).To(Succeed())
+			Expect(webhook.avoidRebalancedClusters(ctx, &nsoff, pod)).To(Succeed())
+			Expect(pod.Spec.Affinity).To(BeNil())
+		})
+
+		It("Should not mutate pods without a controller", func() {
+			pod.OwnerReferences = nil
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, nil)).To(Succeed())
+			Expect(pod.Labels).ToNot(HaveKey(liqoconst.ReplicaTargetClusterLabelKey))
+			Expect(pod.Spec.Affinity).To(BeNil())
+		})
+
+		It("Should not mutate pods in case no weight is specified", func() {
+			nsoff.Spec.ReplicaWeights = nil
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, nil)).To(Succeed())
+			Expect(pod.Labels).ToNot(HaveKey(liqoconst.ReplicaTargetClusterLabelKey))
+			Expect(pod.Spec.Affinity).To(BeNil())
+		})
+	})
+
+	Context("8 - Check the enforcement of the remote cluster quota", func() {
+		const localClusterID = "local-cluster-id"
+
+		var (
+			ctx     context.Context
+			webhook *podwh
+			nsoff   offv1alpha1.NamespaceOffloading
+			pod     *corev1.Pod
+		)
+
+		newPod := func(name, node, cpu string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+				Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}}}}},
+			}
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			nsoff = testutils.GetNamespaceOffloading(offv1alpha1.LocalAndRemotePodOffloadingStrategyType)
+			nsoff.Namespace = "test"
+			nsoff.Spec.RemoteClusterQuota = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourcePods: resource.MustParse("10")}
+
+			virtualNode := func(clusterID string) *corev1.Node {
+				return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: clusterID, Labels: map[string]string{
+					liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: clusterID}}}
+			}
+
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+				virtualNode("remote-1"), virtualNode("remote-2"), &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "local"}},
+				newPod("existing-1", "remote-1", "750m"), newPod("existing-2", "local", "2"),
+			).Build()
+			webhook = &podwh{client: cl, reader: cl, localClusterID: localClusterID, random: func(n int64) int64 { return 0 }}
+			pod = newPod("new", "", "500m")
+		})
+
+		It("Should exclude the remote clusters whose quota would be exceeded", func() {
+			violations, err := webhook.quotaViolations(ctx, &nsoff, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violations).To(HaveLen(2))
+			Expect(violations).To(HaveKeyWithValue("remote-1", ConsistOf(corev1.ResourceCPU)))
+			Expect(violations).To(HaveKeyWithValue("remote-2", BeEmpty()))
+
+			Expect(enforceRemoteClusterQuota(&nsoff, pod, violations)).To(BeEmpty())
+			Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(ConsistOf(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"remote-1"}}}},
+			))
+		})
+
+		It("Should account for the clusters not yet hosting any pod", func() {
+			pod = newPod("new", "", "1500m")
+			violations, err := webhook.quotaViolations(ctx, &nsoff, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violations).To(HaveKeyWithValue("remote-2", ConsistOf(corev1.ResourceCPU)))
+		})
+
+		It("Should reject the pod if it can only be offloaded remotely, and no cluster has enough quota left", func() {
+			nsoff.Spec.PodOffloadingStrategy = offv1alpha1.RemotePodOffloadingStrategyType
+			violations := map[string][]corev1.ResourceName{"remote-1": {corev1.ResourceCPU}, "remote-2": {corev1.ResourcePods}}
+			Expect(enforceRemoteClusterQuota(&nsoff, pod, violations)).To(ContainSubstring("exceed the quota of all remote clusters"))
+		})
+
+		It("Should not assign the pod to clusters whose quota would be exceeded", func() {
+			nsoff.Spec.ReplicaWeights = []offv1alpha1.ClusterWeight{{ClusterID: "remote-1", Weight: 100}, {ClusterID: localClusterID, Weight: 1}}
+			pod.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", UID: "uid", Controller: pointer.Bool(true)}}
+			violations := map[string][]corev1.ResourceName{"remote-1": {corev1.ResourceCPU}, "remote-2": nil}
+			Expect(webhook.enforceReplicaDistribution(ctx, &nsoff, pod, violations)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue(liqoconst.ReplicaTargetClusterLabelKey, localClusterID))
+		})
+
+		It("Should account for the pending pods in the clusters they could be scheduled onto", func() {
+			pending := newPod("pending", "", "400m")
+			pending.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"remote-1"}}},
+				}}}}}
+			Expect(webhook.client.Create(ctx, pending)).To(Succeed())
+
+			pod = newPod("new", "", "700m")
+			violations, err := webhook.quotaViolations(ctx, &nsoff, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violations).To(HaveKeyWithValue("remote-1", ConsistOf(corev1.ResourceCPU)))
+			Expect(violations).To(HaveKeyWithValue("remote-2", ConsistOf(corev1.ResourceCPU)))
+		})
+
+		It("Should reject the pods not specifying the requests of the resources limited by the quota, with the Remote strategy", func() {
+			nsoff.Spec.PodOffloadingStrategy = offv1alpha1.RemotePodOffloadingStrategyType
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{})
+			Expect(enforceRemoteClusterQuota(&nsoff, pod, nil)).To(ContainSubstring("shall specify the requests"))
+		})
+
+		It("Should keep in the local cluster the pods not specifying the requests of the resources limited by the quota", func() {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{})
+			violations, err := webhook.quotaViolations(ctx, &nsoff, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violations).To(HaveKeyWithValue("remote-2", ConsistOf(corev1.ResourceCPU)))
+
+			Expect(enforceRemoteClusterQuota(&nsoff, pod, violations)).To(BeEmpty())
+			Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(ConsistOf(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: liqoconst.TypeLabel, Operator: corev1.NodeSelectorOpNotIn, Values: []string{liqoconst.TypeNode}}}},
+			))
+		})
+
+		It("Should not reject the pods not specifying the requests of the resources not limited by the quota", func() {
+			nsoff.Spec.RemoteClusterQuota = corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{})
+			Expect(enforceRemoteClusterQuota(&nsoff, pod, nil)).To(BeEmpty())
+		})
+
+		It("Should not compute the usage in case no quota is specified", func() {
+			nsoff.Spec.RemoteClusterQuota = nil
+			Expect(webhook.quotaViolations(ctx, &nsoff, pod)).To(BeEmpty())
+			Expect(enforceRemoteClusterQuota(&nsoff, pod, nil)).To(BeEmpty())
+			Expect(pod.Spec.Affinity).To(BeNil())
+		})
+	})
 })
@@ -0,0 +1,185 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+// quotaViolations returns, for each remote cluster, the resources whose quota would be exceeded in case the given pod
+// was offloaded there (an empty list meaning that the pod fits the quota). The remote clusters considered are the ones
+// associated with a virtual node, as well as the ones already hosting pods of the namespace. The check is best-effort,
+// since concurrent admissions are not serialized, while the ResourceQuota enforced in each remote namespace is the actual limit.
+func (w *podwh) quotaViolations(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading,
+	pod *corev1.Pod) (map[string][]corev1.ResourceName, error) {
+	if len(nsoff.Spec.RemoteClusterQuota) == 0 || nsoff.Spec.PodOffloadingStrategy == offv1alpha1.LocalPodOffloadingStrategyType {
+		return nil, nil
+	}
+
+	var pods corev1.PodList
+	if err := w.reader.List(ctx, &pods, client.InNamespace(nsoff.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %q: %w", nsoff.Namespace, err)
+	}
+
+	usage, err := distribution.NewClusterResolver(w.client, w.localClusterID).CurrentUsage(ctx, pods.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes corev1.NodeList
+	if err = w.client.List(ctx, &nodes, client.MatchingLabels{liqoconst.TypeLabel: liqoconst.TypeNode}); err != nil {
+		return nil, fmt.Errorf("failed to list the virtual nodes: %w", err)
+	}
+
+	for i := range nodes.Items {
+		if clusterID, found := utils.GetNodeClusterID(&nodes.Items[i]); found {
+			if _, ok := usage[clusterID]; !ok {
+				usage[clusterID] = corev1.ResourceList{}
+			}
+		}
+	}
+
+	// The pods not yet scheduled (nor assigned to any cluster) are conservatively accounted for in all the remote
+	// clusters they could be scheduled onto, so that a burst of pods created at the same time does not exceed the quota.
+	for i := range pods.Items {
+		pending := &pods.Items[i]
+		_, assigned := pending.Labels[liqoconst.ReplicaTargetClusterLabelKey]
+		if assigned || pending.Spec.NodeName != "" || !distribution.IsActive(pending) {
+			continue
+		}
+
+		for clusterID := range schedulableClusters(pending, nodes.Items) {
+			distribution.AddRequests(usage[clusterID], distribution.PodRequests(pending))
+		}
+	}
+
+	// The pods not specifying the requests of the limited resources would be rejected by all the remote clusters.
+	missing := missingRequests(nsoff.Spec.RemoteClusterQuota, pod)
+	requests := distribution.PodRequests(pod)
+	violations := make(map[string][]corev1.ResourceName, len(usage))
+	for clusterID, used := range usage {
+		violations[clusterID] = append(distribution.ExceededResources(nsoff.Spec.RemoteClusterQuota, used, requests), missing...)
+	}
+	return violations, nil
+}
+
+// schedulableClusters returns the set of remote clusters the given pod could be scheduled onto, according to its required
+// node affinity, given the virtual nodes. Errors are conservatively considered as a match.
+func schedulableClusters(pod *corev1.Pod, nodes []corev1.Node) map[string]struct{} {
+	affinity := nodeaffinity.GetRequiredNodeAffinity(pod)
+	clusters := map[string]struct{}{}
+	for i := range nodes {
+		clusterID, found := utils.GetNodeClusterID(&nodes[i])
+		if !found {
+			continue
+		}
+
+		if match, err := affinity.Match(&nodes[i]); match || err != nil {
+			clusters[clusterID] = struct{}{}
+		}
+	}
+	return clusters
+}
+
+// missingRequests returns the compute resources limited by the given quota, whose requests are not specified by all the
+// containers of the given pod. Indeed, the ResourceQuota enforced in the remote namespaces rejects these pods.
+func missingRequests(quota corev1.ResourceList, pod *corev1.Pod) []corev1.ResourceName {
+	var missing []corev1.ResourceName
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if _, limited := quota[name]; !limited {
+			continue
+		}
+
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for i := range containers {
+			if _, found := containers[i].Resources.Requests[name]; !found {
+				missing = append(missing, name)
+				break
+			}
+		}
+	}
+	return missing
+}
+
+// enforceRemoteClusterQuota prevents the pod from being scheduled on the remote clusters whose quota would be exceeded,
+// through an additional NodeSelector excluding the corresponding virtual nodes. It returns a non-empty reason in case the
+// pod shall be rejected, since it cannot be hosted by any suitable cluster without exceeding the quota.
+func enforceRemoteClusterQuota(nsoff *offv1alpha1.NamespaceOffloading, pod *corev1.Pod, violations map[string][]corev1.ResourceName) string {
+	if nsoff.Spec.PodOffloadingStrategy == offv1alpha1.LocalPodOffloadingStrategyType {
+		return ""
+	}
+
+	// The pods not specifying the requests of the limited resources would be rejected by the remote ResourceQuota.
+	// Hence, they are rejected in case they can be offloaded only remotely, and kept in the local cluster otherwise.
+	if missing := missingRequests(nsoff.Spec.RemoteClusterQuota, pod); len(missing) > 0 {
+		if nsoff.Spec.PodOffloadingStrategy == offv1alpha1.RemotePodOffloadingStrategyType {
+			return fmt.Sprintf("all containers shall specify the requests of the resources limited by the remote cluster quota %v", missing)
+		}
+
+		klog.V(4).Infof("Pod in namespace %q prevented from being offloaded, as not specifying the requests limited by the quota %v",
+			nsoff.Namespace, missing)
+		fillPodWithTheNewNodeSelector(&corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{
+				{Key: liqoconst.TypeLabel, Operator: corev1.NodeSelectorOpNotIn, Values: []string{liqoconst.TypeNode}}},
+		}}}, pod)
+		return ""
+	}
+
+	var clusters, descriptions []string
+	for clusterID, exceeded := range violations {
+		if len(exceeded) > 0 {
+			clusters = append(clusters, clusterID)
+		}
+	}
+
+	if len(clusters) == 0 {
+		return ""
+	}
+
+	sort.Strings(clusters)
+	for _, clusterID := range clusters {
+		descriptions = append(descriptions, fmt.Sprintf("%q %v", clusterID, violations[clusterID]))
+	}
+
+	// The pod has already been assigned to a cluster whose quota would be exceeded.
+	if target, found := pod.Labels[liqoconst.ReplicaTargetClusterLabelKey]; found && len(violations[target]) > 0 {
+		return fmt.Sprintf("the pod would exceed the quota of the target remote cluster %q %v", target, violations[target])
+	}
+
+	// The pod can be offloaded only remotely, and no remote cluster has enough quota left.
+	if nsoff.Spec.PodOffloadingStrategy == offv1alpha1.RemotePodOffloadingStrategyType && len(clusters) == len(violations) {
+		return fmt.Sprintf("the pod would exceed the quota of all remote clusters (%s)", strings.Join(descriptions, ", "))
+	}
+
+	klog.V(4).Infof("Pod in namespace %q prevented from being offloaded to remote clusters exceeding the quota: %s",
+		nsoff.Namespace, strings.Join(descriptions, ", "))
+	fillPodWithTheNewNodeSelector(&corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+		MatchExpressions: []corev1.NodeSelectorRequirement{{Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpNotIn, Values: clusters}},
+	}}}, pod)
+	return ""
+}
@@ -0,0 +1,86 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+// avoidRebalancedClusters prevents the pod from being scheduled onto the remote clusters its predecessor has just been
+// evicted from to rebalance the offloaded workloads (i.e., it is controlled by an owner marked on the corresponding
+// virtual nodes), which would otherwise possibly select the same node again. The constraint is enforced through a
+// required node affinity term, as the eviction is performed only if an alternative node exists.
+func (w *podwh) avoidRebalancedClusters(ctx context.Context, nsoff *offv1alpha1.NamespaceOffloading, pod *corev1.Pod) error {
+	if nsoff.Spec.PodOffloadingStrategy == offv1alpha1.LocalPodOffloadingStrategyType {
+		return nil
+	}
+
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil
+	}
+
+	var nodes corev1.NodeList
+	if err := w.client.List(ctx, &nodes, client.MatchingLabels{liqoconst.TypeLabel: liqoconst.TypeNode}); err != nil {
+		return fmt.Errorf("failed to list the virtual nodes: %w", err)
+	}
+
+	var excluded []string
+	key := distribution.OwnerKey(nsoff.Namespace, owner.UID)
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if _, found := node.Annotations[liqoconst.RebalancingEvictionsAnnotationKey]; !found {
+			continue
+		}
+
+		evictions, err := distribution.RebalancingEvictions(node)
+		if err != nil {
+			klog.Warningf("Invalid %q annotation on node %s: %v", liqoconst.RebalancingEvictionsAnnotationKey, node.Name, err)
+			continue
+		}
+
+		clusterID, found := utils.GetNodeClusterID(node)
+		if deadline, evicted := evictions[key]; found && evicted && time.Now().Before(time.Unix(deadline, 0)) {
+			excluded = append(excluded, clusterID)
+		}
+	}
+
+	if len(excluded) == 0 {
+		return nil
+	}
+
+	sort.Strings(excluded)
+	klog.V(4).Infof("Pod controlled by %s %q in namespace %q prevented from being scheduled onto the rebalanced clusters %v",
+		owner.Kind, owner.Name, nsoff.Namespace, excluded)
+	fillPodWithTheNewNodeSelector(&corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+		MatchExpressions: []corev1.NodeSelectorRequirement{{
+			Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpNotIn, Values: excluded,
+		}},
+	}}}, pod)
+	return nil
+}
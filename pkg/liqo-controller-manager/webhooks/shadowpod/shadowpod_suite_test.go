@@ -17,12 +17,14 @@ package shadowpod
 import (
 	"context"
 	"encoding/json"
+	"strconv"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -96,6 +98,7 @@ var _ = BeforeSuite(func() {
 	testutil.LogsToGinkgoWriter()
 	Expect(vkv1alpha1.AddToScheme(scheme)).To(Succeed())
 	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	Expect(schedulingv1.AddToScheme(scheme)).To(Succeed())
 	Expect(sharingv1alpha1.AddToScheme(scheme)).To(Succeed())
 	Expect(discoveryv1alpha1.AddToScheme(scheme)).To(Succeed())
 	var err error
@@ -165,6 +168,16 @@ func forgeShadowPodWithClusterID(clusterID, namespace string) *vkv1alpha1.Shadow
 	}
 }
 
+func forgePriorityClass(name string, allowed bool) *schedulingv1.PriorityClass {
+	return &schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{consts.RemotePriorityClassLabelKey: strconv.FormatBool(allowed)},
+		},
+		Value: 1000,
+	}
+}
+
 func forgeShadowPod(name, namespace, uid, clusterID string) *vkv1alpha1.ShadowPod {
 	return &vkv1alpha1.ShadowPod{
 		ObjectMeta: metav1.ObjectMeta{
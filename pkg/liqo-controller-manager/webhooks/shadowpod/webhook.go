@@ -21,6 +21,8 @@ import (
 
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
@@ -40,6 +42,7 @@ import (
 // +kubebuilder:rbac:groups=virtualkubelet.liqo.io,resources=shadowpods,verbs=get;list;watch
 // +kubebuilder:rbac:groups=sharing.liqo.io,resources=resourceoffers,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=get;list;watch
 
 // Validator is the handler used by the Validating Webhook to validate shadow pods.
 type Validator struct {
@@ -106,6 +109,13 @@ func (spv *Validator) HandleCreate(ctx context.Context, req *admission.Request)
 		return admission.Denied(err.Error())
 	}
 
+	if code, err = spv.validateShadowPodPriorityClass(ctx, &shadowpod.Spec.Pod); err != nil {
+		if code == http.StatusInternalServerError {
+			return admission.Errored(code, err)
+		}
+		return admission.Denied(err.Error())
+	}
+
 	if !spv.enableResourceValidation {
 		return admission.Allowed("")
 	}
@@ -238,6 +248,30 @@ func (spv *Validator) validateShadowPodClusterID(ctx context.Context, ns, spClus
 	return http.StatusOK, nil
 }
 
+// validateShadowPodPriorityClass checks that the priority class of the shadow pod (if any) has been explicitly allowed
+// by the local cluster for offloaded pods, to prevent remote clusters from preempting the local workloads.
+// The priority classes are retrieved through the cached client, hence changes to their labels are promptly enforced.
+func (spv *Validator) validateShadowPodPriorityClass(ctx context.Context, spec *corev1.PodSpec) (int32, error) {
+	if spec.PriorityClassName == "" {
+		return http.StatusOK, nil
+	}
+
+	var priorityClass schedulingv1.PriorityClass
+	if err := spv.client.Get(ctx, client.ObjectKey{Name: spec.PriorityClassName}, &priorityClass); err != nil {
+		if kerrors.IsNotFound(err) {
+			return http.StatusForbidden, fmt.Errorf("priority class %q does not exist", spec.PriorityClassName)
+		}
+		return http.StatusInternalServerError, fmt.Errorf("failed retrieving priority class %q: %w", spec.PriorityClassName, err)
+	}
+
+	if priorityClass.Labels[consts.RemotePriorityClassLabelKey] != "true" {
+		klog.Warningf("Priority class %q is not allowed for offloaded pods", spec.PriorityClassName)
+		return http.StatusForbidden, fmt.Errorf("priority class %q is not allowed for offloaded pods", spec.PriorityClassName)
+	}
+
+	return http.StatusOK, nil
+}
+
 // DecodeShadowPod decodes a shadow pod from a given runtime object.
 func (spv *Validator) DecodeShadowPod(obj runtime.RawExtension) (shadowpod *vkv1alpha1.ShadowPod, err error) {
 	shadowpod = &vkv1alpha1.ShadowPod{}
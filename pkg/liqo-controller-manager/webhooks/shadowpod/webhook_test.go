@@ -91,6 +91,40 @@ var _ = Describe("Validating webhook", func() {
 				Expect(response.Allowed).To(BeTrue())
 			})
 		})
+		When("the shadowpod refers to a priority class allowed for offloaded pods", func() {
+			BeforeEach(func() {
+				Expect(fakeClient.Create(ctx, forgePriorityClass("allowed", true))).To(Succeed())
+				fakeNewShadowPod = forgeShadowPodWithClusterID(clusterID, testNamespace)
+				fakeNewShadowPod.Spec.Pod.PriorityClassName = "allowed"
+				request = forgeRequest(admissionv1.Create, fakeNewShadowPod, nil)
+			})
+			It("should admit the request", func() {
+				Expect(response.Allowed).To(BeTrue())
+			})
+		})
+		When("the shadowpod refers to a priority class not allowed for offloaded pods", func() {
+			BeforeEach(func() {
+				Expect(fakeClient.Create(ctx, forgePriorityClass("denied", false))).To(Succeed())
+				fakeNewShadowPod = forgeShadowPodWithClusterID(clusterID, testNamespace)
+				fakeNewShadowPod.Spec.Pod.PriorityClassName = "denied"
+				request = forgeRequest(admissionv1.Create, fakeNewShadowPod, nil)
+			})
+			It("should return a forbidden response", func() {
+				Expect(response.Allowed).To(BeFalse())
+				Expect(response.Result.Code).To(BeNumerically("==", http.StatusForbidden))
+			})
+		})
+		When("the shadowpod refers to a non-existing priority class", func() {
+			BeforeEach(func() {
+				fakeNewShadowPod = forgeShadowPodWithClusterID(clusterID, testNamespace)
+				fakeNewShadowPod.Spec.Pod.PriorityClassName = "non-existing"
+				request = forgeRequest(admissionv1.Create, fakeNewShadowPod, nil)
+			})
+			It("should return a forbidden response", func() {
+				Expect(response.Allowed).To(BeFalse())
+				Expect(response.Result.Code).To(BeNumerically("==", http.StatusForbidden))
+			})
+		})
 		When("the shadowpod namespace not exists", func() {
 			BeforeEach(func() {
 				fakeNewShadowPod = forgeShadowPodWithClusterID(clusterID, testNamespaceInvalid)
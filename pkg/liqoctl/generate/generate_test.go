@@ -16,13 +16,23 @@ package generate
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/liqotech/liqo/pkg/auth"
@@ -119,4 +129,66 @@ var _ = Describe("Test the generate command works as expected", func() {
 			commandName+" peer out-of-band "+localClusterName+" --auth-url https://foo.bar.com:8443 --cluster-id "+localClusterID+" --auth-token "+token,
 		),
 	)
+
+	When("the authentication service serves a TLS certificate", func() {
+		var fingerprint string
+
+		BeforeEach(func() {
+			setup([]string{fmt.Sprintf("--%v=%v", consts.ClusterNameParameter, localClusterName)}, map[string]string{})
+
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+			Expect(err).ToNot(HaveOccurred())
+
+			sum := sha256.Sum256(der)
+			fingerprint = hex.EncodeToString(sum[:])
+
+			Expect(options.CRClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: auth.ServingCertificateSecretName, Namespace: options.LiqoNamespace},
+				Data:       map[string][]byte{corev1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})},
+			})).To(Succeed())
+		})
+
+		It("should embed the fingerprint of the CA in the command", func() {
+			Expect(options.generate(ctx)).To(HaveSuffix(" --auth-token " + token + " --ca-fingerprint " + fingerprint))
+		})
+	})
+
+	When("a dedicated peering token is requested", func() {
+		var (
+			command string
+			err     error
+		)
+
+		BeforeEach(func() {
+			setup([]string{fmt.Sprintf("--%v=%v", consts.ClusterNameParameter, localClusterName)}, map[string]string{})
+			options.TokenName = "foo"
+			options.RemoteClusterID = "remote-cluster-id"
+			options.TTL = time.Hour
+			options.SingleUse = true
+		})
+
+		JustBeforeEach(func() { command, err = options.generate(ctx) })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+		It("should store the peering token in a secret", func() {
+			var secret corev1.Secret
+			Expect(options.CRClient.Get(ctx, types.NamespacedName{Namespace: options.LiqoNamespace,
+				Name: auth.PeeringTokenSecretName("foo")}, &secret)).To(Succeed())
+
+			peeringToken, err := auth.PeeringTokenFromSecret(&secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(peeringToken.Name).To(Equal("foo"))
+			Expect(peeringToken.ClusterID).To(Equal("remote-cluster-id"))
+			Expect(peeringToken.Expiration).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+			Expect(peeringToken.MaxUses).To(Equal(1))
+			Expect(peeringToken.Uses).To(BeZero())
+
+			Expect(command).To(HaveSuffix(" --auth-token " + peeringToken.Token))
+			Expect(peeringToken.Token).ToNot(Equal(token), "the cluster-wide token should not be used")
+		})
+	})
 })
@@ -18,6 +18,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/liqotech/liqo/pkg/auth"
 	"github.com/liqotech/liqo/pkg/liqoctl/factory"
@@ -33,6 +36,12 @@ type Options struct {
 
 	CommandName string
 	OnlyCommand bool
+
+	// The parameters of the named peering token to generate, instead of leveraging the cluster-wide one.
+	TokenName       string
+	RemoteClusterID string
+	TTL             time.Duration
+	SingleUse       bool
 }
 
 // Run implements the generate peer-command command.
@@ -62,7 +71,7 @@ func (o *Options) Run(ctx context.Context) error {
 }
 
 func (o *Options) generate(ctx context.Context) (string, error) {
-	localToken, err := auth.GetToken(ctx, o.CRClient, o.LiqoNamespace)
+	localToken, err := o.token(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -83,10 +92,40 @@ func (o *Options) generate(ctx context.Context) (string, error) {
 		clusterIdentity.ClusterName = clusterIdentity.ClusterID
 	}
 
-	return strings.Join([]string{
+	command := []string{
 		o.CommandName, "peer out-of-band", clusterIdentity.ClusterName,
 		"--" + peeroob.AuthURLFlagName, authEP,
 		"--" + peeroob.ClusterIDFlagName, clusterIdentity.ClusterID,
 		"--" + peeroob.ClusterTokenFlagName, localToken,
-	}, " "), nil
+	}
+
+	// Embed the fingerprint of the authentication service CA, so that it is pinned by the remote cluster.
+	// The corresponding secret does not exist in case TLS is not enabled for the authentication service.
+	fingerprint, err := auth.GetCAFingerprint(ctx, o.CRClient, o.LiqoNamespace)
+	switch {
+	case err == nil:
+		command = append(command, "--"+peeroob.CAFingerprintFlagName, fingerprint)
+	case !kerrors.IsNotFound(err):
+		return "", fmt.Errorf("failed to retrieve the authentication service CA fingerprint: %w", err)
+	}
+
+	return strings.Join(command, " "), nil
+}
+
+// token returns the token to be included in the peer command, generating a named peering token if any restriction is requested.
+func (o *Options) token(ctx context.Context) (string, error) {
+	if o.TokenName == "" && o.RemoteClusterID == "" && o.TTL == 0 && !o.SingleUse {
+		return auth.GetToken(ctx, o.CRClient, o.LiqoNamespace)
+	}
+
+	opts := &auth.PeeringTokenOptions{Name: o.TokenName, ClusterID: o.RemoteClusterID, TTL: o.TTL}
+	if o.SingleUse {
+		opts.MaxUses = 1
+	}
+
+	token, err := auth.CreatePeeringToken(ctx, o.CRClient, o.LiqoNamespace, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate the peering token: %w", err)
+	}
+	return token.Token, nil
 }
@@ -0,0 +1,315 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offload
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	offloadingv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	mapsv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	"github.com/liqotech/liqo/internal/crdReplicator/reflection"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+	"github.com/liqotech/liqo/pkg/utils/namespacemapping"
+)
+
+// podNodeNameField is the field selector key to retrieve the pods scheduled on a given node.
+const podNodeNameField = "spec.nodeName"
+
+// ClusterSimulation summarizes the expected outcome of the offloading of the namespace towards a given remote cluster.
+type ClusterSimulation struct {
+	// ClusterID is the ID of the remote cluster.
+	ClusterID string
+	// NodeName is the name of the virtual node associated with the remote cluster.
+	NodeName string
+	// Selected is whether the remote cluster is selected by the ClusterSelector.
+	Selected bool
+	// RemoteNamespace is the name of the remote namespace that would be created in the remote cluster, if selected.
+	RemoteNamespace string
+	// ConflictingNamespace is the local namespace already mapped to the same remote namespace, if any.
+	// Namespaces already existing in the remote cluster, and not managed by Liqo, are not detected.
+	ConflictingNamespace string
+	// Available is the amount of resources offered by the remote cluster, and not already requested by other pods.
+	Available corev1.ResourceList
+	// Exceeded are the resources that would be exceeded if the current workload was entirely offloaded to the remote cluster.
+	Exceeded []corev1.ResourceName
+}
+
+// Simulate evaluates the outcome of the offloading of the namespace, according to the current virtual nodes,
+// without applying any change. It returns the simulation for each remote cluster (sorted by cluster ID),
+// as well as the overall amount of resources currently requested by the pods of the namespace.
+func (o *Options) Simulate(ctx context.Context) ([]ClusterSimulation, corev1.ResourceList, error) {
+	nsoff := o.forgeNamespaceOffloading()
+
+	var nodes corev1.NodeList
+	if err := o.CRClient.List(ctx, &nodes, client.MatchingLabels{consts.TypeLabel: consts.TypeNode}); err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve virtual nodes: %w", err)
+	}
+
+	remoteNamespace, err := o.remoteNamespaceName(ctx, nsoff)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conflicts, err := o.conflictingNamespaces(ctx, remoteNamespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Compute the resources requested by the pods of the namespace.
+	var pods corev1.PodList
+	if err = o.CRClient.List(ctx, &pods, client.InNamespace(o.Namespace)); err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve pods: %w", err)
+	}
+
+	requests := corev1.ResourceList{}
+	for i := range pods.Items {
+		if distribution.IsActive(&pods.Items[i]) {
+			distribution.AddRequests(requests, distribution.PodRequests(&pods.Items[i]))
+		}
+	}
+
+	simulations := make([]ClusterSimulation, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		clusterID, _ := utils.GetNodeClusterID(node)
+		simulation := ClusterSimulation{ClusterID: clusterID, NodeName: node.Name}
+
+		if simulation.Selected, err = utils.MatchNodeSelectorTerms(node, &nsoff.Spec.ClusterSelector); err != nil {
+			return nil, nil, fmt.Errorf("invalid cluster selector: %w", err)
+		}
+
+		if simulation.Selected {
+			simulation.RemoteNamespace = remoteNamespace
+			simulation.ConflictingNamespace = conflicts[clusterID]
+			var used corev1.ResourceList
+			if used, err = o.usedResources(ctx, node); err != nil {
+				return nil, nil, err
+			}
+			simulation.Available = availableResources(node, used)
+			simulation.Exceeded = exceededResources(simulation.Available, nsoff.Spec.RemoteClusterQuota, requests)
+		}
+
+		simulations = append(simulations, simulation)
+	}
+
+	sort.Slice(simulations, func(i, j int) bool { return simulations[i].ClusterID < simulations[j].ClusterID })
+	return simulations, requests, nil
+}
+
+// dryRun simulates the offloading of the namespace, and outputs the expected outcome.
+func (o *Options) dryRun(ctx context.Context) error {
+	s := o.Printer.StartSpinner(fmt.Sprintf("Simulating the offloading of namespace %q", o.Namespace))
+	simulations, requests, err := o.Simulate(ctx)
+	if err != nil {
+		s.Fail(fmt.Sprintf("Failed simulating the offloading: %v", output.PrettyErr(err)))
+		return err
+	}
+	s.Success(fmt.Sprintf("Offloading of namespace %q simulated (no changes applied)", o.Namespace))
+
+	if len(simulations) == 0 {
+		o.Printer.Warning.Println("No remote clusters are currently available (i.e., no virtual nodes found)")
+		return nil
+	}
+
+	o.Printer.Info.Printfln("Resources currently requested by the pods in namespace %q: %s", o.Namespace, formatResources(requests))
+
+	var selected int
+	for i := range simulations {
+		simulation := &simulations[i]
+		if !simulation.Selected {
+			o.Printer.Info.Printfln("Cluster %q (virtual node %q): not selected", simulation.ClusterID, simulation.NodeName)
+			continue
+		}
+
+		selected++
+		o.Printer.Success.Printfln("Cluster %q (virtual node %q): selected, remote namespace %q",
+			simulation.ClusterID, simulation.NodeName, simulation.RemoteNamespace)
+
+		if simulation.ConflictingNamespace != "" {
+			o.Printer.Warning.Printfln("Cluster %q: remote namespace %q is already mapped to the local namespace %q",
+				simulation.ClusterID, simulation.RemoteNamespace, simulation.ConflictingNamespace)
+		}
+
+		switch {
+		case o.PodOffloadingStrategy == offloadingv1alpha1.LocalPodOffloadingStrategyType:
+			// Pods are not offloaded, hence there is no need to check whether they fit into the remote cluster.
+		case len(simulation.Exceeded) > 0:
+			o.Printer.Warning.Printfln("Cluster %q: the current workload does not fit in the available resources (%s), exceeded: %s",
+				simulation.ClusterID, formatResources(simulation.Available), joinResourceNames(simulation.Exceeded))
+		default:
+			o.Printer.Info.Printfln("Cluster %q: the current workload fits in the available resources (%s)",
+				simulation.ClusterID, formatResources(simulation.Available))
+		}
+	}
+
+	if selected == 0 {
+		o.Printer.Warning.Println("No remote clusters are selected by the given cluster selector")
+		return nil
+	}
+
+	o.Printer.Info.Println("Conflicts are detected only with respect to the namespaces already offloaded through Liqo: " +
+		"remote namespaces with the same name already existing in the remote clusters, and not managed by Liqo, " +
+		"cannot be detected from the local cluster, and would cause the offloading towards those clusters to fail")
+	return nil
+}
+
+// remoteNamespaceName returns the name of the remote namespace resulting from the given NamespaceOffloading.
+func (o *Options) remoteNamespaceName(ctx context.Context, nsoff *offloadingv1alpha1.NamespaceOffloading) (string, error) {
+	// The name of the remote namespace does not depend on the local cluster, in case the same name is enforced.
+	var localCluster discoveryv1alpha1.ClusterIdentity
+	if nsoff.Spec.NamespaceMappingStrategy != offloadingv1alpha1.EnforceSameNameMappingStrategyType {
+		var err error
+		if localCluster, err = utils.GetClusterIdentityWithControllerClient(ctx, o.CRClient, o.LiqoNamespace); err != nil {
+			return "", fmt.Errorf("failed to retrieve the local cluster identity: %w", err)
+		}
+	}
+
+	var labels map[string]string
+	if nsoff.Spec.NamespaceMappingStrategy == offloadingv1alpha1.TemplateNameMappingStrategyType {
+		var namespace corev1.Namespace
+		if err := o.CRClient.Get(ctx, types.NamespacedName{Name: o.Namespace}, &namespace); err != nil {
+			return "", fmt.Errorf("failed to retrieve namespace %q: %w", o.Namespace, err)
+		}
+		labels = namespace.Labels
+	}
+
+	return namespacemapping.RemoteNamespaceName(nsoff, labels, &localCluster)
+}
+
+// conflictingNamespaces returns, for each remote cluster, the local namespace (other than the one being offloaded)
+// already mapped to a remote namespace with the given name, if any. Being based on the local NamespaceMaps only,
+// it cannot detect the namespaces already existing in the remote clusters, and not managed by Liqo.
+func (o *Options) conflictingNamespaces(ctx context.Context, remoteNamespace string) (map[string]string, error) {
+	// Consider only local NamespaceMaps.
+	metals := reflection.LocalResourcesLabelSelector()
+	selector, err := metav1.LabelSelectorAsSelector(&metals)
+	if err != nil {
+		return nil, err
+	}
+
+	var nms mapsv1alpha1.NamespaceMapList
+	if err = o.CRClient.List(ctx, &nms, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to retrieve NamespaceMaps: %w", err)
+	}
+
+	conflicts := map[string]string{}
+	for i := range nms.Items {
+		nm := &nms.Items[i]
+		clusterID := nm.Labels[consts.RemoteClusterID]
+
+		for local, remote := range nm.Spec.DesiredMapping {
+			if local != o.Namespace && remote == remoteNamespace {
+				conflicts[clusterID] = local
+			}
+		}
+		for local, status := range nm.Status.CurrentMapping {
+			if local != o.Namespace && status.RemoteNamespace == remoteNamespace {
+				conflicts[clusterID] = local
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// usedResources returns the resources already requested by the pods (other than the ones of the namespace being offloaded)
+// scheduled on the given virtual node.
+func (o *Options) usedResources(ctx context.Context, node *corev1.Node) (corev1.ResourceList, error) {
+	var pods corev1.PodList
+	selector := fields.OneTermEqualSelector(podNodeNameField, node.Name)
+	if err := o.CRClient.List(ctx, &pods, client.MatchingFieldsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to retrieve the pods scheduled on virtual node %q: %w", node.Name, err)
+	}
+
+	used := corev1.ResourceList{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Namespace != o.Namespace && distribution.IsActive(pod) {
+			distribution.AddRequests(used, distribution.PodRequests(pod))
+		}
+	}
+	return used, nil
+}
+
+// availableResources returns the resources offered through the given virtual node, and not already requested by other pods.
+func availableResources(node *corev1.Node, used corev1.ResourceList) corev1.ResourceList {
+	available := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods} {
+		if allocatable, found := node.Status.Allocatable[name]; found {
+			quantity := allocatable.DeepCopy()
+			quantity.Sub(used[name])
+			available[name] = quantity
+		}
+	}
+	return available
+}
+
+// exceededResources returns the resources that would be exceeded if the given requests were satisfied by the
+// available resources, further constrained by the remote cluster quota (if any).
+func exceededResources(available, quota, requests corev1.ResourceList) []corev1.ResourceName {
+	exceeded := distribution.ExceededResources(available, nil, requests)
+	for _, name := range distribution.ExceededResources(quota, nil, requests) {
+		if !containsResource(exceeded, name) {
+			exceeded = append(exceeded, name)
+		}
+	}
+
+	sort.Slice(exceeded, func(i, j int) bool { return exceeded[i] < exceeded[j] })
+	return exceeded
+}
+
+func containsResource(names []corev1.ResourceName, name corev1.ResourceName) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func formatResources(resources corev1.ResourceList) string {
+	var formatted []string
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods} {
+		if quantity, found := resources[name]; found {
+			formatted = append(formatted, fmt.Sprintf("%s: %s", name, quantity.String()))
+		}
+	}
+
+	if len(formatted) == 0 {
+		return "none"
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func joinResourceNames(names []corev1.ResourceName) string {
+	formatted := make([]string, len(names))
+	for i := range names {
+		formatted[i] = string(names[i])
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,197 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offload_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offloadingv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	mapsv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/offload"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("Dry-run tests", func() {
+	const (
+		namespace = "foo"
+		liqoNs    = "liqo"
+	)
+
+	var (
+		ctx         context.Context
+		options     *offload.Options
+		nodes       []corev1.Node
+		extra       []*mapsv1alpha1.NamespaceMap
+		pods        []*corev1.Pod
+		simulations []offload.ClusterSimulation
+		requests    corev1.ResourceList
+		err         error
+	)
+
+	virtualNode := func(clusterID, region string, cpu, memory string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "liqo-" + clusterID, Labels: map[string]string{
+				consts.TypeLabel: consts.TypeNode, consts.RemoteClusterID: clusterID, "region": region}},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory),
+				corev1.ResourcePods: resource.MustParse("110")}},
+		}
+	}
+
+	pod := func(ns, name, node, cpu string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Name: "c", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse("1Gi")}}}}},
+		}
+	}
+
+	namespaceMap := func(clusterID string, mapping map[string]string) *mapsv1alpha1.NamespaceMap {
+		return &mapsv1alpha1.NamespaceMap{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterID, Namespace: "liqo-tenant-" + clusterID, Labels: map[string]string{
+				consts.ReplicationRequestedLabel: "true", consts.ReplicationDestinationLabel: clusterID, consts.RemoteClusterID: clusterID}},
+			Spec: mapsv1alpha1.NamespaceMapSpec{DesiredMapping: mapping},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		options = &offload.Options{
+			Factory:                  &factory.Factory{LiqoNamespace: liqoNs},
+			Namespace:                namespace,
+			PodOffloadingStrategy:    offloadingv1alpha1.LocalAndRemotePodOffloadingStrategyType,
+			NamespaceMappingStrategy: offloadingv1alpha1.EnforceSameNameMappingStrategyType,
+		}
+
+		nodes = []corev1.Node{virtualNode("europe-cluster", "europe", "4", "8Gi"), virtualNode("us-cluster", "us", "2", "4Gi")}
+		extra = nil
+		pods = []*corev1.Pod{pod(namespace, "pod-1", "", "1"), pod(namespace, "pod-2", "", "1")}
+	})
+
+	JustBeforeEach(func() {
+		builder := ctrlfake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(testutil.FakeClusterIDConfigMap(liqoNs, "local-cluster-id", "local-cluster-name")).
+			WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+				return []string{obj.(*corev1.Pod).Spec.NodeName}
+			})
+		for i := range nodes {
+			builder.WithObjects(&nodes[i])
+		}
+		for i := range extra {
+			builder.WithObjects(extra[i])
+		}
+		for i := range pods {
+			builder.WithObjects(pods[i])
+		}
+
+		options.CRClient = builder.Build()
+		simulations, requests, err = options.Simulate(ctx)
+	})
+
+	It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+	It("should compute the resources requested by the pods of the namespace", func() {
+		Expect(requests.Cpu().String()).To(Equal("2"))
+		Expect(requests.Memory().String()).To(Equal("2Gi"))
+		Expect(requests.Pods().String()).To(Equal("2"))
+	})
+
+	When("no cluster selector is specified", func() {
+		It("should select all remote clusters, with the same remote namespace name", func() {
+			Expect(simulations).To(HaveLen(2))
+			for i := range simulations {
+				Expect(simulations[i].Selected).To(BeTrue())
+				Expect(simulations[i].RemoteNamespace).To(Equal(namespace))
+				Expect(simulations[i].ConflictingNamespace).To(BeEmpty())
+				Expect(simulations[i].Exceeded).To(BeEmpty())
+			}
+		})
+	})
+
+	When("a cluster selector is specified", func() {
+		BeforeEach(func() { Expect(options.ParseClusterSelectors([]string{"region=europe"})).To(Succeed()) })
+
+		It("should select only the matching remote clusters", func() {
+			Expect(simulations).To(HaveLen(2))
+			Expect(simulations[0].ClusterID).To(Equal("europe-cluster"))
+			Expect(simulations[0].Selected).To(BeTrue())
+			Expect(simulations[1].ClusterID).To(Equal("us-cluster"))
+			Expect(simulations[1].Selected).To(BeFalse())
+			Expect(simulations[1].RemoteNamespace).To(BeEmpty())
+		})
+	})
+
+	When("the default mapping strategy is selected", func() {
+		BeforeEach(func() { options.NamespaceMappingStrategy = offloadingv1alpha1.DefaultNameMappingStrategyType })
+
+		It("should produce remote namespace names including the local cluster name", func() {
+			Expect(simulations).To(HaveLen(2))
+			Expect(simulations[0].RemoteNamespace).To(HavePrefix(namespace + "-local-cluster-name-"))
+		})
+	})
+
+	When("the remote namespace is already mapped to a different local namespace", func() {
+		BeforeEach(func() { extra = append(extra, namespaceMap("us-cluster", map[string]string{"bar": namespace})) })
+
+		It("should report the conflict for the given remote cluster only", func() {
+			Expect(simulations).To(HaveLen(2))
+			Expect(simulations[0].ConflictingNamespace).To(BeEmpty())
+			Expect(simulations[1].ConflictingNamespace).To(Equal("bar"))
+		})
+	})
+
+	When("the remote cluster offers insufficient resources", func() {
+		BeforeEach(func() { pods = append(pods, pod("other", "pod-3", "liqo-us-cluster", "1500m")) })
+
+		It("should report the exceeded resources", func() {
+			Expect(simulations).To(HaveLen(2))
+			Expect(simulations[0].Exceeded).To(BeEmpty())
+			Expect(simulations[1].Available.Cpu().String()).To(Equal("500m"))
+			Expect(simulations[1].Exceeded).To(ConsistOf(corev1.ResourceCPU))
+		})
+	})
+
+	When("other pods are scheduled on different nodes", func() {
+		BeforeEach(func() {
+			pods = append(pods, pod("other", "pod-3", "local-node", "3"), pod("other", "pod-4", "", "3"))
+		})
+
+		It("should not account for them", func() {
+			Expect(simulations).To(HaveLen(2))
+			Expect(simulations[0].Available.Cpu().String()).To(Equal("4"))
+			Expect(simulations[1].Available.Cpu().String()).To(Equal("2"))
+		})
+	})
+
+	When("the remote cluster quota is lower than the current requests", func() {
+		BeforeEach(func() { Expect(options.ParseRemoteClusterQuota(map[string]string{"memory": "1Gi"})).To(Succeed()) })
+
+		It("should report the exceeded resources for all remote clusters", func() {
+			Expect(simulations).To(HaveLen(2))
+			Expect(simulations[0].Exceeded).To(ConsistOf(corev1.ResourceMemory))
+			Expect(simulations[1].Exceeded).To(ConsistOf(corev1.ResourceMemory))
+		})
+	})
+})
@@ -18,9 +18,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/printers"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -39,9 +42,18 @@ type Options struct {
 	Namespace                string
 	PodOffloadingStrategy    offloadingv1alpha1.PodOffloadingStrategyType
 	NamespaceMappingStrategy offloadingv1alpha1.NamespaceMappingStrategyType
+	NamespaceMappingTemplate string
 	ClusterSelector          [][]metav1.LabelSelectorRequirement
+	ReplicaWeights           []offloadingv1alpha1.ClusterWeight
+	FailoverPolicy           offloadingv1alpha1.FailoverPolicyType
+	RemoteClusterQuota       corev1.ResourceList
+	MigrationPolicy          offloadingv1alpha1.MigrationPolicyType
+	RetentionPolicy          offloadingv1alpha1.RemoteNamespaceRetentionPolicyType
+	PropagatedLabels         []string
+	PropagatedAnnotations    []string
 
 	OutputFormat string
+	DryRun       bool
 
 	Timeout time.Duration
 }
@@ -66,6 +78,48 @@ func (o *Options) ParseClusterSelectors(selectors []string) error {
 	return nil
 }
 
+// ParseReplicaWeights parses the replica weights, in the form <cluster-id>=<weight>.
+func (o *Options) ParseReplicaWeights(weights []string) error {
+	for _, weight := range weights {
+		clusterID, value, found := strings.Cut(weight, "=")
+		if !found || clusterID == "" {
+			return fmt.Errorf("invalid replica weight %q: expected format <cluster-id>=<weight>", weight)
+		}
+
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || parsed < 0 || parsed > 100 {
+			return fmt.Errorf("invalid replica weight %q: the weight must be an integer between 0 and 100", weight)
+		}
+
+		o.ReplicaWeights = append(o.ReplicaWeights, offloadingv1alpha1.ClusterWeight{ClusterID: clusterID, Weight: int32(parsed)})
+	}
+
+	return nil
+}
+
+// ParseRemoteClusterQuota parses the remote cluster quota, in the form <resource>=<quantity>.
+func (o *Options) ParseRemoteClusterQuota(quota map[string]string) error {
+	for name, value := range quota {
+		switch corev1.ResourceName(name) {
+		case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods:
+		default:
+			return fmt.Errorf("invalid remote cluster quota %q: supported resources are cpu, memory and pods", name)
+		}
+
+		parsed, err := resource.ParseQuantity(value)
+		if err != nil || parsed.Sign() < 0 {
+			return fmt.Errorf("invalid remote cluster quota %q: %q is not a valid non-negative quantity", name, value)
+		}
+
+		if o.RemoteClusterQuota == nil {
+			o.RemoteClusterQuota = corev1.ResourceList{}
+		}
+		o.RemoteClusterQuota[corev1.ResourceName(name)] = parsed
+	}
+
+	return nil
+}
+
 // Run implements the offload namespace command.
 func (o *Options) Run(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
@@ -77,6 +131,11 @@ func (o *Options) Run(ctx context.Context) error {
 		return nil
 	}
 
+	// Simulate the offloading of the namespace, instead of applying it.
+	if o.DryRun {
+		return o.dryRun(ctx)
+	}
+
 	s := o.Printer.StartSpinner(fmt.Sprintf("Enabling namespace offloading for %q", o.Namespace))
 
 	nsoff := &offloadingv1alpha1.NamespaceOffloading{ObjectMeta: metav1.ObjectMeta{
@@ -87,7 +146,15 @@ func (o *Options) Run(ctx context.Context) error {
 		oldStrategy = nsoff.Spec.PodOffloadingStrategy
 		nsoff.Spec.PodOffloadingStrategy = o.PodOffloadingStrategy
 		nsoff.Spec.NamespaceMappingStrategy = o.NamespaceMappingStrategy
+		nsoff.Spec.NamespaceMappingTemplate = o.NamespaceMappingTemplate
 		nsoff.Spec.ClusterSelector = toNodeSelector(o.ClusterSelector)
+		nsoff.Spec.ReplicaWeights = o.ReplicaWeights
+		nsoff.Spec.FailoverPolicy = o.FailoverPolicy
+		nsoff.Spec.RemoteClusterQuota = o.RemoteClusterQuota
+		nsoff.Spec.MigrationPolicy = o.MigrationPolicy
+		nsoff.Spec.RemoteNamespaceRetentionPolicy = o.RetentionPolicy
+		nsoff.Spec.PropagatedLabels = o.PropagatedLabels
+		nsoff.Spec.PropagatedAnnotations = o.PropagatedAnnotations
 		return nil
 	})
 	if err != nil {
@@ -126,17 +193,28 @@ func (o *Options) output() error {
 		return fmt.Errorf("unsupported output format %q", o.OutputFormat)
 	}
 
-	nsoff := offloadingv1alpha1.NamespaceOffloading{
+	return printer.PrintObj(o.forgeNamespaceOffloading(), os.Stdout)
+}
+
+// forgeNamespaceOffloading forges the NamespaceOffloading resource corresponding to the given options.
+func (o *Options) forgeNamespaceOffloading() *offloadingv1alpha1.NamespaceOffloading {
+	return &offloadingv1alpha1.NamespaceOffloading{
 		TypeMeta:   metav1.TypeMeta{APIVersion: offloadingv1alpha1.GroupVersion.String(), Kind: "NamespaceOffloading"},
 		ObjectMeta: metav1.ObjectMeta{Name: consts.DefaultNamespaceOffloadingName, Namespace: o.Namespace},
 		Spec: offloadingv1alpha1.NamespaceOffloadingSpec{
-			PodOffloadingStrategy:    o.PodOffloadingStrategy,
-			NamespaceMappingStrategy: o.NamespaceMappingStrategy,
-			ClusterSelector:          toNodeSelector(o.ClusterSelector),
+			PodOffloadingStrategy:          o.PodOffloadingStrategy,
+			NamespaceMappingStrategy:       o.NamespaceMappingStrategy,
+			NamespaceMappingTemplate:       o.NamespaceMappingTemplate,
+			ClusterSelector:                toNodeSelector(o.ClusterSelector),
+			ReplicaWeights:                 o.ReplicaWeights,
+			FailoverPolicy:                 o.FailoverPolicy,
+			RemoteClusterQuota:             o.RemoteClusterQuota,
+			MigrationPolicy:                o.MigrationPolicy,
+			RemoteNamespaceRetentionPolicy: o.RetentionPolicy,
+			PropagatedLabels:               o.PropagatedLabels,
+			PropagatedAnnotations:          o.PropagatedAnnotations,
 		},
 	}
-
-	return printer.PrintObj(&nsoff, os.Stdout)
 }
 
 func toNodeSelector(selectors [][]metav1.LabelSelectorRequirement) corev1.NodeSelector {
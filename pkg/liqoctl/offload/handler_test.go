@@ -18,8 +18,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	offloadingv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
 	"github.com/liqotech/liqo/pkg/liqoctl/offload"
 )
 
@@ -58,4 +61,35 @@ var _ = Describe("Handler tests", func() {
 			ErrMatcher: Not(HaveOccurred()),
 		}),
 	)
+
+	DescribeTable("replica weights parsing",
+		func(weights []string, expected []offloadingv1alpha1.ClusterWeight, errMatcher types.GomegaMatcher) {
+			var opts offload.Options
+			Expect(opts.ParseReplicaWeights(weights)).To(errMatcher)
+			Expect(opts.ReplicaWeights).To(ConsistOf(expected))
+		},
+		Entry("single weight", []string{"foo=70"},
+			[]offloadingv1alpha1.ClusterWeight{{ClusterID: "foo", Weight: 70}}, Not(HaveOccurred())),
+		Entry("multiple weights", []string{"foo=70", "bar=30"},
+			[]offloadingv1alpha1.ClusterWeight{{ClusterID: "foo", Weight: 70}, {ClusterID: "bar", Weight: 30}}, Not(HaveOccurred())),
+		Entry("missing weight", []string{"foo"}, []offloadingv1alpha1.ClusterWeight{}, HaveOccurred()),
+		Entry("missing cluster ID", []string{"=30"}, []offloadingv1alpha1.ClusterWeight{}, HaveOccurred()),
+		Entry("non-numeric weight", []string{"foo=bar"}, []offloadingv1alpha1.ClusterWeight{}, HaveOccurred()),
+		Entry("out of range weight", []string{"foo=101"}, []offloadingv1alpha1.ClusterWeight{}, HaveOccurred()),
+	)
+
+	DescribeTable("remote cluster quota parsing",
+		func(quota map[string]string, expected corev1.ResourceList, errMatcher types.GomegaMatcher) {
+			var opts offload.Options
+			Expect(opts.ParseRemoteClusterQuota(quota)).To(errMatcher)
+			Expect(opts.RemoteClusterQuota).To(Equal(expected))
+		},
+		Entry("no quota", map[string]string{}, nil, Not(HaveOccurred())),
+		Entry("valid quota", map[string]string{"cpu": "2", "memory": "4Gi", "pods": "10"}, corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi"), corev1.ResourcePods: resource.MustParse("10"),
+		}, Not(HaveOccurred())),
+		Entry("unsupported resource", map[string]string{"storage": "10Gi"}, nil, HaveOccurred()),
+		Entry("invalid quantity", map[string]string{"cpu": "foo"}, nil, HaveOccurred()),
+		Entry("negative quantity", map[string]string{"memory": "-1Gi"}, nil, HaveOccurred()),
+	)
 })
@@ -19,9 +19,17 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	mapsv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 )
 
 func TestOffload(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Offload Suite")
 }
+
+var _ = BeforeSuite(func() {
+	utilruntime.Must(mapsv1alpha1.AddToScheme(scheme.Scheme))
+})
@@ -21,4 +21,6 @@ const (
 	ClusterIDFlagName = "cluster-id"
 	// ClusterTokenFlagName contains the name for the token flag.
 	ClusterTokenFlagName = "auth-token"
+	// CAFingerprintFlagName contains the name of the CA fingerprint flag.
+	CAFingerprintFlagName = "ca-fingerprint"
 )
@@ -24,6 +24,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/auth"
 	"github.com/liqotech/liqo/pkg/discovery"
 	"github.com/liqotech/liqo/pkg/liqoctl/output"
 	"github.com/liqotech/liqo/pkg/liqoctl/peer"
@@ -39,6 +40,7 @@ type Options struct {
 	ClusterToken   string
 	ClusterAuthURL string
 	ClusterID      string
+	CAFingerprint  string
 }
 
 // Run implements the peer out-of-band command.
@@ -115,6 +117,9 @@ func (o *Options) enforceForeignCluster(ctx context.Context) (*discoveryv1alpha1
 		if fc.Spec.InsecureSkipTLSVerify == nil {
 			fc.Spec.InsecureSkipTLSVerify = pointer.BoolPtr(true)
 		}
+		if o.CAFingerprint != "" {
+			fc.Spec.CAFingerprint = auth.NormalizeCAFingerprint(o.CAFingerprint)
+		}
 		return nil
 	})
 
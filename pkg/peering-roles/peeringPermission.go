@@ -16,6 +16,7 @@ package peeringroles
 
 import (
 	"context"
+	"fmt"
 
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,6 +38,10 @@ const (
 	remotePermissionsLabelIncoming = "incoming"
 	// remotePermissionsLabelOutgoing -> the label value identifying outgoing peering permissions.
 	remotePermissionsLabelOutgoing = "outgoing"
+
+	// RemotePermissionsProfileLabelKey -> the label key used to identify the peering permission profile the cluster roles belong to.
+	// The cluster roles not characterized by this label belong to the default profile.
+	RemotePermissionsProfileLabelKey = "auth.liqo.io/remote-peering-permissions-profile"
 )
 
 // PeeringPermission contains the reference to the ClusterRoles
@@ -81,6 +86,48 @@ func GetPeeringPermission(ctx context.Context, client kubernetes.Interface) (*Pe
 	}, nil
 }
 
+// NewPeeringPermissionFromProfile populates a PeeringPermission with the given ClusterRoles belonging to the given profile,
+// and validates that the profile can actually be granted (i.e., it exists and it includes the basic permissions).
+func NewPeeringPermissionFromProfile(profile string, clusterRoles []rbacv1.ClusterRole) (*PeeringPermission, error) {
+	var permission PeeringPermission
+	for i := range clusterRoles {
+		clusterRole := &clusterRoles[i]
+		if clusterRole.Labels[RemotePermissionsProfileLabelKey] != profile {
+			continue
+		}
+
+		switch level := clusterRole.Labels[remotePermissionsLabelKey]; level {
+		case remotePermissionsLabelBasic:
+			permission.Basic = append(permission.Basic, clusterRole)
+		case remotePermissionsLabelIncoming:
+			permission.Incoming = append(permission.Incoming, clusterRole)
+		case remotePermissionsLabelOutgoing:
+			permission.Outgoing = append(permission.Outgoing, clusterRole)
+		default:
+			return nil, fmt.Errorf("ClusterRole %q of peering permission profile %q has invalid level %q", clusterRole.Name, profile, level)
+		}
+	}
+
+	if len(permission.Basic) == 0 && len(permission.Incoming) == 0 && len(permission.Outgoing) == 0 {
+		return nil, fmt.Errorf("peering permission profile %q not found", profile)
+	}
+	if len(permission.Basic) == 0 {
+		return nil, fmt.Errorf("peering permission profile %q does not include any basic permission", profile)
+	}
+	return &permission, nil
+}
+
+// ClusterRoleNames returns the names of all the ClusterRoles included in the PeeringPermission.
+func (pp *PeeringPermission) ClusterRoleNames() []string {
+	names := make([]string, 0, len(pp.Basic)+len(pp.Incoming)+len(pp.Outgoing))
+	for _, clusterRoles := range [][]*rbacv1.ClusterRole{pp.Basic, pp.Incoming, pp.Outgoing} {
+		for _, clusterRole := range clusterRoles {
+			names = append(names, clusterRole.Name)
+		}
+	}
+	return names
+}
+
 // getClusterRoles gets a set of ClusterRoles given a label selector.
 func getClusterRoles(ctx context.Context, client kubernetes.Interface, selector labels.Selector) ([]*rbacv1.ClusterRole, error) {
 	clusterroleslist, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
@@ -96,9 +143,12 @@ func getClusterRoles(ctx context.Context, client kubernetes.Interface, selector
 	return output, nil
 }
 
-// remotePermissionsLabelSelector returns a label selector matching the custer roles including the permissions for the given level.
+// remotePermissionsLabelSelector returns a label selector matching the custer roles including the permissions for the given level,
+// and belonging to the default profile.
 func remotePermissionsLabelSelector(level string) labels.Selector {
 	req, err := labels.NewRequirement(remotePermissionsLabelKey, selection.Equals, []string{level})
 	utilruntime.Must(err)
-	return labels.NewSelector().Add(*req)
+	profileReq, err := labels.NewRequirement(RemotePermissionsProfileLabelKey, selection.DoesNotExist, nil)
+	utilruntime.Must(err)
+	return labels.NewSelector().Add(*req, *profileReq)
 }
@@ -0,0 +1,91 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peeringroles
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("NewPeeringPermissionFromProfile", func() {
+	const profile = "restricted"
+
+	var (
+		clusterRoles []rbacv1.ClusterRole
+		permission   *PeeringPermission
+		err          error
+	)
+
+	clusterRole := func(name, level, profile string) rbacv1.ClusterRole {
+		labels := map[string]string{remotePermissionsLabelKey: level}
+		if profile != "" {
+			labels[RemotePermissionsProfileLabelKey] = profile
+		}
+		return rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	JustBeforeEach(func() {
+		permission, err = NewPeeringPermissionFromProfile(profile, clusterRoles)
+	})
+
+	When("the profile includes the basic permissions", func() {
+		BeforeEach(func() {
+			clusterRoles = []rbacv1.ClusterRole{
+				clusterRole("default-basic", remotePermissionsLabelBasic, ""),
+				clusterRole("restricted-basic", remotePermissionsLabelBasic, profile),
+				clusterRole("restricted-incoming", remotePermissionsLabelIncoming, profile),
+				clusterRole("other-outgoing", remotePermissionsLabelOutgoing, "other"),
+			}
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should include only the ClusterRoles of the profile", func() {
+			Expect(permission.ClusterRoleNames()).To(ConsistOf("restricted-basic", "restricted-incoming"))
+			Expect(permission.Basic).To(ConsistOf(PointTo(HaveField("Name", "restricted-basic"))))
+			Expect(permission.Incoming).To(ConsistOf(PointTo(HaveField("Name", "restricted-incoming"))))
+			Expect(permission.Outgoing).To(BeEmpty())
+		})
+	})
+
+	When("the profile does not exist", func() {
+		BeforeEach(func() {
+			clusterRoles = []rbacv1.ClusterRole{clusterRole("default-basic", remotePermissionsLabelBasic, "")}
+		})
+
+		It("should fail", func() { Expect(err).To(MatchError(ContainSubstring("not found"))) })
+	})
+
+	When("the profile does not include the basic permissions", func() {
+		BeforeEach(func() {
+			clusterRoles = []rbacv1.ClusterRole{clusterRole("restricted-incoming", remotePermissionsLabelIncoming, profile)}
+		})
+
+		It("should fail", func() { Expect(err).To(MatchError(ContainSubstring("basic"))) })
+	})
+
+	When("a ClusterRole of the profile has an invalid level", func() {
+		BeforeEach(func() {
+			clusterRoles = []rbacv1.ClusterRole{
+				clusterRole("restricted-basic", remotePermissionsLabelBasic, profile),
+				clusterRole("restricted-invalid", "invalid", profile),
+			}
+		})
+
+		It("should fail", func() { Expect(err).To(MatchError(ContainSubstring("invalid level"))) })
+	})
+})
@@ -0,0 +1,195 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+)
+
+// ClusterIDForNode returns the ID of the cluster hosting the pods scheduled on the given node,
+// that is the remote cluster ID in case of virtual nodes, and the local one otherwise.
+func ClusterIDForNode(node *corev1.Node, localClusterID string) string {
+	if utils.IsVirtualNode(node) {
+		if clusterID, found := utils.GetNodeClusterID(node); found {
+			return clusterID
+		}
+	}
+	return localClusterID
+}
+
+// NodeSelectorForCluster returns the node selector matching the nodes of the given cluster.
+func NodeSelectorForCluster(clusterID, localClusterID string) *corev1.NodeSelector {
+	requirement := corev1.NodeSelectorRequirement{Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpIn, Values: []string{clusterID}}
+	if clusterID == localClusterID {
+		requirement = corev1.NodeSelectorRequirement{Key: liqoconst.TypeLabel, Operator: corev1.NodeSelectorOpNotIn, Values: []string{liqoconst.TypeNode}}
+	}
+
+	return &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+		MatchExpressions: []corev1.NodeSelectorRequirement{requirement},
+	}}}
+}
+
+// WeightedCluster returns a cluster selected at random, with a probability proportional to the given weights.
+// The random function shall return a non-negative pseudo-random number in the half-open interval [0,n).
+// The second return value is false in case no cluster is associated with a positive weight.
+func WeightedCluster(weights []offv1alpha1.ClusterWeight, random func(n int64) int64) (string, bool) {
+	var total int64
+	for i := range weights {
+		if weights[i].Weight > 0 {
+			total += int64(weights[i].Weight)
+		}
+	}
+	if total == 0 {
+		return "", false
+	}
+
+	value := random(total)
+	for i := range weights {
+		if weights[i].Weight <= 0 {
+			continue
+		}
+		if value < int64(weights[i].Weight) {
+			return weights[i].ClusterID, true
+		}
+		value -= int64(weights[i].Weight)
+	}
+	return "", false
+}
+
+// IsActive returns whether the given pod shall be accounted for when computing the distribution of replicas.
+func IsActive(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp.IsZero() && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// ClusterResolver retrieves the cluster hosting a given pod, caching the information about the nodes.
+type ClusterResolver struct {
+	cl             client.Client
+	localClusterID string
+	nodes          map[string]string
+}
+
+// NewClusterResolver returns a new ClusterResolver instance.
+func NewClusterResolver(cl client.Client, localClusterID string) *ClusterResolver {
+	return &ClusterResolver{cl: cl, localClusterID: localClusterID, nodes: map[string]string{}}
+}
+
+// ClusterForPod returns the ID of the cluster hosting (or assigned to host) the given pod.
+// The second return value is false in case the pod has not yet been scheduled, nor assigned to any cluster.
+func (r *ClusterResolver) ClusterForPod(ctx context.Context, pod *corev1.Pod) (string, bool, error) {
+	if pod.Spec.NodeName == "" {
+		clusterID, found := pod.Labels[liqoconst.ReplicaTargetClusterLabelKey]
+		return clusterID, found, nil
+	}
+
+	if clusterID, found := r.nodes[pod.Spec.NodeName]; found {
+		return clusterID, true, nil
+	}
+
+	var node corev1.Node
+	if err := r.cl.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to retrieve node %q: %w", pod.Spec.NodeName, err)
+	}
+
+	r.nodes[pod.Spec.NodeName] = ClusterIDForNode(&node, r.localClusterID)
+	return r.nodes[pod.Spec.NodeName], true, nil
+}
+
+// CurrentReplicas returns the number of active pods hosted by (or assigned to) each cluster. In case the owner
+// is not nil, only the pods controlled by the given owner are considered, while the pods yet to be scheduled
+// are not accounted for otherwise.
+func (r *ClusterResolver) CurrentReplicas(ctx context.Context, pods []corev1.Pod, owner *metav1.OwnerReference) (map[string]int32, error) {
+	current := map[string]int32{}
+	for i := range pods {
+		pod := &pods[i]
+		if !IsActive(pod) {
+			continue
+		}
+
+		if owner != nil {
+			if controller := metav1.GetControllerOf(pod); controller == nil || controller.UID != owner.UID {
+				continue
+			}
+		} else if pod.Spec.NodeName == "" {
+			continue
+		}
+
+		clusterID, found, err := r.ClusterForPod(ctx, pod)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			current[clusterID]++
+		}
+	}
+	return current, nil
+}
+
+// ToClusterReplicas converts the given map into a list of ClusterReplicas, sorted by cluster ID.
+func ToClusterReplicas(current map[string]int32) []offv1alpha1.ClusterReplicas {
+	replicas := make([]offv1alpha1.ClusterReplicas, 0, len(current))
+	for clusterID, count := range current {
+		replicas = append(replicas, offv1alpha1.ClusterReplicas{ClusterID: clusterID, Replicas: count})
+	}
+
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].ClusterID < replicas[j].ClusterID })
+	return replicas
+}
+
+// OwnerKey returns the key identifying the pods controlled by the given owner, in the annotations of the virtual nodes.
+func OwnerKey(namespace string, owner types.UID) string {
+	return fmt.Sprintf("%s/%s", namespace, owner)
+}
+
+// FailoverRestoring returns the deadline (as a Unix timestamp) until which the replacements of the pods controlled by each
+// owner shall preferably be scheduled onto the given virtual node, as stored in the node annotation.
+func FailoverRestoring(node *corev1.Node) (map[string]int64, error) {
+	return ownerDeadlines(node, liqoconst.FailoverRestoringAnnotationKey)
+}
+
+// RebalancingEvictions returns the deadline (as a Unix timestamp) until which the replacements of the pods controlled by each
+// owner shall not be scheduled onto the given virtual node, as stored in the node annotation.
+func RebalancingEvictions(node *corev1.Node) (map[string]int64, error) {
+	return ownerDeadlines(node, liqoconst.RebalancingEvictionsAnnotationKey)
+}
+
+// ownerDeadlines decodes the deadlines associated with each owner, as stored in the given annotation of the node.
+func ownerDeadlines(node *corev1.Node, annotation string) (map[string]int64, error) {
+	deadlines := map[string]int64{}
+	value, found := node.Annotations[annotation]
+	if !found {
+		return deadlines, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &deadlines); err != nil {
+		return map[string]int64{}, err
+	}
+	return deadlines, nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/distribution"
+)
+
+var _ = Describe("Replica distribution", func() {
+	const (
+		local  = "local-cluster-id"
+		remote = "remote-cluster-id"
+	)
+
+	Describe("the WeightedCluster function", func() {
+		weights := []offv1alpha1.ClusterWeight{{ClusterID: local, Weight: 70}, {ClusterID: "other"}, {ClusterID: remote, Weight: 30}}
+
+		DescribeTable("selecting the target cluster",
+			func(weights []offv1alpha1.ClusterWeight, value int64, expected string, found bool) {
+				target, ok := distribution.WeightedCluster(weights, func(n int64) int64 { return value })
+				Expect(ok).To(Equal(found))
+				Expect(target).To(Equal(expected))
+			},
+			Entry("lower bound of the first cluster", weights, int64(0), local, true),
+			Entry("upper bound of the first cluster", weights, int64(69), local, true),
+			Entry("lower bound of the second cluster", weights, int64(70), remote, true),
+			Entry("upper bound of the second cluster", weights, int64(99), remote, true),
+			Entry("zero weights", []offv1alpha1.ClusterWeight{{ClusterID: local}}, int64(0), "", false),
+			Entry("no weights", nil, int64(0), "", false),
+		)
+
+		It("should draw the value among the total positive weight", func() {
+			var drawn int64
+			_, ok := distribution.WeightedCluster(weights, func(n int64) int64 { drawn = n; return 0 })
+			Expect(ok).To(BeTrue())
+			Expect(drawn).To(BeNumerically("==", 100))
+		})
+	})
+
+	Describe("the NodeSelectorForCluster function", func() {
+		It("should exclude virtual nodes for the local cluster", func() {
+			Expect(distribution.NodeSelectorForCluster(local, local).NodeSelectorTerms).To(ConsistOf(corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key: liqoconst.TypeLabel, Operator: corev1.NodeSelectorOpNotIn, Values: []string{liqoconst.TypeNode}}},
+			}))
+		})
+
+		It("should select the corresponding virtual nodes for a remote cluster", func() {
+			Expect(distribution.NodeSelectorForCluster(remote, local).NodeSelectorTerms).To(ConsistOf(corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpIn, Values: []string{remote}}},
+			}))
+		})
+	})
+
+	Describe("the ClusterResolver", func() {
+		var (
+			ctx      context.Context
+			resolver *distribution.ClusterResolver
+			owner    metav1.OwnerReference
+			pods     []corev1.Pod
+		)
+
+		pod := func(name, node string, owner *metav1.OwnerReference, labels map[string]string) corev1.Pod {
+			p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "foo", Labels: labels}, Spec: corev1.PodSpec{NodeName: node}}
+			if owner != nil {
+				p.OwnerReferences = []metav1.OwnerReference{*owner}
+			}
+			return p
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			owner = metav1.OwnerReference{Kind: "ReplicaSet", Name: "rs", UID: types.UID("uid"), Controller: &[]bool{true}[0]}
+
+			nodes := []corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "local-node"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "virtual-node", Labels: map[string]string{
+					liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: remote}}},
+			}
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&nodes[0], &nodes[1]).Build()
+			resolver = distribution.NewClusterResolver(cl, local)
+
+			other := metav1.OwnerReference{Kind: "ReplicaSet", Name: "other", UID: types.UID("other"), Controller: &[]bool{true}[0]}
+			pods = []corev1.Pod{
+				pod("local-1", "local-node", &owner, nil),
+				pod("local-2", "local-node", &owner, nil),
+				pod("remote-1", "virtual-node", &owner, nil),
+				pod("pending-1", "", &owner, map[string]string{liqoconst.ReplicaTargetClusterLabelKey: remote}),
+				pod("pending-2", "", &owner, nil),
+				pod("other-1", "local-node", &other, nil),
+				pod("standalone-1", "virtual-node", nil, nil),
+				pod("missing-node", "missing", &owner, nil),
+			}
+			pods = append(pods, pod("completed-1", "local-node", &owner, nil))
+			pods[len(pods)-1].Status.Phase = corev1.PodSucceeded
+		})
+
+		It("should count the pods controlled by the given owner", func() {
+			current, err := resolver.CurrentReplicas(ctx, pods, &owner)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current).To(Equal(map[string]int32{local: 2, remote: 2}))
+		})
+
+		It("should count all the scheduled pods if no owner is specified", func() {
+			current, err := resolver.CurrentReplicas(ctx, pods, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current).To(Equal(map[string]int32{local: 3, remote: 2}))
+		})
+
+		It("should convert the distribution into a sorted list", func() {
+			Expect(distribution.ToClusterReplicas(map[string]int32{remote: 2, local: 3})).To(Equal([]offv1alpha1.ClusterReplicas{
+				{ClusterID: local, Replicas: 3}, {ClusterID: remote, Replicas: 2},
+			}))
+		})
+
+		It("should account for the resources requested on each remote cluster", func() {
+			pods[2].Spec.Containers = []corev1.Container{{Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}}}}
+			usage, err := resolver.CurrentUsage(ctx, pods)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage).To(HaveLen(1))
+			used := usage[remote]
+			Expect(used.Pods().Value()).To(BeNumerically("==", 3))
+			Expect(used.Cpu().MilliValue()).To(BeNumerically("==", 500))
+		})
+	})
+
+	Describe("the resource accounting functions", func() {
+		container := func(cpu, memory string) corev1.Container {
+			return corev1.Container{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}}}
+		}
+
+		It("should compute the resources requested by a pod", func() {
+			pod := corev1.Pod{Spec: corev1.PodSpec{
+				Containers:     []corev1.Container{container("100m", "100Mi"), container("200m", "100Mi")},
+				InitContainers: []corev1.Container{container("500m", "10Mi")},
+			}}
+
+			requests := distribution.PodRequests(&pod)
+			Expect(requests.Cpu().MilliValue()).To(BeNumerically("==", 500))
+			Expect(requests.Memory().Value()).To(BeNumerically("==", 200*1024*1024))
+			Expect(requests.Pods().Value()).To(BeNumerically("==", 1))
+		})
+
+		It("should return the resources of the quota which would be exceeded", func() {
+			quota := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourcePods: resource.MustParse("2")}
+			used := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourcePods: resource.MustParse("2")}
+			requests := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourcePods: resource.MustParse("1")}
+			Expect(distribution.ExceededResources(quota, used, requests)).To(ConsistOf(corev1.ResourcePods))
+			Expect(distribution.ExceededResources(quota, corev1.ResourceList{}, requests)).To(BeEmpty())
+		})
+
+		It("should add and subtract the requests", func() {
+			usage := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+			requests := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourcePods: resource.MustParse("1")}
+
+			distribution.AddRequests(usage, requests)
+			Expect(usage.Cpu().MilliValue()).To(BeNumerically("==", 1500))
+			Expect(usage.Pods().Value()).To(BeNumerically("==", 1))
+
+			distribution.SubtractRequests(usage, requests)
+			distribution.SubtractRequests(usage, requests)
+			Expect(usage.Cpu().MilliValue()).To(BeNumerically("==", 500))
+			Expect(usage.Pods().Value()).To(BeNumerically("==", -1))
+			Expect(requests.Cpu().MilliValue()).To(BeNumerically("==", 500))
+		})
+
+		It("should compute the allocatable resources not yet requested", func() {
+			allocatable := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourcePods: resource.MustParse("10"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("10Gi")}
+			free := distribution.FreeResources(allocatable, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")})
+			Expect(free).To(HaveLen(2))
+			Expect(free.Cpu().MilliValue()).To(BeNumerically("==", 1500))
+			Expect(free.Pods().Value()).To(BeNumerically("==", 10))
+			Expect(allocatable.Cpu().MilliValue()).To(BeNumerically("==", 2000))
+		})
+
+		It("should report only the resources limited by the quota", func() {
+			usage := map[string]corev1.ResourceList{remote: {corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourcePods: resource.MustParse("2")}}
+			Expect(distribution.ToClusterResourceUsage(usage, corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5")})).To(Equal(
+				[]offv1alpha1.ClusterResourceUsage{{ClusterID: remote, Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("2")}}},
+			))
+		})
+	})
+})
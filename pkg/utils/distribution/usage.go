@@ -0,0 +1,137 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distribution
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+)
+
+// PodRequests returns the amount of resources (i.e., cpu, memory and pods) requested by the given pod, according to
+// the same semantic adopted by Kubernetes ResourceQuotas: the sum of the requests of the containers (or the maximum
+// request of the init containers, if higher), plus the pod overhead.
+func PodRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(1, resource.DecimalSI)}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var containers resource.Quantity
+		for i := range pod.Spec.Containers {
+			containers.Add(pod.Spec.Containers[i].Resources.Requests[name])
+		}
+
+		for i := range pod.Spec.InitContainers {
+			if request := pod.Spec.InitContainers[i].Resources.Requests[name]; request.Cmp(containers) > 0 {
+				containers = request.DeepCopy()
+			}
+		}
+
+		containers.Add(pod.Spec.Overhead[name])
+		requests[name] = containers
+	}
+	return requests
+}
+
+// ExceededResources returns the resources of the quota that would be exceeded in case a pod with the given requests
+// was added to the ones accounting for the given usage. The result is sorted by resource name.
+func ExceededResources(quota, used, requests corev1.ResourceList) []corev1.ResourceName {
+	var exceeded []corev1.ResourceName
+	for name, hard := range quota {
+		total := used[name].DeepCopy()
+		total.Add(requests[name])
+		if total.Cmp(hard) > 0 {
+			exceeded = append(exceeded, name)
+		}
+	}
+
+	sort.Slice(exceeded, func(i, j int) bool { return exceeded[i] < exceeded[j] })
+	return exceeded
+}
+
+// CurrentUsage returns the amount of resources requested by the active pods hosted by (or assigned to) each remote
+// cluster. Pods hosted by the local cluster, as well as the ones not yet assigned to any cluster, are not accounted for.
+func (r *ClusterResolver) CurrentUsage(ctx context.Context, pods []corev1.Pod) (map[string]corev1.ResourceList, error) {
+	usage := map[string]corev1.ResourceList{}
+	for i := range pods {
+		pod := &pods[i]
+		if !IsActive(pod) {
+			continue
+		}
+
+		clusterID, found, err := r.ClusterForPod(ctx, pod)
+		if err != nil {
+			return nil, err
+		}
+		if !found || clusterID == r.localClusterID {
+			continue
+		}
+
+		if _, ok := usage[clusterID]; !ok {
+			usage[clusterID] = corev1.ResourceList{}
+		}
+		AddRequests(usage[clusterID], PodRequests(pod))
+	}
+	return usage, nil
+}
+
+// AddRequests adds the given requests to the given usage.
+func AddRequests(usage, requests corev1.ResourceList) {
+	for name, quantity := range requests {
+		total := usage[name].DeepCopy()
+		total.Add(quantity)
+		usage[name] = total
+	}
+}
+
+// SubtractRequests subtracts the given requests from the given usage.
+func SubtractRequests(usage, requests corev1.ResourceList) {
+	for name, quantity := range requests {
+		total := usage[name].DeepCopy()
+		total.Sub(quantity)
+		usage[name] = total
+	}
+}
+
+// FreeResources returns the allocatable resources (i.e., cpu, memory and pods) not already requested.
+func FreeResources(allocatable, requested corev1.ResourceList) corev1.ResourceList {
+	free := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods} {
+		if quantity, found := allocatable[name]; found {
+			quantity = quantity.DeepCopy()
+			quantity.Sub(requested[name])
+			free[name] = quantity
+		}
+	}
+	return free
+}
+
+// ToClusterResourceUsage converts the given map into a list of ClusterResourceUsage, sorted by cluster ID.
+// Only the resources limited by the given quota are reported.
+func ToClusterResourceUsage(usage map[string]corev1.ResourceList, quota corev1.ResourceList) []offv1alpha1.ClusterResourceUsage {
+	result := make([]offv1alpha1.ClusterResourceUsage, 0, len(usage))
+	for clusterID, used := range usage {
+		filtered := corev1.ResourceList{}
+		for name := range quota {
+			filtered[name] = used[name].DeepCopy()
+		}
+		result = append(result, offv1alpha1.ClusterResourceUsage{ClusterID: clusterID, Used: filtered})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ClusterID < result[j].ClusterID })
+	return result
+}
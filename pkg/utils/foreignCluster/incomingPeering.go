@@ -22,7 +22,12 @@ import (
 
 // AllowIncomingPeering returns the value set in the ForeignCluster spec if it has been set,
 // it returns the value set through the command line flag if it is automatic.
+// The incoming peering is never allowed if the identity of the remote cluster has been revoked.
 func AllowIncomingPeering(foreignCluster *discoveryv1alpha1.ForeignCluster, defaultEnableIncomingPeering bool) bool {
+	if IsIdentityRevoked(foreignCluster) {
+		return false
+	}
+
 	switch foreignCluster.Spec.IncomingPeeringEnabled {
 	case discoveryv1alpha1.PeeringEnabledYes:
 		return true
@@ -35,3 +40,8 @@ func AllowIncomingPeering(foreignCluster *discoveryv1alpha1.ForeignCluster, defa
 		return false
 	}
 }
+
+// IsIdentityRevoked returns whether the identity granted to the remote cluster has been revoked.
+func IsIdentityRevoked(foreignCluster *discoveryv1alpha1.ForeignCluster) bool {
+	return foreignCluster.Spec.RevokeIncomingIdentity
+}
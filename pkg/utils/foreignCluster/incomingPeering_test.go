@@ -47,6 +47,15 @@ var _ = Describe("IncomingPeering", func() {
 			}
 		}
 
+		var revokedForeignCluster = func() *discoveryv1alpha1.ForeignCluster {
+			return &discoveryv1alpha1.ForeignCluster{
+				Spec: discoveryv1alpha1.ForeignClusterSpec{
+					IncomingPeeringEnabled: discoveryv1alpha1.PeeringEnabledYes,
+					RevokeIncomingIdentity: true,
+				},
+			}
+		}
+
 		type allowIncomingPeeringTestcase struct {
 			foreignCluster               *discoveryv1alpha1.ForeignCluster
 			defaultEnableIncomingPeering bool
@@ -94,6 +103,12 @@ var _ = Describe("IncomingPeering", func() {
 				defaultEnableIncomingPeering: false,
 				expectedResult:               BeFalse(),
 			}),
+
+			Entry("incoming peering enabled but identity revoked", allowIncomingPeeringTestcase{
+				foreignCluster:               revokedForeignCluster(),
+				defaultEnableIncomingPeering: true,
+				expectedResult:               BeFalse(),
+			}),
 		)
 	})
 
@@ -14,9 +14,45 @@
 
 package foreigncluster
 
-import discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+import (
+	"net/url"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+)
 
 // InsecureSkipTLSVerify returns true if the ForeignCluster has to be contacted without the TLS verification.
 func InsecureSkipTLSVerify(foreignCluster *discoveryv1alpha1.ForeignCluster) bool {
 	return foreignCluster.Spec.InsecureSkipTLSVerify != nil && *foreignCluster.Spec.InsecureSkipTLSVerify
 }
+
+// CAFingerprint returns the fingerprint of the CA the remote Authentication Service is expected to be rooted in,
+// either explicitly pinned or trusted on first use. An empty string is returned if it has not been established yet.
+func CAFingerprint(foreignCluster *discoveryv1alpha1.ForeignCluster) string {
+	if foreignCluster.Spec.CAFingerprint != "" {
+		return foreignCluster.Spec.CAFingerprint
+	}
+	return foreignCluster.Status.CAFingerprint
+}
+
+// TrustedCAFingerprints returns the fingerprints of the CAs the remote Authentication Service is currently trusted to be
+// rooted in, that is the established one and the one announced for the next rotation (if any).
+func TrustedCAFingerprints(foreignCluster *discoveryv1alpha1.ForeignCluster) []string {
+	var fingerprints []string
+	if fingerprint := CAFingerprint(foreignCluster); fingerprint != "" {
+		fingerprints = append(fingerprints, fingerprint)
+		if next := foreignCluster.Status.NextCAFingerprint; next != "" {
+			fingerprints = append(fingerprints, next)
+		}
+	}
+	return fingerprints
+}
+
+// AuthServerName returns the name the certificate presented by the remote Authentication Service is expected to be
+// issued for, that is the host of the corresponding URL.
+func AuthServerName(foreignCluster *discoveryv1alpha1.ForeignCluster) string {
+	authURL, err := url.Parse(foreignCluster.Spec.ForeignAuthURL)
+	if err != nil {
+		return ""
+	}
+	return authURL.Hostname()
+}
@@ -0,0 +1,111 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespacemapping
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+)
+
+// TemplateData contains the information the NamespaceMappingTemplate can refer to.
+type TemplateData struct {
+	// Namespace is the name of the local namespace.
+	Namespace string
+	// ClusterName is the name of the local cluster.
+	ClusterName string
+	// ClusterID is the ID of the local cluster.
+	ClusterID string
+	// Labels are the labels of the local namespace.
+	Labels map[string]string
+}
+
+// ParseTemplate parses the given NamespaceMappingTemplate.
+func ParseTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("the NamespaceMappingTemplate must not be empty with the %v strategy", offv1alpha1.TemplateNameMappingStrategyType)
+	}
+
+	// Referring to missing labels is considered an error, rather than silently producing an empty segment. The missingkey
+	// option covers the field access form (i.e., .Labels.key), while the index function is overridden to cover the other one.
+	tmpl, err := template.New("namespace-mapping").Option("missingkey=error").Funcs(template.FuncMap{"index": indexLabel}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NamespaceMappingTemplate: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RemoteNamespaceName returns the name of the remote namespace corresponding to the local one the given
+// NamespaceOffloading refers to, according to the configured NamespaceMappingStrategy. The namespace labels
+// are required only in case of the Template strategy.
+func RemoteNamespaceName(nsoff *offv1alpha1.NamespaceOffloading, namespaceLabels map[string]string,
+	localCluster *discoveryv1alpha1.ClusterIdentity) (string, error) {
+	switch nsoff.Spec.NamespaceMappingStrategy {
+	case offv1alpha1.EnforceSameNameMappingStrategyType:
+		return nsoff.Namespace, nil
+	case offv1alpha1.TemplateNameMappingStrategyType:
+		return evaluateTemplate(nsoff.Spec.NamespaceMappingTemplate, &TemplateData{
+			Namespace:   nsoff.Namespace,
+			ClusterName: localCluster.ClusterName,
+			ClusterID:   localCluster.ClusterID,
+			Labels:      namespaceLabels,
+		})
+	default:
+		return nsoff.Namespace + "-" + foreignclusterutils.UniqueName(localCluster), nil
+	}
+}
+
+// evaluateTemplate evaluates the given NamespaceMappingTemplate, and validates the resulting name.
+func evaluateTemplate(text string, data *TemplateData) (string, error) {
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	// Labels with empty values are filtered out, so that referring to them fails as well.
+	labels := make(map[string]string, len(data.Labels))
+	for key, value := range data.Labels {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	data.Labels = labels
+
+	var builder strings.Builder
+	if err = tmpl.Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("failed to evaluate the NamespaceMappingTemplate: %w", err)
+	}
+
+	name := strings.TrimSpace(builder.String())
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return "", fmt.Errorf("the remote namespace name %q is not valid: %v", name, strings.Join(errs, ", "))
+	}
+	return name, nil
+}
+
+// indexLabel returns the value of the given label, and an error if it is missing (rather than the empty string).
+func indexLabel(labels map[string]string, key string) (string, error) {
+	value, found := labels[key]
+	if !found {
+		return "", fmt.Errorf("label %q is missing or empty", key)
+	}
+	return value, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespacemapping_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/namespacemapping"
+)
+
+var _ = Describe("RemoteNamespaceName", func() {
+	var (
+		localCluster = discoveryv1alpha1.ClusterIdentity{ClusterID: "cluster-id", ClusterName: "local"}
+		labels       = map[string]string{"team": "blue", "empty": ""}
+	)
+
+	forge := func(strategy offv1alpha1.NamespaceMappingStrategyType, template string) *offv1alpha1.NamespaceOffloading {
+		return &offv1alpha1.NamespaceOffloading{
+			ObjectMeta: metav1.ObjectMeta{Name: "offloading", Namespace: "foo"},
+			Spec:       offv1alpha1.NamespaceOffloadingSpec{NamespaceMappingStrategy: strategy, NamespaceMappingTemplate: template},
+		}
+	}
+
+	DescribeTable("computing the remote namespace name",
+		func(strategy offv1alpha1.NamespaceMappingStrategyType, template string, matcher OmegaMatcher, expectErr bool) {
+			name, err := namespacemapping.RemoteNamespaceName(forge(strategy, template), labels, &localCluster)
+			if expectErr {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(matcher)
+		},
+		Entry("the EnforceSameName strategy", offv1alpha1.EnforceSameNameMappingStrategyType, "", Equal("foo"), false),
+		Entry("the DefaultName strategy", offv1alpha1.DefaultNameMappingStrategyType, "", HavePrefix("foo-local-"), false),
+		Entry("a template with placeholders", offv1alpha1.TemplateNameMappingStrategyType,
+			"tenant-{{ .ClusterName }}-{{ .Namespace }}", Equal("tenant-local-foo"), false),
+		Entry("a template referring to the cluster ID and labels", offv1alpha1.TemplateNameMappingStrategyType,
+			`{{ index .Labels "team" }}-{{ .ClusterID }}`, Equal("blue-cluster-id"), false),
+		Entry("an explicit name", offv1alpha1.TemplateNameMappingStrategyType, "explicit", Equal("explicit"), false),
+		Entry("an empty template", offv1alpha1.TemplateNameMappingStrategyType, "", nil, true),
+		Entry("a malformed template", offv1alpha1.TemplateNameMappingStrategyType, "{{ .Namespace", nil, true),
+		Entry("a template referring to missing labels", offv1alpha1.TemplateNameMappingStrategyType,
+			`{{ .Labels.missing }}`, nil, true),
+		Entry("a template indexing missing labels", offv1alpha1.TemplateNameMappingStrategyType,
+			`foo-{{ index .Labels "missing" }}-bar`, nil, true),
+		Entry("a template referring to empty labels", offv1alpha1.TemplateNameMappingStrategyType,
+			`foo-{{ .Labels.empty }}-bar`, nil, true),
+		Entry("a template indexing empty labels", offv1alpha1.TemplateNameMappingStrategyType,
+			`foo-{{ index .Labels "empty" }}-bar`, nil, true),
+		Entry("a template producing an invalid name", offv1alpha1.TemplateNameMappingStrategyType,
+			"Tenant_{{ .Namespace }}", nil, true),
+		Entry("a template producing a too long name", offv1alpha1.TemplateNameMappingStrategyType,
+			strings.Repeat("a", 60)+"-{{ .Namespace }}", nil, true),
+	)
+})
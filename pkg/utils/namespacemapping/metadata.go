@@ -0,0 +1,142 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespacemapping
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+)
+
+const (
+	// wildcard is the suffix used to match all the keys with a given prefix.
+	wildcard = "*"
+	// reservedDomain is the domain of the labels and annotations reserved to Liqo, which cannot be propagated.
+	reservedDomain = "liqo.io"
+	// podSecurityLabelPrefix is the prefix of the labels configuring the Pod Security Admission levels of a namespace.
+	podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+)
+
+// PodSecurityLevels are the Pod Security Admission levels, sorted from the least to the most restrictive one.
+var PodSecurityLevels = []string{"privileged", "baseline", "restricted"}
+
+// ValidatePropagatedKeys checks that the given label/annotation keys (possibly terminating with the "*" wildcard)
+// are valid, and that they do not refer to the keys reserved to Liqo.
+func ValidatePropagatedKeys(keys []string) error {
+	for _, key := range keys {
+		// Replace the wildcard with a valid character, to check whether the prefix is well-formed.
+		candidate := key
+		if strings.HasSuffix(key, wildcard) {
+			candidate = strings.TrimSuffix(key, wildcard) + "x"
+		}
+
+		if errs := validation.IsQualifiedName(candidate); len(errs) > 0 {
+			return fmt.Errorf("the key %q is not valid: %v", key, strings.Join(errs, ", "))
+		}
+		if isReservedKey(candidate) {
+			return fmt.Errorf("the key %q is reserved to Liqo, and it cannot be propagated", key)
+		}
+	}
+	return nil
+}
+
+// RemoteNamespaceOptions returns the options to be enforced on the remote namespaces associated with the given
+// NamespaceOffloading, according to the metadata of the corresponding local namespace. It returns nil in case
+// there is no option to be enforced.
+func RemoteNamespaceOptions(nsoff *offv1alpha1.NamespaceOffloading, namespace *corev1.Namespace) *vkv1alpha1.RemoteNamespaceOptions {
+	options := vkv1alpha1.RemoteNamespaceOptions{
+		Labels:      FilterPropagatedMetadata(namespace.GetLabels(), nsoff.Spec.PropagatedLabels),
+		Annotations: FilterPropagatedMetadata(namespace.GetAnnotations(), nsoff.Spec.PropagatedAnnotations),
+		Retain:      nsoff.Spec.RemoteNamespaceRetentionPolicy == offv1alpha1.RetainRemoteNamespaceRetentionPolicyType,
+	}
+
+	if options.Labels == nil && options.Annotations == nil && !options.Retain {
+		return nil
+	}
+	return &options
+}
+
+// FilterPropagatedMetadata returns the subset of the given labels/annotations matching at least one of the given keys,
+// excluding those reserved to Liqo. It returns nil in case no entry matches.
+func FilterPropagatedMetadata(metadata map[string]string, keys []string) map[string]string {
+	var filtered map[string]string
+	for key, value := range metadata {
+		if isReservedKey(key) || !matchesAnyKey(key, keys) {
+			continue
+		}
+
+		if filtered == nil {
+			filtered = map[string]string{}
+		}
+		filtered[key] = value
+	}
+	return filtered
+}
+
+// matchesAnyKey returns whether the given key matches at least one of the given ones, possibly terminating with the "*" wildcard.
+func matchesAnyKey(key string, keys []string) bool {
+	for _, candidate := range keys {
+		if strings.HasSuffix(candidate, wildcard) {
+			if strings.HasPrefix(key, strings.TrimSuffix(candidate, wildcard)) {
+				return true
+			}
+		} else if key == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// isReservedKey returns whether the given label/annotation key is reserved to Liqo.
+func isReservedKey(key string) bool {
+	domain, _, found := strings.Cut(key, "/")
+	return found && (domain == reservedDomain || strings.HasSuffix(domain, "."+reservedDomain))
+}
+
+// FilterPodSecurityLabels returns the subset of the given labels excluding those configuring a Pod Security Admission level
+// (for any of the enforce, audit and warn modes) less restrictive than the given minimum one, or not valid.
+func FilterPodSecurityLabels(labels map[string]string, minimum string) map[string]string {
+	var filtered map[string]string
+	for key, value := range labels {
+		switch key {
+		case podSecurityLabelPrefix + "enforce", podSecurityLabelPrefix + "audit", podSecurityLabelPrefix + "warn":
+			if level := podSecurityLevelIndex(value); level < 0 || level < podSecurityLevelIndex(minimum) {
+				continue
+			}
+		}
+
+		if filtered == nil {
+			filtered = map[string]string{}
+		}
+		filtered[key] = value
+	}
+	return filtered
+}
+
+// podSecurityLevelIndex returns the position of the given Pod Security Admission level in the restrictiveness order,
+// or -1 if it is not valid.
+func podSecurityLevelIndex(level string) int {
+	for i := range PodSecurityLevels {
+		if PodSecurityLevels[i] == level {
+			return i
+		}
+	}
+	return -1
+}
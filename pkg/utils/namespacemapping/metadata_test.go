@@ -0,0 +1,120 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespacemapping_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/namespacemapping"
+)
+
+var _ = Describe("Remote namespace metadata", func() {
+	DescribeTable("validating the propagated keys",
+		func(keys []string, expectErr bool) {
+			err := namespacemapping.ValidatePropagatedKeys(keys)
+			if expectErr {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+		},
+		Entry("no keys", nil, false),
+		Entry("plain keys", []string{"cost-center", "example.com/team"}, false),
+		Entry("wildcard keys", []string{"pod-security.kubernetes.io/*", "*"}, false),
+		Entry("an invalid key", []string{"invalid key"}, true),
+		Entry("a wildcard in the middle of the key", []string{"example.com/*-team"}, true),
+		Entry("a key reserved to Liqo", []string{"liqo.io/remote-cluster-id"}, true),
+		Entry("a wildcard key reserved to Liqo", []string{"offloading.liqo.io/*"}, true),
+	)
+
+	DescribeTable("filtering the propagated metadata",
+		func(keys []string, expected map[string]string) {
+			metadata := map[string]string{
+				"cost-center":                        "42",
+				"pod-security.kubernetes.io/enforce": "restricted",
+				"pod-security.kubernetes.io/warn":    "baseline",
+				"liqo.io/scheduling-enabled":         "true",
+			}
+			Expect(namespacemapping.FilterPropagatedMetadata(metadata, keys)).To(Equal(expected))
+		},
+		Entry("no keys", nil, nil),
+		Entry("no matching keys", []string{"team"}, nil),
+		Entry("plain keys", []string{"cost-center"}, map[string]string{"cost-center": "42"}),
+		Entry("wildcard keys", []string{"pod-security.kubernetes.io/*"}, map[string]string{
+			"pod-security.kubernetes.io/enforce": "restricted", "pod-security.kubernetes.io/warn": "baseline"}),
+		Entry("the catch-all wildcard, excluding the keys reserved to Liqo", []string{"*"}, map[string]string{"cost-center": "42",
+			"pod-security.kubernetes.io/enforce": "restricted", "pod-security.kubernetes.io/warn": "baseline"}),
+	)
+
+	DescribeTable("filtering the Pod Security Admission labels",
+		func(minimum string, expected map[string]string) {
+			labels := map[string]string{
+				"team":                               "foo",
+				"pod-security.kubernetes.io/enforce": "baseline",
+				"pod-security.kubernetes.io/enforce-version": "latest",
+				"pod-security.kubernetes.io/audit":           "restricted",
+				"pod-security.kubernetes.io/warn":            "invalid",
+			}
+			Expect(namespacemapping.FilterPodSecurityLabels(labels, minimum)).To(Equal(expected))
+		},
+		Entry("the privileged level", "privileged", map[string]string{"team": "foo", "pod-security.kubernetes.io/enforce": "baseline",
+			"pod-security.kubernetes.io/enforce-version": "latest", "pod-security.kubernetes.io/audit": "restricted"}),
+		Entry("the baseline level", "baseline", map[string]string{"team": "foo", "pod-security.kubernetes.io/enforce": "baseline",
+			"pod-security.kubernetes.io/enforce-version": "latest", "pod-security.kubernetes.io/audit": "restricted"}),
+		Entry("the restricted level", "restricted", map[string]string{"team": "foo",
+			"pod-security.kubernetes.io/enforce-version": "latest", "pod-security.kubernetes.io/audit": "restricted"}),
+	)
+
+	Describe("computing the remote namespace options", func() {
+		var (
+			nsoff     offv1alpha1.NamespaceOffloading
+			namespace corev1.Namespace
+		)
+
+		BeforeEach(func() {
+			nsoff = offv1alpha1.NamespaceOffloading{ObjectMeta: metav1.ObjectMeta{Name: "offloading", Namespace: "foo"}}
+			namespace = corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo",
+				Labels:      map[string]string{"pod-security.kubernetes.io/enforce": "restricted"},
+				Annotations: map[string]string{"cost-center": "42"},
+			}}
+		})
+
+		It("should return nil if no option is configured", func() {
+			Expect(namespacemapping.RemoteNamespaceOptions(&nsoff, &namespace)).To(BeNil())
+		})
+
+		It("should return nil if no metadata matches", func() {
+			nsoff.Spec.PropagatedLabels = []string{"team"}
+			nsoff.Spec.RemoteNamespaceRetentionPolicy = offv1alpha1.DeleteRemoteNamespaceRetentionPolicyType
+			Expect(namespacemapping.RemoteNamespaceOptions(&nsoff, &namespace)).To(BeNil())
+		})
+
+		It("should return the propagated metadata and the retention option", func() {
+			nsoff.Spec.PropagatedLabels = []string{"pod-security.kubernetes.io/*"}
+			nsoff.Spec.PropagatedAnnotations = []string{"cost-center"}
+			nsoff.Spec.RemoteNamespaceRetentionPolicy = offv1alpha1.RetainRemoteNamespaceRetentionPolicyType
+			Expect(namespacemapping.RemoteNamespaceOptions(&nsoff, &namespace)).To(Equal(&vkv1alpha1.RemoteNamespaceOptions{
+				Labels:      map[string]string{"pod-security.kubernetes.io/enforce": "restricted"},
+				Annotations: map[string]string{"cost-center": "42"},
+				Retain:      true,
+			}))
+		})
+	})
+})
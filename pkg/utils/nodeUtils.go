@@ -16,6 +16,7 @@ package utils
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	k8shelper "k8s.io/component-helpers/scheduling/corev1"
 
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
 )
@@ -66,3 +67,14 @@ func GetNodeClusterID(selectedNode *corev1.Node) (string, bool) {
 
 	return remoteClusterID, true
 }
+
+// MatchNodeSelectorTerms returns whether the given node matches the given node selector.
+// An empty node selector (i.e., with no terms) matches all nodes.
+func MatchNodeSelectorTerms(node *corev1.Node, selector *corev1.NodeSelector) (bool, error) {
+	// Shortcircuit the matching logic, to always return a positive outcome in case no selector is specified.
+	if len(selector.NodeSelectorTerms) == 0 {
+		return true, nil
+	}
+
+	return k8shelper.MatchNodeSelectorTerms(node, selector)
+}
@@ -301,4 +301,28 @@ var _ = Describe("NodeUtils", func() {
 
 	})
 
+	Context("matchNodeSelectorTerms", func() {
+
+		node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"label1": "val1"}}}
+		selectorFor := func(n int) *v1.NodeSelector {
+			return &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{{
+				MatchExpressions: []v1.NodeSelectorRequirement{{
+					Key: fmt.Sprintf("label%v", n), Operator: v1.NodeSelectorOpIn, Values: []string{fmt.Sprintf("val%v", n)},
+				}},
+			}}}
+		}
+
+		DescribeTable("matchNodeSelectorTerms table",
+			func(selector *v1.NodeSelector, expected types.GomegaMatcher) {
+				match, err := MatchNodeSelectorTerms(node, selector)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(match).To(expected)
+			},
+			Entry("empty selector", &v1.NodeSelector{}, BeTrue()),
+			Entry("matching selector", selectorFor(1), BeTrue()),
+			Entry("non matching selector", selectorFor(2), BeFalse()),
+		)
+
+	})
+
 })
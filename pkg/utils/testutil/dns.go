@@ -32,9 +32,9 @@ type DnsServer struct {
 func (s *DnsServer) Serve() {
 	s.registryDomain = "test.liqo.io."
 	s.ptrQueries = map[string][]string{
-		s.registryDomain: {
-			"myliqo1." + s.registryDomain,
-			"myliqo2." + s.registryDomain,
+		"_liqo_auth._tcp." + s.registryDomain: {
+			"myliqo1._liqo_auth._tcp." + s.registryDomain,
+			"myliqo2._liqo_auth._tcp." + s.registryDomain,
 		},
 	}
 
@@ -92,10 +92,10 @@ func (s *DnsServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	case dns.TypeSRV:
 		var port int
 		var host string
-		if domain == s.ptrQueries[s.registryDomain][0] {
+		if domain == s.ptrQueries["_liqo_auth._tcp."+s.registryDomain][0] {
 			port = 1234
 			host = "h1." + s.registryDomain
-		} else if domain == s.ptrQueries[s.registryDomain][1] {
+		} else if domain == s.ptrQueries["_liqo_auth._tcp."+s.registryDomain][1] {
 			port = 4321
 			host = "h2." + s.registryDomain
 		}
@@ -106,6 +106,13 @@ func (s *DnsServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 			Port:     uint16(port),
 			Target:   host,
 		})
+	case dns.TypeTXT:
+		if domain == s.ptrQueries["_liqo_auth._tcp."+s.registryDomain][0] {
+			msg.Answer = append(msg.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{Name: domain, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+				Txt: []string{"cluster-id=myliqo1-cluster-id"},
+			})
+		}
 	case dns.TypeA:
 		var host string
 		if domain == "h1."+s.registryDomain {
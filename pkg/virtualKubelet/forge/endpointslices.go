@@ -108,3 +108,39 @@ func RemoteEndpointHints(local *discoveryv1.EndpointHints) *discoveryv1apply.End
 	}
 	return hints
 }
+
+// ImportedEndpointSlice forges the apply patch for the local endpointslice importing the given remote one.
+func ImportedEndpointSlice(remote *discoveryv1.EndpointSlice, targetNamespace string,
+	translator EndpointTranslator) *discoveryv1apply.EndpointSliceApplyConfiguration {
+	return discoveryv1apply.EndpointSlice(remote.GetName(), targetNamespace).
+		WithLabels(remote.GetLabels()).WithLabels(ImportLabels()).
+		WithLabels(EndpointSliceLabels()).WithAnnotations(remote.GetAnnotations()).
+		WithAddressType(remote.AddressType).
+		WithEndpoints(ImportedEndpointSliceEndpoints(remote.Endpoints, translator)...).
+		WithPorts(RemoteEndpointSlicePorts(remote.Ports)...)
+}
+
+// ImportedEndpointSliceEndpoints forges the apply patch for the endpoints of the local endpointslice, given the remote ones.
+// Endpoints are assigned to the virtual node, while the zone hints and the target references are dropped, as meaningless locally.
+// Endpoints whose addresses cannot be translated are omitted.
+func ImportedEndpointSliceEndpoints(remotes []discoveryv1.Endpoint,
+	translator EndpointTranslator) []*discoveryv1apply.EndpointApplyConfiguration {
+	var locals []*discoveryv1apply.EndpointApplyConfiguration
+
+	for i := range remotes {
+		remote := remotes[i].DeepCopy()
+		addresses := translator(remote.Addresses)
+		if len(addresses) == 0 {
+			continue
+		}
+
+		conditions := &discoveryv1apply.EndpointConditionsApplyConfiguration{Ready: remote.Conditions.Ready}
+		local := discoveryv1apply.Endpoint().
+			WithAddresses(addresses...).WithConditions(conditions).WithNodeName(LiqoNodeName)
+		local.Hostname = remote.Hostname
+
+		locals = append(locals, local)
+	}
+
+	return locals
+}
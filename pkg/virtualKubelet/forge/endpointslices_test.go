@@ -147,6 +147,57 @@ var _ = Describe("EndpointSlices Forging", func() {
 		})
 	})
 
+	Describe("the ImportedEndpointSlice function", func() {
+		var (
+			input  *discoveryv1.EndpointSlice
+			output *discoveryv1apply.EndpointSliceApplyConfiguration
+		)
+
+		BeforeEach(func() {
+			input = &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "name", Namespace: "remote",
+					Labels: map[string]string{discoveryv1.LabelServiceName: "service", discoveryv1.LabelManagedBy: "endpointslice-controller"},
+				},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints: []discoveryv1.Endpoint{{
+					Addresses:  []string{"first"},
+					Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)},
+					NodeName:   pointer.String("remote-node"),
+					Zone:       pointer.String("remote-zone"),
+					TargetRef:  &corev1.ObjectReference{Kind: "Pod"},
+				}},
+			}
+		})
+
+		JustBeforeEach(func() { output = forge.ImportedEndpointSlice(input, "local", Translator) })
+
+		It("should correctly set the name and namespace", func() {
+			Expect(output.Name).To(PointTo(Equal("name")))
+			Expect(output.Namespace).To(PointTo(Equal("local")))
+		})
+		It("should correctly set the labels", func() {
+			Expect(output.Labels).To(HaveKeyWithValue(discoveryv1.LabelServiceName, "service"))
+			Expect(output.Labels).To(HaveKeyWithValue(discoveryv1.LabelManagedBy, forge.EndpointSliceManagedBy))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoImportedKey, "true"))
+		})
+		It("should correctly translate the endpoints", func() {
+			Expect(output.Endpoints).To(HaveLen(1))
+			Expect(output.Endpoints[0].Addresses).To(ConsistOf("first-reflected"))
+			Expect(output.Endpoints[0].Conditions.Ready).To(PointTo(BeTrue()))
+			Expect(output.Endpoints[0].NodeName).To(PointTo(Equal(LiqoNodeName)))
+			Expect(output.Endpoints[0].Zone).To(BeNil())
+			Expect(output.Endpoints[0].TargetRef).To(BeNil())
+		})
+
+		When("the addresses of an endpoint cannot be translated", func() {
+			JustBeforeEach(func() {
+				output = forge.ImportedEndpointSlice(input, "local", func([]string) []string { return nil })
+			})
+			It("should omit the endpoint", func() { Expect(output.Endpoints).To(BeEmpty()) })
+		})
+	})
+
 	Describe("the RemoteEndpointSlicePorts function", func() {
 		var (
 			input  discoveryv1.EndpointPort
@@ -14,7 +14,10 @@
 
 package forge
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	// EventSuccessfulReflection -> the reason for the event when the reflection completes successfully.
@@ -23,6 +26,9 @@ const (
 	// EventFailedReflection -> the reason for the event when the reflection fails.
 	EventFailedReflection = "FailedReflection"
 
+	// EventPartialReflection -> the reason for the event when the reflection completes, but part of the object could not be translated.
+	EventPartialReflection = "PartialReflection"
+
 	// EventFailedDeletion -> the reason for the event when the deletion of an object fails.
 	EventFailedDeletion = "FailedDeletion"
 
@@ -51,6 +57,17 @@ func EventFailedReflectionMsg(err error) string {
 	return fmt.Sprintf("Error reflecting object to cluster %q: %v", RemoteCluster.ClusterName, err)
 }
 
+// EventPartialReflectionMsg returns the message for the event when the outgoing reflection completes, but some elements were not translated.
+func EventPartialReflectionMsg(untranslatable []string) string {
+	return fmt.Sprintf("Partially reflected object to cluster %q, skipping untranslatable elements: %v",
+		RemoteCluster.ClusterName, strings.Join(untranslatable, "; "))
+}
+
+// EventSuccessfulImportMsg returns the message for the event when the import of a remote object completes successfully.
+func EventSuccessfulImportMsg() string {
+	return fmt.Sprintf("Successfully imported object from cluster %q", RemoteCluster.ClusterName)
+}
+
 // EventFailedStatusReflectionMsg returns the message for the event when the incoming reflection fails due to an error.
 func EventFailedStatusReflectionMsg(err error) string {
 	return fmt.Sprintf("Error reflecting object status back from cluster %q: %v", RemoteCluster.ClusterName, err)
@@ -62,6 +79,12 @@ func EventFailedReflectionAlreadyExistsMsg() string {
 	return fmt.Sprintf("Error reflecting object to cluster %q: remote object already exists", RemoteCluster.ClusterName)
 }
 
+// EventFailedImportAlreadyExistsMsg returns the message for the event when the import of a remote object
+// has been aborted because the local object already exists.
+func EventFailedImportAlreadyExistsMsg() string {
+	return fmt.Sprintf("Error importing object from cluster %q: local object already exists", RemoteCluster.ClusterName)
+}
+
 // EventFailedLabelsUpdateMsg returns the message for the event when it is impossible to update the labels of a local object.
 func EventFailedLabelsUpdateMsg(err error) string {
 	return fmt.Sprintf("Error updating local object labels: %v", err)
@@ -83,8 +106,8 @@ func EventReflectionDisabledErrorMsg(namespace string, err error) string {
 }
 
 // EventObjectReflectionDisabledMsg returns the message for the event when reflection is disabled for a given resource.
-func EventObjectReflectionDisabledMsg() string {
-	return fmt.Sprintf("Reflection to cluster %q disabled for the current object", RemoteCluster.ClusterName)
+func EventObjectReflectionDisabledMsg(reason string) string {
+	return fmt.Sprintf("Reflection to cluster %q disabled for the current object: %v", RemoteCluster.ClusterName, reason)
 }
 
 // EventSAReflectionDisabledMsg returns the message for the event when service account reflection is disabled.
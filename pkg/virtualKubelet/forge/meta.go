@@ -26,6 +26,8 @@ const (
 	LiqoOriginClusterIDKey = "virtualkubelet.liqo.io/origin"
 	// LiqoDestinationClusterIDKey is the key of a label identifying the destination cluster of a reflected resource.
 	LiqoDestinationClusterIDKey = "virtualkubelet.liqo.io/destination"
+	// LiqoImportedKey is the key of a label identifying the resources imported from the remote to the local cluster.
+	LiqoImportedKey = "virtualkubelet.liqo.io/imported"
 )
 
 // ReflectionLabels returns the labels assigned to the objects reflected from the local to the remote cluster.
@@ -46,6 +48,25 @@ func IsReflected(obj metav1.Object) bool {
 	return ReflectedLabelSelector().Matches(labels.Set(obj.GetLabels()))
 }
 
+// ImportLabels returns the labels assigned to the objects imported from the remote to the local cluster.
+func ImportLabels() labels.Set {
+	return map[string]string{
+		LiqoOriginClusterIDKey:      RemoteCluster.ClusterID,
+		LiqoDestinationClusterIDKey: LocalCluster.ClusterID,
+		LiqoImportedKey:             "true",
+	}
+}
+
+// ImportedLabelSelector returns a label selector matching the objects imported from the remote to the local cluster.
+func ImportedLabelSelector() labels.Selector {
+	return ImportLabels().AsSelectorPreValidated()
+}
+
+// IsImported returns whether the current object has been imported from the remote to the local cluster.
+func IsImported(obj metav1.Object) bool {
+	return ImportedLabelSelector().Matches(labels.Set(obj.GetLabels()))
+}
+
 // RemoteObjectMeta forges the local ObjectMeta for a reflected object.
 func RemoteObjectMeta(local, remote *metav1.ObjectMeta) metav1.ObjectMeta {
 	output := remote.DeepCopy()
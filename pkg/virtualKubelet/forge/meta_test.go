@@ -70,6 +70,40 @@ var _ = Describe("Meta forging", func() {
 		}))
 	})
 
+	Describe("Import labels", func() {
+		Describe("the ImportLabels function", func() {
+			It("should set the origin cluster label", func() {
+				Expect(forge.ImportLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, RemoteClusterID))
+			})
+			It("should set the destination cluster label", func() {
+				Expect(forge.ImportLabels()).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, LocalClusterID))
+			})
+			It("should set the imported label", func() {
+				Expect(forge.ImportLabels()).To(HaveKeyWithValue(forge.LiqoImportedKey, "true"))
+			})
+		})
+
+		DescribeTable("the IsImported function",
+			func(labels map[string]string, matches bool) {
+				Expect(forge.IsImported(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Labels: labels}})).To(BeIdenticalTo(matches))
+			},
+			Entry("when no label is specified", nil, false),
+			Entry("when the object is reflected from the local cluster", map[string]string{
+				forge.LiqoOriginClusterIDKey:      LocalClusterID,
+				forge.LiqoDestinationClusterIDKey: RemoteClusterID,
+			}, false),
+			Entry("when the object is reflected by the remote cluster", map[string]string{
+				forge.LiqoOriginClusterIDKey:      RemoteClusterID,
+				forge.LiqoDestinationClusterIDKey: LocalClusterID,
+			}, false),
+			Entry("when the object is imported from the remote cluster", map[string]string{
+				forge.LiqoOriginClusterIDKey:      RemoteClusterID,
+				forge.LiqoDestinationClusterIDKey: LocalClusterID,
+				forge.LiqoImportedKey:             "true",
+			}, true),
+		)
+	})
+
 	Describe("the RemoteObjectMeta function", func() {
 		var local, remote, original, output metav1.ObjectMeta
 
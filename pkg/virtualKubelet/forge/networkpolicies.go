@@ -0,0 +1,123 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1apply "k8s.io/client-go/applyconfigurations/meta/v1"
+	netv1apply "k8s.io/client-go/applyconfigurations/networking/v1"
+)
+
+// NetworkPolicyPeersTranslator translates the peers of a local NetworkPolicy rule into the corresponding remote ones.
+// Peers which cannot be translated are expected to be omitted from the returned slice.
+type NetworkPolicyPeersTranslator func(peers []netv1.NetworkPolicyPeer) []netv1.NetworkPolicyPeer
+
+// RemoteNetworkPolicy forges the apply patch for the reflected networkpolicy, given the local one.
+func RemoteNetworkPolicy(local *netv1.NetworkPolicy, targetNamespace string,
+	translator NetworkPolicyPeersTranslator) *netv1apply.NetworkPolicyApplyConfiguration {
+	return netv1apply.NetworkPolicy(local.GetName(), targetNamespace).
+		WithLabels(local.GetLabels()).WithLabels(ReflectionLabels()).
+		WithAnnotations(local.GetAnnotations()).
+		WithSpec(RemoteNetworkPolicySpec(local.Spec.DeepCopy(), translator))
+}
+
+// RemoteNetworkPolicySpec forges the apply patch for the specs of the reflected networkpolicy, given the local ones.
+// Rules whose peers are all untranslatable are dropped, to prevent them from turning into allow-all rules.
+func RemoteNetworkPolicySpec(local *netv1.NetworkPolicySpec, translator NetworkPolicyPeersTranslator) *netv1apply.NetworkPolicySpecApplyConfiguration {
+	remote := netv1apply.NetworkPolicySpec().
+		WithPodSelector(RemoteLabelSelector(&local.PodSelector)).
+		WithPolicyTypes(local.PolicyTypes...)
+
+	for i := range local.Ingress {
+		peers, ok := remoteNetworkPolicyPeers(local.Ingress[i].From, translator)
+		if !ok {
+			continue
+		}
+		remote.WithIngress(netv1apply.NetworkPolicyIngressRule().
+			WithFrom(peers...).WithPorts(RemoteNetworkPolicyPorts(local.Ingress[i].Ports)...))
+	}
+
+	for i := range local.Egress {
+		peers, ok := remoteNetworkPolicyPeers(local.Egress[i].To, translator)
+		if !ok {
+			continue
+		}
+		remote.WithEgress(netv1apply.NetworkPolicyEgressRule().
+			WithTo(peers...).WithPorts(RemoteNetworkPolicyPorts(local.Egress[i].Ports)...))
+	}
+
+	return remote
+}
+
+// remoteNetworkPolicyPeers translates the given peers, returning false in case
+// the corresponding rule shall be dropped since none of them could be translated.
+func remoteNetworkPolicyPeers(locals []netv1.NetworkPolicyPeer, translator NetworkPolicyPeersTranslator) (
+	[]*netv1apply.NetworkPolicyPeerApplyConfiguration, bool) {
+	// An empty list of peers matches all sources/destinations, hence no translation is required.
+	if len(locals) == 0 {
+		return nil, true
+	}
+
+	translated := translator(locals)
+	if len(translated) == 0 {
+		return nil, false
+	}
+
+	remotes := make([]*netv1apply.NetworkPolicyPeerApplyConfiguration, len(translated))
+	for i := range translated {
+		remotes[i] = RemoteNetworkPolicyPeer(&translated[i])
+	}
+	return remotes, true
+}
+
+// RemoteNetworkPolicyPeer forges the apply patch for an already translated networkpolicy peer.
+func RemoteNetworkPolicyPeer(peer *netv1.NetworkPolicyPeer) *netv1apply.NetworkPolicyPeerApplyConfiguration {
+	remote := netv1apply.NetworkPolicyPeer()
+	if peer.PodSelector != nil {
+		remote.WithPodSelector(RemoteLabelSelector(peer.PodSelector))
+	}
+	if peer.NamespaceSelector != nil {
+		remote.WithNamespaceSelector(RemoteLabelSelector(peer.NamespaceSelector))
+	}
+	if peer.IPBlock != nil {
+		remote.WithIPBlock(netv1apply.IPBlock().WithCIDR(peer.IPBlock.CIDR).WithExcept(peer.IPBlock.Except...))
+	}
+	return remote
+}
+
+// RemoteNetworkPolicyPorts forges the apply patch for the ports of a networkpolicy rule, given the local ones.
+func RemoteNetworkPolicyPorts(locals []netv1.NetworkPolicyPort) []*netv1apply.NetworkPolicyPortApplyConfiguration {
+	remotes := make([]*netv1apply.NetworkPolicyPortApplyConfiguration, len(locals))
+	for i := range locals {
+		remotes[i] = netv1apply.NetworkPolicyPort()
+		remotes[i].Protocol = locals[i].Protocol
+		remotes[i].Port = locals[i].Port
+		remotes[i].EndPort = locals[i].EndPort
+	}
+	return remotes
+}
+
+// RemoteLabelSelector forges the apply patch for a label selector, given the local one.
+func RemoteLabelSelector(local *metav1.LabelSelector) *metav1apply.LabelSelectorApplyConfiguration {
+	remote := metav1apply.LabelSelector().WithMatchLabels(local.MatchLabels)
+	for i := range local.MatchExpressions {
+		remote.WithMatchExpressions(metav1apply.LabelSelectorRequirement().
+			WithKey(local.MatchExpressions[i].Key).
+			WithOperator(local.MatchExpressions[i].Operator).
+			WithValues(local.MatchExpressions[i].Values...))
+	}
+	return remote
+}
@@ -0,0 +1,138 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	netv1apply "k8s.io/client-go/applyconfigurations/networking/v1"
+
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("NetworkPolicies Forging", func() {
+	Describe("the RemoteNetworkPolicy function", func() {
+		var (
+			input      *netv1.NetworkPolicy
+			translator forge.NetworkPolicyPeersTranslator
+			output     *netv1apply.NetworkPolicyApplyConfiguration
+		)
+
+		BeforeEach(func() {
+			port := intstr.FromInt(8080)
+			input = &netv1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "name", Namespace: "original",
+					Labels:      map[string]string{"foo": "bar"},
+					Annotations: map[string]string{"bar": "baz"},
+				},
+				Spec: netv1.NetworkPolicySpec{
+					PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+					PolicyTypes: []netv1.PolicyType{netv1.PolicyTypeIngress, netv1.PolicyTypeEgress},
+					Ingress: []netv1.NetworkPolicyIngressRule{
+						{From: []netv1.NetworkPolicyPeer{{IPBlock: &netv1.IPBlock{CIDR: "10.0.0.1/32"}}}},
+						{From: []netv1.NetworkPolicyPeer{{IPBlock: &netv1.IPBlock{CIDR: "8.8.8.0/24"}}}},
+						{Ports: []netv1.NetworkPolicyPort{{Protocol: protocolPtr(corev1.ProtocolTCP), Port: &port}}},
+					},
+					Egress: []netv1.NetworkPolicyEgressRule{
+						{To: []netv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}}}},
+					},
+				},
+			}
+
+			// Translate the /32 addresses only, and keep the pod selectors verbatim.
+			translator = func(peers []netv1.NetworkPolicyPeer) []netv1.NetworkPolicyPeer {
+				var translated []netv1.NetworkPolicyPeer
+				for i := range peers {
+					switch {
+					case peers[i].IPBlock == nil:
+						translated = append(translated, peers[i])
+					case peers[i].IPBlock.CIDR == "10.0.0.1/32":
+						translated = append(translated, netv1.NetworkPolicyPeer{IPBlock: &netv1.IPBlock{CIDR: "10.1.0.1/32"}})
+					}
+				}
+				return translated
+			}
+		})
+
+		JustBeforeEach(func() { output = forge.RemoteNetworkPolicy(input, "reflected", translator) })
+
+		It("should correctly set the name and namespace", func() {
+			Expect(output.Name).To(PointTo(Equal("name")))
+			Expect(output.Namespace).To(PointTo(Equal("reflected")))
+		})
+
+		It("should correctly set the labels", func() {
+			Expect(output.Labels).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, LocalClusterID))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, RemoteClusterID))
+		})
+
+		It("should correctly set the annotations", func() {
+			Expect(output.Annotations).To(HaveKeyWithValue("bar", "baz"))
+		})
+
+		It("should correctly set the pod selector and the policy types", func() {
+			Expect(output.Spec.PodSelector.MatchLabels).To(HaveKeyWithValue("app", "db"))
+			Expect(output.Spec.PolicyTypes).To(ConsistOf(netv1.PolicyTypeIngress, netv1.PolicyTypeEgress))
+		})
+
+		It("should translate the ingress peers, dropping the rules which became empty", func() {
+			Expect(output.Spec.Ingress).To(HaveLen(2))
+			Expect(output.Spec.Ingress[0].From).To(HaveLen(1))
+			Expect(output.Spec.Ingress[0].From[0].IPBlock.CIDR).To(PointTo(Equal("10.1.0.1/32")))
+		})
+
+		It("should preserve the rules without peers", func() {
+			Expect(output.Spec.Ingress[1].From).To(BeEmpty())
+			Expect(output.Spec.Ingress[1].Ports).To(HaveLen(1))
+			Expect(output.Spec.Ingress[1].Ports[0].Protocol).To(PointTo(Equal(corev1.ProtocolTCP)))
+			Expect(output.Spec.Ingress[1].Ports[0].Port).To(PointTo(Equal(intstr.FromInt(8080))))
+		})
+
+		It("should correctly set the egress rules", func() {
+			Expect(output.Spec.Egress).To(HaveLen(1))
+			Expect(output.Spec.Egress[0].To).To(HaveLen(1))
+			Expect(output.Spec.Egress[0].To[0].PodSelector.MatchLabels).To(HaveKeyWithValue("app", "api"))
+			Expect(output.Spec.Egress[0].To[0].NamespaceSelector).To(BeNil())
+		})
+	})
+
+	Describe("the RemoteLabelSelector function", func() {
+		It("should correctly replicate the labels and the expressions", func() {
+			output := forge.RemoteLabelSelector(&metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "baz", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+				},
+			})
+
+			Expect(output.MatchLabels).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.MatchExpressions).To(HaveLen(1))
+			Expect(output.MatchExpressions[0].Key).To(PointTo(Equal("baz")))
+			Expect(output.MatchExpressions[0].Operator).To(PointTo(Equal(metav1.LabelSelectorOpIn)))
+			Expect(output.MatchExpressions[0].Values).To(ConsistOf("a", "b"))
+		})
+	})
+})
+
+func protocolPtr(protocol corev1.Protocol) *corev1.Protocol {
+	return &protocol
+}
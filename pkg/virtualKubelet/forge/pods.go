@@ -15,6 +15,8 @@
 package forge
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
@@ -24,7 +26,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	"k8s.io/utils/pointer"
 
@@ -243,7 +247,7 @@ func APIServerSupportMutator(apiServerSupport APIServerSupportType, saName strin
 func AntiAffinityPropagateMutator(affinity *corev1.Affinity) RemotePodSpecMutator {
 	return func(remote *corev1.PodSpec) {
 		if affinity != nil && affinity.PodAntiAffinity != nil {
-			remote.Affinity = &corev1.Affinity{PodAntiAffinity: affinity.PodAntiAffinity}
+			remoteAffinity(remote).PodAntiAffinity = affinity.PodAntiAffinity
 		}
 	}
 }
@@ -251,7 +255,7 @@ func AntiAffinityPropagateMutator(affinity *corev1.Affinity) RemotePodSpecMutato
 // AntiAffinitySoftMutator is a mutator which implements the support to enable soft anti-affinity between pods sharing the same labels.
 func AntiAffinitySoftMutator(labels map[string]string) RemotePodSpecMutator {
 	return func(remote *corev1.PodSpec) {
-		remote.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		remoteAffinity(remote).PodAntiAffinity = &corev1.PodAntiAffinity{
 			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
 				Weight: 1,
 				PodAffinityTerm: corev1.PodAffinityTerm{
@@ -259,20 +263,122 @@ func AntiAffinitySoftMutator(labels map[string]string) RemotePodSpecMutator {
 					LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
 				},
 			}},
-		}}
+		}
 	}
 }
 
 // AntiAffinityHardMutator is a mutator which implements the support to enable hard anti-affinity between pods sharing the same labels.
 func AntiAffinityHardMutator(labels map[string]string) RemotePodSpecMutator {
 	return func(remote *corev1.PodSpec) {
-		remote.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		remoteAffinity(remote).PodAntiAffinity = &corev1.PodAntiAffinity{
 			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
 				TopologyKey:   corev1.LabelHostname,
 				LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
 			}},
-		}}
+		}
+	}
+}
+
+// SchedulingConstraintsMutator is a mutator which implements the support to constrain the placement of offloaded pods
+// inside the remote cluster, based on the annotations of the local pod and on the priority class mapping configured for the peer.
+// An error is returned in case the constraints are invalid, as the resulting pod would be rejected or never scheduled.
+func SchedulingConstraintsMutator(local *corev1.Pod, priorityClasses map[string]string) (RemotePodSpecMutator, error) {
+	nodeSelector, err := RemoteNodeSelector(local.Annotations[liqoconst.RemoteNodeSelectorAnnotationKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q annotation: %w", liqoconst.RemoteNodeSelectorAnnotationKey, err)
+	}
+
+	nodeAffinity, err := RemoteNodeAffinity(local.Annotations[liqoconst.RemoteNodeAffinityAnnotationKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q annotation: %w", liqoconst.RemoteNodeAffinityAnnotationKey, err)
+	}
+
+	runtimeClass := local.Annotations[liqoconst.RemoteRuntimeClassAnnotationKey]
+	if errs := validation.IsDNS1123Subdomain(runtimeClass); runtimeClass != "" && len(errs) > 0 {
+		return nil, fmt.Errorf("invalid %q annotation: %v", liqoconst.RemoteRuntimeClassAnnotationKey, strings.Join(errs, ", "))
 	}
+
+	// Local priority classes without a corresponding mapping are not propagated, as possibly not existing in the remote cluster.
+	priorityClass := priorityClasses[local.Spec.PriorityClassName]
+
+	return func(remote *corev1.PodSpec) {
+		remote.NodeSelector = nodeSelector
+		if nodeAffinity != nil {
+			remoteAffinity(remote).NodeAffinity = nodeAffinity
+		}
+		if runtimeClass != "" {
+			remote.RuntimeClassName = pointer.String(runtimeClass)
+		}
+		if priorityClass != "" {
+			remote.PriorityClassName = priorityClass
+		}
+	}, nil
+}
+
+// RemoteNodeSelector parses and validates the node selector (in the form "key1=value1,key2=value2") to apply to offloaded pods.
+func RemoteNodeSelector(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	selector := make(map[string]string)
+	for _, chunk := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(strings.TrimSpace(chunk), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid selector %q, expected key=value", chunk)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %q: %v", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(val); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label value %q: %v", val, strings.Join(errs, ", "))
+		}
+		selector[key] = val
+	}
+	return selector, nil
+}
+
+// RemoteNodeAffinity parses and validates the node affinity (JSON encoded) to apply to offloaded pods.
+func RemoteNodeAffinity(value string) (*corev1.NodeAffinity, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var affinity corev1.NodeAffinity
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&affinity); err != nil {
+		return nil, err
+	}
+
+	if required := affinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+		if len(required.NodeSelectorTerms) == 0 {
+			return nil, errors.New("the required node selector terms cannot be empty")
+		}
+		for idx := range required.NodeSelectorTerms {
+			term := &required.NodeSelectorTerms[idx]
+			if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+				return nil, errors.New("the required node selector terms cannot be empty")
+			}
+		}
+		if _, err := nodeaffinity.NewNodeSelector(required); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := nodeaffinity.NewPreferredSchedulingTerms(affinity.PreferredDuringSchedulingIgnoredDuringExecution); err != nil {
+		return nil, err
+	}
+
+	return &affinity, nil
+}
+
+// remoteAffinity returns the affinity of the remote pod, initializing it if not yet set.
+func remoteAffinity(remote *corev1.PodSpec) *corev1.Affinity {
+	if remote.Affinity == nil {
+		remote.Affinity = &corev1.Affinity{}
+	}
+	return remote.Affinity
 }
 
 // FilterAntiAffinityLabels filters the label keys which are used to implement the anti-affinity constraints, based on the specified whitelist.
@@ -392,21 +498,82 @@ func RemoteVolumes(volumes []corev1.Volume, apiServerSupport APIServerSupportTyp
 func LocalNodeStats(pods []statsv1alpha1.PodStats) *statsv1alpha1.Summary {
 	now := metav1.Now()
 
-	return &statsv1alpha1.Summary{
+	summary := &statsv1alpha1.Summary{
 		Node: statsv1alpha1.NodeStats{
 			NodeName: LiqoNodeName, StartTime: metav1.NewTime(StartTime),
 			CPU: &statsv1alpha1.CPUStats{
-				Time:           now,
-				UsageNanoCores: SumPodStats(pods, func(s statsv1alpha1.PodStats) uint64 { return *s.CPU.UsageNanoCores }),
+				Time: now,
+				UsageNanoCores: SumPodStats(pods, func(s statsv1alpha1.PodStats) uint64 {
+					return valueOrZero(s.CPU, func(cpu *statsv1alpha1.CPUStats) *uint64 { return cpu.UsageNanoCores })
+				}),
 			},
 			Memory: &statsv1alpha1.MemoryStats{
-				Time:            now,
-				UsageBytes:      SumPodStats(pods, func(s statsv1alpha1.PodStats) uint64 { return *s.Memory.UsageBytes }),
-				WorkingSetBytes: SumPodStats(pods, func(s statsv1alpha1.PodStats) uint64 { return *s.Memory.WorkingSetBytes }),
+				Time: now,
+				UsageBytes: SumPodStats(pods, func(s statsv1alpha1.PodStats) uint64 {
+					return valueOrZero(s.Memory, func(mem *statsv1alpha1.MemoryStats) *uint64 { return mem.UsageBytes })
+				}),
+				WorkingSetBytes: SumPodStats(pods, func(s statsv1alpha1.PodStats) uint64 {
+					return valueOrZero(s.Memory, func(mem *statsv1alpha1.MemoryStats) *uint64 { return mem.WorkingSetBytes })
+				}),
 			},
 		},
 		Pods: pods,
 	}
+
+	// The network and filesystem stats are available only if retrieved from the remote kubelets,
+	// hence they are aggregated only in case at least one pod exposes them.
+	if containsPodStats(pods, func(s statsv1alpha1.PodStats) bool { return s.Network != nil }) {
+		netstat := func(retriever func(*statsv1alpha1.NetworkStats) *uint64) func(statsv1alpha1.PodStats) uint64 {
+			return func(s statsv1alpha1.PodStats) uint64 { return valueOrZero(s.Network, retriever) }
+		}
+
+		summary.Node.Network = &statsv1alpha1.NetworkStats{
+			Time: now,
+			InterfaceStats: statsv1alpha1.InterfaceStats{
+				RxBytes:  SumPodStats(pods, netstat(func(n *statsv1alpha1.NetworkStats) *uint64 { return n.RxBytes })),
+				RxErrors: SumPodStats(pods, netstat(func(n *statsv1alpha1.NetworkStats) *uint64 { return n.RxErrors })),
+				TxBytes:  SumPodStats(pods, netstat(func(n *statsv1alpha1.NetworkStats) *uint64 { return n.TxBytes })),
+				TxErrors: SumPodStats(pods, netstat(func(n *statsv1alpha1.NetworkStats) *uint64 { return n.TxErrors })),
+			},
+		}
+	}
+
+	if containsPodStats(pods, func(s statsv1alpha1.PodStats) bool { return s.EphemeralStorage != nil }) {
+		fsstat := func(retriever func(*statsv1alpha1.FsStats) *uint64) func(statsv1alpha1.PodStats) uint64 {
+			return func(s statsv1alpha1.PodStats) uint64 { return valueOrZero(s.EphemeralStorage, retriever) }
+		}
+
+		summary.Node.Fs = &statsv1alpha1.FsStats{
+			Time:       now,
+			UsedBytes:  SumPodStats(pods, fsstat(func(fs *statsv1alpha1.FsStats) *uint64 { return fs.UsedBytes })),
+			InodesUsed: SumPodStats(pods, fsstat(func(fs *statsv1alpha1.FsStats) *uint64 { return fs.InodesUsed })),
+		}
+	}
+
+	return summary
+}
+
+// LocalPodStatsFromSummary forges the stats for a local pod managed by the virtual kubelet,
+// starting from the ones retrieved from the summary API of the node hosting the remote pod.
+func LocalPodStatsFromSummary(pod *corev1.Pod, remote *statsv1alpha1.PodStats) statsv1alpha1.PodStats {
+	stats := *remote
+	stats.PodRef = statsv1alpha1.PodReference{
+		Name:      pod.GetName(),
+		Namespace: pod.GetNamespace(),
+		UID:       string(pod.GetUID()),
+	}
+
+	// Translate the namespace of the persistent volume claims, to match the local one.
+	stats.VolumeStats = make([]statsv1alpha1.VolumeStats, len(remote.VolumeStats))
+	for idx := range remote.VolumeStats {
+		stats.VolumeStats[idx] = remote.VolumeStats[idx]
+		if remote.VolumeStats[idx].PVCRef != nil {
+			stats.VolumeStats[idx].PVCRef = &statsv1alpha1.PVCReference{
+				Name: remote.VolumeStats[idx].PVCRef.Name, Namespace: pod.GetNamespace()}
+		}
+	}
+
+	return stats
 }
 
 // LocalPodStats forges the metric stats for a local pod managed by the virtual kubelet.
@@ -481,3 +648,24 @@ func SumContainerStats(stats []statsv1alpha1.ContainerStats, retriever func(stat
 	}
 	return &sum
 }
+
+// containsPodStats returns whether at least one of the pod stats matches the given predicate.
+func containsPodStats(stats []statsv1alpha1.PodStats, predicate func(statsv1alpha1.PodStats) bool) bool {
+	for idx := range stats {
+		if predicate(stats[idx]) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueOrZero returns the value retrieved from the given object, or zero in case either the object or the value is nil.
+func valueOrZero[T any](obj *T, retriever func(*T) *uint64) uint64 {
+	if obj == nil {
+		return 0
+	}
+	if value := retriever(obj); value != nil {
+		return *value
+	}
+	return 0
+}
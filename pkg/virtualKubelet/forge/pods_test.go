@@ -349,9 +349,95 @@ var _ = Describe("Pod forging", func() {
 				Expect(constraints[0].LabelSelector.MatchExpressions).To(HaveLen(0))
 				Expect(constraints[0].TopologyKey).To(Equal("kubernetes.io/hostname"))
 			})
+
+			When("the remote node affinity is already set", func() {
+				BeforeEach(func() { remote.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}} })
+				It("should preserve the remote node affinity", func() { Expect(remote.Affinity.NodeAffinity).ToNot(BeNil()) })
+			})
 		})
 	})
 
+	Describe("the SchedulingConstraintsMutator function", func() {
+		var (
+			local   corev1.Pod
+			remote  corev1.PodSpec
+			mapping map[string]string
+			err     error
+		)
+
+		BeforeEach(func() {
+			local = corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+				Spec:       corev1.PodSpec{PriorityClassName: "high-priority"},
+			}
+			remote = corev1.PodSpec{}
+			mapping = map[string]string{"high-priority": "remote-high-priority"}
+		})
+
+		JustBeforeEach(func() {
+			var mutator forge.RemotePodSpecMutator
+			mutator, err = forge.SchedulingConstraintsMutator(&local, mapping)
+			if err == nil {
+				mutator(&remote)
+			}
+		})
+
+		When("no scheduling constraints annotation is set", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not configure the node selector", func() { Expect(remote.NodeSelector).To(BeNil()) })
+			It("should not configure the affinity", func() { Expect(remote.Affinity).To(BeNil()) })
+			It("should not configure the runtime class", func() { Expect(remote.RuntimeClassName).To(BeNil()) })
+			It("should translate the priority class", func() { Expect(remote.PriorityClassName).To(BeIdenticalTo("remote-high-priority")) })
+		})
+
+		When("the priority class is not mapped", func() {
+			BeforeEach(func() { local.Spec.PriorityClassName = "low-priority" })
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not configure the priority class", func() { Expect(remote.PriorityClassName).To(BeEmpty()) })
+		})
+
+		When("all the scheduling constraints annotations are set", func() {
+			BeforeEach(func() {
+				local.Annotations[consts.RemoteNodeSelectorAnnotationKey] = "foo=bar, baz=qux"
+				local.Annotations[consts.RemoteNodeAffinityAnnotationKey] = `{"requiredDuringSchedulingIgnoredDuringExecution":` +
+					`{"nodeSelectorTerms":[{"matchExpressions":[{"key":"zone","operator":"In","values":["a","b"]}]}]}}`
+				local.Annotations[consts.RemoteRuntimeClassAnnotationKey] = "gvisor"
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should configure the node selector", func() {
+				Expect(remote.NodeSelector).To(Equal(map[string]string{"foo": "bar", "baz": "qux"}))
+			})
+			It("should configure the node affinity", func() {
+				Expect(remote.Affinity).ToNot(BeNil())
+				Expect(remote.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(ConsistOf(
+					corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a", "b"}}}}))
+			})
+			It("should configure the runtime class", func() { Expect(remote.RuntimeClassName).To(PointTo(Equal("gvisor"))) })
+		})
+
+		DescribeTable("invalid scheduling constraints annotations",
+			func(key, value string) {
+				local.Annotations[key] = value
+				_, err = forge.SchedulingConstraintsMutator(&local, mapping)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("malformed node selector", consts.RemoteNodeSelectorAnnotationKey, "foo"),
+			Entry("invalid node selector key", consts.RemoteNodeSelectorAnnotationKey, "foo bar=baz"),
+			Entry("invalid node selector value", consts.RemoteNodeSelectorAnnotationKey, "foo=bar baz"),
+			Entry("malformed node affinity", consts.RemoteNodeAffinityAnnotationKey, "{"),
+			Entry("node affinity with unknown fields", consts.RemoteNodeAffinityAnnotationKey, `{"foo":"bar"}`),
+			Entry("node affinity without required terms", consts.RemoteNodeAffinityAnnotationKey,
+				`{"requiredDuringSchedulingIgnoredDuringExecution":{"nodeSelectorTerms":[]}}`),
+			Entry("node affinity with empty required terms", consts.RemoteNodeAffinityAnnotationKey,
+				`{"requiredDuringSchedulingIgnoredDuringExecution":{"nodeSelectorTerms":[{}]}}`),
+			Entry("node affinity with invalid operator", consts.RemoteNodeAffinityAnnotationKey,
+				`{"requiredDuringSchedulingIgnoredDuringExecution":{"nodeSelectorTerms":[{"matchExpressions":[{"key":"zone","operator":"Foo"}]}]}}`),
+			Entry("invalid runtime class", consts.RemoteRuntimeClassAnnotationKey, "Invalid_Class"),
+		)
+	})
+
 	Describe("the FilterAntiAffinityLabels function", func() {
 		var (
 			input, output map[string]string
@@ -650,6 +736,83 @@ var _ = Describe("Pod forging", func() {
 			It("should propagate the correct pod stats", func() {
 				Expect(output.Pods).To(Equal(input))
 			})
+
+			It("should not configure the network and filesystem stats, if not available", func() {
+				Expect(output.Node.Network).To(BeNil())
+				Expect(output.Node.Fs).To(BeNil())
+			})
+
+			When("the pod stats include the network and filesystem stats", func() {
+				Uint64Ptr := func(value uint64) *uint64 { return &value }
+
+				BeforeEach(func() {
+					input[0].Network = &statsv1alpha1.NetworkStats{InterfaceStats: statsv1alpha1.InterfaceStats{
+						RxBytes: Uint64Ptr(100), TxBytes: Uint64Ptr(200), RxErrors: Uint64Ptr(1)}}
+					input[0].EphemeralStorage = &statsv1alpha1.FsStats{UsedBytes: Uint64Ptr(1000), InodesUsed: Uint64Ptr(10)}
+					input[1].Network = &statsv1alpha1.NetworkStats{InterfaceStats: statsv1alpha1.InterfaceStats{
+						RxBytes: Uint64Ptr(300), TxBytes: Uint64Ptr(400)}}
+					input = append(input, statsv1alpha1.PodStats{})
+				})
+
+				It("should configure the correct network stats", func() {
+					Expect(output.Node.Network).ToNot(BeNil())
+					Expect(output.Node.Network.RxBytes).To(PointTo(BeNumerically("==", 400)))
+					Expect(output.Node.Network.TxBytes).To(PointTo(BeNumerically("==", 600)))
+					Expect(output.Node.Network.RxErrors).To(PointTo(BeNumerically("==", 1)))
+					Expect(output.Node.Network.TxErrors).To(PointTo(BeNumerically("==", 0)))
+				})
+
+				It("should configure the correct filesystem stats", func() {
+					Expect(output.Node.Fs).ToNot(BeNil())
+					Expect(output.Node.Fs.UsedBytes).To(PointTo(BeNumerically("==", 1000)))
+					Expect(output.Node.Fs.InodesUsed).To(PointTo(BeNumerically("==", 10)))
+				})
+
+				It("should tolerate pods without CPU and memory stats", func() {
+					Expect(output.Node.CPU.UsageNanoCores).To(PointTo(BeNumerically("==", 700*1e6)))
+					Expect(output.Node.Memory.UsageBytes).To(PointTo(BeNumerically("==", 110*1e6)))
+				})
+			})
+		})
+
+		Describe("the LocalPodStatsFromSummary function", func() {
+			var (
+				pod    corev1.Pod
+				remote statsv1alpha1.PodStats
+				output statsv1alpha1.PodStats
+			)
+
+			BeforeEach(func() {
+				pod = corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace", UID: "uid"}}
+				remote = PodStats(0.2, 10)
+				remote.PodRef = statsv1alpha1.PodReference{Name: "name", Namespace: "remote-namespace", UID: "remote-uid"}
+				remote.VolumeStats = []statsv1alpha1.VolumeStats{
+					{Name: "foo", PVCRef: &statsv1alpha1.PVCReference{Name: "pvc", Namespace: "remote-namespace"}},
+					{Name: "bar"},
+				}
+			})
+
+			JustBeforeEach(func() { output = forge.LocalPodStatsFromSummary(&pod, &remote) })
+
+			It("should configure the correct pod reference", func() {
+				Expect(output.PodRef).To(Equal(statsv1alpha1.PodReference{Name: "name", Namespace: "namespace", UID: "uid"}))
+			})
+
+			It("should preserve the remote metrics", func() {
+				Expect(output.CPU).To(Equal(remote.CPU))
+				Expect(output.Memory).To(Equal(remote.Memory))
+			})
+
+			It("should translate the namespace of the volume references", func() {
+				Expect(output.VolumeStats).To(ConsistOf(
+					statsv1alpha1.VolumeStats{Name: "foo", PVCRef: &statsv1alpha1.PVCReference{Name: "pvc", Namespace: "namespace"}},
+					statsv1alpha1.VolumeStats{Name: "bar"},
+				))
+			})
+
+			It("should not mutate the remote stats", func() {
+				Expect(remote.VolumeStats[0].PVCRef.Namespace).To(BeIdenticalTo("remote-namespace"))
+			})
 		})
 
 		Describe("the LocalPodStats function", func() {
@@ -92,3 +92,37 @@ func getForceRemoteNodePort(local *corev1.Service) bool {
 	val, ok := local.Annotations[liqoconst.ForceRemoteNodePortAnnotationKey]
 	return ok && val == "true"
 }
+
+// IsServiceExported returns whether the given remote service has been marked to be imported in the local cluster.
+func IsServiceExported(remote *corev1.Service) bool {
+	val, ok := remote.Annotations[liqoconst.ExportServiceAnnotationKey]
+	return ok && val == "true" && !IsReflected(remote)
+}
+
+// ImportedService forges the apply patch for the local service importing the given remote one.
+func ImportedService(remote *corev1.Service, targetNamespace string) *corev1apply.ServiceApplyConfiguration {
+	return corev1apply.Service(remote.GetName(), targetNamespace).
+		WithLabels(remote.GetLabels()).WithLabels(ImportLabels()).
+		WithAnnotations(remote.GetAnnotations()).
+		WithSpec(ImportedServiceSpec(remote.Spec.DeepCopy()))
+}
+
+// ImportedServiceSpec forges the apply patch for the specs of the local service importing the given remote one.
+// The resulting service is always of type ClusterIP, and it has no selector, as the endpointslices are imported as well.
+func ImportedServiceSpec(remote *corev1.ServiceSpec) *corev1apply.ServiceSpecApplyConfiguration {
+	local := corev1apply.ServiceSpec().WithType(corev1.ServiceTypeClusterIP).
+		WithSessionAffinity(remote.SessionAffinity).WithPublishNotReadyAddresses(remote.PublishNotReadyAddresses)
+
+	for i := range remote.Ports {
+		port := corev1apply.ServicePort().WithName(remote.Ports[i].Name).WithPort(remote.Ports[i].Port).
+			WithTargetPort(remote.Ports[i].TargetPort).WithProtocol(remote.Ports[i].Protocol)
+		port.AppProtocol = remote.Ports[i].AppProtocol
+		local.WithPorts(port)
+	}
+
+	if remote.ClusterIP == corev1.ClusterIPNone {
+		local.WithClusterIP(corev1.ClusterIPNone)
+	}
+
+	return local
+}
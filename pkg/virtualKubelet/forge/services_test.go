@@ -24,6 +24,7 @@ import (
 	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
 	"k8s.io/utils/pointer"
 
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 )
 
@@ -191,4 +192,76 @@ var _ = Describe("Services Forging", func() {
 			It("should be replicated", func() { Expect(output[0].NodePort).To(PointTo(BeNumerically("==", 33333))) })
 		})
 	})
+
+	Describe("the IsServiceExported function", func() {
+		DescribeTable("checking whether the service is exported",
+			func(labels, annotations map[string]string, expected bool) {
+				svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Labels: labels, Annotations: annotations}}
+				Expect(forge.IsServiceExported(svc)).To(BeIdenticalTo(expected))
+			},
+			Entry("when the annotation is not set", nil, nil, false),
+			Entry("when the annotation is set to false", nil, map[string]string{liqoconst.ExportServiceAnnotationKey: "false"}, false),
+			Entry("when the annotation is set to true", nil, map[string]string{liqoconst.ExportServiceAnnotationKey: "true"}, true),
+			Entry("when the annotation is set to true, but the service is reflected",
+				map[string]string{forge.LiqoOriginClusterIDKey: LocalClusterID, forge.LiqoDestinationClusterIDKey: RemoteClusterID},
+				map[string]string{liqoconst.ExportServiceAnnotationKey: "true"}, false),
+		)
+	})
+
+	Describe("the ImportedService function", func() {
+		var (
+			input  *corev1.Service
+			output *corev1apply.ServiceApplyConfiguration
+		)
+
+		BeforeEach(func() {
+			input = &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "name", Namespace: "remote",
+					Labels:      map[string]string{"foo": "bar"},
+					Annotations: map[string]string{liqoconst.ExportServiceAnnotationKey: "true"},
+				},
+				Spec: corev1.ServiceSpec{
+					Type: corev1.ServiceTypeLoadBalancer, Selector: map[string]string{"app": "db"},
+					ClusterIP: "10.0.0.1", SessionAffinity: corev1.ServiceAffinityClientIP,
+					Ports: []corev1.ServicePort{{Name: "db", Port: 5432, TargetPort: intstr.FromString("db"),
+						Protocol: corev1.ProtocolTCP, NodePort: 30000}},
+				},
+			}
+		})
+
+		JustBeforeEach(func() { output = forge.ImportedService(input, "local") })
+
+		It("should correctly set the name and namespace", func() {
+			Expect(output.Name).To(PointTo(Equal("name")))
+			Expect(output.Namespace).To(PointTo(Equal("local")))
+		})
+
+		It("should correctly set the labels", func() {
+			Expect(output.Labels).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, RemoteClusterID))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, LocalClusterID))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoImportedKey, "true"))
+		})
+
+		It("should forge a selectorless ClusterIP service", func() {
+			Expect(output.Spec.Type).To(PointTo(Equal(corev1.ServiceTypeClusterIP)))
+			Expect(output.Spec.Selector).To(BeEmpty())
+			Expect(output.Spec.ClusterIP).To(BeNil())
+			Expect(output.Spec.SessionAffinity).To(PointTo(Equal(corev1.ServiceAffinityClientIP)))
+		})
+
+		It("should correctly set the ports, without the node ports", func() {
+			Expect(output.Spec.Ports).To(HaveLen(1))
+			Expect(output.Spec.Ports[0].Name).To(PointTo(Equal("db")))
+			Expect(output.Spec.Ports[0].Port).To(PointTo(BeNumerically("==", 5432)))
+			Expect(output.Spec.Ports[0].TargetPort).To(PointTo(Equal(intstr.FromString("db"))))
+			Expect(output.Spec.Ports[0].NodePort).To(BeNil())
+		})
+
+		When("the remote service is headless", func() {
+			BeforeEach(func() { input.Spec.ClusterIP = corev1.ClusterIPNone })
+			It("should forge a headless service", func() { Expect(output.Spec.ClusterIP).To(PointTo(Equal(corev1.ClusterIPNone))) })
+		})
+	})
 })
@@ -0,0 +1,108 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/maps"
+)
+
+// latencyBuckets are the upper bounds of the buckets the latency towards the remote cluster is mapped to.
+var latencyBuckets = []time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second,
+}
+
+// latencyBucketTolerance is the relative margin the latency shall exceed the bounds of the current bucket by,
+// before moving to a different one, to prevent continuous label changes when close to the bounds.
+const latencyBucketTolerance = 0.1
+
+// topologyLabels maps the cluster labels of the remote cluster (as advertised through the ResourceOffer) to the
+// standard topology labels (i.e., region and zone), as well as the provider one, to be set on the virtual node.
+func topologyLabels(clusterLabels map[string]string) map[string]string {
+	lbls := map[string]string{}
+	for target, sources := range map[string][]string{
+		consts.TopologyRegionClusterLabel: {consts.TopologyRegionClusterLabel, corev1.LabelFailureDomainBetaRegion},
+		consts.TopologyZoneClusterLabel:   {consts.TopologyZoneClusterLabel, corev1.LabelFailureDomainBetaZone},
+		consts.ProviderClusterLabel:       {consts.ProviderClusterLabel},
+	} {
+		for _, source := range sources {
+			if value, found := clusterLabels[source]; found && value != "" {
+				lbls[target] = value
+				break
+			}
+		}
+	}
+	return lbls
+}
+
+// latencyBucket returns the latency bucket corresponding to the given latency (as reported by the TunnelEndpoint status),
+// preferring the current one if the latency does not exceed its bounds by more than the tolerance.
+// An empty string is returned in case the latency is unknown.
+func latencyBucket(latency, current string) string {
+	if latency == "" {
+		return ""
+	}
+
+	value, err := time.ParseDuration(latency)
+	if err != nil {
+		klog.Warningf("Failed to parse the latency %q towards the remote cluster: %v", latency, err)
+		return current
+	}
+
+	if lower, upper, found := bucketBounds(current); found {
+		if float64(value) >= float64(lower)*(1-latencyBucketTolerance) &&
+			(upper < 0 || float64(value) <= float64(upper)*(1+latencyBucketTolerance)) {
+			return current
+		}
+	}
+
+	for _, bound := range latencyBuckets {
+		if value <= bound {
+			return strconv.FormatInt(bound.Milliseconds(), 10)
+		}
+	}
+	return consts.LatencyBucketUnboundedValue
+}
+
+// bucketBounds returns the lower and upper bounds of the given latency bucket (a negative upper bound means unbounded),
+// and whether the bucket is valid.
+func bucketBounds(bucket string) (lower, upper time.Duration, found bool) {
+	for _, bound := range latencyBuckets {
+		if strconv.FormatInt(bound.Milliseconds(), 10) == bucket {
+			return lower, bound, true
+		}
+		lower = bound
+	}
+	return lower, -1, bucket == consts.LatencyBucketUnboundedValue
+}
+
+// desiredLabels returns the labels to be set on the virtual node, merging the ones advertised through the ResourceOffer
+// with those derived from the topology of the remote cluster and the latency towards it. The cluster identity label is
+// always enforced, so that it cannot be overridden by the labels advertised by the remote cluster.
+func (p *LiqoNodeProvider) desiredLabels() map[string]string {
+	lbls := maps.Merge(maps.Merge(map[string]string{}, p.offerLabels), topologyLabels(p.offerLabels))
+	if p.latencyBucket != "" {
+		lbls[consts.LatencyBucketLabelKey] = p.latencyBucket
+	}
+	lbls[consts.ClusterIdentityLabelKey] = p.foreignClusterID
+	return lbls
+}
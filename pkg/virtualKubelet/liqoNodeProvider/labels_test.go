@@ -0,0 +1,77 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Virtual node labels", func() {
+	DescribeTable("topologyLabels table",
+		func(clusterLabels, expected map[string]string) {
+			Expect(topologyLabels(clusterLabels)).To(Equal(expected))
+		},
+		Entry("no cluster labels", nil, map[string]string{}),
+		Entry("unrelated cluster labels", map[string]string{"foo": "bar"}, map[string]string{}),
+		Entry("standard topology labels", map[string]string{
+			consts.TopologyRegionClusterLabel: "europe", consts.TopologyZoneClusterLabel: "europe-1", consts.ProviderClusterLabel: "aws",
+		}, map[string]string{
+			consts.TopologyRegionClusterLabel: "europe", consts.TopologyZoneClusterLabel: "europe-1", consts.ProviderClusterLabel: "aws",
+		}),
+		Entry("deprecated topology labels", map[string]string{
+			v1.LabelFailureDomainBetaRegion: "us-east", v1.LabelFailureDomainBetaZone: "us-east-1",
+		}, map[string]string{
+			consts.TopologyRegionClusterLabel: "us-east", consts.TopologyZoneClusterLabel: "us-east-1",
+		}),
+		Entry("both standard and deprecated topology labels", map[string]string{
+			consts.TopologyRegionClusterLabel: "europe", v1.LabelFailureDomainBetaRegion: "us-east",
+		}, map[string]string{consts.TopologyRegionClusterLabel: "europe"}),
+	)
+
+	DescribeTable("latencyBucket table",
+		func(latency, current, expected string) {
+			Expect(latencyBucket(latency, current)).To(Equal(expected))
+		},
+		Entry("unknown latency", "", "10", ""),
+		Entry("invalid latency", "foo", "10", "10"),
+		Entry("lowest bucket", "3ms", "", "5"),
+		Entry("intermediate bucket", "42.5ms", "", "50"),
+		Entry("bucket upper bound", "100ms", "", "100"),
+		Entry("unbounded bucket", "2.3s", "", consts.LatencyBucketUnboundedValue),
+		Entry("within the tolerance of the current bucket", "54ms", "50", "50"),
+		Entry("within the tolerance of the current bucket (lower bound)", "23ms", "50", "50"),
+		Entry("beyond the tolerance of the current bucket", "60ms", "50", "100"),
+		Entry("within the tolerance of the unbounded bucket", "950ms", consts.LatencyBucketUnboundedValue, consts.LatencyBucketUnboundedValue),
+		Entry("invalid current bucket", "42.5ms", "7", "50"),
+	)
+
+	It("should merge the ResourceOffer labels with the topology, latency and cluster identity ones", func() {
+		provider := &LiqoNodeProvider{
+			foreignClusterID: "foreign-id",
+			offerLabels: map[string]string{"foo": "bar", v1.LabelFailureDomainBetaRegion: "europe",
+				consts.ClusterIdentityLabelKey: "spoofed-id"},
+			latencyBucket: "25",
+		}
+		Expect(provider.desiredLabels()).To(Equal(map[string]string{
+			"foo": "bar", v1.LabelFailureDomainBetaRegion: "europe", consts.TopologyRegionClusterLabel: "europe",
+			consts.LatencyBucketLabelKey: "25", consts.ClusterIdentityLabelKey: "foreign-id",
+		}))
+		Expect(provider.offerLabels).To(HaveLen(3))
+	})
+})
@@ -35,6 +35,8 @@ type LiqoNodeProvider struct {
 	node              *corev1.Node
 	terminating       bool
 	lastAppliedLabels map[string]string
+	offerLabels       map[string]string
+	latencyBucket     string
 
 	nodeName         string
 	foreignClusterID string
@@ -153,6 +153,11 @@ func (p *LiqoNodeProvider) reconcileNodeFromTep(event watch.Event) error {
 		defer p.updateMutex.Unlock()
 		klog.Infof("tunnelEndpoint %v deleted", tep.Name)
 		p.networkReady = false
+		p.latencyBucket = ""
+		if err := p.patchLabels(p.desiredLabels()); err != nil {
+			klog.Error(err)
+			return err
+		}
 		err := p.updateNode()
 		if err != nil {
 			klog.Error(err)
@@ -183,7 +188,8 @@ func (p *LiqoNodeProvider) updateFromResourceOffer(resourceOffer *sharingv1alpha
 		lbls[consts.StorageAvailableLabel] = "true"
 	}
 
-	if err := p.patchLabels(lbls); err != nil {
+	p.offerLabels = lbls
+	if err := p.patchLabels(p.desiredLabels()); err != nil {
 		klog.Error(err)
 		return err
 	}
@@ -209,6 +215,17 @@ func (p *LiqoNodeProvider) updateFromTep(tep *netv1alpha1.TunnelEndpoint) error
 	p.updateMutex.Lock()
 	defer p.updateMutex.Unlock()
 
+	// The latency is exposed only while the connection is established, as it might be stale otherwise.
+	bucket := ""
+	if tep.Status.Connection.Status == netv1alpha1.Connected {
+		bucket = latencyBucket(tep.Status.Connection.Latency.Value, p.latencyBucket)
+	}
+	p.latencyBucket = bucket
+	if err := p.patchLabels(p.desiredLabels()); err != nil {
+		klog.Error(err)
+		return err
+	}
+
 	// if tep is not connected yet, return
 	if tep.Status.Connection.Status != netv1alpha1.Connected {
 		p.networkReady = false
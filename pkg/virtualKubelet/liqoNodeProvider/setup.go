@@ -88,8 +88,9 @@ func node(cfg *InitConfig) *corev1.Node {
 		corev1.LabelOSStable:   strings.ToLower(linuxos),
 		corev1.LabelArchStable: architecture,
 
-		liqoconst.TypeLabel:       liqoconst.TypeNode,
-		liqoconst.RemoteClusterID: cfg.RemoteClusterID,
+		liqoconst.TypeLabel:               liqoconst.TypeNode,
+		liqoconst.RemoteClusterID:         cfg.RemoteClusterID,
+		liqoconst.ClusterIdentityLabelKey: cfg.RemoteClusterID,
 
 		corev1.LabelNodeExcludeBalancers: strconv.FormatBool(true),
 		labelNodeExcludeBalancersAlpha:   strconv.FormatBool(true),
@@ -23,6 +23,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	corev1 "k8s.io/api/core/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -63,7 +64,9 @@ type InitConfig struct {
 	PodWorkers                  uint
 	ServiceWorkers              uint
 	EndpointSliceWorkers        uint
+	ImportedServiceWorkers      uint
 	IngressWorkers              uint
+	NetworkPolicyWorkers        uint
 	PersistenVolumeClaimWorkers uint
 	ConfigMapWorkers            uint
 	SecretWorkers               uint
@@ -73,6 +76,7 @@ type InitConfig struct {
 	EnableStorage              bool
 	VirtualStorageClassName    string
 	RemoteRealStorageClassName string
+	PriorityClassMapping       map[string]string
 }
 
 // LiqoProvider implements the virtual-kubelet provider interface and stores pods in memory.
@@ -115,12 +119,20 @@ func NewLiqoProvider(ctx context.Context, cfg *InitConfig, eb record.EventBroadc
 	}
 
 	reflectionManager := manager.New(localClient, remoteClient, localLiqoClient, remoteLiqoClient, cfg.InformerResyncPeriod, eb)
-	podreflector := workload.NewPodReflector(cfg.RemoteConfig, remoteMetricsClient, ipamClient, apiServerSupport, cfg.PodWorkers)
+	remoteSummaries := workload.NewSummaryRetriever(remoteClient.CoreV1().RESTClient())
+	podreflector := workload.NewPodReflector(cfg.RemoteConfig, remoteMetricsClient, remoteSummaries, ipamClient,
+		apiServerSupport, cfg.PriorityClassMapping, cfg.PodWorkers)
 	namespaceMapHandler := namespacemap.NewHandler(localLiqoClient, cfg.Namespace, cfg.InformerResyncPeriod)
+
+	// The cluster-wide namespace informer, used to evaluate the namespace selectors of networkpolicies.
+	localNamespaceFactory := informers.NewSharedInformerFactory(localClient, cfg.InformerResyncPeriod)
+	localNamespaces := localNamespaceFactory.Core().V1().Namespaces().Lister()
 	reflectionManager.
 		With(exposition.NewServiceReflector(cfg.ServiceWorkers)).
 		With(exposition.NewEndpointSliceReflector(ipamClient, cfg.EndpointSliceWorkers)).
+		With(exposition.NewImportedServiceReflector(ipamClient, cfg.ImportedServiceWorkers)).
 		With(exposition.NewIngressReflector(cfg.IngressWorkers)).
+		With(exposition.NewNetworkPolicyReflector(ipamClient, localNamespaces, namespaceMapHandler.RemoteNamespace, cfg.NetworkPolicyWorkers)).
 		With(configuration.NewConfigMapReflector(cfg.ConfigMapWorkers)).
 		With(configuration.NewSecretReflector(apiServerSupport == forge.APIServerSupportLegacy, cfg.SecretWorkers)).
 		With(configuration.NewServiceAccountReflector(apiServerSupport == forge.APIServerSupportTokenAPI, cfg.ServiceAccountWorkers)).
@@ -129,6 +141,10 @@ func NewLiqoProvider(ctx context.Context, cfg *InitConfig, eb record.EventBroadc
 			cfg.VirtualStorageClassName, cfg.RemoteRealStorageClassName, cfg.EnableStorage)).
 		WithNamespaceHandler(namespaceMapHandler)
 
+	// Start the namespace informer, and wait for its cache to sync before starting the reflection.
+	localNamespaceFactory.Start(ctx.Done())
+	localNamespaceFactory.WaitForCacheSync(ctx.Done())
+
 	reflectionManager.Start(ctx)
 
 	return &LiqoProvider{
@@ -70,6 +70,7 @@ func NewNamespacedConfigMapReflector(opts *options.NamespacedOpts) manager.Names
 	// no matter the cluster, hence it will be processed by the handle function in the same way.
 	local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 	remote.Informer().AddEventHandler(opts.HandlerFactory(RemoteConfigMapNamespacedKeyer(opts.LocalNamespace)))
+	generic.WatchReflectionPolicies(opts, local.Informer())
 
 	return &NamespacedConfigMapReflector{
 		NamespacedReflector:    generic.NewNamespacedReflector(opts, ConfigMapReflectorName),
@@ -106,16 +107,18 @@ func (ncr *NamespacedConfigMapReflector) Handle(ctx context.Context, name string
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
-	if !kerrors.IsNotFound(lerr) && ncr.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local ConfigMap %q as marked with the skip annotation", ncr.LocalRef(name))
-		ncr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
-		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
-			return nil
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is denied by a reflection policy.
+	if !kerrors.IsNotFound(lerr) {
+		if skip, reason := ncr.ShouldSkipReflection(local); skip {
+			klog.Infof("Skipping reflection of local ConfigMap %q: %v", ncr.LocalRef(name), reason)
+			ncr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(reason))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(corev1.Resource("configmap"), local.GetName())
 		}
-
-		// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
-		lerr = kerrors.NewNotFound(corev1.Resource("configmap"), local.GetName())
 	}
 
 	tracer.Step("Performed the sanity checks")
@@ -63,6 +63,7 @@ func NewNamespacedSecretReflector(enableSAReflection bool) func(*options.Namespa
 		// no matter the cluster, hence it will be processed by the handle function in the same way.
 		local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 		remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		generic.WatchReflectionPolicies(opts, local.Informer())
 
 		return &NamespacedSecretReflector{
 			NamespacedReflector: generic.NewNamespacedReflector(opts, SecretReflectorName),
@@ -109,16 +110,18 @@ func (nsr *NamespacedSecretReflector) Handle(ctx context.Context, name string) e
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
-	if !kerrors.IsNotFound(lerr) && nsr.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local Secret %q as marked with the skip annotation", nsr.LocalRef(name))
-		nsr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
-		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
-			return nil
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is denied by a reflection policy.
+	if !kerrors.IsNotFound(lerr) {
+		if skip, reason := nsr.ShouldSkipReflection(local); skip {
+			klog.Infof("Skipping reflection of local Secret %q: %v", nsr.LocalRef(name), reason)
+			nsr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(reason))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(corev1.Resource("secret"), local.GetName())
 		}
-
-		// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
-		lerr = kerrors.NewNotFound(corev1.Resource("secret"), local.GetName())
 	}
 
 	tracer.Step("Performed the sanity checks")
@@ -28,6 +28,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
 	"k8s.io/utils/trace"
@@ -50,7 +51,8 @@ const (
 type ServiceAccountReflector struct {
 	manager.Reflector
 
-	localPods corev1listers.PodLister
+	localPods        corev1listers.PodLister
+	localPodInformer cache.SharedIndexInformer
 }
 
 // NamespacedServiceAccountReflector manages the reflection of tokens associated with ServiceAccounts.
@@ -58,6 +60,7 @@ type NamespacedServiceAccountReflector struct {
 	generic.NamespacedReflector
 
 	localPods     corev1listers.PodNamespaceLister
+	localSAs      corev1listers.ServiceAccountNamespaceLister
 	remoteSecrets corev1listers.SecretNamespaceLister
 
 	localSAsClient      corev1clients.ServiceAccountInterface
@@ -105,11 +108,13 @@ func (sar *ServiceAccountReflector) NewNamespaced(opts *options.NamespacedOpts)
 
 	// Regardless of the type of the event, we always enqueue the key corresponding to the pod.
 	remoteSecrets.Informer().AddEventHandler(opts.HandlerFactory(RemoteSASecretNamespacedKeyer(opts.LocalNamespace)))
+	generic.WatchReflectionPolicies(opts, sar.localPodInformer)
 
 	return &NamespacedServiceAccountReflector{
 		NamespacedReflector: generic.NewNamespacedReflector(opts, ServiceAccountReflectorName),
 
 		localPods:     sar.localPods.Pods(opts.LocalNamespace),
+		localSAs:      opts.LocalFactory.Core().V1().ServiceAccounts().Lister().ServiceAccounts(opts.LocalNamespace),
 		remoteSecrets: remoteSecrets.Lister().Secrets(opts.RemoteNamespace),
 
 		localSAsClient:      opts.LocalClient.CoreV1().ServiceAccounts(opts.LocalNamespace),
@@ -131,6 +136,7 @@ func (sar *ServiceAccountReflector) NewFallback(opts *options.ReflectorOpts) man
 // Start starts the reflector.
 func (sar *ServiceAccountReflector) Start(ctx context.Context, opts *options.ReflectorOpts) {
 	sar.localPods = opts.LocalPodInformer.Lister()
+	sar.localPodInformer = opts.LocalPodInformer.Informer()
 	sar.Reflector.Start(ctx, opts)
 }
 
@@ -155,6 +161,20 @@ func (nsar *NamespacedServiceAccountReflector) Handle(ctx context.Context, name
 		}
 		return nil
 	}
+
+	// Abort the reflection if it is denied by a reflection policy concerning the service account of the pod.
+	if lerr == nil {
+		if skip, reason := nsar.ShouldSkipReflection(nsar.serviceAccount(local)); skip {
+			klog.Infof("Skipping reflection of the SA tokens secret for pod %q: %v", nsar.LocalRef(name), reason)
+			nsar.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(reason))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(corev1.Resource("pod"), local.GetName())
+		}
+	}
 	tracer.Step("Performed the sanity checks")
 
 	// Remove the secret if the pod does no longer exist.
@@ -252,6 +272,16 @@ func (nsar *NamespacedServiceAccountReflector) enforceSecretAbsence(ctx context.
 	return nil
 }
 
+// serviceAccount returns the service account associated with the given pod, to evaluate the reflection policies against.
+// In case it is not found, only the name is returned, as the pod might have been created before the service account.
+func (nsar *NamespacedServiceAccountReflector) serviceAccount(po *corev1.Pod) metav1.Object {
+	name := pod.ServiceAccountName(po)
+	if sa, err := nsar.localSAs.Get(name); err == nil {
+		return sa
+	}
+	return &metav1.ObjectMeta{Name: name, Namespace: po.GetNamespace()}
+}
+
 func (nsar *NamespacedServiceAccountReflector) buildTokensInfo(po *corev1.Pod, secret *corev1.Secret) *forge.ServiceAccountPodTokens {
 	saName := pod.ServiceAccountName(po)
 	expiration := forge.ServiceAccountTokenExpirationFromSecret(secret)
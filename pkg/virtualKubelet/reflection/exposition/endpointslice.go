@@ -75,6 +75,7 @@ func NewNamespacedEndpointSliceReflector(ipamclient ipam.IpamClient) func(*optio
 
 		local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 		remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		generic.WatchReflectionPolicies(opts, local.Informer())
 
 		ner := &NamespacedEndpointSliceReflector{
 			NamespacedReflector:        generic.NewNamespacedReflector(opts, EndpointSliceReflectorName),
@@ -110,6 +111,12 @@ func (ner *NamespacedEndpointSliceReflector) Handle(ctx context.Context, name st
 	utilruntime.Must(client.IgnoreNotFound(rerr))
 	tracer.Step("Retrieved the local and remote objects")
 
+	// Skip the local objects imported from the remote cluster, as managed by the dedicated reflector.
+	if lerr == nil && forge.IsImported(local) {
+		klog.V(4).Infof("Skipping reflection of local EndpointSlice %q as imported from the remote cluster", ner.LocalRef(name))
+		return nil
+	}
+
 	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
 	if rerr == nil && (!forge.IsReflected(remote) || !forge.IsEndpointSliceManagedByReflection(remote)) {
 		// Prevent misleading warnings triggered by remote non-reflected endpointslices, since they inherit
@@ -121,16 +128,18 @@ func (ner *NamespacedEndpointSliceReflector) Handle(ctx context.Context, name st
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
-	if !kerrors.IsNotFound(lerr) && ner.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local EndpointSlice %q as marked with the skip annotation", ner.LocalRef(name))
-		ner.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
-		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
-			return nil
-		}
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is denied by a reflection policy.
+	if !kerrors.IsNotFound(lerr) {
+		if skip, reason := ner.ShouldSkipReflection(local); skip {
+			klog.Infof("Skipping reflection of local EndpointSlice %q: %v", ner.LocalRef(name), reason)
+			ner.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(reason))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
 
-		// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
-		lerr = kerrors.NewNotFound(discoveryv1.Resource("endpointslice"), local.GetName())
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(discoveryv1.Resource("endpointslice"), local.GetName())
+		}
 	}
 
 	tracer.Step("Performed the sanity checks")
@@ -249,23 +258,31 @@ func (ner *NamespacedEndpointSliceReflector) UnmapEndpointIPs(ctx context.Contex
 	return nil
 }
 
-// ShouldSkipReflection returns whether the reflection of the given object should be skipped.
-func (ner *NamespacedEndpointSliceReflector) ShouldSkipReflection(obj metav1.Object) bool {
-	if ner.NamespacedReflector.ShouldSkipReflection(obj) {
-		return true
+// ShouldSkipReflection returns whether the reflection of the given object should be skipped, along with the corresponding reason.
+func (ner *NamespacedEndpointSliceReflector) ShouldSkipReflection(obj metav1.Object) (skip bool, reason string) {
+	if skip, reason = ner.NamespacedReflector.ShouldSkipReflection(obj); skip {
+		return skip, reason
 	}
 
 	// Check if a service is associated to the EndpointSlice, and whether it is marked to be skipped.
 	svcname, ok := obj.GetLabels()[discoveryv1.LabelServiceName]
 	if !ok {
-		return false
+		return false, ""
 	}
 
 	svc, err := ner.localServices.Get(svcname)
 	// Continue with the reflection in case the service is not found, as this is likely due to a race conditions
 	// (i.e., the service has not yet been cached). If necessary, the informer will trigger a re-enqueue,
 	// thus performing once more this check.
-	return err == nil && ner.NamespacedReflector.ShouldSkipReflection(svc)
+	if err != nil {
+		return false, ""
+	}
+
+	// The reflection policies concerning services are enforced also for the corresponding endpointslices.
+	if skip, reason = ner.ShouldSkipReflectionOf(ServiceReflectorName, svc); skip {
+		return skip, fmt.Sprintf("associated Service %q: %v", svcname, reason)
+	}
+	return false, ""
 }
 
 // ServiceToEndpointSlicesKeyer returns the NamespacedName of all local EndpointSlices associated with the given local Service.
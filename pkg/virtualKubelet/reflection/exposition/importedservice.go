@@ -0,0 +1,261 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exposition
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
+	discoveryv1clients "k8s.io/client-go/kubernetes/typed/discovery/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	discoveryv1listers "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/liqonet/ipam"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ manager.NamespacedReflector = (*NamespacedImportedServiceReflector)(nil)
+
+const (
+	// ImportedServiceReflectorName -> The name associated with the ImportedService reflector.
+	ImportedServiceReflectorName = "ImportedService"
+)
+
+// NamespacedImportedServiceReflector manages the reverse reflection of the Services (and the corresponding EndpointSlices)
+// exported by the remote cluster, for a given pair of local and remote namespaces.
+type NamespacedImportedServiceReflector struct {
+	generic.NamespacedReflector
+
+	localServices             corev1listers.ServiceNamespaceLister
+	remoteServices            corev1listers.ServiceNamespaceLister
+	localServicesClient       corev1clients.ServiceInterface
+	localEndpointSlices       discoveryv1listers.EndpointSliceNamespaceLister
+	remoteEndpointSlices      discoveryv1listers.EndpointSliceNamespaceLister
+	localEndpointSlicesClient discoveryv1clients.EndpointSliceInterface
+	ipamclient                ipam.IpamClient
+	translations              sync.Map
+}
+
+// NewImportedServiceReflector returns a new ImportedServiceReflector instance.
+func NewImportedServiceReflector(ipamclient ipam.IpamClient, workers uint) manager.Reflector {
+	return generic.NewReflector(ImportedServiceReflectorName, NewNamespacedImportedServiceReflector(ipamclient), generic.WithoutFallback(), workers)
+}
+
+// NewNamespacedImportedServiceReflector returns a function generating NamespacedImportedServiceReflector instances.
+func NewNamespacedImportedServiceReflector(ipamclient ipam.IpamClient) func(*options.NamespacedOpts) manager.NamespacedReflector {
+	return func(opts *options.NamespacedOpts) manager.NamespacedReflector {
+		localServices := opts.LocalFactory.Core().V1().Services()
+		remoteServices := opts.RemoteFactory.Core().V1().Services()
+		localEndpointSlices := opts.LocalFactory.Discovery().V1().EndpointSlices()
+		remoteEndpointSlices := opts.RemoteFactory.Discovery().V1().EndpointSlices()
+
+		nisr := &NamespacedImportedServiceReflector{
+			NamespacedReflector:       generic.NewNamespacedReflector(opts, ImportedServiceReflectorName),
+			localServices:             localServices.Lister().Services(opts.LocalNamespace),
+			remoteServices:            remoteServices.Lister().Services(opts.RemoteNamespace),
+			localServicesClient:       opts.LocalClient.CoreV1().Services(opts.LocalNamespace),
+			localEndpointSlices:       localEndpointSlices.Lister().EndpointSlices(opts.LocalNamespace),
+			remoteEndpointSlices:      remoteEndpointSlices.Lister().EndpointSlices(opts.RemoteNamespace),
+			localEndpointSlicesClient: opts.LocalClient.DiscoveryV1().EndpointSlices(opts.LocalNamespace),
+			ipamclient:                ipamclient,
+		}
+
+		localServices.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		remoteServices.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		localEndpointSlices.Informer().AddEventHandler(opts.HandlerFactory(nisr.EndpointSliceToServiceKeyer))
+		remoteEndpointSlices.Informer().AddEventHandler(opts.HandlerFactory(nisr.EndpointSliceToServiceKeyer))
+
+		return nisr
+	}
+}
+
+// Handle reconciles the services exported by the remote cluster.
+func (nisr *NamespacedImportedServiceReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling import of remote Service %q (local: %q)", nisr.RemoteRef(name), nisr.LocalRef(name))
+	local, lerr := nisr.localServices.Get(name)
+	utilruntime.Must(client.IgnoreNotFound(lerr))
+	remote, rerr := nisr.remoteServices.Get(name)
+	utilruntime.Must(client.IgnoreNotFound(rerr))
+	tracer.Step("Retrieved the local and remote objects")
+
+	exported := rerr == nil && forge.IsServiceExported(remote)
+
+	// Abort the import if the local object is not managed by us, as we do not want to mutate others' objects.
+	if lerr == nil && !forge.IsImported(local) {
+		if exported {
+			klog.Infof("Skipping import of remote Service %q as local already exists and is not managed by us", nisr.RemoteRef(name))
+			nisr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedImportAlreadyExistsMsg())
+		}
+		return nil
+	}
+
+	tracer.Step("Performed the sanity checks")
+
+	// The remote service does no longer exist, or it is no longer exported. Ensure it is also absent from the local cluster.
+	if !exported {
+		defer tracer.Step("Ensured the absence of the local objects")
+		if err := nisr.EnsureLocalEndpointSlices(ctx, name, nil); err != nil {
+			return err
+		}
+
+		if lerr == nil {
+			klog.V(4).Infof("Deleting local Service %q, since remote %q is no longer exported", nisr.LocalRef(name), nisr.RemoteRef(name))
+			return nisr.DeleteLocal(ctx, nisr.localServicesClient, ServiceReflectorName, name, local.GetUID())
+		}
+
+		klog.V(4).Infof("Remote Service %q is not exported, and local Service %q does not exist", nisr.RemoteRef(name), nisr.LocalRef(name))
+		return nil
+	}
+
+	// Forge the mutation to be applied to the local cluster.
+	mutation := forge.ImportedService(remote, nisr.LocalNamespace())
+	tracer.Step("Local mutation created")
+
+	local, err := nisr.localServicesClient.Apply(ctx, mutation, forge.ApplyOptions())
+	if err != nil {
+		klog.Errorf("Failed to enforce local Service %q (remote: %q): %v", nisr.LocalRef(name), nisr.RemoteRef(name), err)
+		return err
+	}
+	tracer.Step("Enforced the correctness of the local service")
+
+	// Retrieve the remote endpointslices associated with the service (the ones reflected by us are filtered out afterwards).
+	remotes, err := nisr.remoteEndpointSlices.List(labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: name}))
+	utilruntime.Must(err)
+
+	if err := nisr.EnsureLocalEndpointSlices(ctx, name, remotes); err != nil {
+		nisr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+	tracer.Step("Enforced the correctness of the local endpointslices")
+
+	klog.Infof("Local Service %q successfully enforced (remote: %q)", nisr.LocalRef(name), nisr.RemoteRef(name))
+	nisr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulImportMsg())
+	return nil
+}
+
+// EnsureLocalEndpointSlices enforces the local endpointslices importing the given remote ones,
+// and deletes the stale local endpointslices associated with the given service.
+func (nisr *NamespacedImportedServiceReflector) EnsureLocalEndpointSlices(ctx context.Context, service string,
+	remotes []*discoveryv1.EndpointSlice) error {
+	expected := make(map[string]struct{}, len(remotes))
+	for _, remote := range remotes {
+		// Skip the endpointslices reflected by us (e.g., endpointslices of a local service targeting offloaded pods).
+		if forge.IsReflected(remote) || remote.AddressType != discoveryv1.AddressTypeIPv4 {
+			continue
+		}
+
+		// The local endpointslice already exists, but it is not managed by us.
+		if local, err := nisr.localEndpointSlices.Get(remote.GetName()); err == nil && !forge.IsImported(local) {
+			klog.Warningf("Skipping import of remote EndpointSlice %q as local already exists and is not managed by us", nisr.RemoteRef(remote.GetName()))
+			continue
+		}
+
+		mutation := forge.ImportedEndpointSlice(remote, nisr.LocalNamespace(), nisr.TranslatorFor(ctx))
+		if _, err := nisr.localEndpointSlicesClient.Apply(ctx, mutation, forge.ApplyOptions()); err != nil {
+			klog.Errorf("Failed to enforce local EndpointSlice %q (remote: %q): %v", nisr.LocalRef(remote.GetName()), nisr.RemoteRef(remote.GetName()), err)
+			return err
+		}
+		expected[remote.GetName()] = struct{}{}
+	}
+
+	// Retrieve the local endpointslices imported for the given service, to delete the stale ones.
+	locals, err := nisr.localEndpointSlices.List(labels.SelectorFromSet(
+		labels.Merge(forge.ImportLabels(), labels.Set{discoveryv1.LabelServiceName: service})))
+	utilruntime.Must(err)
+
+	for _, local := range locals {
+		if _, found := expected[local.GetName()]; found {
+			continue
+		}
+
+		klog.V(4).Infof("Deleting local EndpointSlice %q, since no longer exported by the remote cluster", nisr.LocalRef(local.GetName()))
+		if err := nisr.DeleteLocal(ctx, nisr.localEndpointSlicesClient, EndpointSliceReflectorName, local.GetName(), local.GetUID()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteLocal deletes the given local resource from the cluster.
+func (nisr *NamespacedImportedServiceReflector) DeleteLocal(ctx context.Context, deleter generic.ResourceDeleter,
+	resource, name string, uid types.UID) error {
+	err := deleter.Delete(ctx, name, *metav1.NewPreconditionDeleteOptions(string(uid)))
+	if err != nil && !kerrors.IsNotFound(err) {
+		klog.Errorf("Failed to delete local %v %q: %v", resource, nisr.LocalRef(name), err)
+		return err
+	}
+
+	klog.Infof("Local %v %q successfully deleted", resource, nisr.LocalRef(name))
+	return nil
+}
+
+// TranslatorFor returns the function translating the remote endpoint addresses into the corresponding local ones.
+// Addresses which cannot be translated (e.g., since not belonging to the remote pod CIDR) are omitted.
+func (nisr *NamespacedImportedServiceReflector) TranslatorFor(ctx context.Context) forge.EndpointTranslator {
+	return func(originals []string) []string {
+		var translations []string
+		for _, original := range originals {
+			translation, err := nisr.MapRemoteIP(ctx, original)
+			if err != nil {
+				klog.Warningf("Failed to translate remote endpoint IP %v: %v", original, err)
+				continue
+			}
+			translations = append(translations, translation)
+		}
+		return translations
+	}
+}
+
+// MapRemoteIP maps the given remote endpoint address to the corresponding local one, caching the result.
+func (nisr *NamespacedImportedServiceReflector) MapRemoteIP(ctx context.Context, original string) (string, error) {
+	if translation, found := nisr.translations.Load(original); found {
+		return translation.(string), nil
+	}
+
+	response, err := nisr.ipamclient.GetHomePodIP(ctx, &ipam.GetHomePodIPRequest{ClusterID: forge.RemoteCluster.ClusterID, Ip: original})
+	if err != nil {
+		return "", err
+	}
+
+	klog.V(6).Infof("Translated remote endpoint IP %v to local %v", original, response.GetHomeIP())
+	nisr.translations.Store(original, response.GetHomeIP())
+	return response.GetHomeIP(), nil
+}
+
+// EndpointSliceToServiceKeyer returns the NamespacedName of the Service associated with the given EndpointSlice (if any).
+func (nisr *NamespacedImportedServiceReflector) EndpointSliceToServiceKeyer(metadata metav1.Object) []types.NamespacedName {
+	if service, found := metadata.GetLabels()[discoveryv1.LabelServiceName]; found {
+		return []types.NamespacedName{{Namespace: nisr.LocalNamespace(), Name: service}}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exposition_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"k8s.io/utils/trace"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	fakeipam "github.com/liqotech/liqo/pkg/liqonet/ipam/fake"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/exposition"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ = Describe("ImportedService Reflection Tests", func() {
+	Describe("the NewImportedServiceReflector function", func() {
+		It("should not return a nil reflector", func() {
+			Expect(exposition.NewImportedServiceReflector(nil, 1)).ToNot(BeNil())
+		})
+	})
+
+	Describe("imported service handling", func() {
+		const ServiceName = "imported"
+
+		var (
+			reflector manager.NamespacedReflector
+
+			local, remote corev1.Service
+			remoteslice   discoveryv1.EndpointSlice
+			err           error
+		)
+
+		GetService := func(namespace string) *corev1.Service {
+			svc, errsvc := client.CoreV1().Services(namespace).Get(ctx, ServiceName, metav1.GetOptions{})
+			Expect(errsvc).ToNot(HaveOccurred())
+			return svc
+		}
+
+		CreateService := func(svc *corev1.Service) *corev1.Service {
+			svc, errsvc := client.CoreV1().Services(svc.GetNamespace()).Create(ctx, svc, metav1.CreateOptions{})
+			Expect(errsvc).ToNot(HaveOccurred())
+			return svc
+		}
+
+		BeforeEach(func() {
+			ports := []corev1.ServicePort{{Name: "db", Port: 5432, Protocol: corev1.ProtocolTCP}}
+			local = corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: ServiceName, Namespace: LocalNamespace},
+				Spec: corev1.ServiceSpec{Ports: ports}}
+			remote = corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: ServiceName, Namespace: RemoteNamespace},
+				Spec: corev1.ServiceSpec{Ports: ports}}
+			remoteslice = discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: ServiceName + "-abcde", Namespace: RemoteNamespace,
+					Labels: map[string]string{discoveryv1.LabelServiceName: ServiceName}},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.7"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)}}},
+			}
+		})
+
+		AfterEach(func() {
+			for _, namespace := range []string{LocalNamespace, RemoteNamespace} {
+				Expect(client.CoreV1().Services(namespace).Delete(ctx, ServiceName, metav1.DeleteOptions{})).To(
+					Or(BeNil(), WithTransform(kerrors.IsNotFound, BeTrue())))
+				Expect(client.DiscoveryV1().EndpointSlices(namespace).DeleteCollection(ctx,
+					metav1.DeleteOptions{}, metav1.ListOptions{})).To(Succeed())
+			}
+		})
+
+		JustBeforeEach(func() {
+			factory := informers.NewSharedInformerFactory(client, 10*time.Hour)
+			reflector = exposition.NewNamespacedImportedServiceReflector(fakeipam.NewIPAMClient("192.168.200.0/24", "192.168.201.0/24", false))(
+				options.NewNamespaced().
+					WithLocal(LocalNamespace, client, factory).
+					WithRemote(RemoteNamespace, client, factory).
+					WithHandlerFactory(FakeEventHandler).
+					WithEventBroadcaster(record.NewBroadcaster()))
+
+			factory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+
+			err = reflector.Handle(trace.ContextWithTrace(ctx, trace.New("ImportedService")), ServiceName)
+		})
+
+		When("the remote service is exported", func() {
+			BeforeEach(func() {
+				remote.SetAnnotations(map[string]string{consts.ExportServiceAnnotationKey: "true"})
+				CreateService(&remote)
+				_, errslice := client.DiscoveryV1().EndpointSlices(RemoteNamespace).Create(ctx, &remoteslice, metav1.CreateOptions{})
+				Expect(errslice).ToNot(HaveOccurred())
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should import the service as a selectorless one", func() {
+				imported := GetService(LocalNamespace)
+				Expect(forge.IsImported(imported)).To(BeTrue())
+				Expect(imported.Spec.Selector).To(BeEmpty())
+				Expect(imported.Spec.Ports).To(HaveLen(1))
+			})
+			It("should import the endpointslices, translating the addresses", func() {
+				slice, errslice := client.DiscoveryV1().EndpointSlices(LocalNamespace).Get(ctx, remoteslice.GetName(), metav1.GetOptions{})
+				Expect(errslice).ToNot(HaveOccurred())
+				Expect(forge.IsImported(slice)).To(BeTrue())
+				Expect(slice.Endpoints).To(HaveLen(1))
+				Expect(slice.Endpoints[0].Addresses).To(ConsistOf("192.168.201.7"))
+			})
+		})
+
+		When("the remote service is not exported", func() {
+			BeforeEach(func() { CreateService(&remote) })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not import the service", func() {
+				_, err = client.CoreV1().Services(LocalNamespace).Get(ctx, ServiceName, metav1.GetOptions{})
+				Expect(err).To(BeNotFound())
+			})
+		})
+
+		When("the remote service is no longer exported", func() {
+			BeforeEach(func() {
+				CreateService(&remote)
+				local.SetLabels(forge.ImportLabels())
+				CreateService(&local)
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should delete the imported service", func() {
+				_, err = client.CoreV1().Services(LocalNamespace).Get(ctx, ServiceName, metav1.GetOptions{})
+				Expect(err).To(BeNotFound())
+			})
+		})
+
+		When("the local service already exists, but is not managed by the import", func() {
+			var localBefore *corev1.Service
+
+			BeforeEach(func() {
+				remote.SetAnnotations(map[string]string{consts.ExportServiceAnnotationKey: "true"})
+				CreateService(&remote)
+				localBefore = CreateService(&local)
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("the local object should be unmodified", func() {
+				Expect(GetService(LocalNamespace)).To(Equal(localBefore))
+			})
+		})
+	})
+})
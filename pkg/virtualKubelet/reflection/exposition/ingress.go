@@ -61,6 +61,7 @@ func NewNamespacedIngressReflector(opts *options.NamespacedOpts) manager.Namespa
 
 	local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 	remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+	generic.WatchReflectionPolicies(opts, local.Informer())
 
 	return &NamespacedIngressReflector{
 		NamespacedReflector:   generic.NewNamespacedReflector(opts, IngressReflectorName),
@@ -91,16 +92,18 @@ func (nir *NamespacedIngressReflector) Handle(ctx context.Context, name string)
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
-	if !kerrors.IsNotFound(lerr) && nir.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local Ingress %q as marked with the skip annotation", nir.LocalRef(name))
-		nir.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
-		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
-			return nil
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is denied by a reflection policy.
+	if !kerrors.IsNotFound(lerr) {
+		if skip, reason := nir.ShouldSkipReflection(local); skip {
+			klog.Infof("Skipping reflection of local Ingress %q: %v", nir.LocalRef(name), reason)
+			nir.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(reason))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(netv1.Resource("ingress"), local.GetName())
 		}
-
-		// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
-		lerr = kerrors.NewNotFound(netv1.Resource("ingress"), local.GetName())
 	}
 
 	tracer.Step("Performed the sanity checks")
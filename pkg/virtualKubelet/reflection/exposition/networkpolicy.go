@@ -0,0 +1,430 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exposition
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	netv1clients "k8s.io/client-go/kubernetes/typed/networking/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	netv1listers "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/liqonet/ipam"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ manager.NamespacedReflector = (*NamespacedNetworkPolicyReflector)(nil)
+
+const (
+	// NetworkPolicyReflectorName -> The name associated with the NetworkPolicy reflector.
+	NetworkPolicyReflectorName = "NetworkPolicy"
+
+	// networkPolicyRefreshInterval -> The interval after which networkpolicies selecting other namespaces are reprocessed,
+	// to account for changes in the labels of the local namespaces and in the namespace mappings.
+	networkPolicyRefreshInterval = 1 * time.Minute
+)
+
+// NamespaceTranslator returns the name of the remote namespace a local one is mapped to, if any.
+type NamespaceTranslator func(local string) (remote string, found bool)
+
+// NamespacedNetworkPolicyReflector manages the NetworkPolicy reflection for a given pair of local and remote namespaces.
+type NamespacedNetworkPolicyReflector struct {
+	generic.NamespacedReflector
+
+	localNamespaces             corev1listers.NamespaceLister
+	localNetworkPolicies        netv1listers.NetworkPolicyNamespaceLister
+	remoteNetworkPolicies       netv1listers.NetworkPolicyNamespaceLister
+	remoteNetworkPoliciesClient netv1clients.NetworkPolicyInterface
+	namespaceTranslator         NamespaceTranslator
+	ipamclient                  ipam.IpamClient
+	translations                sync.Map
+	// inUse tracks the local addresses referenced by the translation in progress of each networkpolicy,
+	// so that the ones no longer referenced can be released once the remote object has been updated.
+	inUse sync.Map
+}
+
+// NewNetworkPolicyReflector returns a new NetworkPolicyReflector instance.
+func NewNetworkPolicyReflector(ipamclient ipam.IpamClient, namespaces corev1listers.NamespaceLister,
+	translator NamespaceTranslator, workers uint) manager.Reflector {
+	return generic.NewReflector(NetworkPolicyReflectorName, NewNamespacedNetworkPolicyReflector(ipamclient, namespaces, translator),
+		generic.WithoutFallback(), workers)
+}
+
+// NewNamespacedNetworkPolicyReflector returns a function generating NamespacedNetworkPolicyReflector instances.
+func NewNamespacedNetworkPolicyReflector(ipamclient ipam.IpamClient, namespaces corev1listers.NamespaceLister,
+	translator NamespaceTranslator) func(*options.NamespacedOpts) manager.NamespacedReflector {
+	return func(opts *options.NamespacedOpts) manager.NamespacedReflector {
+		local := opts.LocalFactory.Networking().V1().NetworkPolicies()
+		remote := opts.RemoteFactory.Networking().V1().NetworkPolicies()
+
+		local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		generic.WatchReflectionPolicies(opts, local.Informer())
+
+		return &NamespacedNetworkPolicyReflector{
+			NamespacedReflector:         generic.NewNamespacedReflector(opts, NetworkPolicyReflectorName),
+			localNamespaces:             namespaces,
+			localNetworkPolicies:        local.Lister().NetworkPolicies(opts.LocalNamespace),
+			remoteNetworkPolicies:       remote.Lister().NetworkPolicies(opts.RemoteNamespace),
+			remoteNetworkPoliciesClient: opts.RemoteClient.NetworkingV1().NetworkPolicies(opts.RemoteNamespace),
+			namespaceTranslator:         translator,
+			ipamclient:                  ipamclient,
+		}
+	}
+}
+
+// Handle reconciles networkpolicy objects.
+func (npr *NamespacedNetworkPolicyReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling reflection of local NetworkPolicy %q (remote: %q)", npr.LocalRef(name), npr.RemoteRef(name))
+	local, lerr := npr.localNetworkPolicies.Get(name)
+	utilruntime.Must(client.IgnoreNotFound(lerr))
+	remote, rerr := npr.remoteNetworkPolicies.Get(name)
+	utilruntime.Must(client.IgnoreNotFound(rerr))
+	tracer.Step("Retrieved the local and remote objects")
+
+	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
+	if rerr == nil && !forge.IsReflected(remote) {
+		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
+			klog.Infof("Skipping reflection of local NetworkPolicy %q as remote already exists and is not managed by us", npr.LocalRef(name))
+			npr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
+		}
+		return nil
+	}
+
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is denied by a reflection policy.
+	if !kerrors.IsNotFound(lerr) {
+		if skip, reason := npr.ShouldSkipReflection(local); skip {
+			klog.Infof("Skipping reflection of local NetworkPolicy %q: %v", npr.LocalRef(name), reason)
+			npr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(reason))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(netv1.Resource("networkpolicy"), local.GetName())
+		}
+	}
+
+	tracer.Step("Performed the sanity checks")
+
+	// The local networkpolicy does no longer exist. Ensure it is also absent from the remote cluster.
+	if kerrors.IsNotFound(lerr) {
+		// Release the address translations
+		if err := npr.UnmapIPs(ctx, name); err != nil {
+			return err
+		}
+
+		defer tracer.Step("Ensured the absence of the remote object")
+		if !kerrors.IsNotFound(rerr) {
+			klog.V(4).Infof("Deleting remote NetworkPolicy %q, since local %q does no longer exist", npr.RemoteRef(name), npr.LocalRef(name))
+			return npr.DeleteRemote(ctx, npr.remoteNetworkPoliciesClient, NetworkPolicyReflectorName, name, remote.GetUID())
+		}
+
+		klog.V(4).Infof("Local NetworkPolicy %q and remote NetworkPolicy %q both vanished", npr.LocalRef(name), npr.RemoteRef(name))
+		return nil
+	}
+
+	// Track the addresses referenced by the current translation, to release the ones no longer required.
+	npr.inUse.Store(name, map[string]struct{}{})
+	defer npr.inUse.Delete(name)
+
+	// Wrap the peers translation logic, so that we do not have to handle errors in the forge logic.
+	var terr error
+	var untranslatable []string
+	var selectsNamespaces bool
+	translator := func(originals []netv1.NetworkPolicyPeer) []netv1.NetworkPolicyPeer {
+		// Avoid processing further peers if one already failed.
+		if terr != nil {
+			return nil
+		}
+
+		var translations []netv1.NetworkPolicyPeer
+		for i := range originals {
+			selectsNamespaces = selectsNamespaces || originals[i].NamespaceSelector != nil
+
+			translation, reason, err := npr.TranslatePeer(ctx, name, &originals[i])
+			if err != nil {
+				terr = err
+				return nil
+			}
+			if translation == nil {
+				untranslatable = append(untranslatable, reason)
+				continue
+			}
+			translations = append(translations, *translation)
+		}
+		return translations
+	}
+
+	// Forge the mutation to be applied to the remote cluster.
+	mutation := forge.RemoteNetworkPolicy(local, npr.RemoteNamespace(), translator)
+	if terr != nil {
+		klog.Errorf("Reflection of local NetworkPolicy %q to %q failed: %v", npr.LocalRef(name), npr.RemoteRef(name), terr)
+		npr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(terr))
+		return terr
+	}
+	tracer.Step("Remote mutation created")
+
+	// Apply the mutation.
+	defer tracer.Step("Enforced the correctness of the remote object")
+	if _, err := npr.remoteNetworkPoliciesClient.Apply(ctx, mutation, forge.ApplyOptions()); err != nil {
+		klog.Errorf("Failed to enforce remote NetworkPolicy %q (local: %q): %v", npr.RemoteRef(name), npr.LocalRef(name), err)
+		npr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+
+	// Release the addresses previously mapped, but no longer referenced by the remote networkpolicy (e.g., removed ipBlocks).
+	ucurrent, _ := npr.inUse.Load(name)
+	if err := npr.unmapIPs(ctx, name, ucurrent.(map[string]struct{})); err != nil {
+		return err
+	}
+
+	if len(untranslatable) > 0 {
+		klog.Warningf("Remote NetworkPolicy %q partially enforced (local: %q), skipping untranslatable peers: %v",
+			npr.RemoteRef(name), npr.LocalRef(name), untranslatable)
+		npr.Event(local, corev1.EventTypeWarning, forge.EventPartialReflection, forge.EventPartialReflectionMsg(untranslatable))
+	} else {
+		klog.Infof("Remote NetworkPolicy %q successfully enforced (local: %q)", npr.RemoteRef(name), npr.LocalRef(name))
+		npr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+	}
+
+	// Namespace selectors depend on the labels of the local namespaces and on the current mappings, which are not watched.
+	// Hence, periodically re-enqueue the networkpolicy to ensure the remote one is eventually consistent.
+	if selectsNamespaces {
+		return generic.EnqueueAfter(networkPolicyRefreshInterval)
+	}
+	return nil
+}
+
+// TranslatePeer translates a local networkpolicy peer into the corresponding remote one. In case the peer cannot be
+// translated (e.g., it selects namespaces not offloaded to the remote cluster), a nil peer is returned, together with
+// a human-readable reason. An error is returned only in case of transient failures interacting with the IPAM.
+func (npr *NamespacedNetworkPolicyReflector) TranslatePeer(ctx context.Context, policy string,
+	original *netv1.NetworkPolicyPeer) (translation *netv1.NetworkPolicyPeer, reason string, err error) {
+	if original.IPBlock != nil {
+		return npr.translateIPBlock(ctx, policy, original.IPBlock)
+	}
+
+	// The peer selects pods in the same namespace of the networkpolicy, which are reflected with the same labels.
+	if original.NamespaceSelector == nil {
+		return original.DeepCopy(), "", nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(original.NamespaceSelector)
+	if err != nil {
+		return nil, fmt.Sprintf("invalid namespace selector: %v", err), nil
+	}
+
+	namespaces, err := npr.localNamespaces.List(selector)
+	utilruntime.Must(err)
+
+	var remotes, unmapped []string
+	for _, namespace := range namespaces {
+		remote, found := npr.namespaceTranslator(namespace.GetName())
+		if !found {
+			unmapped = append(unmapped, namespace.GetName())
+			continue
+		}
+		remotes = append(remotes, remote)
+	}
+
+	// Namespaces not offloaded to the remote cluster host local pods only, which cannot be selected
+	// from the remote cluster. Hence, refuse to translate the peer, as it would otherwise get narrower.
+	if len(unmapped) > 0 {
+		sort.Strings(unmapped)
+		return nil, fmt.Sprintf("namespaces %v are not offloaded to the remote cluster", unmapped), nil
+	}
+	if len(remotes) == 0 {
+		return nil, fmt.Sprintf("namespace selector %q does not match any offloaded namespace", selector.String()), nil
+	}
+
+	sort.Strings(remotes)
+	return &netv1.NetworkPolicyPeer{
+		PodSelector: original.PodSelector.DeepCopy(),
+		NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpIn, Values: remotes,
+		}}},
+	}, "", nil
+}
+
+// translateIPBlock translates a local ipBlock into the corresponding remote one.
+func (npr *NamespacedNetworkPolicyReflector) translateIPBlock(ctx context.Context, policy string,
+	original *netv1.IPBlock) (*netv1.NetworkPolicyPeer, string, error) {
+	cidr, reason, err := npr.MapCIDR(ctx, policy, original.CIDR)
+	if err != nil || reason != "" {
+		return nil, reason, err
+	}
+
+	translation := &netv1.IPBlock{CIDR: cidr}
+	for _, except := range original.Except {
+		// Dropping an untranslatable exception would widen the peer, hence the entire peer is refused.
+		translated, reason, err := npr.MapCIDR(ctx, policy, except)
+		if err != nil || reason != "" {
+			return nil, reason, err
+		}
+		translation.Except = append(translation.Except, translated)
+	}
+
+	return &netv1.NetworkPolicyPeer{IPBlock: translation}, "", nil
+}
+
+// MapCIDR maps a local CIDR to the corresponding remote one. Single addresses are remapped through the IPAM
+// (possibly leveraging the ExternalCIDR), while larger CIDRs are supported only if contained in the local pod CIDR,
+// whose remapping preserves the address structure. In the other cases, a human-readable reason is returned.
+func (npr *NamespacedNetworkPolicyReflector) MapCIDR(ctx context.Context, policy, cidr string) (mapped, reason string, err error) {
+	ip, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", fmt.Sprintf("invalid CIDR %q", cidr), nil
+	}
+
+	ones, bits := network.Mask.Size()
+	if ones == bits {
+		translations, err := npr.MapIPs(ctx, policy, []string{ip.String()})
+		if err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("%v/%v", translations[0], ones), "", nil
+	}
+
+	first, last := network.IP, lastAddress(network)
+	for _, address := range []net.IP{first, last} {
+		response, err := npr.ipamclient.BelongsToPodCIDR(ctx, &ipam.BelongsRequest{Ip: address.String()})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to check whether CIDR %v belongs to the pod CIDR: %w", cidr, err)
+		}
+		if !response.GetBelongs() {
+			return "", fmt.Sprintf("CIDR %v is neither a single address nor contained in the local pod CIDR", cidr), nil
+		}
+	}
+
+	// Addresses belonging to the pod CIDR are remapped by network prefix substitution, without allocating new IPs.
+	translations, err := npr.MapIPs(ctx, policy, []string{first.String()})
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("%v/%v", translations[0], ones), "", nil
+}
+
+// MapIPs maps the given set of local addresses to the corresponding remote ones.
+func (npr *NamespacedNetworkPolicyReflector) MapIPs(ctx context.Context, policy string, originals []string) ([]string, error) {
+	var translations []string
+
+	// Retrieve the cache for the given networkpolicy. The cache is not synchronized,
+	// since we are guaranteed to be the only ones operating on this object.
+	ucache, _ := npr.translations.LoadOrStore(policy, map[string]string{})
+	cache := ucache.(map[string]string)
+
+	// Retrieve the set of addresses referenced by the translation in progress, if any.
+	var current map[string]struct{}
+	if ucurrent, found := npr.inUse.Load(policy); found {
+		current = ucurrent.(map[string]struct{})
+	}
+
+	for _, original := range originals {
+		if current != nil {
+			current[original] = struct{}{}
+		}
+
+		// Check if we already know the translation.
+		translation, found := cache[original]
+
+		if !found {
+			// Cache miss -> we need to interact with the IPAM to request the translation.
+			response, err := npr.ipamclient.MapEndpointIP(ctx, &ipam.MapRequest{ClusterID: forge.RemoteCluster.ClusterID, Ip: original})
+			if err != nil {
+				return nil, fmt.Errorf("failed to translate IP %v: %w", original, err)
+			}
+			translation = response.GetIp()
+			cache[original] = translation
+		}
+
+		translations = append(translations, translation)
+		klog.V(6).Infof("Translated local IP %v to remote %v", original, translation)
+	}
+
+	return translations, nil
+}
+
+// UnmapIPs releases the remote addresses associated with the given networkpolicy.
+func (npr *NamespacedNetworkPolicyReflector) UnmapIPs(ctx context.Context, policy string) error {
+	return npr.unmapIPs(ctx, policy, nil)
+}
+
+// unmapIPs releases the remote addresses associated with the given networkpolicy, except for those whose
+// local counterpart belongs to the retained set. The whole cache is removed in case no address is retained.
+func (npr *NamespacedNetworkPolicyReflector) unmapIPs(ctx context.Context, policy string, retained map[string]struct{}) error {
+	// Retrieve the cache for the given networkpolicy. The cache is not synchronized,
+	// since we are guaranteed to be the only ones operating on this object.
+	ucache, found := npr.translations.Load(policy)
+	if !found {
+		klog.V(4).Infof("Mappings from local NetworkPolicy %q to remote %q already released", npr.LocalRef(policy), npr.RemoteRef(policy))
+		return nil
+	}
+
+	cache := ucache.(map[string]string)
+	for original, translation := range cache {
+		if _, retain := retained[original]; retain {
+			continue
+		}
+
+		// Interact with the IPAM to release the translation.
+		_, err := npr.ipamclient.UnmapEndpointIP(ctx, &ipam.UnmapRequest{ClusterID: forge.RemoteCluster.ClusterID, Ip: original})
+		if err != nil {
+			klog.Errorf("Failed to release IP %v of NetworkPolicy %q: %v", original, npr.LocalRef(policy), err)
+			return fmt.Errorf("failed to release IP %v of NetworkPolicy %q: %w", original, npr.LocalRef(policy), err)
+		}
+
+		// Remove the object from our local cache, to avoid retrying to free it again if an error occurs with the subsequent entries.
+		klog.V(6).Infof("Released mapping from local IP %v to remote %v of NetworkPolicy %q", original, translation, npr.LocalRef(policy))
+		delete(cache, original)
+	}
+
+	if len(retained) > 0 {
+		return nil
+	}
+
+	// Remove the cache for this networkpolicy.
+	npr.translations.Delete(policy)
+	klog.V(4).Infof("Released mappings from local NetworkPolicy %q to remote %q", npr.LocalRef(policy), npr.RemoteRef(policy))
+	return nil
+}
+
+// lastAddress returns the last address belonging to the given network.
+func lastAddress(network *net.IPNet) net.IP {
+	last := make(net.IP, len(network.IP))
+	for i := range network.IP {
+		last[i] = network.IP[i] | ^network.Mask[i]
+	}
+	return last
+}
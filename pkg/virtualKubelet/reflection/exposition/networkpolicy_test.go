@@ -0,0 +1,240 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exposition_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/trace"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	fakeipam "github.com/liqotech/liqo/pkg/liqonet/ipam/fake"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/exposition"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ = Describe("NetworkPolicy Reflection Tests", func() {
+	Describe("the NewNetworkPolicyReflector function", func() {
+		It("should not return a nil reflector", func() {
+			Expect(exposition.NewNetworkPolicyReflector(nil, nil, nil, 1)).ToNot(BeNil())
+		})
+	})
+
+	Describe("networkpolicy handling", func() {
+		const NetworkPolicyName = "name"
+
+		var (
+			reflector manager.NamespacedReflector
+			ipam      *fakeipam.IPAMClient
+
+			local, remote netv1.NetworkPolicy
+			err           error
+		)
+
+		GetNetworkPolicy := func(namespace string) *netv1.NetworkPolicy {
+			np, errnp := client.NetworkingV1().NetworkPolicies(namespace).Get(ctx, NetworkPolicyName, metav1.GetOptions{})
+			Expect(errnp).ToNot(HaveOccurred())
+			return np
+		}
+
+		CreateNetworkPolicy := func(np *netv1.NetworkPolicy) *netv1.NetworkPolicy {
+			np, errnp := client.NetworkingV1().NetworkPolicies(np.GetNamespace()).Create(ctx, np, metav1.CreateOptions{})
+			Expect(errnp).ToNot(HaveOccurred())
+			return np
+		}
+
+		// The local namespace is mapped to the remote one, while all the other namespaces are not offloaded.
+		Translator := func(local string) (string, bool) {
+			if local == LocalNamespace {
+				return RemoteNamespace, true
+			}
+			return "", false
+		}
+
+		WhenBodyRemoteShouldNotExist := func(createRemote bool) func() {
+			return func() {
+				BeforeEach(func() {
+					if createRemote {
+						remote.SetLabels(forge.ReflectionLabels())
+						CreateNetworkPolicy(&remote)
+					}
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("the remote object should not be present", func() {
+					_, err = client.NetworkingV1().NetworkPolicies(RemoteNamespace).Get(ctx, NetworkPolicyName, metav1.GetOptions{})
+					Expect(err).To(BeNotFound())
+				})
+			}
+		}
+
+		BeforeEach(func() {
+			local = netv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: NetworkPolicyName, Namespace: LocalNamespace}}
+			remote = netv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: NetworkPolicyName, Namespace: RemoteNamespace}}
+		})
+
+		AfterEach(func() {
+			Expect(client.NetworkingV1().NetworkPolicies(LocalNamespace).Delete(ctx, NetworkPolicyName, metav1.DeleteOptions{})).To(
+				Or(BeNil(), WithTransform(kerrors.IsNotFound, BeTrue())))
+			Expect(client.NetworkingV1().NetworkPolicies(RemoteNamespace).Delete(ctx, NetworkPolicyName, metav1.DeleteOptions{})).To(
+				Or(BeNil(), WithTransform(kerrors.IsNotFound, BeTrue())))
+		})
+
+		JustBeforeEach(func() {
+			ipam = fakeipam.NewIPAMClient("192.168.200.0/24", "192.168.201.0/24", true)
+			factory := informers.NewSharedInformerFactory(client, 10*time.Hour)
+			namespaces := factory.Core().V1().Namespaces().Lister()
+			reflector = exposition.NewNamespacedNetworkPolicyReflector(ipam, namespaces, Translator)(options.NewNamespaced().
+				WithLocal(LocalNamespace, client, factory).
+				WithRemote(RemoteNamespace, client, factory).
+				WithHandlerFactory(FakeEventHandler).
+				WithEventBroadcaster(record.NewBroadcaster()))
+
+			factory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+
+			err = reflector.Handle(trace.ContextWithTrace(ctx, trace.New("NetworkPolicy")), NetworkPolicyName)
+		})
+
+		When("the local object does not exist", func() {
+			When("the remote object does not exist", WhenBodyRemoteShouldNotExist(false))
+			When("the remote object does exist", WhenBodyRemoteShouldNotExist(true))
+		})
+
+		When("the local object does exist", func() {
+			BeforeEach(func() {
+				local.SetLabels(map[string]string{"foo": "bar"})
+				local.SetAnnotations(map[string]string{"bar": "baz"})
+				local.Spec.PolicyTypes = []netv1.PolicyType{netv1.PolicyTypeIngress}
+				local.Spec.Ingress = []netv1.NetworkPolicyIngressRule{{From: []netv1.NetworkPolicyPeer{
+					{IPBlock: &netv1.IPBlock{CIDR: "192.168.0.25/32"}},
+					{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "client"}}},
+				}}}
+			})
+
+			When("the remote object does not exist", func() {
+				BeforeEach(func() { CreateNetworkPolicy(&local) })
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("the metadata should have been correctly replicated to the remote object", func() {
+					remoteAfter := GetNetworkPolicy(RemoteNamespace)
+					Expect(remoteAfter.Labels).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, LocalClusterID))
+					Expect(remoteAfter.Labels).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, RemoteClusterID))
+					Expect(remoteAfter.Labels).To(HaveKeyWithValue("foo", "bar"))
+					Expect(remoteAfter.Annotations).To(HaveKeyWithValue("bar", "baz"))
+				})
+				It("the ipBlock should have been correctly translated", func() {
+					remoteAfter := GetNetworkPolicy(RemoteNamespace)
+					Expect(remoteAfter.Spec.Ingress).To(HaveLen(1))
+					Expect(remoteAfter.Spec.Ingress[0].From).To(ContainElement(
+						netv1.NetworkPolicyPeer{IPBlock: &netv1.IPBlock{CIDR: "192.168.200.25/32"}}))
+					Expect(ipam.IsEndpointTranslated("192.168.0.25")).To(BeTrue())
+				})
+				It("the pod selector should have been preserved", func() {
+					remoteAfter := GetNetworkPolicy(RemoteNamespace)
+					Expect(remoteAfter.Spec.Ingress[0].From).To(ContainElement(
+						netv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "client"}}}))
+				})
+
+				When("an ipBlock is subsequently removed", func() {
+					JustBeforeEach(func() {
+						Expect(err).ToNot(HaveOccurred())
+						updated := GetNetworkPolicy(LocalNamespace)
+						updated.Spec.Ingress[0].From = updated.Spec.Ingress[0].From[1:]
+						_, err = client.NetworkingV1().NetworkPolicies(LocalNamespace).Update(ctx, updated, metav1.UpdateOptions{})
+						Expect(err).ToNot(HaveOccurred())
+
+						// Wait for the informer to observe the update, and the remote object to be enforced accordingly.
+						Eventually(func() []netv1.NetworkPolicyPeer {
+							err = reflector.Handle(trace.ContextWithTrace(ctx, trace.New("NetworkPolicy")), NetworkPolicyName)
+							return GetNetworkPolicy(RemoteNamespace).Spec.Ingress[0].From
+						}).Should(HaveLen(1))
+					})
+
+					It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+					It("the address translation should have been released", func() {
+						Expect(ipam.IsEndpointTranslated("192.168.0.25")).To(BeFalse())
+					})
+				})
+			})
+
+			When("a peer selects the local namespace", func() {
+				BeforeEach(func() {
+					local.Spec.Ingress[0].From = []netv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{corev1.LabelMetadataName: LocalNamespace}}}}
+					CreateNetworkPolicy(&local)
+				})
+
+				It("should request to be re-enqueued", func() { Expect(err).To(HaveOccurred()) })
+				It("the namespace selector should have been translated", func() {
+					remoteAfter := GetNetworkPolicy(RemoteNamespace)
+					Expect(remoteAfter.Spec.Ingress).To(HaveLen(1))
+					Expect(remoteAfter.Spec.Ingress[0].From).To(HaveLen(1))
+					Expect(remoteAfter.Spec.Ingress[0].From[0].NamespaceSelector.MatchExpressions).To(ConsistOf(
+						metav1.LabelSelectorRequirement{Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpIn, Values: []string{RemoteNamespace}}))
+				})
+			})
+
+			When("a peer selects a namespace which is not offloaded", func() {
+				BeforeEach(func() {
+					local.Spec.Ingress[0].From = []netv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{corev1.LabelMetadataName: RemoteNamespace}}}}
+					CreateNetworkPolicy(&local)
+				})
+
+				It("the corresponding rule should have been dropped", func() {
+					remoteAfter := GetNetworkPolicy(RemoteNamespace)
+					Expect(remoteAfter.Spec.Ingress).To(BeEmpty())
+				})
+			})
+
+			When("the remote object already exists, but is not managed by the reflection", func() {
+				var remoteBefore *netv1.NetworkPolicy
+
+				BeforeEach(func() {
+					CreateNetworkPolicy(&local)
+					remoteBefore = CreateNetworkPolicy(&remote)
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("the remote object should be unmodified", func() {
+					remoteAfter := GetNetworkPolicy(RemoteNamespace)
+					Expect(remoteAfter).To(Equal(remoteBefore))
+				})
+			})
+		})
+
+		When("the local object does exist, but has the skip annotation", func() {
+			BeforeEach(func() {
+				local.SetAnnotations(map[string]string{consts.SkipReflectionAnnotationKey: "whatever"})
+				CreateNetworkPolicy(&local)
+			})
+
+			When("the remote object does not exist", WhenBodyRemoteShouldNotExist(false))
+			When("the remote object does exist", WhenBodyRemoteShouldNotExist(true))
+		})
+	})
+})
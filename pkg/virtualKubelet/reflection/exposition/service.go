@@ -63,6 +63,7 @@ func NewNamespacedServiceReflector(opts *options.NamespacedOpts) manager.Namespa
 
 	local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 	remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+	generic.WatchReflectionPolicies(opts, local.Informer())
 
 	return &NamespacedServiceReflector{
 		NamespacedReflector:  generic.NewNamespacedReflector(opts, ServiceReflectorName),
@@ -90,6 +91,12 @@ func (nsr *NamespacedServiceReflector) Handle(ctx context.Context, name string)
 	utilruntime.Must(client.IgnoreNotFound(rerr))
 	tracer.Step("Retrieved the local and remote objects")
 
+	// Skip the local objects imported from the remote cluster, as managed by the dedicated reflector.
+	if lerr == nil && forge.IsImported(local) {
+		klog.V(4).Infof("Skipping reflection of local Service %q as imported from the remote cluster", nsr.LocalRef(name))
+		return nil
+	}
+
 	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
 	if rerr == nil && !forge.IsReflected(remote) {
 		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
@@ -99,16 +106,18 @@ func (nsr *NamespacedServiceReflector) Handle(ctx context.Context, name string)
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
-	if !kerrors.IsNotFound(lerr) && nsr.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local Service %q as marked with the skip annotation", nsr.LocalRef(name))
-		nsr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
-		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
-			return nil
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is denied by a reflection policy.
+	if !kerrors.IsNotFound(lerr) {
+		if skip, reason := nsr.ShouldSkipReflection(local); skip {
+			klog.Infof("Skipping reflection of local Service %q: %v", nsr.LocalRef(name), reason)
+			nsr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(reason))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(corev1.Resource("service"), local.GetName())
 		}
-
-		// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
-		lerr = kerrors.NewNotFound(corev1.Resource("service"), local.GetName())
 	}
 
 	tracer.Step("Performed the sanity checks")
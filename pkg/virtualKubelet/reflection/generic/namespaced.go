@@ -16,16 +16,21 @@ package generic
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 
+	vkv1alpha1listers "github.com/liqotech/liqo/pkg/client/listers/virtualkubelet/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
 )
@@ -36,8 +41,11 @@ type NamespacedReflector struct {
 
 	ready func() bool
 
+	name   string
 	local  string
 	remote string
+
+	policies vkv1alpha1listers.ReflectionPolicyNamespaceLister
 }
 
 // ResourceDeleter know how to delete a Kubernetes object with the given name.
@@ -47,9 +55,49 @@ type ResourceDeleter interface {
 
 // NewNamespacedReflector returns a new NamespacedReflector for the given namespaces.
 func NewNamespacedReflector(opts *options.NamespacedOpts, name string) NamespacedReflector {
+	var policies vkv1alpha1listers.ReflectionPolicyNamespaceLister
+	if opts.LocalLiqoFactory != nil {
+		policies = opts.LocalLiqoFactory.Virtualkubelet().V1alpha1().ReflectionPolicies().Lister().ReflectionPolicies(opts.LocalNamespace)
+	}
+
 	return NamespacedReflector{
 		EventRecorder: opts.EventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "liqo-" + strings.ToLower(name) + "-reflection"}),
-		local:         opts.LocalNamespace, remote: opts.RemoteNamespace, ready: opts.Ready,
+		name:          name, local: opts.LocalNamespace, remote: opts.RemoteNamespace, ready: opts.Ready, policies: policies,
+	}
+}
+
+// WatchReflectionPolicies registers an event handler which, whenever a ReflectionPolicy of the local namespace changes,
+// enqueues all the local objects cached by the given informer, so that the updated policies are promptly enforced.
+func WatchReflectionPolicies(opts *options.NamespacedOpts, informer cache.SharedIndexInformer) {
+	if opts.LocalLiqoFactory == nil {
+		return
+	}
+
+	policies := opts.LocalLiqoFactory.Virtualkubelet().V1alpha1().ReflectionPolicies()
+	policies.Informer().AddEventHandler(opts.HandlerFactory(ReflectionPolicyKeyer(opts.LocalNamespace, informer.GetIndexer())))
+}
+
+// ReflectionPolicyKeyer returns a keyer associated with the given namespace, which maps a ReflectionPolicy
+// to all the objects of that namespace cached by the given indexer (i.e., the ones potentially affected by the policy).
+func ReflectionPolicyKeyer(namespace string, indexer cache.Indexer) options.Keyer {
+	return func(metadata metav1.Object) []types.NamespacedName {
+		if metadata.GetNamespace() != namespace {
+			return nil
+		}
+
+		objects, err := indexer.ByIndex(cache.NamespaceIndex, namespace)
+		if err != nil {
+			klog.Errorf("Failed to retrieve the objects affected by ReflectionPolicy %q: %v", klog.KObj(metadata), err)
+			return nil
+		}
+
+		keys := make([]types.NamespacedName, 0, len(objects))
+		for _, object := range objects {
+			if accessor, aerr := meta.Accessor(object); aerr == nil {
+				keys = append(keys, types.NamespacedName{Namespace: namespace, Name: accessor.GetName()})
+			}
+		}
+		return keys
 	}
 }
 
@@ -90,8 +138,31 @@ func (gnr *NamespacedReflector) DeleteRemote(ctx context.Context, deleter Resour
 	return nil
 }
 
-// ShouldSkipReflection returns whether the reflection of the given object should be skipped.
-func (gnr *NamespacedReflector) ShouldSkipReflection(obj metav1.Object) bool {
-	_, ok := obj.GetAnnotations()[consts.SkipReflectionAnnotationKey]
-	return ok
+// ShouldSkipReflection returns whether the reflection of the given object should be skipped, along with the corresponding reason.
+func (gnr *NamespacedReflector) ShouldSkipReflection(obj metav1.Object) (skip bool, reason string) {
+	return gnr.ShouldSkipReflectionOf(gnr.name, obj)
+}
+
+// ShouldSkipReflectionOf returns whether the reflection of the given object, as performed by the given reflector, should be skipped,
+// either because it is marked with the skip annotation, or because it is denied by a ReflectionPolicy of the local namespace.
+func (gnr *NamespacedReflector) ShouldSkipReflectionOf(reflector string, obj metav1.Object) (skip bool, reason string) {
+	if _, ok := obj.GetAnnotations()[consts.SkipReflectionAnnotationKey]; ok {
+		return true, "marked with the skip annotation"
+	}
+
+	if gnr.policies == nil {
+		return false, ""
+	}
+
+	// The lister never returns an error.
+	policies, _ := gnr.policies.List(labels.Everything())
+	for _, policy := range policies {
+		for i := range policy.Spec.Rules {
+			if reason, denied := ReflectionDenied(&policy.Spec.Rules[i], reflector, obj); denied {
+				return true, fmt.Sprintf("%v, as per ReflectionPolicy %q", reason, policy.GetName())
+			}
+		}
+	}
+
+	return false, ""
 }
@@ -16,17 +16,24 @@ package generic
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 
+	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	liqoclientfake "github.com/liqotech/liqo/pkg/client/clientset/versioned/fake"
+	liqoinformers "github.com/liqotech/liqo/pkg/client/informers/externalversions"
+	"github.com/liqotech/liqo/pkg/consts"
 	. "github.com/liqotech/liqo/pkg/utils/testutil"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
 )
@@ -114,4 +121,101 @@ var _ = Describe("NamespacedReflector tests", func() {
 			})
 		})
 	})
+
+	Context("the ShouldSkipReflection function", func() {
+		var (
+			nsrfl    NamespacedReflector
+			policies []runtime.Object
+			obj      corev1.ConfigMap
+
+			skip   bool
+			reason string
+		)
+
+		BeforeEach(func() {
+			policies = nil
+			obj = corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings-private", Namespace: localNamespace}}
+		})
+
+		JustBeforeEach(func() {
+			ctx := context.Background()
+			factory := liqoinformers.NewSharedInformerFactory(liqoclientfake.NewSimpleClientset(policies...), 10*time.Hour)
+			opts := options.NamespacedOpts{
+				LocalNamespace: localNamespace, RemoteNamespace: remoteNamespace,
+				LocalLiqoFactory: factory, EventBroadcaster: record.NewBroadcaster(),
+			}
+			nsrfl = NewNamespacedReflector(&opts, "ConfigMap")
+
+			factory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+			skip, reason = nsrfl.ShouldSkipReflection(&obj)
+		})
+
+		When("the object has no skip annotation, and no policy exists", func() {
+			It("should not skip the reflection", func() { Expect(skip).To(BeFalse()) })
+		})
+
+		When("the object has the skip annotation", func() {
+			BeforeEach(func() { obj.SetAnnotations(map[string]string{consts.SkipReflectionAnnotationKey: "whatever"}) })
+			It("should skip the reflection", func() { Expect(skip).To(BeTrue()) })
+			It("should return a meaningful reason", func() { Expect(reason).To(ContainSubstring("skip annotation")) })
+		})
+
+		When("a policy in the local namespace denies the reflection", func() {
+			BeforeEach(func() {
+				policies = append(policies, &vkv1alpha1.ReflectionPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: localNamespace},
+					Spec: vkv1alpha1.ReflectionPolicySpec{Rules: []vkv1alpha1.ReflectionRule{
+						{Reflector: "ConfigMap", ExcludedNames: []string{"*-private"}},
+					}},
+				})
+			})
+
+			It("should skip the reflection", func() { Expect(skip).To(BeTrue()) })
+			It("should return a meaningful reason", func() { Expect(reason).To(ContainSubstring(`ReflectionPolicy "policy"`)) })
+		})
+
+		When("a policy in a different namespace denies the reflection", func() {
+			BeforeEach(func() {
+				policies = append(policies, &vkv1alpha1.ReflectionPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: remoteNamespace},
+					Spec:       vkv1alpha1.ReflectionPolicySpec{Rules: []vkv1alpha1.ReflectionRule{{Reflector: "ConfigMap", Disabled: true}}},
+				})
+			})
+
+			It("should not skip the reflection", func() { Expect(skip).To(BeFalse()) })
+		})
+	})
+
+	Context("the ReflectionPolicyKeyer function", func() {
+		var (
+			indexer cache.Indexer
+			policy  vkv1alpha1.ReflectionPolicy
+			keys    []types.NamespacedName
+		)
+
+		BeforeEach(func() {
+			indexer = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			Expect(indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: localNamespace}})).To(Succeed())
+			Expect(indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: localNamespace}})).To(Succeed())
+			Expect(indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "baz", Namespace: remoteNamespace}})).To(Succeed())
+			policy = vkv1alpha1.ReflectionPolicy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: localNamespace}}
+		})
+
+		JustBeforeEach(func() { keys = ReflectionPolicyKeyer(localNamespace, indexer)(&policy) })
+
+		When("the policy refers to the given namespace", func() {
+			It("should return the keys of all the objects in that namespace", func() {
+				Expect(keys).To(ConsistOf(
+					types.NamespacedName{Namespace: localNamespace, Name: "foo"},
+					types.NamespacedName{Namespace: localNamespace, Name: "bar"},
+				))
+			})
+		})
+
+		When("the policy refers to a different namespace", func() {
+			BeforeEach(func() { policy.SetNamespace(remoteNamespace) })
+			It("should return no keys", func() { Expect(keys).To(BeEmpty()) })
+		})
+	})
 })
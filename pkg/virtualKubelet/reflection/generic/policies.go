@@ -0,0 +1,73 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+)
+
+// ReflectionDenied returns whether the given rule denies the reflection of the given object, as performed by the given reflector,
+// along with a human-readable reason. Malformed selectors and patterns deny the reflection, to err on the safe side.
+func ReflectionDenied(rule *vkv1alpha1.ReflectionRule, reflector string, obj metav1.Object) (reason string, denied bool) {
+	if rule.Reflector != reflector {
+		return "", false
+	}
+
+	if rule.Disabled {
+		return fmt.Sprintf("reflection of all %v objects disabled", reflector), true
+	}
+
+	for _, pattern := range rule.ExcludedNames {
+		matches, err := path.Match(pattern, obj.GetName())
+		if err != nil {
+			return fmt.Sprintf("invalid excluded name pattern %q", pattern), true
+		}
+		if matches {
+			return fmt.Sprintf("name matches the excluded pattern %q", pattern), true
+		}
+	}
+
+	if rule.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(rule.Selector)
+		if err != nil {
+			return fmt.Sprintf("invalid label selector: %v", err), true
+		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			return fmt.Sprintf("labels do not match the selector %q", selector.String()), true
+		}
+	}
+
+	if secret, ok := obj.(*corev1.Secret); ok && len(rule.Types) > 0 && !containsSecretType(rule.Types, secret.Type) {
+		return fmt.Sprintf("secret type %q is not allowed", secret.Type), true
+	}
+
+	return "", false
+}
+
+func containsSecretType(types []corev1.SecretType, target corev1.SecretType) bool {
+	for _, t := range types {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+)
+
+var _ = Describe("Reflection policies tests", func() {
+	Describe("the ReflectionDenied function", func() {
+		var (
+			rule   vkv1alpha1.ReflectionRule
+			obj    metav1.Object
+			denied bool
+			reason string
+		)
+
+		BeforeEach(func() {
+			rule = vkv1alpha1.ReflectionRule{Reflector: "Secret"}
+			obj = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "credentials", Labels: map[string]string{"foo": "bar"}},
+				Type:       corev1.SecretTypeOpaque,
+			}
+		})
+
+		JustBeforeEach(func() { reason, denied = ReflectionDenied(&rule, "Secret", obj) })
+
+		When("the rule is empty", func() {
+			It("should allow the reflection", func() { Expect(denied).To(BeFalse()) })
+		})
+
+		When("the rule refers to a different reflector", func() {
+			BeforeEach(func() { rule.Reflector = "ConfigMap"; rule.Disabled = true })
+			It("should allow the reflection", func() { Expect(denied).To(BeFalse()) })
+		})
+
+		When("the rule disables the reflector", func() {
+			BeforeEach(func() { rule.Disabled = true })
+			It("should deny the reflection", func() { Expect(denied).To(BeTrue()) })
+			It("should return a meaningful reason", func() { Expect(reason).To(ContainSubstring("disabled")) })
+		})
+
+		When("the name matches an excluded pattern", func() {
+			BeforeEach(func() { rule.ExcludedNames = []string{"foo", "cred*"} })
+			It("should deny the reflection", func() { Expect(denied).To(BeTrue()) })
+			It("should return a meaningful reason", func() { Expect(reason).To(ContainSubstring("cred*")) })
+		})
+
+		When("the name does not match any excluded pattern", func() {
+			BeforeEach(func() { rule.ExcludedNames = []string{"*-private"} })
+			It("should allow the reflection", func() { Expect(denied).To(BeFalse()) })
+		})
+
+		When("an excluded pattern is malformed", func() {
+			BeforeEach(func() { rule.ExcludedNames = []string{"[cred"} })
+			It("should deny the reflection", func() { Expect(denied).To(BeTrue()) })
+		})
+
+		When("the labels match the selector", func() {
+			BeforeEach(func() { rule.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}} })
+			It("should allow the reflection", func() { Expect(denied).To(BeFalse()) })
+		})
+
+		When("the labels do not match the selector", func() {
+			BeforeEach(func() { rule.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "baz"}} })
+			It("should deny the reflection", func() { Expect(denied).To(BeTrue()) })
+			It("should return a meaningful reason", func() { Expect(reason).To(ContainSubstring("foo=baz")) })
+		})
+
+		When("the secret type is allowed", func() {
+			BeforeEach(func() { rule.Types = []corev1.SecretType{corev1.SecretTypeTLS, corev1.SecretTypeOpaque} })
+			It("should allow the reflection", func() { Expect(denied).To(BeFalse()) })
+		})
+
+		When("the secret type is not allowed", func() {
+			BeforeEach(func() { rule.Types = []corev1.SecretType{corev1.SecretTypeTLS} })
+			It("should deny the reflection", func() { Expect(denied).To(BeTrue()) })
+			It("should return a meaningful reason", func() { Expect(reason).To(ContainSubstring(string(corev1.SecretTypeOpaque))) })
+		})
+	})
+})
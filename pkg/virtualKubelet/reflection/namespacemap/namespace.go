@@ -78,6 +78,20 @@ func (nh *Handler) Start(ctx context.Context, namespaceStartStopper manager.Name
 	klog.Info("namespaceMap handler started")
 }
 
+// RemoteNamespace returns the name of the remote namespace the given local one is currently mapped to, if any.
+func (nh *Handler) RemoteNamespace(local string) (string, bool) {
+	nsList, err := nh.lister.List(labels.Everything())
+	utilruntime.Must(err)
+
+	for _, namespaceMap := range nsList {
+		if status, found := namespaceMap.Status.CurrentMapping[local]; found && status.Phase == vkv1alpha1.MappingAccepted {
+			return status.RemoteNamespace, true
+		}
+	}
+
+	return "", false
+}
+
 func (nh *Handler) onAddNamespaceMap(obj interface{}) {
 	namespaceMap := obj.(*vkv1alpha1.NamespaceMap)
 
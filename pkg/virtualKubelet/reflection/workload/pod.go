@@ -16,10 +16,13 @@ package workload
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/virtual-kubelet/virtual-kubelet/node/api"
 	"github.com/virtual-kubelet/virtual-kubelet/node/api/statsv1alpha1"
@@ -29,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
@@ -58,6 +62,25 @@ const (
 // MetricsFactory represents a function to generate the interface to retrieve the pod metrics for a given namespace.
 type MetricsFactory func(namespace string) metricsv1beta1.PodMetricsInterface
 
+// SummaryRetriever represents a function to retrieve the stats summary of a given remote node.
+type SummaryRetriever func(ctx context.Context, node string) (*statsv1alpha1.Summary, error)
+
+// NewSummaryRetriever returns a SummaryRetriever leveraging the API server node proxy to query the kubelet of the given remote node.
+func NewSummaryRetriever(client rest.Interface) SummaryRetriever {
+	return func(ctx context.Context, node string) (*statsv1alpha1.Summary, error) {
+		raw, err := client.Get().Resource("nodes").Name(node).SubResource("proxy").Suffix("stats/summary").DoRaw(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var summary statsv1alpha1.Summary
+		if err := json.Unmarshal(raw, &summary); err != nil {
+			return nil, err
+		}
+		return &summary, nil
+	}
+}
+
 // PodHandler exposes an interface to interact with pods offloaded to the remote cluster.
 type PodHandler interface {
 	// List returns the list of reflected pods.
@@ -78,11 +101,15 @@ type PodReflector struct {
 
 	remoteRESTConfig     *rest.Config
 	remoteMetricsFactory MetricsFactory
+	remoteSummaries      SummaryRetriever
+	// summaryWarned tracks whether a warning has already been emitted due to the failure to retrieve the stats summary.
+	summaryWarned atomic.Bool
 
 	ipamclient ipam.IpamClient
 	handlers   sync.Map /* implicit signature: map[string]NamespacedPodHandler */
 
-	apiServerSupport forge.APIServerSupportType
+	apiServerSupport     forge.APIServerSupportType
+	priorityClassMapping map[string]string
 }
 
 // FallbackPodReflector handles the "orphan" pods outside the managed namespaces.
@@ -97,14 +124,18 @@ type FallbackPodReflector struct {
 func NewPodReflector(
 	remoteRESTConfig *rest.Config, /* required to establish the connection to implement `kubectl exec` */
 	remoteMetricsFactory MetricsFactory, /* required to retrieve the pod metrics from the remote cluster */
+	remoteSummaries SummaryRetriever, /* required to retrieve the full pod stats from the remote nodes (optional) */
 	ipamclient ipam.IpamClient, /* required to translate the remote IP addresses to the corresponding local ones */
 	apiServerSupport forge.APIServerSupportType, /* how to forge the fields required to allow offloaded pods to contact the local API server */
+	priorityClassMapping map[string]string, /* the mapping between the local priority classes and the remote ones */
 	workers uint) *PodReflector {
 	reflector := &PodReflector{
 		remoteRESTConfig:     remoteRESTConfig,
 		remoteMetricsFactory: remoteMetricsFactory,
+		remoteSummaries:      remoteSummaries,
 		ipamclient:           ipamclient,
 		apiServerSupport:     apiServerSupport,
+		priorityClassMapping: priorityClassMapping,
 	}
 
 	genericReflector := generic.NewReflector(PodReflectorName, reflector.NewNamespaced, reflector.NewFallback, workers)
@@ -138,6 +169,7 @@ func (pr *PodReflector) NewNamespaced(opts *options.NamespacedOpts) manager.Name
 
 		ipamclient:                pr.ipamclient,
 		apiServerSupport:          pr.apiServerSupport,
+		priorityClassMapping:      pr.priorityClassMapping,
 		kubernetesServiceIPGetter: pr.KubernetesServiceIPGetter(),
 	}
 
@@ -191,12 +223,28 @@ func (pr *PodReflector) Logs(ctx context.Context, namespace, pod, container stri
 }
 
 // Stats retrieves the stats of the reflected pods.
+// The stats are gathered from the summary API of the remote nodes hosting the reflected pods (through the API server node proxy),
+// falling back to the metrics exposed by the remote metrics server in case they cannot be retrieved.
 func (pr *PodReflector) Stats(ctx context.Context) (*statsv1alpha1.Summary, error) {
+	if pr.remoteSummaries != nil {
+		pods, err := pr.summaryStats(ctx)
+		if err == nil {
+			return forge.LocalNodeStats(pods), nil
+		}
+		// Warn only the first time, as the failure is likely persistent (e.g., missing permissions), and stats are requested frequently.
+		if pr.summaryWarned.CompareAndSwap(false, true) {
+			klog.Warningf("Failed to retrieve the stats summary from the remote nodes, falling back to metrics: %v", err)
+		} else {
+			klog.V(4).Infof("Failed to retrieve the stats summary from the remote nodes, falling back to metrics: %v", err)
+		}
+	}
+
 	var pods []statsv1alpha1.PodStats
 	var err error
 
 	pr.handlers.Range(func(_, handler interface{}) bool {
-		stats, err := handler.(NamespacedPodHandler).Stats(ctx)
+		var stats []statsv1alpha1.PodStats
+		stats, err = handler.(NamespacedPodHandler).Stats(ctx)
 		pods = append(pods, stats...)
 		return err == nil
 	})
@@ -208,6 +256,32 @@ func (pr *PodReflector) Stats(ctx context.Context) (*statsv1alpha1.Summary, erro
 	return forge.LocalNodeStats(pods), nil
 }
 
+// summaryStats retrieves the stats of the reflected pods from the summary API of the remote nodes hosting them.
+func (pr *PodReflector) summaryStats(ctx context.Context) ([]statsv1alpha1.PodStats, error) {
+	nodes := sets.NewString()
+	pr.handlers.Range(func(_, handler interface{}) bool {
+		nodes.Insert(handler.(NamespacedPodHandler).RemoteNodes()...)
+		return true
+	})
+
+	var remote []statsv1alpha1.PodStats
+	for _, node := range nodes.List() {
+		summary, err := pr.remoteSummaries(ctx, node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the stats summary of remote node %q: %w", node, err)
+		}
+		remote = append(remote, summary.Pods...)
+	}
+
+	var pods []statsv1alpha1.PodStats
+	pr.handlers.Range(func(_, handler interface{}) bool {
+		pods = append(pods, handler.(NamespacedPodHandler).SummaryStats(remote)...)
+		return true
+	})
+
+	return pods, nil
+}
+
 // KubernetesServiceIPGetter returns a function to retrieve the IP associated with the kubernetes.default service.
 func (pr *PodReflector) KubernetesServiceIPGetter() func(ctx context.Context) (string, error) {
 	var address string
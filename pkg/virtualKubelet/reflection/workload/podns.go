@@ -64,6 +64,10 @@ type NamespacedPodHandler interface {
 	Logs(ctx context.Context, pod, container string, opts api.ContainerLogOpts) (io.ReadCloser, error)
 	// Stats retrieves the stats of the reflected pods.
 	Stats(ctx context.Context) ([]statsv1alpha1.PodStats, error)
+	// RemoteNodes returns the names of the remote nodes hosting the reflected pods.
+	RemoteNodes() []string
+	// SummaryStats filters the stats retrieved from the remote summary API to the reflected pods, translating them.
+	SummaryStats(remote []statsv1alpha1.PodStats) []statsv1alpha1.PodStats
 }
 
 // NamespacedPodReflector manages the Pod reflection for a given pair of local and remote namespaces.
@@ -83,8 +87,9 @@ type NamespacedPodReflector struct {
 	remoteRESTConfig *rest.Config
 	remoteMetrics    metricsv1beta1.PodMetricsInterface
 
-	ipamclient       ipam.IpamClient
-	apiServerSupport forge.APIServerSupportType
+	ipamclient           ipam.IpamClient
+	apiServerSupport     forge.APIServerSupportType
+	priorityClassMapping map[string]string
 
 	kubernetesServiceIPGetter func(context.Context) (string, error)
 	pods                      sync.Map /* implicit signature: map[string]*PodInfo */
@@ -308,9 +313,15 @@ func (npr *NamespacedPodReflector) ForgeShadowPod(ctx context.Context, local *co
 		return ip
 	}
 
+	// Validate the scheduling constraints to be enforced in the remote cluster, as offloaded pods would be otherwise unschedulable.
+	schedulingMutator, err := forge.SchedulingConstraintsMutator(local, npr.priorityClassMapping)
+	if err != nil {
+		return nil, err
+	}
+
 	// Forge the target shadowpod object.
 	target := forge.RemoteShadowPod(local, shadow, npr.RemoteNamespace(),
-		forge.APIServerSupportMutator(npr.apiServerSupport, pod.ServiceAccountName(local), saSecretRetriever, ipGetter))
+		forge.APIServerSupportMutator(npr.apiServerSupport, pod.ServiceAccountName(local), saSecretRetriever, ipGetter), schedulingMutator)
 
 	// Check whether an error occurred during secret name retrieval.
 	if saerr != nil {
@@ -500,6 +511,49 @@ func (npr *NamespacedPodReflector) Stats(ctx context.Context) ([]statsv1alpha1.P
 	return stats, nil
 }
 
+// RemoteNodes returns the names of the remote nodes hosting the reflected pods.
+func (npr *NamespacedPodReflector) RemoteNodes() []string {
+	remotes, err := npr.remotePods.List(forge.ReflectedLabelSelector())
+	utilruntime.Must(err) // Listing from the cache never fails.
+
+	var nodes []string
+	for _, remote := range remotes {
+		if remote.Spec.NodeName != "" {
+			nodes = append(nodes, remote.Spec.NodeName)
+		}
+	}
+	return nodes
+}
+
+// SummaryStats filters the stats retrieved from the remote summary API to the reflected pods, translating them.
+func (npr *NamespacedPodReflector) SummaryStats(remote []statsv1alpha1.PodStats) []statsv1alpha1.PodStats {
+	var stats []statsv1alpha1.PodStats
+
+	for idx := range remote {
+		ref := &remote[idx].PodRef
+		if ref.Namespace != npr.RemoteNamespace() {
+			continue
+		}
+
+		// Ensure the stats refer to a pod managed by us, and not to a different one with the same name.
+		rpod, err := npr.remotePods.Get(ref.Name)
+		if err != nil || !forge.IsReflected(rpod) || string(rpod.GetUID()) != ref.UID {
+			continue
+		}
+
+		// Retrieve the local pod corresponding to the remote stats.
+		local, err := npr.localPods.Get(ref.Name)
+		if err != nil {
+			klog.V(4).Infof("Skipping stats of remote pod %q, as local pod %q not found", npr.RemoteRef(ref.Name), npr.LocalRef(ref.Name))
+			continue
+		}
+
+		stats = append(stats, forge.LocalPodStatsFromSummary(local, &remote[idx]))
+	}
+
+	return stats
+}
+
 // RetrievePodInfo retrieves the pod information regarding a given pod.
 func (npr *NamespacedPodReflector) RetrievePodInfo(po string) *PodInfo {
 	info, _ := npr.pods.LoadOrStore(po, &PodInfo{})
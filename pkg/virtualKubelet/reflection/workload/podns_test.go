@@ -21,6 +21,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/virtual-kubelet/virtual-kubelet/node/api/statsv1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -72,7 +73,7 @@ var _ = Describe("Namespaced Pod Reflection Tests", func() {
 
 			broadcaster := record.NewBroadcaster()
 			metricsFactory := func(string) metricsv1beta1.PodMetricsInterface { return nil }
-			rfl := workload.NewPodReflector(nil, metricsFactory, ipam, forge.APIServerSupportTokenAPI, 0)
+			rfl := workload.NewPodReflector(nil, metricsFactory, nil, ipam, forge.APIServerSupportTokenAPI, nil, 0)
 			rfl.Start(ctx, options.New(client, factory.Core().V1().Pods()).WithEventBroadcaster(broadcaster))
 			reflector = rfl.NewNamespaced(options.NewNamespaced().
 				WithLocal(LocalNamespace, client, factory).WithLiqoLocal(liqoClient, liqoFactory).
@@ -504,6 +505,61 @@ var _ = Describe("Namespaced Pod Reflection Tests", func() {
 			})
 		})
 
+		Context("stats retrieval from the summary API", func() {
+			var local, remote, other corev1.Pod
+
+			PodStats := func(name, namespace, uid string) statsv1alpha1.PodStats {
+				return statsv1alpha1.PodStats{
+					PodRef:      statsv1alpha1.PodReference{Name: name, Namespace: namespace, UID: uid},
+					VolumeStats: []statsv1alpha1.VolumeStats{{Name: "volume", PVCRef: &statsv1alpha1.PVCReference{Name: "pvc", Namespace: namespace}}},
+				}
+			}
+
+			BeforeEach(func() {
+				local = corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: PodName, Namespace: LocalNamespace, UID: "local-uid"}}
+				remote = corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: PodName, Namespace: RemoteNamespace, UID: "remote-uid", Labels: forge.ReflectionLabels()},
+					Spec:       corev1.PodSpec{NodeName: "remote-node"},
+				}
+				other = corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: RemoteNamespace, UID: "other-uid"},
+					Spec:       corev1.PodSpec{NodeName: "other-node"},
+				}
+
+				CreatePod(client, &local)
+				CreatePod(client, &remote)
+				CreatePod(client, &other)
+			})
+
+			Describe("the RemoteNodes function", func() {
+				It("should return the nodes hosting the reflected pods", func() {
+					Expect(reflector.(*workload.NamespacedPodReflector).RemoteNodes()).To(ConsistOf("remote-node"))
+				})
+			})
+
+			Describe("the SummaryStats function", func() {
+				var output []statsv1alpha1.PodStats
+
+				JustBeforeEach(func() {
+					output = reflector.(*workload.NamespacedPodReflector).SummaryStats([]statsv1alpha1.PodStats{
+						PodStats(PodName, RemoteNamespace, "remote-uid"),
+						PodStats(PodName, RemoteNamespace, "stale-uid"),
+						PodStats("other", RemoteNamespace, "other-uid"),
+						PodStats(PodName, "foo", "remote-uid"),
+					})
+				})
+
+				It("should return only the stats of the reflected pods", func() { Expect(output).To(HaveLen(1)) })
+				It("should translate the pod reference", func() {
+					Expect(output[0].PodRef).To(Equal(statsv1alpha1.PodReference{Name: PodName, Namespace: LocalNamespace, UID: "local-uid"}))
+				})
+				It("should translate the namespace of the volume references", func() {
+					Expect(output[0].VolumeStats).To(ConsistOf(statsv1alpha1.VolumeStats{
+						Name: "volume", PVCRef: &statsv1alpha1.PVCReference{Name: "pvc", Namespace: LocalNamespace}}))
+				})
+			})
+		})
+
 		Context("pod restarts inference", func() {
 			Status := func(name string, restarts int32) *corev1.PodStatus {
 				return &corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: name, RestartCount: restarts}}}
@@ -0,0 +1,20 @@
+// Copyright 2019-2023 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remotestats defines the ClusterRole containing the cluster-wide permissions required by the virtual kubelet
+// in the remote cluster to retrieve the stats of the offloaded pods from the summary API of the remote nodes.
+// Differently from the remote one, this ClusterRole is bound through a ClusterRoleBinding, as nodes are not namespaced.
+package remotestats
+
+// +kubebuilder:rbac:groups=core,resources=nodes/proxy,verbs=get
@@ -68,6 +68,9 @@ func forgeVKContainers(
 	if extraLabels := opts.NodeExtraLabels.StringMap; len(extraLabels) != 0 {
 		args = append(args, stringifyArgument("--node-extra-labels", opts.NodeExtraLabels.String()))
 	}
+	if priorityClassMapping := opts.PriorityClassMapping.StringMap; len(priorityClassMapping) != 0 {
+		args = append(args, stringifyArgument("--priority-class-mapping", opts.PriorityClassMapping.String()))
+	}
 
 	args = append(args, opts.ExtraArgs...)
 
@@ -29,6 +29,7 @@ type VirtualKubeletOpts struct {
 	ExtraArgs            []string
 	NodeExtraAnnotations argsutils.StringMap
 	NodeExtraLabels      argsutils.StringMap
+	PriorityClassMapping argsutils.StringMap
 	RequestsCPU          resource.Quantity
 	LimitsCPU            resource.Quantity
 	RequestsRAM          resource.Quantity